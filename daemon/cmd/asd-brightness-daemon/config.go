@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/dbus"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileName is the config file looked up in the user's config directory,
+// i.e. $XDG_CONFIG_HOME/asd-brightness/config.yaml.
+var configFileName = filepath.Join("asd-brightness", "config.yaml")
+
+var (
+	// errUnknownConfigKeys is returned when the config file contains keys that
+	// don't match any command-line flag.
+	errUnknownConfigKeys = errors.New("unknown config keys")
+
+	// errInvalidConfigValue is returned when a config value has an unsupported type
+	// or can't be parsed for its flag.
+	errInvalidConfigValue = errors.New("invalid config value")
+)
+
+// configOnlyFlags are flags that make no sense inside the config file itself.
+var configOnlyFlags = []string{"config", "help"}
+
+// defaultConfigPath returns the config file path in the user's config directory.
+func defaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configFileName), nil
+}
+
+// loadConfig applies the config file to flags and returns its per-display
+// overrides (see parseDisplayConfigs). Other keys are flag names, e.g.
+// "poll-interval: 10s", and only fill in flags that weren't set on the command
+// line, so the precedence is flag > file > default.
+//
+// An empty path means the default location, where a missing file is not an
+// error; an explicitly given path must exist.
+func loadConfig(flags *pflag.FlagSet, path string) (map[string]dbus.DisplayConfig, error) {
+	required := path != ""
+	if !required {
+		var err error
+		if path, err = defaultConfigPath(); err != nil {
+			log.Debug().Err(err).Msg("No config directory, skipping config file")
+			return nil, nil
+		}
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 -- path is chosen by the user running the daemon
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	displays, err := applyConfig(flags, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	log.Debug().Str("path", path).Int("displays", len(displays)).Msg("Loaded config file")
+	return displays, nil
+}
+
+// applyConfig parses YAML config data, sets every flag it names that wasn't
+// already set on the command line and returns the per-display overrides. All
+// keys are validated before any flag is changed, and every unknown key is
+// reported at once.
+func applyConfig(flags *pflag.FlagSet, data []byte) (map[string]dbus.DisplayConfig, error) {
+	var values map[string]any
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	var displays map[string]dbus.DisplayConfig
+	if value, ok := values[displaysConfigKey]; ok {
+		var err error
+		if displays, err = parseDisplayConfigs(value); err != nil {
+			return nil, err
+		}
+		delete(values, displaysConfigKey)
+	}
+
+	var unknown []string
+	for key := range values {
+		if flags.Lookup(key) == nil || slices.Contains(configOnlyFlags, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("%w: %s", errUnknownConfigKeys, strings.Join(unknown, ", "))
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if flags.Changed(key) {
+			continue
+		}
+		if err := setFlagFromConfig(flags, key, values[key]); err != nil {
+			return nil, err
+		}
+	}
+	return displays, nil
+}
+
+// setFlagFromConfig sets a flag from a decoded YAML value. Lists are applied
+// element by element, like repeating the flag on the command line.
+func setFlagFromConfig(flags *pflag.FlagSet, key string, value any) error {
+	var items []any
+	switch v := value.(type) {
+	case []any:
+		items = v
+	case map[string]any, nil:
+		return fmt.Errorf("%w: %s must be a scalar or a list", errInvalidConfigValue, key)
+	default:
+		items = []any{v}
+	}
+
+	for _, item := range items {
+		if _, ok := item.(map[string]any); ok {
+			return fmt.Errorf("%w: %s must be a scalar or a list", errInvalidConfigValue, key)
+		}
+		if err := flags.Set(key, fmt.Sprint(item)); err != nil {
+			return fmt.Errorf("%w: %s: %w", errInvalidConfigValue, key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// configFlags is a small flag set mirroring the kinds of flags the daemon defines.
+type configFlags struct {
+	set          *pflag.FlagSet
+	pollInterval time.Duration
+	maxDisplays  int
+	noUdev       bool
+	allowlist    []string
+}
+
+func newConfigFlags(t *testing.T, args ...string) *configFlags {
+	t.Helper()
+
+	f := &configFlags{set: pflag.NewFlagSet("test", pflag.ContinueOnError)}
+	f.set.DurationVar(&f.pollInterval, "poll-interval", 5*time.Second, "")
+	f.set.IntVar(&f.maxDisplays, "max-displays", 4, "")
+	f.set.BoolVar(&f.noUdev, "no-udev", false, "")
+	f.set.StringSliceVar(&f.allowlist, "product-allowlist", nil, "")
+	f.set.String("config", "", "")
+	require.NoError(t, f.set.Parse(args))
+	return f
+}
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadConfig_Precedence(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		content      string // empty means no config file
+		pollInterval time.Duration
+		maxDisplays  int
+	}{
+		{name: "defaults without a file", pollInterval: 5 * time.Second, maxDisplays: 4},
+		{name: "file overrides defaults", content: "poll-interval: 10s\nmax-displays: 2\n", pollInterval: 10 * time.Second, maxDisplays: 2},
+		{name: "flag overrides file", args: []string{"--poll-interval=1s"}, content: "poll-interval: 10s\nmax-displays: 2\n", pollInterval: time.Second, maxDisplays: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", dir)
+			if tt.content != "" {
+				require.NoError(t, os.MkdirAll(filepath.Join(dir, "asd-brightness"), 0o750))
+				require.NoError(t, os.WriteFile(filepath.Join(dir, configFileName), []byte(tt.content), 0o600))
+			}
+
+			flags := newConfigFlags(t, tt.args...)
+			_, err := loadConfig(flags.set, "")
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.pollInterval, flags.pollInterval)
+			assert.Equal(t, tt.maxDisplays, flags.maxDisplays)
+		})
+	}
+}
+
+func TestLoadConfig_ExplicitPathMustExist(t *testing.T) {
+	flags := newConfigFlags(t)
+	_, err := loadConfig(flags.set, filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLoadConfig_Lists(t *testing.T) {
+	flags := newConfigFlags(t)
+	_, err := loadConfig(flags.set, writeConfig(t, "product-allowlist:\n  - Studio Display\n  - Pro Display XDR\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Studio Display", "Pro Display XDR"}, flags.allowlist)
+}
+
+func TestLoadConfig_ValidationErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     error
+		message string
+	}{
+		{name: "unknown keys", content: "poll-intervall: 1s\nbrightness: 50\n", err: errUnknownConfigKeys, message: "brightness, poll-intervall"},
+		{name: "config key", content: "config: other.yaml\n", err: errUnknownConfigKeys, message: "config"},
+		{name: "bad value", content: "max-displays: many\n", err: errInvalidConfigValue, message: "max-displays"},
+		{name: "nested value", content: "poll-interval:\n  seconds: 5\n", err: errInvalidConfigValue, message: "poll-interval"},
+		{name: "empty value", content: "poll-interval:\n", err: errInvalidConfigValue, message: "poll-interval"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := newConfigFlags(t)
+			path := writeConfig(t, tt.content)
+
+			_, err := loadConfig(flags.set, path)
+			require.ErrorIs(t, err, tt.err)
+			assert.Contains(t, err.Error(), tt.message)
+			assert.Contains(t, err.Error(), path)
+			assert.Equal(t, 5*time.Second, flags.pollInterval, "no flag changes on validation errors")
+		})
+	}
+
+	flags := newConfigFlags(t)
+	_, err := loadConfig(flags.set, writeConfig(t, "poll-interval: [unterminated\n"))
+	require.Error(t, err)
+}
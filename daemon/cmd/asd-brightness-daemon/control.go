@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"github.com/shini4i/asd-brightness-daemon/internal/dbus"
+)
+
+// controlHandler serves control socket requests through the D-Bus server, so they
+// share its rate limits, brightness caps and signals with D-Bus clients.
+type controlHandler struct {
+	server *dbus.Server
+}
+
+// SetBrightness sets the brightness of a display like the D-Bus method.
+func (h controlHandler) SetBrightness(serial string, brightness uint32) error {
+	// Return a nil interface rather than a nil *dbus.Error
+	if err := h.server.SetBrightness(serial, brightness); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetBrightness reads the brightness of a display like the D-Bus method.
+func (h controlHandler) GetBrightness(serial string) (uint32, error) {
+	brightness, err := h.server.GetBrightness(serial)
+	if err != nil {
+		return 0, err
+	}
+	return brightness, nil
+}
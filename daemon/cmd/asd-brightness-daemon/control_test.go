@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildDaemon_ControlSocketWritesReachManager(t *testing.T) {
+	var writes atomic.Int32
+	opener := func(serial string) (hid.Device, error) {
+		return &writeCountingDevice{mockDevice: mockDevice{serial: serial}, writes: &writes}, nil
+	}
+
+	opts := testDaemonOptions(&fakeMonitor{}, "A")
+	opts.managerOpts = append(opts.managerOpts, hid.WithOpener(opener))
+	opts.controlSocket = filepath.Join(t.TempDir(), "control.sock")
+	d, err := buildDaemon(opts)
+	require.NoError(t, err)
+	require.NotNil(t, d.controlSocket)
+
+	conn, err := net.Dial("unix", opts.controlSocket)
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	reader := bufio.NewReader(conn)
+	roundTrip := func(line string) string {
+		_, err := conn.Write([]byte(line + "\n"))
+		require.NoError(t, err)
+		response, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		return response
+	}
+
+	assert.Equal(t, "ok\n", roundTrip("set A 40"))
+	assert.Equal(t, int32(1), writes.Load(), "the write should reach the display")
+
+	// Failures are reported as the D-Bus method would report them
+	response := roundTrip("set MISSING 40")
+	assert.True(t, strings.HasPrefix(response, "err "), response)
+	assert.Equal(t, int32(1), writes.Load())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.Run(ctx)
+
+	_, err = net.Dial("unix", opts.controlSocket)
+	assert.Error(t, err, "the control socket should be closed on shutdown")
+}
+
+func TestBuildDaemon_ControlSocketFailureIsNotFatal(t *testing.T) {
+	opts := testDaemonOptions(&fakeMonitor{}, "A")
+	opts.controlSocket = filepath.Join(t.TempDir(), "missing", "control.sock")
+
+	d, err := buildDaemon(opts)
+	require.NoError(t, err)
+	assert.Nil(t, d.controlSocket)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.Run(ctx)
+}
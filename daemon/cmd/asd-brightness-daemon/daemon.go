@@ -0,0 +1,505 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	gohid "github.com/sstallion/go-hid"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/control"
+	"github.com/shini4i/asd-brightness-daemon/internal/dbus"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/shini4i/asd-brightness-daemon/internal/udev"
+)
+
+// daemonOptions configures buildDaemon. It mirrors the command-line flags, plus
+// seams that let tests replace hardware- and bus-facing dependencies.
+type daemonOptions struct {
+	noUdev              bool
+	pollInterval        time.Duration
+	siblingWait         time.Duration
+	hotplugDebounce     time.Duration
+	refreshBudget       time.Duration
+	healthCheck         time.Duration
+	notFoundPolicy      string
+	maxDisplays         int
+	readinessTimeout    time.Duration
+	openConcurrency     int
+	controllerQueue     bool
+	handleGrace         time.Duration
+	transientRetries    int
+	verifyWrites        bool
+	experimentalReports bool
+	backlightDir        string // empty disables the sysfs backlight fallback
+	drmDir              string // empty disables mapping displays to DRM connectors
+	advisoryLock        bool
+	controlSocket       string // empty disables the control socket
+	productAllowlist    []string
+	defaultBrightness   uint32
+	minBrightness       uint32
+	stops               []uint32                       // empty disables snapping
+	modes               map[string]dbus.BrightnessMode // nil keeps dbus.DefaultBrightnessModes
+	nightMode           *dbus.BrightnessMode           // nil keeps dbus.DefaultNightMode
+	externalOnlyMode    *dbus.BrightnessMode           // nil keeps dbus.DefaultExternalOnlyMode
+	connectBrightness   int
+	resetLevel          int // negative disables re-applying brightness after spontaneous resets
+	displayConfigs      map[string]dbus.DisplayConfig
+	setAllQuiet         bool // suppress per-display signals on SetAllBrightness
+	silentChanges       bool // suppress BrightnessChanged for daemon-initiated changes
+	noRateLimit         bool
+	displayAddedV2      bool
+	errorCommand        string
+	errorDebounce       time.Duration // 0 recovers on every device error
+	maxRecoveries       int           // below 1 uses dbus.DefaultMaxConcurrentRecoveries
+	idleStandby         time.Duration // 0 never puts idle displays in standby
+	brightnessPoll      time.Duration
+	cacheTTL            time.Duration
+	smoothSteps         int
+	exitWhenEmpty       bool
+	emptyGrace          time.Duration
+	restoreOnResume     bool
+	retentionDays       int // 0 keeps state of disconnected displays forever
+	contentionSignal    bool
+	shutdownStepTimeout time.Duration // 0 means defaultShutdownStepTimeout
+	enableRaw           bool
+	checkedSetRead      bool
+	staleFallback       bool
+	stepWindow          time.Duration
+	maxStepDelta        uint32 // 0 applies coalesced steps whole
+	warmUpPeriod        time.Duration
+	warmUpPolicy        string // empty means block
+	migrateSerials      bool
+
+	initHID          func() error  // initializes the HID library; nil if it needs none, e.g. in tests
+	hidRetryInterval time.Duration // retry interval for initHID in degraded mode; 0 means defaultHIDInitRetryInterval
+
+	managerOpts []hid.ManagerOption                         // extra manager options, e.g. a fake enumerator
+	newMonitor  func(udev.EventHandler) hotplugMonitor      // defaults to newUdevMonitor
+	startServer func(*dbus.Server) error                    // defaults to (*dbus.Server).Start
+	watchSleep  func(onResume func()) (sleepWatcher, error) // defaults to watchLogindSleep
+	usbPort     func(hid.DeviceInfo) string                 // defaults to hid.USBPort
+}
+
+// sleepWatcher is a running resume watcher that must be stopped on shutdown.
+type sleepWatcher interface {
+	Stop() error
+}
+
+// watchLogindSleep adapts dbus.WatchSleep to return a sleepWatcher.
+func watchLogindSleep(onResume func()) (sleepWatcher, error) {
+	watcher, err := dbus.WatchSleep(onResume)
+	if err != nil {
+		return nil, err
+	}
+	return watcher, nil
+}
+
+// optionsFromFlags returns the daemon options set on the command line.
+func optionsFromFlags() daemonOptions {
+	return daemonOptions{
+		noUdev:            noUdev,
+		initHID:           gohid.Init,
+		pollInterval:      pollInterval,
+		siblingWait:       siblingWait,
+		hotplugDebounce:   hotplugSettle,
+		refreshBudget:     refreshBudget,
+		healthCheck:       healthCheck,
+		notFoundPolicy:    notFoundPolicy,
+		maxDisplays:       maxDisplays,
+		readinessTimeout:  readyTimeout,
+		openConcurrency:   openWorkers,
+		controllerQueue:   ctrlQueue,
+		handleGrace:       handleGrace,
+		transientRetries:  eioRetries,
+		verifyWrites:      verifyWrites,
+		backlightDir:      backlightDir,
+		drmDir:            drmDir,
+		advisoryLock:      advisoryLock,
+		controlSocket:     controlSocket,
+		errorDebounce:     errorDebounce,
+		maxRecoveries:     maxRecoveries,
+		idleStandby:       idleStandby,
+		productAllowlist:  productAllow,
+		defaultBrightness: defaultBright,
+		minBrightness:     minBright,
+		stops:             brightnessStops(stops),
+		modes: map[string]dbus.BrightnessMode{
+			dbus.ModeSDR: {Max: sdrMax, Level: sdrLevel},
+			dbus.ModeHDR: {Max: hdrMax, Level: hdrLevel},
+		},
+		nightMode:           &dbus.BrightnessMode{Max: nightMax, Level: nightLevel},
+		externalOnlyMode:    &dbus.BrightnessMode{Max: extOnlyMax, Level: extOnlyLevel},
+		connectBrightness:   connectBright,
+		resetLevel:          resetLevel,
+		displayConfigs:      displayConfigs,
+		setAllQuiet:         !setAllSignals,
+		errorCommand:        errorCmdPath,
+		brightnessPoll:      brightPoll,
+		cacheTTL:            cacheTTL,
+		smoothSteps:         smoothSteps,
+		exitWhenEmpty:       exitWhenEmpty,
+		emptyGrace:          emptyGrace,
+		restoreOnResume:     resumeRestore,
+		retentionDays:       retentionDays,
+		contentionSignal:    contentionSig,
+		shutdownStepTimeout: stepTimeout,
+		enableRaw:           enableRaw,
+		checkedSetRead:      checkedRead,
+		staleFallback:       staleFallback,
+		stepWindow:          stepWindow,
+		maxStepDelta:        maxStepDelta,
+		warmUpPeriod:        warmUpPeriod,
+		warmUpPolicy:        warmUpPolicy,
+		migrateSerials:      migrateSerials,
+		silentChanges:       silentChanges,
+		noRateLimit:         noRateLimit,
+		displayAddedV2:      addedV2,
+		experimentalReports: experimental,
+	}
+}
+
+// brightnessStops converts the --brightness-stops values, clamping them to 100.
+func brightnessStops(values []uint) []uint32 {
+	stops := make([]uint32, 0, len(values))
+	for _, value := range values {
+		stops = append(stops, uint32(min(value, 100)))
+	}
+	return stops
+}
+
+// Daemon is a fully wired brightness daemon: display manager, D-Bus server,
+// hot-plug detection and optional background pollers.
+type Daemon struct {
+	manager            *hid.Manager
+	server             *dbus.Server
+	deviceErrorHandler dbus.DeviceErrorHandler
+	hotplug            hotplugStopper  // nil if hot-plug detection is off
+	brightnessPoller   *displayPoller  // nil unless --brightness-poll-interval is set
+	healthPoller       *displayPoller  // nil unless --health-check-interval is set
+	hidInitPoller      *displayPoller  // nil unless HID initialization failed at startup
+	emptyPoller        *displayPoller  // nil unless --exit-when-empty is set
+	sleepWatcher       sleepWatcher    // nil unless --restore-on-resume is set and logind is reachable
+	controlSocket      *control.Server // nil unless --control-socket is set
+	empty              chan struct{}   // closed when --exit-when-empty fires
+	shutdownTimeout    time.Duration   // per shutdown step
+}
+
+// buildDaemon creates and starts all daemon components. On error, components
+// started so far are released.
+//
+// If the HID library fails to initialize, the daemon still starts in degraded
+// mode: it serves the D-Bus API with no displays, reports the failure through
+// GetHIDStatus and keeps retrying, so clients stay connected and informed.
+func buildDaemon(opts daemonOptions) (*Daemon, error) {
+	policy, err := dbus.ParseNotFoundPolicy(opts.notFoundPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	warmUp := dbus.WarmUpBlock
+	if opts.warmUpPolicy != "" {
+		if warmUp, err = dbus.ParseWarmUpPolicy(opts.warmUpPolicy); err != nil {
+			return nil, fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+	if opts.newMonitor == nil {
+		opts.newMonitor = newUdevMonitor
+	}
+	if opts.startServer == nil {
+		opts.startServer = (*dbus.Server).Start
+	}
+	if opts.shutdownStepTimeout <= 0 {
+		opts.shutdownStepTimeout = defaultShutdownStepTimeout
+	}
+	if opts.watchSleep == nil {
+		opts.watchSleep = watchLogindSleep
+	}
+	if opts.usbPort == nil {
+		opts.usbPort = hid.USBPort
+	}
+	if opts.hidRetryInterval <= 0 {
+		opts.hidRetryInterval = defaultHIDInitRetryInterval
+	}
+
+	d := &Daemon{
+		empty:           make(chan struct{}),
+		shutdownTimeout: opts.shutdownStepTimeout,
+	}
+
+	// Initialize HID manager
+	var controllerOf func(hid.DeviceInfo) string
+	if opts.controllerQueue {
+		controllerOf = hid.USBController
+	}
+	managerOpts := append([]hid.ManagerOption{
+		hid.WithMaxDisplays(opts.maxDisplays),
+		hid.WithProductAllowlist(opts.productAllowlist...),
+		hid.WithConnectHandler(func(serial string, display *hid.Display) {
+			// Displays found by the initial refresh, before the server exists, are handled below
+			if d.server != nil {
+				d.server.ApplyConnectBrightness(serial, display)
+			}
+		}),
+		hid.WithCurves(displayCurves(opts.displayConfigs)),
+		hid.WithReadinessProbe(opts.readinessTimeout, hid.DefaultReadinessPollInterval),
+		hid.WithOpenConcurrency(opts.openConcurrency),
+		hid.WithControllerWriteQueue(controllerOf),
+		hid.WithHandleGrace(opts.handleGrace),
+		hid.WithTransientRetries(opts.transientRetries, hid.DefaultTransientRetryInterval),
+		hid.WithWriteVerification(opts.verifyWrites, hid.DefaultVerifyTolerance),
+		hid.WithExperimentalReports(opts.experimentalReports),
+		hid.WithBacklightFallback(opts.backlightDir),
+		hid.WithAdvisoryLock(opts.advisoryLock, hid.DefaultAdvisoryLockWait),
+	}, opts.managerOpts...)
+	if opts.migrateSerials {
+		managerOpts = append(managerOpts, hid.WithSerialMigration(opts.usbPort, func(oldSerial, newSerial string) {
+			// The initial refresh runs before the server exists, but can't migrate anything
+			if d.server != nil {
+				d.server.MigrateDisplayState(oldSerial, newSerial)
+			}
+		}))
+	}
+	d.manager = hid.NewManager(managerOpts...)
+
+	// Initialize HID library (recommended for concurrent programs)
+	var hidErr error
+	if opts.initHID != nil {
+		hidErr = opts.initHID()
+	}
+	// On failure the server reports it below, see dbus.Server.SetHIDError
+	if hidErr == nil {
+		if err := d.manager.RefreshDisplays(); err != nil && !errors.Is(err, hid.ErrNoDisplaysFound) {
+			log.Error().Err(err).Msg("Failed to enumerate displays")
+		}
+
+		displayCount := d.manager.Count()
+		if displayCount == 0 {
+			log.Warn().Msg("No Apple Studio Displays found")
+		} else {
+			log.Info().Int("count", displayCount).Msg("Found Apple Studio Displays")
+		}
+	}
+
+	// Initialize D-Bus server
+	serverOpts := []dbus.ServerOption{
+		dbus.WithNotFoundPolicy(policy),
+		dbus.WithDefaultBrightness(opts.defaultBrightness),
+		dbus.WithMinBrightness(opts.minBrightness),
+		dbus.WithDisplayConfigs(opts.displayConfigs),
+		dbus.WithConnectBrightness(opts.connectBrightness),
+		dbus.WithResetReapply(opts.resetLevel),
+		dbus.WithBrightnessStops(opts.stops),
+		dbus.WithBrightnessSmoothing(opts.smoothSteps, dbus.DefaultSmoothingInterval),
+		dbus.WithSetAllPerDisplaySignals(!opts.setAllQuiet),
+		dbus.WithSilentDaemonChanges(opts.silentChanges),
+		dbus.WithBrightnessCache(opts.cacheTTL),
+		dbus.WithContentionSignal(opts.contentionSignal),
+		dbus.WithRawFeatureReports(opts.enableRaw),
+		dbus.WithCheckedSetRead(opts.checkedSetRead),
+		dbus.WithStaleFallback(opts.staleFallback),
+		dbus.WithStepCoalescing(opts.stepWindow),
+		dbus.WithMaxCoalescedStep(opts.maxStepDelta),
+		dbus.WithWarmUp(opts.warmUpPeriod, warmUp, dbus.DefaultWarmUpMaxBlock),
+		dbus.WithStateRetention(time.Duration(opts.retentionDays) * 24 * time.Hour),
+		dbus.WithRateLimiting(!opts.noRateLimit),
+		dbus.WithDisplayAddedV2(opts.displayAddedV2),
+		dbus.WithDeviceErrorDebounce(opts.errorDebounce),
+		dbus.WithMaxConcurrentRecoveries(opts.maxRecoveries),
+		dbus.WithIdleStandby(opts.idleStandby),
+	}
+	if opts.noRateLimit {
+		log.Warn().Msg("Rate limiting disabled, any client on the session bus can flood the displays with brightness writes")
+	}
+	for name, mode := range opts.modes {
+		serverOpts = append(serverOpts, dbus.WithBrightnessMode(name, mode))
+	}
+	if opts.nightMode != nil {
+		serverOpts = append(serverOpts, dbus.WithNightMode(*opts.nightMode))
+	}
+	if opts.externalOnlyMode != nil {
+		serverOpts = append(serverOpts, dbus.WithExternalOnlyMode(*opts.externalOnlyMode))
+	}
+	if !opts.noUdev || opts.pollInterval > 0 {
+		serverOpts = append(serverOpts, dbus.WithFeatures(dbus.FeatureHotplug))
+	}
+	if opts.drmDir != "" {
+		serverOpts = append(serverOpts, dbus.WithConnectorLookup(func(displays []hid.DeviceInfo) (map[string]string, error) {
+			return hid.MapConnectors(opts.drmDir, displays)
+		}))
+	}
+	d.server = dbus.NewServer(d.manager, serverOpts...)
+	d.server.SetHIDError(hidErr)
+	if hidErr == nil {
+		for serial, display := range d.manager.Snapshot() {
+			d.server.ApplyConnectBrightness(serial, display)
+		}
+		d.server.RefreshConnectors()
+		d.server.PruneStaleState()
+		d.server.WarnUnknownDisplayConfigs()
+	}
+	if err := opts.startServer(d.server); err != nil {
+		if closeErr := d.manager.Close(); closeErr != nil {
+			log.Error().Err(closeErr).Msg("Failed to close display manager")
+		}
+		return nil, fmt.Errorf("failed to start D-Bus server: %w", err)
+	}
+
+	// Set up device error recovery handler, optionally running a user command too
+	var onErrorCmd *errorCommand
+	if opts.errorCommand != "" {
+		onErrorCmd = newErrorCommand(opts.errorCommand)
+	}
+	d.deviceErrorHandler = withErrorCommand(createDeviceErrorHandler(d.manager, d.server, opts.refreshBudget), onErrorCmd)
+	d.server.SetDeviceErrorHandler(d.deviceErrorHandler)
+
+	// Optionally serve low-latency clients, sharing the server's rate limits and caps
+	if opts.controlSocket != "" {
+		socket, err := control.Listen(opts.controlSocket, controlHandler{server: d.server})
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to start control socket, only D-Bus clients will be served")
+		} else {
+			d.controlSocket = socket
+		}
+	}
+
+	// Initialize hot-plug detection (udev monitor or polling fallback)
+	d.hotplug = startHotplugDetection(hotplugConfig{
+		noUdev:        opts.noUdev,
+		pollInterval:  opts.pollInterval,
+		siblingWait:   opts.siblingWait,
+		debounce:      opts.hotplugDebounce,
+		refreshBudget: opts.refreshBudget,
+	}, opts.newMonitor, d.manager, d.server)
+
+	// In degraded mode, keep retrying HID initialization and pick up displays once it works
+	if hidErr != nil {
+		d.hidInitPoller = newDisplayPoller(opts.hidRetryInterval, newHIDInitRetry(opts.initHID, func() {
+			d.server.SetHIDError(nil)
+			createPollHandler(d.manager, d.server)()
+		}))
+		d.hidInitPoller.Start()
+	}
+
+	// Optionally re-enumerate periodically as a safety net for missed hot-plug events
+	if opts.healthCheck > 0 {
+		d.healthPoller = newJitteredPoller(opts.healthCheck, opts.healthCheck/healthCheckJitterDivisor,
+			createHealthCheckHandler(d.manager, d.server))
+		d.healthPoller.Start()
+	}
+
+	// Optionally poll brightness to detect changes made outside the daemon
+	if opts.brightnessPoll > 0 {
+		d.brightnessPoller = newDisplayPoller(opts.brightnessPoll, d.server.PollBrightness)
+		d.brightnessPoller.Start()
+	}
+
+	// Optionally shut down once all displays have been gone for the grace period
+	if opts.exitWhenEmpty {
+		watcher := newEmptyWatcher(opts.emptyGrace, d.manager.Count, func() { close(d.empty) })
+		d.emptyPoller = newDisplayPoller(emptyCheckInterval, watcher.check)
+		d.emptyPoller.Start()
+	}
+
+	// Optionally restore brightness after resume, before displays are noticed at full brightness
+	if opts.restoreOnResume {
+		watcher, err := opts.watchSleep(func() {
+			go d.server.ReapplyKnownBrightness(dbus.DefaultResumeAttempts, dbus.DefaultResumeRetryInterval)
+		})
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to watch for resume, brightness won't be restored after suspend")
+		} else {
+			d.sleepWatcher = watcher
+		}
+	}
+
+	return d, nil
+}
+
+// Run blocks until ctx is done or --exit-when-empty fires, then shuts the daemon down.
+func (d *Daemon) Run(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-d.empty:
+	}
+	d.shutdown()
+}
+
+// shutdownStep is a component stopped on shutdown.
+type shutdownStep struct {
+	name string
+	stop func() error
+}
+
+// shutdown stops all components in order. Every step gets its own timeout, so a
+// component that hangs only delays shutdown by that long and the remaining
+// components are still released.
+func (d *Daemon) shutdown() {
+	log.Info().Msg("Shutting down...")
+
+	var steps []shutdownStep
+	if d.controlSocket != nil {
+		steps = append(steps, shutdownStep{name: "control socket", stop: d.controlSocket.Close})
+	}
+	if d.sleepWatcher != nil {
+		steps = append(steps, shutdownStep{name: "resume watcher", stop: d.sleepWatcher.Stop})
+	}
+	if d.emptyPoller != nil {
+		steps = append(steps, shutdownStep{name: "empty watcher", stop: d.emptyPoller.Stop})
+	}
+	if d.brightnessPoller != nil {
+		steps = append(steps, shutdownStep{name: "brightness poller", stop: d.brightnessPoller.Stop})
+	}
+	if d.healthPoller != nil {
+		steps = append(steps, shutdownStep{name: "health check", stop: d.healthPoller.Stop})
+	}
+	if d.hidInitPoller != nil {
+		steps = append(steps, shutdownStep{name: "HID init retry", stop: d.hidInitPoller.Stop})
+	}
+	if d.hotplug != nil {
+		steps = append(steps, shutdownStep{name: "hot-plug detection", stop: d.hotplug.Stop})
+	}
+	// Stop the server before closing the manager: it completes in-progress
+	// fades by writing their target values through the open display handles
+	steps = append(steps,
+		shutdownStep{name: "D-Bus server", stop: d.server.Stop},
+		shutdownStep{name: "display manager", stop: d.manager.Close},
+	)
+
+	graceful := true
+	for _, step := range steps {
+		if !runShutdownStep(step, d.shutdownTimeout) {
+			graceful = false
+		}
+	}
+
+	if graceful {
+		log.Info().Msg("Daemon stopped gracefully")
+	} else {
+		log.Warn().Msg("Daemon stopped, but some components didn't stop in time")
+	}
+}
+
+// runShutdownStep stops a component, giving up after timeout, and reports whether
+// it finished in time. A step that times out is left running in the background.
+func runShutdownStep(step shutdownStep, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- step.stop() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			log.Error().Err(err).Str("component", step.name).Msg("Failed to stop component")
+		}
+		return true
+	case <-ctx.Done():
+		log.Warn().Str("component", step.name).Dur("timeout", timeout).Msg("Component didn't stop in time, skipping it")
+		return false
+	}
+}
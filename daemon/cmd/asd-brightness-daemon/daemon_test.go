@@ -0,0 +1,384 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/dbus"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/shini4i/asd-brightness-daemon/internal/udev"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDaemonOptions returns options that build a daemon without hardware or a bus.
+func testDaemonOptions(monitor *fakeMonitor, serials ...string) daemonOptions {
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		infos := make([]hid.DeviceInfo, 0, len(serials))
+		for _, serial := range serials {
+			infos = append(infos, hid.DeviceInfo{Serial: serial, Product: "Studio Display"})
+		}
+		return infos, nil
+	}
+	opener := func(serial string) (hid.Device, error) {
+		return &mockDevice{serial: serial}, nil
+	}
+
+	return daemonOptions{
+		notFoundPolicy:    "error",
+		maxDisplays:       hid.DefaultMaxDisplays,
+		connectBrightness: -1,
+		resetLevel:        -1,
+		emptyGrace:        defaultEmptyGracePeriod,
+		managerOpts:       []hid.ManagerOption{hid.WithEnumerator(enumerator), hid.WithOpener(opener)},
+		newMonitor:        func(udev.EventHandler) hotplugMonitor { return monitor },
+		startServer:       func(*dbus.Server) error { return nil },
+	}
+}
+
+func TestBuildDaemon_WiresComponents(t *testing.T) {
+	monitor := &fakeMonitor{}
+	d, err := buildDaemon(testDaemonOptions(monitor, "A", "B"))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, d.manager.Count(), "initial enumeration should run")
+	assert.NotNil(t, d.deviceErrorHandler, "device error handler should be set")
+	assert.NotNil(t, monitor.recoveryHandler, "recovery handler should be set")
+	assert.True(t, monitor.started)
+	assert.Same(t, monitor, d.hotplug)
+	assert.Nil(t, d.brightnessPoller)
+	assert.Nil(t, d.emptyPoller)
+
+	features, _ := d.server.GetSupportedFeatures()
+	assert.Contains(t, features, dbus.FeatureHotplug)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.Run(ctx)
+
+	assert.True(t, monitor.stopped, "hot-plug detection should be stopped on shutdown")
+	assert.Equal(t, 0, d.manager.Count(), "displays should be closed on shutdown")
+}
+
+func TestBuildDaemon_OptionalPollers(t *testing.T) {
+	opts := testDaemonOptions(&fakeMonitor{}, "A")
+	opts.brightnessPoll = time.Hour
+	opts.exitWhenEmpty = true
+
+	d, err := buildDaemon(opts)
+	require.NoError(t, err)
+
+	assert.NotNil(t, d.brightnessPoller)
+	assert.NotNil(t, d.emptyPoller)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.Run(ctx)
+}
+
+func TestBuildDaemon_InvalidPolicy(t *testing.T) {
+	opts := testDaemonOptions(&fakeMonitor{})
+	opts.notFoundPolicy = "bogus"
+
+	_, err := buildDaemon(opts)
+	assert.Error(t, err)
+}
+
+func TestBuildDaemon_InvalidWarmUpPolicy(t *testing.T) {
+	opts := testDaemonOptions(&fakeMonitor{})
+	opts.warmUpPolicy = "bogus"
+
+	_, err := buildDaemon(opts)
+	assert.Error(t, err)
+}
+
+func TestBuildDaemon_ServerStartFailure(t *testing.T) {
+	monitor := &fakeMonitor{}
+	opts := testDaemonOptions(monitor, "A")
+	opts.startServer = func(*dbus.Server) error { return errors.New("name taken") }
+
+	_, err := buildDaemon(opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "name taken")
+	assert.False(t, monitor.started, "hot-plug detection must not start if the server fails")
+}
+
+func TestDaemon_RunReturnsWhenEmpty(t *testing.T) {
+	d, err := buildDaemon(testDaemonOptions(&fakeMonitor{}))
+	require.NoError(t, err)
+
+	// Simulate --exit-when-empty firing
+	close(d.empty)
+
+	done := make(chan struct{})
+	go func() {
+		d.Run(context.Background())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run should return once the daemon is empty")
+	}
+}
+
+func TestBuildDaemon_HealthCheckFindsMissedDisplay(t *testing.T) {
+	var connected atomic.Bool
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		infos := []hid.DeviceInfo{{Serial: "A", Product: "Studio Display"}}
+		if connected.Load() {
+			infos = append(infos, hid.DeviceInfo{Serial: "B", Product: "Studio Display"})
+		}
+		return infos, nil
+	}
+
+	monitor := &fakeMonitor{}
+	opts := testDaemonOptions(monitor)
+	opts.managerOpts = append(opts.managerOpts, hid.WithEnumerator(enumerator))
+	opts.healthCheck = 10 * time.Millisecond
+
+	d, err := buildDaemon(opts)
+	require.NoError(t, err)
+	require.NotNil(t, d.healthPoller)
+	require.Equal(t, 1, d.manager.Count())
+
+	// Display B connects without the (fake) udev monitor reporting it
+	connected.Store(true)
+	assert.Eventually(t, func() bool { return d.manager.Count() == 2 }, time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.Run(ctx)
+}
+
+func TestHealthCheckHandler_SkipsDuringRefresh(t *testing.T) {
+	var enumerations atomic.Int32
+	manager := hid.NewManager(hid.WithEnumerator(func() ([]hid.DeviceInfo, error) {
+		enumerations.Add(1)
+		return nil, nil
+	}))
+	check := createHealthCheckHandler(manager, dbus.NewServer(manager))
+
+	refreshMu.Lock()
+	check()
+	refreshMu.Unlock()
+	assert.Zero(t, enumerations.Load(), "health check must not wait for or compete with an active refresh")
+
+	check()
+	assert.Equal(t, int32(1), enumerations.Load())
+}
+
+func TestBuildDaemon_MigratesStateOnSerialChange(t *testing.T) {
+	var updated atomic.Bool
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		serial := "OLD"
+		if updated.Load() {
+			serial = "NEW"
+		}
+		return []hid.DeviceInfo{{Path: "/dev/hidraw4", Serial: serial, Product: "Studio Display"}}, nil
+	}
+
+	opts := testDaemonOptions(&fakeMonitor{})
+	opts.managerOpts = append(opts.managerOpts, hid.WithEnumerator(enumerator))
+	opts.migrateSerials = true
+	opts.usbPort = func(hid.DeviceInfo) string { return "3-1" }
+
+	d, err := buildDaemon(opts)
+	require.NoError(t, err)
+	require.Nil(t, d.server.SetBrightness("OLD", 40))
+
+	// A firmware update changes the serial the display reports on the same port
+	updated.Store(true)
+	require.NoError(t, d.manager.RefreshDisplays())
+
+	info, dbusErr := d.server.GetCachedDisplayInfo("NEW")
+	require.Nil(t, dbusErr)
+	assert.True(t, info.BrightnessKnown)
+	assert.Equal(t, uint32(40), info.Brightness)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.Run(ctx)
+}
+
+func TestBuildDaemon_AppliesConnectBrightness(t *testing.T) {
+	var plugged atomic.Bool
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		infos := []hid.DeviceInfo{{Serial: "A", Product: "Studio Display"}}
+		if plugged.Load() {
+			infos = append(infos, hid.DeviceInfo{Serial: "B", Product: "Studio Display"})
+		}
+		return infos, nil
+	}
+
+	opts := testDaemonOptions(&fakeMonitor{})
+	opts.managerOpts = append(opts.managerOpts, hid.WithEnumerator(enumerator))
+	opts.connectBrightness = 5
+	opts.minBrightness = 20
+
+	d, err := buildDaemon(opts)
+	require.NoError(t, err)
+
+	// Both the initial display and a later one get it, within the floor
+	plugged.Store(true)
+	require.NoError(t, d.manager.RefreshDisplays())
+	for _, serial := range []string{"A", "B"} {
+		info, dbusErr := d.server.GetCachedDisplayInfo(serial)
+		require.Nil(t, dbusErr)
+		assert.True(t, info.BrightnessKnown, serial)
+		assert.Equal(t, uint32(20), info.Brightness, serial)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.Run(ctx)
+}
+
+// fakeSleepWatcher captures the resume handler instead of watching logind.
+type fakeSleepWatcher struct {
+	onResume func()
+	stopped  atomic.Bool
+}
+
+func (w *fakeSleepWatcher) Stop() error {
+	w.stopped.Store(true)
+	return nil
+}
+
+// writeCountingDevice counts brightness writes.
+type writeCountingDevice struct {
+	mockDevice
+	writes *atomic.Int32
+}
+
+func (d *writeCountingDevice) SendFeatureReport(data []byte) (int, error) {
+	d.writes.Add(1)
+	return len(data), nil
+}
+
+func TestBuildDaemon_RestoresBrightnessOnResume(t *testing.T) {
+	var writes atomic.Int32
+	opener := func(serial string) (hid.Device, error) {
+		return &writeCountingDevice{mockDevice: mockDevice{serial: serial}, writes: &writes}, nil
+	}
+
+	watcher := &fakeSleepWatcher{}
+	opts := testDaemonOptions(&fakeMonitor{}, "A", "B")
+	opts.managerOpts = append(opts.managerOpts, hid.WithOpener(opener))
+	opts.restoreOnResume = true
+	opts.watchSleep = func(onResume func()) (sleepWatcher, error) {
+		watcher.onResume = onResume
+		return watcher, nil
+	}
+
+	d, err := buildDaemon(opts)
+	require.NoError(t, err)
+	require.Same(t, watcher, d.sleepWatcher)
+
+	require.Nil(t, d.server.SetBrightness("A", 40))
+	require.Nil(t, d.server.SetBrightness("B", 60))
+	require.Equal(t, int32(2), writes.Load())
+
+	watcher.onResume()
+	assert.Eventually(t, func() bool { return writes.Load() == 4 }, time.Second, time.Millisecond,
+		"each display's last-known brightness should be re-applied")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.Run(ctx)
+	assert.True(t, watcher.stopped.Load(), "resume watcher should be stopped on shutdown")
+}
+
+func TestBuildDaemon_ResumeWatcherFailureIsNotFatal(t *testing.T) {
+	opts := testDaemonOptions(&fakeMonitor{}, "A")
+	opts.restoreOnResume = true
+	opts.watchSleep = func(func()) (sleepWatcher, error) {
+		return nil, errors.New("no system bus")
+	}
+
+	d, err := buildDaemon(opts)
+	require.NoError(t, err)
+	assert.Nil(t, d.sleepWatcher)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.Run(ctx)
+}
+
+// stuckStopper is a component whose Stop blocks until released.
+type stuckStopper struct {
+	release chan struct{}
+}
+
+func (s *stuckStopper) Stop() error {
+	<-s.release
+	return nil
+}
+
+func TestDaemon_ShutdownStepTimeout(t *testing.T) {
+	opts := testDaemonOptions(&fakeMonitor{}, "A", "B")
+	opts.shutdownStepTimeout = 20 * time.Millisecond
+
+	d, err := buildDaemon(opts)
+	require.NoError(t, err)
+
+	// Hot-plug detection hangs on shutdown
+	stuck := &stuckStopper{release: make(chan struct{})}
+	defer close(stuck.release)
+	d.hotplug = stuck
+
+	start := time.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.Run(ctx)
+
+	assert.Less(t, time.Since(start), time.Second, "a stuck component should only cost its own timeout")
+	assert.Equal(t, 0, d.manager.Count(), "later components should still be stopped")
+}
+
+func TestRunShutdownStep(t *testing.T) {
+	assert.True(t, runShutdownStep(shutdownStep{name: "ok", stop: func() error { return nil }}, time.Second))
+	assert.True(t, runShutdownStep(shutdownStep{name: "failing", stop: func() error { return errors.New("boom") }}, time.Second),
+		"a step that fails still finished in time")
+
+	stuck := &stuckStopper{release: make(chan struct{})}
+	defer close(stuck.release)
+	assert.False(t, runShutdownStep(shutdownStep{name: "stuck", stop: stuck.Stop}, 10*time.Millisecond))
+}
+
+func TestBuildDaemon_DegradedModeUntilHIDInitSucceeds(t *testing.T) {
+	var attempts atomic.Int32
+	opts := testDaemonOptions(&fakeMonitor{}, "A", "B")
+	opts.initHID = func() error {
+		if attempts.Add(1) == 1 {
+			return errors.New("hid_init failed")
+		}
+		return nil
+	}
+	opts.hidRetryInterval = 10 * time.Millisecond
+
+	d, err := buildDaemon(opts)
+	require.NoError(t, err, "a failed HID init doesn't stop the daemon")
+
+	available, message, _ := d.server.GetHIDStatus()
+	assert.False(t, available)
+	assert.Equal(t, "hid_init failed", message)
+	displays, _ := d.server.ListDisplays()
+	assert.Empty(t, displays, "degraded mode serves no displays")
+
+	require.Eventually(t, func() bool { return d.manager.Count() == 2 }, time.Second, 5*time.Millisecond)
+	available, message, _ = d.server.GetHIDStatus()
+	assert.True(t, available)
+	assert.Empty(t, message)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.Run(ctx)
+	assert.Equal(t, int32(2), attempts.Load(), "retries stop once initialization succeeds")
+}
@@ -0,0 +1,300 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/cobra"
+	gohid "github.com/sstallion/go-hid"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/shini4i/asd-brightness-daemon/internal/udev"
+)
+
+// defaultDiagnoseUdevWait is how long diagnose listens for udev events.
+const defaultDiagnoseUdevWait = 10 * time.Second
+
+var diagnoseUdevWait time.Duration
+
+var diagnoseCmd = &cobra.Command{
+	Use:   "diagnose",
+	Short: "Print a diagnostic report to attach to bug reports",
+	Long: `diagnose collects the information needed to debug display detection and
+brightness control problems: enumerated HID interfaces, hidraw permissions, a
+feature report round-trip per display, the kernel version and whether udev
+events are received. Replug a display while it listens for udev events.
+
+Stop the daemon first so the report reflects what the daemon itself would see.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		if err := gohid.Init(); err != nil {
+			return fmt.Errorf("failed to initialize HID library: %w", err)
+		}
+		defer func() { _ = gohid.Exit() }()
+
+		return newDiagnostics(diagnoseUdevWait).write(cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	diagnoseCmd.Flags().DurationVar(&diagnoseUdevWait, "udev-wait", defaultDiagnoseUdevWait,
+		"How long to listen for udev events (0 skips the check)")
+	rootCmd.AddCommand(diagnoseCmd)
+}
+
+// diagnostics gathers the diagnose report. Every system-facing dependency is a
+// field so tests can run each check without hardware.
+type diagnostics struct {
+	enumerate     func() ([]hid.DeviceInfo, error)        // all Studio Display HID interfaces
+	open          func(serial string) (hid.Device, error) // opens the brightness interface
+	checkAccess   func(path string) error                 // nil if path can be opened read-write
+	usbSpeed      func(hid.DeviceInfo) string             // USB link speed, "" if unknown
+	kernelRelease func() (string, error)                  // running kernel version
+	newMonitor    func(udev.EventHandler) hotplugMonitor  // udev event source
+	udevWait      time.Duration                           // 0 skips the udev check
+
+	failure error // first failed display check, returned by write
+}
+
+// newDiagnostics returns diagnostics wired to the real system.
+func newDiagnostics(udevWait time.Duration) *diagnostics {
+	return &diagnostics{
+		enumerate: hid.EnumerateInterfaces,
+		open: func(serial string) (hid.Device, error) {
+			return hid.OpenDisplay(serial)
+		},
+		checkAccess:   checkReadWrite,
+		usbSpeed:      hid.USBSpeed,
+		kernelRelease: readKernelRelease,
+		newMonitor:    newUdevMonitor,
+		udevWait:      udevWait,
+	}
+}
+
+// write runs all checks and writes the report to w. Failing checks are reported
+// inline rather than aborting, so the report is always complete; the first
+// failure of a display check is returned afterwards, so the exit code tells
+// what went wrong (see exitCode). The kernel and udev checks are informational.
+func (d *diagnostics) write(w io.Writer) error {
+	fmt.Fprintln(w, "asd-brightness-daemon diagnostics")
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "== Kernel ==")
+	fmt.Fprintln(w, d.kernel())
+	fmt.Fprintln(w)
+
+	interfaces, err := d.enumerate()
+	fmt.Fprintln(w, "== HID interfaces ==")
+	writeLines(w, d.interfaces(interfaces, err))
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "== hidraw access ==")
+	writeLines(w, d.access(interfaces))
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "== USB speed ==")
+	writeLines(w, d.usbSpeeds(interfaces))
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "== Feature report round-trip ==")
+	writeLines(w, d.roundTrips(interfaces))
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "== udev events ==")
+	fmt.Fprintln(w, d.udevEvents())
+
+	if d.failure != nil {
+		return fmt.Errorf("diagnostics found a problem: %w", d.failure)
+	}
+	return nil
+}
+
+// fail records err as the outcome of the diagnostics unless an earlier check failed.
+func (d *diagnostics) fail(err error) {
+	if d.failure == nil {
+		d.failure = err
+	}
+}
+
+// kernel reports the running kernel version.
+func (d *diagnostics) kernel() string {
+	release, err := d.kernelRelease()
+	if err != nil {
+		return fmt.Sprintf("unknown (%v)", err)
+	}
+	return release
+}
+
+// interfaces describes each enumerated HID interface, marking the one used for brightness.
+func (d *diagnostics) interfaces(interfaces []hid.DeviceInfo, err error) []string {
+	if err != nil {
+		d.fail(err)
+		return []string{fmt.Sprintf("enumeration failed: %v", err)}
+	}
+	if len(brightnessInterfaces(interfaces)) == 0 {
+		d.fail(hid.ErrNoDisplaysFound)
+	}
+	if len(interfaces) == 0 {
+		return []string{fmt.Sprintf("no devices with ID %04x:%04x found", hid.AppleVendorID, hid.StudioDisplayProductID)}
+	}
+
+	lines := make([]string, 0, len(interfaces))
+	for _, info := range interfaces {
+		line := fmt.Sprintf("interface %d serial=%q product=%q path=%s", info.Interface, info.Serial, info.Product, info.Path)
+		if info.Interface == hid.BrightnessInterface {
+			line += " (brightness)"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// access reports whether each brightness interface's device node can be opened read-write.
+func (d *diagnostics) access(interfaces []hid.DeviceInfo) []string {
+	var lines []string
+	for _, info := range brightnessInterfaces(interfaces) {
+		if err := d.checkAccess(info.Path); err != nil {
+			d.fail(err)
+			lines = append(lines, fmt.Sprintf("%s: not accessible: %v", info.Path, err))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s: ok", info.Path))
+	}
+	if len(lines) == 0 {
+		return []string{"skipped: no brightness interfaces found"}
+	}
+	return lines
+}
+
+// usbSpeeds reports the USB link speed of each brightness interface, flagging
+// displays connected at less than SuperSpeed, e.g. through a USB 2.0 hub.
+func (d *diagnostics) usbSpeeds(interfaces []hid.DeviceInfo) []string {
+	var lines []string
+	for _, info := range brightnessInterfaces(interfaces) {
+		switch speed := d.usbSpeed(info); speed {
+		case "":
+			lines = append(lines, fmt.Sprintf("%s: unknown", info.Path))
+		case hid.USBSpeedSuper, hid.USBSpeedSuperPlus:
+			lines = append(lines, fmt.Sprintf("%s: %s", info.Path, speed))
+		default:
+			lines = append(lines, fmt.Sprintf("%s: %s (slower than USB 3, check for a USB 2.0 hub or cable)", info.Path, speed))
+		}
+	}
+	if len(lines) == 0 {
+		return []string{"skipped: no brightness interfaces found"}
+	}
+	return lines
+}
+
+// roundTrips reads the brightness feature report of each display and writes the
+// same report back, which exercises both directions without changing brightness.
+func (d *diagnostics) roundTrips(interfaces []hid.DeviceInfo) []string {
+	var lines []string
+	for _, info := range brightnessInterfaces(interfaces) {
+		if info.Serial == "" {
+			lines = append(lines, fmt.Sprintf("%s: skipped: no serial number", info.Path))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", info.Serial, d.roundTrip(info.Serial)))
+	}
+	if len(lines) == 0 {
+		return []string{"skipped: no brightness interfaces found"}
+	}
+	return lines
+}
+
+// roundTrip performs a single read/write-back cycle and describes the outcome.
+func (d *diagnostics) roundTrip(serial string) string {
+	device, err := d.open(serial)
+	if err != nil {
+		d.fail(err)
+		return fmt.Sprintf("open failed: %v", err)
+	}
+	defer func() { _ = device.Close() }()
+
+	data := make([]byte, hid.ReportSize)
+	data[0] = hid.ReportID
+	n, err := device.GetFeatureReport(data)
+	if err != nil {
+		d.fail(err)
+		return fmt.Sprintf("read failed: %v", err)
+	}
+	report := data[:min(n, len(data))]
+
+	nits, err := hid.DecodeReport(report)
+	if err != nil {
+		d.fail(err)
+		return fmt.Sprintf("read returned % x: %v", report, err)
+	}
+
+	if _, err := device.SendFeatureReport(hid.EncodeReport(nits)); err != nil {
+		d.fail(err)
+		return fmt.Sprintf("read %d nits, write failed: %v", nits, err)
+	}
+	return fmt.Sprintf("ok (%d bytes, %d nits)", n, nits)
+}
+
+// udevEvents listens for udev events for udevWait and reports how many arrived.
+func (d *diagnostics) udevEvents() string {
+	if d.udevWait <= 0 {
+		return "skipped"
+	}
+
+	var events atomic.Int32
+	monitor := d.newMonitor(func(udev.Event) { events.Add(1) })
+	if err := monitor.Start(); err != nil {
+		return fmt.Sprintf("monitor failed to start: %v", err)
+	}
+	time.Sleep(d.udevWait)
+	if err := monitor.Stop(); err != nil {
+		return fmt.Sprintf("received %d events; monitor failed to stop: %v", events.Load(), err)
+	}
+
+	if events.Load() == 0 {
+		return fmt.Sprintf("no events received in %s (replug a display while listening to test this)", d.udevWait)
+	}
+	return fmt.Sprintf("received %d events in %s", events.Load(), d.udevWait)
+}
+
+// brightnessInterfaces returns the entries for the interface used for brightness control.
+func brightnessInterfaces(interfaces []hid.DeviceInfo) []hid.DeviceInfo {
+	var result []hid.DeviceInfo
+	for _, info := range interfaces {
+		if info.Interface == hid.BrightnessInterface {
+			result = append(result, info)
+		}
+	}
+	return result
+}
+
+// writeLines writes each line to w.
+func writeLines(w io.Writer, lines []string) {
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+}
+
+// checkReadWrite opens path read-write and closes it again.
+func checkReadWrite(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0) // #nosec G304 -- path comes from HID enumeration
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// readKernelRelease returns the running kernel version.
+func readKernelRelease() (string, error) {
+	data, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
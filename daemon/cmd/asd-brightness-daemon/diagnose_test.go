@@ -0,0 +1,279 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/shini4i/asd-brightness-daemon/internal/udev"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reportDevice answers feature report reads with a fixed report and records writes.
+type reportDevice struct {
+	mockDevice
+	report   []byte
+	readErr  error
+	writeErr error
+	written  []byte
+}
+
+func (d *reportDevice) GetFeatureReport(data []byte) (int, error) {
+	if d.readErr != nil {
+		return 0, d.readErr
+	}
+	return copy(data, d.report), nil
+}
+
+func (d *reportDevice) SendFeatureReport(data []byte) (int, error) {
+	if d.writeErr != nil {
+		return 0, d.writeErr
+	}
+	d.written = append([]byte(nil), data...)
+	return len(data), nil
+}
+
+// eventMonitor delivers a number of events to its handler when started.
+type eventMonitor struct {
+	fakeMonitor
+	handler udev.EventHandler
+	events  int
+}
+
+func (m *eventMonitor) Start() error {
+	if err := m.fakeMonitor.Start(); err != nil {
+		return err
+	}
+	for range m.events {
+		m.handler(udev.Event{Type: udev.EventAdd})
+	}
+	return nil
+}
+
+func TestDiagnostics_Interfaces(t *testing.T) {
+	d := &diagnostics{}
+
+	lines := d.interfaces([]hid.DeviceInfo{
+		{Path: "/dev/hidraw3", Serial: "ABC", Product: "Studio Display", Interface: 0},
+		{Path: "/dev/hidraw4", Serial: "ABC", Product: "Studio Display", Interface: hid.BrightnessInterface},
+	}, nil)
+	require.Len(t, lines, 2)
+	assert.NotContains(t, lines[0], "(brightness)")
+	assert.Contains(t, lines[1], "interface 7")
+	assert.Contains(t, lines[1], "/dev/hidraw4")
+	assert.Contains(t, lines[1], "(brightness)")
+
+	assert.Contains(t, d.interfaces(nil, nil)[0], "no devices with ID 05ac:1114")
+	assert.Contains(t, d.interfaces(nil, errors.New("boom"))[0], "enumeration failed: boom")
+}
+
+func TestDiagnostics_Access(t *testing.T) {
+	d := &diagnostics{
+		checkAccess: func(path string) error {
+			if path == "/dev/hidraw5" {
+				return errors.New("permission denied")
+			}
+			return nil
+		},
+	}
+
+	lines := d.access([]hid.DeviceInfo{
+		{Path: "/dev/hidraw3", Interface: 0},
+		{Path: "/dev/hidraw4", Interface: hid.BrightnessInterface},
+		{Path: "/dev/hidraw5", Interface: hid.BrightnessInterface},
+	})
+	assert.Equal(t, []string{
+		"/dev/hidraw4: ok",
+		"/dev/hidraw5: not accessible: permission denied",
+	}, lines)
+
+	assert.Equal(t, []string{"skipped: no brightness interfaces found"}, d.access(nil))
+}
+
+func TestDiagnostics_USBSpeeds(t *testing.T) {
+	speeds := map[string]string{"/dev/hidraw4": hid.USBSpeedSuper, "/dev/hidraw5": hid.USBSpeedHigh}
+	d := &diagnostics{
+		usbSpeed: func(info hid.DeviceInfo) string { return speeds[info.Path] },
+	}
+
+	lines := d.usbSpeeds([]hid.DeviceInfo{
+		{Path: "/dev/hidraw3", Interface: 0},
+		{Path: "/dev/hidraw4", Interface: hid.BrightnessInterface},
+		{Path: "/dev/hidraw5", Interface: hid.BrightnessInterface},
+		{Path: "/dev/hidraw6", Interface: hid.BrightnessInterface},
+	})
+	assert.Equal(t, []string{
+		"/dev/hidraw4: Super",
+		"/dev/hidraw5: High (slower than USB 3, check for a USB 2.0 hub or cable)",
+		"/dev/hidraw6: unknown",
+	}, lines)
+
+	assert.Equal(t, []string{"skipped: no brightness interfaces found"}, d.usbSpeeds(nil))
+}
+
+func TestDiagnostics_RoundTrip(t *testing.T) {
+	report := hid.EncodeReport(30000)
+
+	tests := []struct {
+		name     string
+		device   *reportDevice
+		openErr  error
+		expected string
+		written  []byte
+	}{
+		{name: "writes back the report it read", device: &reportDevice{report: report}, expected: "ok (7 bytes, 30000 nits)", written: report},
+		{name: "open failure", openErr: errors.New("no such device"), expected: "open failed: no such device"},
+		{name: "read failure", device: &reportDevice{readErr: errors.New("EIO")}, expected: "read failed: EIO"},
+		{name: "short report", device: &reportDevice{report: []byte{0x01, 0x02}}, expected: "read returned 01 02"},
+		{name: "write failure", device: &reportDevice{report: report, writeErr: errors.New("EPIPE")}, expected: "read 30000 nits, write failed: EPIPE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &diagnostics{
+				open: func(serial string) (hid.Device, error) {
+					if tt.openErr != nil {
+						return nil, tt.openErr
+					}
+					return tt.device, nil
+				},
+			}
+
+			assert.Contains(t, d.roundTrip("ABC"), tt.expected)
+			if tt.written != nil {
+				assert.Equal(t, tt.written, tt.device.written)
+			}
+		})
+	}
+}
+
+func TestDiagnostics_RoundTrips_SkipsInterfacesWithoutSerial(t *testing.T) {
+	d := &diagnostics{
+		open: func(serial string) (hid.Device, error) {
+			return &reportDevice{report: hid.EncodeReport(400)}, nil
+		},
+	}
+
+	lines := d.roundTrips([]hid.DeviceInfo{
+		{Path: "/dev/hidraw4", Serial: "ABC", Interface: hid.BrightnessInterface},
+		{Path: "/dev/hidraw5", Interface: hid.BrightnessInterface},
+	})
+	assert.Equal(t, []string{
+		"ABC: ok (7 bytes, 400 nits)",
+		"/dev/hidraw5: skipped: no serial number",
+	}, lines)
+}
+
+func TestDiagnostics_Kernel(t *testing.T) {
+	d := &diagnostics{kernelRelease: func() (string, error) { return "6.8.0-45-generic", nil }}
+	assert.Equal(t, "6.8.0-45-generic", d.kernel())
+
+	d.kernelRelease = func() (string, error) { return "", errors.New("no procfs") }
+	assert.Equal(t, "unknown (no procfs)", d.kernel())
+}
+
+func TestDiagnostics_UdevEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		wait     time.Duration
+		events   int
+		startErr error
+		expected string
+	}{
+		{name: "disabled", wait: 0, expected: "skipped"},
+		{name: "events received", wait: time.Millisecond, events: 3, expected: "received 3 events"},
+		{name: "no events", wait: time.Millisecond, expected: "no events received"},
+		{name: "monitor fails", wait: time.Millisecond, startErr: errors.New("netlink unavailable"), expected: "monitor failed to start: netlink unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor := &eventMonitor{fakeMonitor: fakeMonitor{startErr: tt.startErr}, events: tt.events}
+			d := &diagnostics{
+				udevWait: tt.wait,
+				newMonitor: func(handler udev.EventHandler) hotplugMonitor {
+					monitor.handler = handler
+					return monitor
+				},
+			}
+
+			assert.Contains(t, d.udevEvents(), tt.expected)
+			assert.Equal(t, tt.startErr == nil && tt.wait > 0, monitor.stopped)
+		})
+	}
+}
+
+func TestDiagnostics_Write(t *testing.T) {
+	d := &diagnostics{
+		enumerate: func() ([]hid.DeviceInfo, error) {
+			return []hid.DeviceInfo{{Path: "/dev/hidraw4", Serial: "ABC", Interface: hid.BrightnessInterface}}, nil
+		},
+		open: func(serial string) (hid.Device, error) {
+			return &reportDevice{report: hid.EncodeReport(400)}, nil
+		},
+		checkAccess:   func(string) error { return nil },
+		usbSpeed:      func(hid.DeviceInfo) string { return hid.USBSpeedSuper },
+		kernelRelease: func() (string, error) { return "6.8.0", nil },
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, d.write(&out))
+
+	for _, want := range []string{
+		"== Kernel ==\n6.8.0\n",
+		"== HID interfaces ==\ninterface 7 serial=\"ABC\"",
+		"== hidraw access ==\n/dev/hidraw4: ok\n",
+		"== USB speed ==\n/dev/hidraw4: Super\n",
+		"== Feature report round-trip ==\nABC: ok (7 bytes, 400 nits)\n",
+		"== udev events ==\nskipped\n",
+	} {
+		assert.Contains(t, out.String(), want)
+	}
+}
+
+func TestDiagnostics_Write_ExitCode(t *testing.T) {
+	display := []hid.DeviceInfo{{Path: "/dev/hidraw4", Serial: "ABC", Interface: hid.BrightnessInterface}}
+
+	tests := []struct {
+		name       string
+		interfaces []hid.DeviceInfo
+		accessErr  error
+		readErr    error
+		code       int
+	}{
+		{name: "no displays", code: exitNoDisplays},
+		{
+			name:       "permission denied",
+			interfaces: display,
+			accessErr:  &os.PathError{Op: "open", Path: "/dev/hidraw4", Err: syscall.EACCES},
+			code:       exitPermissionDenied,
+		},
+		{name: "device error", interfaces: display, readErr: syscall.ENODEV, code: exitDeviceError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &diagnostics{
+				enumerate: func() ([]hid.DeviceInfo, error) { return tt.interfaces, nil },
+				open: func(serial string) (hid.Device, error) {
+					return &reportDevice{report: hid.EncodeReport(400), readErr: tt.readErr}, nil
+				},
+				checkAccess:   func(string) error { return tt.accessErr },
+				usbSpeed:      func(hid.DeviceInfo) string { return hid.USBSpeedSuper },
+				kernelRelease: func() (string, error) { return "6.8.0", nil },
+			}
+
+			var out bytes.Buffer
+			err := d.write(&out)
+			assert.Equal(t, tt.code, exitCode(err))
+			assert.Contains(t, out.String(), "== udev events ==", "the report is complete despite the failure")
+		})
+	}
+}
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/brightness"
+	"github.com/shini4i/asd-brightness-daemon/internal/dbus"
+)
+
+// displaysConfigKey is the config file key holding per-display overrides, keyed by
+// serial. It's the only config key that doesn't name a flag.
+const displaysConfigKey = "displays"
+
+// displayConfigKeys are the settings a per-display block may override.
+var displayConfigKeys = []string{"alias", "connect-brightness", "curve", "max-brightness", "min-brightness"}
+
+// parseDisplayConfigs validates the per-display blocks of the config file, e.g.
+//
+//	displays:
+//	  H1234567890:
+//	    alias: left
+//	    max-brightness: 80
+//
+// Serials are only checked for their format here; blocks for displays that never
+// connect are reported by the server at startup.
+func parseDisplayConfigs(value any) (map[string]dbus.DisplayConfig, error) {
+	blocks, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s must map serials to settings", errInvalidConfigValue, displaysConfigKey)
+	}
+
+	configs := make(map[string]dbus.DisplayConfig, len(blocks))
+	aliases := make(map[string]string)
+	for serial, block := range blocks {
+		if err := dbus.ValidateSerial(serial); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", errInvalidConfigValue, displaysConfigKey, err)
+		}
+		config, err := parseDisplayConfig(block)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", displaysConfigKey, serial, err)
+		}
+		if config.Alias != "" {
+			alias := strings.ToLower(config.Alias)
+			if other, taken := aliases[alias]; taken {
+				return nil, fmt.Errorf("%w: %s: alias %q used by both %s and %s",
+					errInvalidConfigValue, displaysConfigKey, config.Alias, min(other, serial), max(other, serial))
+			}
+			aliases[alias] = serial
+		}
+		configs[serial] = config
+	}
+	return configs, nil
+}
+
+// parseDisplayConfig validates the settings of a single display block.
+func parseDisplayConfig(value any) (dbus.DisplayConfig, error) {
+	var config dbus.DisplayConfig
+	settings, ok := value.(map[string]any)
+	if !ok {
+		return config, fmt.Errorf("%w: must be a map of settings", errInvalidConfigValue)
+	}
+
+	var unknown []string
+	for key := range settings {
+		if !slices.Contains(displayConfigKeys, key) {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return config, fmt.Errorf("%w: %s", errUnknownConfigKeys, strings.Join(unknown, ", "))
+	}
+
+	var err error
+	if v, ok := settings["alias"]; ok {
+		if config.Alias, err = configString(v, "alias"); err != nil {
+			return config, err
+		}
+		if err := dbus.ValidateSerial(config.Alias); err != nil {
+			return config, fmt.Errorf("%w: alias: %w", errInvalidConfigValue, err)
+		}
+	}
+	if v, ok := settings["curve"]; ok {
+		if config.Curve, err = configString(v, "curve"); err != nil {
+			return config, err
+		}
+		if !slices.Contains(brightness.Curves, config.Curve) {
+			return config, fmt.Errorf("%w: curve must be one of %q", errInvalidConfigValue, brightness.Curves)
+		}
+	}
+	if v, ok := settings["min-brightness"]; ok {
+		percent, err := configPercent(v, "min-brightness")
+		if err != nil {
+			return config, err
+		}
+		config.MinBrightness = &percent
+	}
+	if v, ok := settings["max-brightness"]; ok {
+		percent, err := configPercent(v, "max-brightness")
+		if err != nil {
+			return config, err
+		}
+		config.MaxBrightness = &percent
+	}
+	if v, ok := settings["connect-brightness"]; ok {
+		percent, ok := v.(int)
+		if !ok || percent > 100 {
+			return config, fmt.Errorf("%w: connect-brightness must be a percentage, or -1 to disable", errInvalidConfigValue)
+		}
+		config.StartupBrightness = &percent
+	}
+	return config, nil
+}
+
+// configString returns a per-display string setting.
+func configString(value any, key string) (string, error) {
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return "", fmt.Errorf("%w: %s must be a non-empty string", errInvalidConfigValue, key)
+	}
+	return s, nil
+}
+
+// configPercent returns a per-display percentage setting.
+func configPercent(value any, key string) (uint32, error) {
+	percent, ok := value.(int)
+	if !ok || percent < 0 || percent > 100 {
+		return 0, fmt.Errorf("%w: %s must be a percentage (0-100)", errInvalidConfigValue, key)
+	}
+	// #nosec G115 -- percent is checked to be 0-100
+	return uint32(percent), nil
+}
+
+// displayCurves returns the per-display curve settings for hid.WithCurves.
+func displayCurves(configs map[string]dbus.DisplayConfig) map[string]string {
+	curves := make(map[string]string)
+	for serial, config := range configs {
+		if config.Curve != "" {
+			curves[serial] = config.Curve
+		}
+	}
+	return curves
+}
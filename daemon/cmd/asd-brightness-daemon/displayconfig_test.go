@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_Displays(t *testing.T) {
+	flags := newConfigFlags(t)
+	displays, err := loadConfig(flags.set, writeConfig(t, `poll-interval: 10s
+displays:
+  H1234567890:
+    alias: left
+    curve: linear
+    min-brightness: 10
+    max-brightness: 80
+    connect-brightness: 60
+  H0987654321:
+    curve: perceptual
+    connect-brightness: -1
+`))
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Second, flags.pollInterval, "flags next to the displays block still apply")
+
+	require.Len(t, displays, 2)
+	left := displays["H1234567890"]
+	assert.Equal(t, "left", left.Alias)
+	assert.Equal(t, "linear", left.Curve)
+	require.NotNil(t, left.MinBrightness)
+	assert.Equal(t, uint32(10), *left.MinBrightness)
+	require.NotNil(t, left.MaxBrightness)
+	assert.Equal(t, uint32(80), *left.MaxBrightness)
+
+	right := displays["H0987654321"]
+	assert.Empty(t, right.Alias)
+	assert.Nil(t, right.MaxBrightness)
+
+	require.NotNil(t, left.StartupBrightness)
+	assert.Equal(t, 60, *left.StartupBrightness)
+	require.NotNil(t, right.StartupBrightness)
+	assert.Equal(t, -1, *right.StartupBrightness)
+	assert.Equal(t, map[string]string{"H1234567890": "linear", "H0987654321": "perceptual"}, displayCurves(displays))
+}
+
+func TestLoadConfig_DisplaysValidationErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     error
+		message string
+	}{
+		{name: "not a map", content: "displays: [A]\n", err: errInvalidConfigValue, message: "displays"},
+		{name: "invalid serial", content: "displays:\n  \"bad serial\":\n    alias: x\n", err: errInvalidConfigValue, message: "displays"},
+		{name: "unknown setting", content: "displays:\n  A:\n    brightness: 50\n", err: errUnknownConfigKeys, message: "brightness"},
+		{name: "bad percent", content: "displays:\n  A:\n    max-brightness: 120\n", err: errInvalidConfigValue, message: "max-brightness"},
+		{name: "bad connect brightness", content: "displays:\n  A:\n    connect-brightness: high\n", err: errInvalidConfigValue, message: "connect-brightness"},
+		{name: "unknown curve", content: "displays:\n  A:\n    curve: gamma\n", err: errInvalidConfigValue, message: "curve"},
+		{name: "duplicate alias", content: "displays:\n  A:\n    alias: main\n  B:\n    alias: Main\n", err: errInvalidConfigValue, message: "used by both A and B"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := newConfigFlags(t)
+			_, err := loadConfig(flags.set, writeConfig(t, "poll-interval: 1s\n"+tt.content))
+			require.ErrorIs(t, err, tt.err)
+			assert.Contains(t, err.Error(), tt.message)
+			assert.Equal(t, 5*time.Second, flags.pollInterval, "no flag changes on validation errors")
+		})
+	}
+}
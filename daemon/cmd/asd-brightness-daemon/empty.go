@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// defaultEmptyGracePeriod is how long no displays must be connected before
+	// --exit-when-empty shuts the daemon down.
+	defaultEmptyGracePeriod = 30 * time.Second
+
+	// emptyCheckInterval is how often the display count is checked for --exit-when-empty.
+	emptyCheckInterval = time.Second
+)
+
+// emptyWatcher fires a callback once no displays have been connected for a grace period.
+// A display reappearing within the grace period (e.g. a brief dock disconnect) resets it.
+type emptyWatcher struct {
+	grace   time.Duration
+	count   func() int
+	onEmpty func()
+	now     func() time.Time
+
+	mu         sync.Mutex
+	emptySince time.Time // zero while displays are connected
+	fired      bool
+}
+
+// newEmptyWatcher creates a watcher that calls onEmpty after count has reported
+// zero for at least grace. onEmpty is called at most once.
+func newEmptyWatcher(grace time.Duration, count func() int, onEmpty func()) *emptyWatcher {
+	return &emptyWatcher{
+		grace:   grace,
+		count:   count,
+		onEmpty: onEmpty,
+		now:     time.Now,
+	}
+}
+
+// check samples the display count and fires onEmpty if the grace period has elapsed.
+// It is intended to be driven periodically, e.g. by a displayPoller.
+func (w *emptyWatcher) check() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.fired {
+		return
+	}
+
+	if w.count() > 0 {
+		if !w.emptySince.IsZero() {
+			log.Info().Msg("Display reconnected, canceling exit-when-empty")
+			w.emptySince = time.Time{}
+		}
+		return
+	}
+
+	now := w.now()
+	if w.emptySince.IsZero() {
+		w.emptySince = now
+		log.Info().Dur("grace", w.grace).Msg("No displays connected, exiting if none reappear")
+		return
+	}
+
+	if now.Sub(w.emptySince) >= w.grace {
+		w.fired = true
+		log.Info().Msg("No displays connected for grace period, shutting down")
+		w.onEmpty()
+	}
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestEmptyWatcher returns a watcher driven by a manual clock and display count.
+func newTestEmptyWatcher(grace time.Duration, count *int, fired *int) (*emptyWatcher, *time.Time) {
+	clock := time.Unix(1000, 0)
+	w := newEmptyWatcher(grace, func() int { return *count }, func() { *fired++ })
+	w.now = func() time.Time { return clock }
+	return w, &clock
+}
+
+func TestEmptyWatcher(t *testing.T) {
+	t.Run("fires after grace period with no displays", func(t *testing.T) {
+		count, fired := 0, 0
+		w, clock := newTestEmptyWatcher(30*time.Second, &count, &fired)
+
+		w.check()
+		*clock = clock.Add(29 * time.Second)
+		w.check()
+		assert.Equal(t, 0, fired, "should not fire before the grace period")
+
+		*clock = clock.Add(time.Second)
+		w.check()
+		assert.Equal(t, 1, fired)
+
+		// Fires only once
+		*clock = clock.Add(time.Minute)
+		w.check()
+		assert.Equal(t, 1, fired)
+	})
+
+	t.Run("quick reconnect cancels the timeout", func(t *testing.T) {
+		count, fired := 0, 0
+		w, clock := newTestEmptyWatcher(30*time.Second, &count, &fired)
+
+		w.check()
+		*clock = clock.Add(20 * time.Second)
+		count = 1
+		w.check()
+
+		// Disconnect again: the grace period restarts from here
+		count = 0
+		*clock = clock.Add(time.Second)
+		w.check()
+		*clock = clock.Add(20 * time.Second)
+		w.check()
+		assert.Equal(t, 0, fired)
+
+		*clock = clock.Add(10 * time.Second)
+		w.check()
+		assert.Equal(t, 1, fired)
+	})
+
+	t.Run("never fires while displays are connected", func(t *testing.T) {
+		count, fired := 2, 0
+		w, clock := newTestEmptyWatcher(time.Second, &count, &fired)
+
+		for range 5 {
+			w.check()
+			*clock = clock.Add(time.Minute)
+		}
+		assert.Equal(t, 0, fired)
+	})
+}
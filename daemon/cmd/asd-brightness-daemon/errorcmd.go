@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/dbus"
+)
+
+const (
+	// errorCommandTimeout bounds how long an --on-device-error-command may run.
+	errorCommandTimeout = 10 * time.Second
+
+	// maxConcurrentErrorCommands bounds how many error commands may run at once.
+	// Further errors are dropped (and logged) rather than queued.
+	maxConcurrentErrorCommands = 2
+
+	// maxErrorArgLength truncates error messages passed to the command.
+	maxErrorArgLength = 512
+)
+
+// commandRunner runs an executable with the given arguments and extra environment.
+type commandRunner func(ctx context.Context, path string, args []string, env []string) error
+
+// runCommand is the default commandRunner. The command is executed directly,
+// never through a shell, so arguments cannot be interpreted as shell syntax.
+func runCommand(ctx context.Context, path string, args []string, env []string) error {
+	// #nosec G204 -- path is an operator-configured executable and args are sanitized
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Env = append(os.Environ(), env...)
+	return cmd.Run()
+}
+
+// errorCommand runs a user-configured executable when a device error is detected.
+// The serial and error message are passed as arguments and as the
+// ASD_SERIAL and ASD_ERROR environment variables.
+type errorCommand struct {
+	path    string
+	timeout time.Duration
+	run     commandRunner
+	slots   chan struct{}
+}
+
+// newErrorCommand creates an errorCommand for the executable at path.
+func newErrorCommand(path string) *errorCommand {
+	return &errorCommand{
+		path:    path,
+		timeout: errorCommandTimeout,
+		run:     runCommand,
+		slots:   make(chan struct{}, maxConcurrentErrorCommands),
+	}
+}
+
+// Notify runs the command for a device error. It blocks until the command exits or
+// times out, so callers should invoke it from a goroutine. If too many commands are
+// already running, the notification is dropped.
+func (c *errorCommand) Notify(serial string, err error) {
+	select {
+	case c.slots <- struct{}{}:
+	default:
+		log.Warn().Str("serial", serial).Msg("Device error command already running, skipping")
+		return
+	}
+	defer func() { <-c.slots }()
+
+	serialArg := sanitizeCommandArg(serial)
+	errArg := sanitizeCommandArg(err.Error())
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	runErr := c.run(ctx, c.path, []string{serialArg, errArg}, []string{
+		"ASD_SERIAL=" + serialArg,
+		"ASD_ERROR=" + errArg,
+	})
+	if runErr != nil {
+		log.Warn().Err(runErr).Str("command", c.path).Str("serial", serial).Msg("Device error command failed")
+		return
+	}
+	log.Debug().Str("command", c.path).Str("serial", serial).Msg("Device error command completed")
+}
+
+// sanitizeCommandArg strips control characters (including newlines) and a leading
+// dash, so a value can't be mistaken for an option, and truncates it on a rune
+// boundary.
+func sanitizeCommandArg(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+	s = strings.TrimLeft(s, "-")
+	if len(s) > maxErrorArgLength {
+		end := maxErrorArgLength
+		for end > 0 && !utf8.RuneStart(s[end]) {
+			end--
+		}
+		s = s[:end]
+	}
+	return s
+}
+
+// withErrorCommand wraps a device error handler so that cmd is notified as well.
+// If cmd is nil the handler is returned unchanged.
+func withErrorCommand(handler dbus.DeviceErrorHandler, cmd *errorCommand) dbus.DeviceErrorHandler {
+	if cmd == nil {
+		return handler
+	}
+	return func(serial string, err error) {
+		go cmd.Notify(serial, err)
+		handler(serial, err)
+	}
+}
@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingRunner is a commandRunner that records invocations.
+type recordingRunner struct {
+	mu    sync.Mutex
+	calls []recordedCommand
+	block chan struct{} // if set, runs wait until it is closed
+}
+
+type recordedCommand struct {
+	path string
+	args []string
+	env  []string
+}
+
+func (r *recordingRunner) run(_ context.Context, path string, args []string, env []string) error {
+	r.mu.Lock()
+	r.calls = append(r.calls, recordedCommand{path: path, args: args, env: env})
+	r.mu.Unlock()
+	if r.block != nil {
+		<-r.block
+	}
+	return nil
+}
+
+func (r *recordingRunner) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.calls)
+}
+
+func TestErrorCommand_Notify(t *testing.T) {
+	runner := &recordingRunner{}
+	cmd := newErrorCommand("/usr/local/bin/notify")
+	cmd.run = runner.run
+
+	cmd.Notify("ABC123", errors.New("display ABC123: no such device"))
+
+	require.Equal(t, 1, runner.count())
+	call := runner.calls[0]
+	assert.Equal(t, "/usr/local/bin/notify", call.path)
+	assert.Equal(t, []string{"ABC123", "display ABC123: no such device"}, call.args)
+	assert.Contains(t, call.env, "ASD_SERIAL=ABC123")
+	assert.Contains(t, call.env, "ASD_ERROR=display ABC123: no such device")
+}
+
+func TestErrorCommand_DropsWhenBusy(t *testing.T) {
+	runner := &recordingRunner{block: make(chan struct{})}
+	cmd := newErrorCommand("/bin/true")
+	cmd.run = runner.run
+
+	var wg sync.WaitGroup
+	for range maxConcurrentErrorCommands {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cmd.Notify("A", errors.New("gone"))
+		}()
+	}
+	require.Eventually(t, func() bool { return runner.count() == maxConcurrentErrorCommands },
+		time.Second, 5*time.Millisecond)
+
+	// All slots are taken: this call returns immediately without running
+	cmd.Notify("A", errors.New("gone"))
+	assert.Equal(t, maxConcurrentErrorCommands, runner.count())
+
+	close(runner.block)
+	wg.Wait()
+}
+
+func TestSanitizeCommandArg(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "plain value", input: "ABC123", expected: "ABC123"},
+		{name: "strips newlines", input: "line1\nline2\r", expected: "line1line2"},
+		{name: "strips leading dashes", input: "--exec=rm", expected: "exec=rm"},
+		{name: "keeps shell characters literal", input: "a; rm -rf $HOME", expected: "a; rm -rf $HOME"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, sanitizeCommandArg(tt.input))
+		})
+	}
+
+	long := make([]byte, maxErrorArgLength*2)
+	for i := range long {
+		long[i] = 'x'
+	}
+	assert.Len(t, sanitizeCommandArg(string(long)), maxErrorArgLength)
+
+	// A multi-byte rune straddling the limit is dropped whole, not split
+	straddling := strings.Repeat("x", maxErrorArgLength-1) + "é" + "tail"
+	truncated := sanitizeCommandArg(straddling)
+	assert.True(t, utf8.ValidString(truncated))
+	assert.Equal(t, strings.Repeat("x", maxErrorArgLength-1), truncated)
+}
+
+func TestWithErrorCommand(t *testing.T) {
+	var handled int
+	handler := func(string, error) { handled++ }
+
+	// No command configured: the handler is used as-is
+	withErrorCommand(handler, nil)("A", errors.New("gone"))
+	assert.Equal(t, 1, handled)
+
+	runner := &recordingRunner{}
+	cmd := newErrorCommand("/bin/true")
+	cmd.run = runner.run
+
+	withErrorCommand(handler, cmd)("A", errors.New("gone"))
+	assert.Equal(t, 2, handled)
+	assert.Eventually(t, func() bool { return runner.count() == 1 }, time.Second, 5*time.Millisecond)
+}
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"errors"
+	"io/fs"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+)
+
+// Exit codes of failed commands, i.e. the daemon failing to start and diagnose
+// finding a problem, so scripts can tell failure modes apart.
+const (
+	exitFailure          = 1 // any error not listed below
+	exitNoDisplays       = 2 // no Studio Display is connected
+	exitPermissionDenied = 3 // a hidraw node or other file couldn't be opened
+	exitDeviceError      = 4 // a display failed to read or write
+	exitDisplayNotFound  = 5 // no display has the requested serial
+)
+
+// exitCode maps the error a command failed with to the process exit code; nil
+// maps to 0.
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, hid.ErrNoDisplaysFound):
+		return exitNoDisplays
+	case errors.Is(err, fs.ErrPermission):
+		return exitPermissionDenied
+	case errors.Is(err, hid.ErrDisplayNotFound), errors.Is(err, hid.ErrAmbiguousSerial):
+		return exitDisplayNotFound
+	case isDeviceError(err):
+		return exitDeviceError
+	default:
+		return exitFailure
+	}
+}
+
+// isDeviceError reports whether err comes from talking to a display rather than
+// from finding one.
+func isDeviceError(err error) bool {
+	return hid.IsDeviceGoneError(err) ||
+		errors.Is(err, hid.ErrDisplayClosed) ||
+		errors.Is(err, hid.ErrDeviceNotReady) ||
+		errors.Is(err, hid.ErrShortReport) ||
+		errors.Is(err, hid.ErrInvalidReportLength)
+}
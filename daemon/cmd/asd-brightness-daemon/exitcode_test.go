@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{name: "success", err: nil, code: 0},
+		{name: "unclassified", err: errors.New("boom"), code: exitFailure},
+		{name: "no displays", err: hid.ErrNoDisplaysFound, code: exitNoDisplays},
+		{name: "permission denied", err: &os.PathError{Op: "open", Path: "/dev/hidraw3", Err: syscall.EACCES}, code: exitPermissionDenied},
+		{name: "wrapped permission denied", err: fmt.Errorf("failed to open display: %w", os.ErrPermission), code: exitPermissionDenied},
+		{name: "device gone", err: fmt.Errorf("failed to get feature report: %w", syscall.ENODEV), code: exitDeviceError},
+		{name: "short report", err: fmt.Errorf("%w: got 2 bytes", hid.ErrShortReport), code: exitDeviceError},
+		{name: "display closed", err: hid.ErrDisplayClosed, code: exitDeviceError},
+		{name: "unknown serial", err: fmt.Errorf("%w: serial H123", hid.ErrDisplayNotFound), code: exitDisplayNotFound},
+		{name: "ambiguous serial", err: hid.ErrAmbiguousSerial, code: exitDisplayNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.code, exitCode(tt.err))
+		})
+	}
+}
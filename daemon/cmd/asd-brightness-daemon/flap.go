@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/udev"
+)
+
+// hotplugDebouncer holds hot-plug events back until none has arrived for a window
+// and then passes only the last one on. A display flapping on a marginal cable thus
+// causes a single refresh, whose snapshot diff reports the net change, instead of a
+// storm of DisplayAdded and DisplayRemoved signals.
+type hotplugDebouncer struct {
+	window  time.Duration
+	handler udev.EventHandler
+
+	mu         sync.Mutex
+	last       udev.Event
+	timer      *time.Timer
+	generation int // bumped per event so a superseded timer that already fired does nothing
+	dropped    int // events collapsed into the pending one
+	stopped    bool
+}
+
+// newHotplugDebouncer creates a debouncer passing settled events on to handler.
+func newHotplugDebouncer(window time.Duration, handler udev.EventHandler) *hotplugDebouncer {
+	return &hotplugDebouncer{window: window, handler: handler}
+}
+
+// handle queues event, replacing any event still waiting for the window to pass.
+func (d *hotplugDebouncer) handle(event udev.Event) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stopped {
+		return
+	}
+	if d.timer != nil && d.timer.Stop() {
+		d.dropped++
+	}
+	d.last = event
+	d.generation++
+	generation := d.generation
+	d.timer = time.AfterFunc(d.window, func() { d.fire(generation) })
+}
+
+// fire passes the pending event on, unless a newer event superseded it.
+func (d *hotplugDebouncer) fire(generation int) {
+	d.mu.Lock()
+	if d.stopped || generation != d.generation {
+		d.mu.Unlock()
+		return
+	}
+	event, dropped := d.last, d.dropped
+	d.timer, d.dropped = nil, 0
+	d.mu.Unlock()
+
+	if dropped > 0 {
+		log.Info().Int("collapsed", dropped).Msg("Collapsed flapping hot-plug events")
+	}
+	d.handler(event)
+}
+
+// stop drops any pending event. Events arriving afterwards are ignored.
+func (d *hotplugDebouncer) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.stopped = true
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+}
+
+// debouncedMonitor is a udev monitor whose events pass through a hotplugDebouncer.
+type debouncedMonitor struct {
+	hotplugMonitor
+	debouncer *hotplugDebouncer
+}
+
+// Stop drops pending events, then stops the monitor.
+func (m debouncedMonitor) Stop() error {
+	m.debouncer.stop()
+	return m.hotplugMonitor.Stop()
+}
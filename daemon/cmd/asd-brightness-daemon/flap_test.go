@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/udev"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventRecorder is an event handler that records the events it receives.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []udev.Event
+}
+
+func (r *eventRecorder) handle(event udev.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *eventRecorder) received() []udev.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]udev.Event(nil), r.events...)
+}
+
+func TestHotplugDebouncer_CollapsesFlapping(t *testing.T) {
+	recorder := &eventRecorder{}
+	debouncer := newHotplugDebouncer(50*time.Millisecond, recorder.handle)
+
+	for _, eventType := range []udev.EventType{udev.EventAdd, udev.EventRemove, udev.EventAdd, udev.EventRemove, udev.EventAdd} {
+		debouncer.handle(udev.Event{Type: eventType})
+	}
+	assert.Empty(t, recorder.received(), "nothing happens while the display keeps flapping")
+
+	require.Eventually(t, func() bool { return len(recorder.received()) == 1 }, time.Second, 5*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, []udev.Event{{Type: udev.EventAdd}}, recorder.received(), "only the settled state is acted on")
+
+	// A later, separate event is handled on its own
+	debouncer.handle(udev.Event{Type: udev.EventRemove})
+	require.Eventually(t, func() bool { return len(recorder.received()) == 2 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, udev.Event{Type: udev.EventRemove}, recorder.received()[1])
+}
+
+func TestHotplugDebouncer_StopDropsPendingEvents(t *testing.T) {
+	recorder := &eventRecorder{}
+	debouncer := newHotplugDebouncer(20*time.Millisecond, recorder.handle)
+
+	debouncer.handle(udev.Event{Type: udev.EventAdd})
+	debouncer.stop()
+	debouncer.handle(udev.Event{Type: udev.EventRemove})
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Empty(t, recorder.received())
+}
+
+func TestStartHotplugDetection_Debounce(t *testing.T) {
+	monitor := &fakeMonitor{}
+	source := startHotplugDetection(hotplugConfig{debounce: time.Second}, func(udev.EventHandler) hotplugMonitor {
+		return monitor
+	}, nil, nil)
+
+	debounced, ok := source.(debouncedMonitor)
+	require.True(t, ok, "events go through a debouncer")
+	assert.Same(t, monitor, debounced.hotplugMonitor)
+
+	require.NoError(t, source.Stop())
+	assert.True(t, monitor.stopped)
+	assert.True(t, debounced.debouncer.stopped)
+}
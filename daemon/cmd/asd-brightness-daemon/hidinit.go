@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// defaultHIDInitRetryInterval is how often the daemon retries initializing the
+// HID library while running in degraded mode.
+const defaultHIDInitRetryInterval = 10 * time.Second
+
+// newHIDInitRetry returns a poll callback that retries initHID until it succeeds,
+// then calls onReady once. Later calls do nothing.
+func newHIDInitRetry(initHID func() error, onReady func()) func() {
+	var ready atomic.Bool
+	return func() {
+		if ready.Load() {
+			return
+		}
+		if err := initHID(); err != nil {
+			log.Debug().Err(err).Msg("HID library still unavailable")
+			return
+		}
+		ready.Store(true)
+		log.Info().Msg("HID library initialized, leaving degraded mode")
+		onReady()
+	}
+}
@@ -5,6 +5,8 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/signal"
 	"sync"
@@ -22,26 +24,220 @@ import (
 )
 
 var (
-	verbose bool
-	rootCmd = &cobra.Command{
+	verbose        bool
+	noUdev         bool
+	pollInterval   time.Duration
+	notFoundPolicy string
+	maxDisplays    int
+	exitWhenEmpty  bool
+	siblingWait    time.Duration
+	hotplugSettle  time.Duration
+	defaultBright  uint32
+	errorCmdPath   string
+	brightPoll     time.Duration
+	smoothSteps    int
+	productAllow   []string
+	refreshBudget  time.Duration
+	connectBright  int
+	resetLevel     int
+	emptyGrace     time.Duration
+	sdrMax         uint32
+	sdrLevel       uint32
+	hdrMax         uint32
+	hdrLevel       uint32
+	nightMax       uint32
+	nightLevel     uint32
+	extOnlyMax     uint32
+	extOnlyLevel   uint32
+	setAllSignals  bool
+	healthCheck    time.Duration
+	minBright      uint32
+	stops          []uint
+	cacheTTL       time.Duration
+	readyTimeout   time.Duration
+	openWorkers    int
+	configPath     string
+	displayConfigs map[string]dbus.DisplayConfig // per-display overrides from the config file
+	ctrlQueue      bool
+	handleGrace    time.Duration
+	eioRetries     int
+	resumeRestore  bool
+	retentionDays  int
+	contentionSig  bool
+	enableRaw      bool
+	checkedRead    bool
+	staleFallback  bool
+	stepWindow     time.Duration
+	maxStepDelta   uint32
+	warmUpPeriod   time.Duration
+	warmUpPolicy   string
+	migrateSerials bool
+	verifyWrites   bool
+	experimental   bool
+	backlightDir   string
+	drmDir         string
+	advisoryLock   bool
+	controlSocket  string
+	errorDebounce  time.Duration
+	maxRecoveries  int
+	silentChanges  bool
+	idleStandby    time.Duration
+	noRateLimit    bool
+	addedV2        bool
+	stepTimeout    time.Duration
+	rootCmd        = &cobra.Command{
 		Use:   "asd-brightness-daemon",
 		Short: "D-Bus daemon for controlling Apple Studio Display brightness",
 		Long: `asd-brightness-daemon is a D-Bus service that provides an interface
 for controlling the brightness of Apple Studio Display monitors via USB HID.
 
 It exposes methods for listing connected displays, getting and setting
-brightness levels, and emits signals when displays are connected or disconnected.`,
-		Run: func(cmd *cobra.Command, args []string) {
-			run()
+brightness levels, and emits signals when displays are connected or disconnected.
+
+Every flag can also be set in $XDG_CONFIG_HOME/asd-brightness/config.yaml using
+the flag name as the key, e.g. "poll-interval: 10s". Command-line flags take
+precedence over the config file. A "displays" block in the config file overrides
+alias, curve, min-brightness, max-brightness and connect-brightness for single
+displays, keyed by serial.`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			displayConfigs, err = loadConfig(cmd.Flags(), configPath)
+			return err
 		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return run()
+		},
+		// main reports the error once, together with its exit code
+		SilenceErrors: true,
 	}
 )
 
 func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose logging")
+	rootCmd.Flags().StringVar(&configPath, "config", "",
+		"Config file to read (default $XDG_CONFIG_HOME/asd-brightness/config.yaml, skipped if missing)")
+	rootCmd.Flags().BoolVar(&noUdev, "no-udev", false, "Disable udev hot-plug monitoring and poll for displays instead")
+	rootCmd.Flags().DurationVar(&pollInterval, "poll-interval", defaultPollInterval,
+		"Display polling interval used when udev monitoring is disabled or unavailable (0 disables polling)")
+	rootCmd.Flags().DurationVar(&refreshBudget, "refresh-budget", defaultRefreshBudget,
+		"Maximum total time a hot-plug or recovery refresh may spend retrying (0 means no limit)")
+	rootCmd.Flags().DurationVar(&healthCheck, "health-check-interval", 0,
+		"Re-enumerate displays about this often to catch missed hot-plug events, e.g. 60s (0 disables)")
+	rootCmd.Flags().StringSliceVar(&productAllow, "product-allowlist", nil,
+		"Only track displays whose product string contains one of these substrings, e.g. \"Studio Display\" (default: all)")
+	rootCmd.Flags().DurationVar(&hotplugSettle, "hotplug-debounce", 0,
+		"Wait until udev events for displays have stopped for this long before acting on them, collapsing a flapping cable's add/remove storm into its net change, e.g. 1s (0 acts at once)")
+	rootCmd.Flags().DurationVar(&siblingWait, "sibling-wait", defaultSiblingWait,
+		"How long to keep polling for other displays on the same dock after one connects (0 disables)")
+	rootCmd.Flags().StringVar(&notFoundPolicy, "not-found-policy", "error",
+		"How to handle brightness changes for unknown serials: error, warn or silent")
+	rootCmd.Flags().IntVar(&maxDisplays, "max-displays", hid.DefaultMaxDisplays,
+		"Maximum number of displays to track (protects against misbehaving docks)")
+	rootCmd.Flags().DurationVar(&readyTimeout, "readiness-timeout", defaultReadinessTimeout,
+		"How long to wait for a newly connected display to answer feature reports before giving up (0 disables the check)")
+	rootCmd.Flags().IntVar(&openWorkers, "open-concurrency", hid.DefaultOpenConcurrency,
+		"Maximum number of newly found displays to open in parallel")
+	rootCmd.Flags().BoolVar(&ctrlQueue, "serialize-controller-writes", false,
+		"Serialize brightness writes to displays sharing a USB host controller, for controllers that fail parallel writes with EIO")
+	rootCmd.Flags().DurationVar(&handleGrace, "handle-grace", 0,
+		"Keep a disconnected display's handle open this long and reuse it if the display returns, e.g. 2s (0 closes immediately)")
+	rootCmd.Flags().IntVar(&eioRetries, "transient-retries", defaultTransientRetries,
+		"Retry a brightness read or write this many times on EIO before treating the display as disconnected (0 disables)")
+	rootCmd.Flags().BoolVar(&noRateLimit, "no-rate-limit", false,
+		"Don't rate limit brightness changes, for trusted local scripts; any client on the session bus can then flood the displays with writes")
+	rootCmd.Flags().BoolVar(&addedV2, "display-added-v2", false,
+		"Emit DisplayAddedV2 with the full device info of a display after every DisplayAdded")
+	rootCmd.Flags().BoolVar(&silentChanges, "silent-daemon-changes", false,
+		"Don't emit BrightnessChanged when the daemon dims displays for idleness or restores them, only for client requests")
+	rootCmd.Flags().DurationVar(&idleStandby, "idle-standby-after", 0,
+		"Put displays that support standby into standby after this long without activity while idle dimming is enabled; requires --experimental-reports (0 disables)")
+	rootCmd.Flags().BoolVar(&verifyWrites, "verify-writes", false,
+		"Read the brightness back after every write and warn if the display rounded or ignored it (costs an extra HID round-trip)")
+	rootCmd.Flags().BoolVar(&experimental, "experimental-reports", false,
+		"Query displays for undocumented feature reports such as calibration data or the serial of displays with a blank USB serial; their layouts are unverified guesses no shipping firmware is known to answer")
+	rootCmd.Flags().BoolVar(&advisoryLock, "advisory-lock", false,
+		"Flock each display's device node around brightness writes and back off while another tool holds the lock")
+	rootCmd.Flags().StringVar(&controlSocket, "control-socket", "",
+		"Path of a unix socket accepting line-based get/set brightness requests from low-latency clients (empty disables it)")
+	rootCmd.Flags().StringVar(&backlightDir, "backlight-dir", hid.DefaultBacklightDir,
+		"Sysfs backlight directory used for displays whose HID interface can't be opened (empty disables the fallback)")
+	rootCmd.Flags().StringVar(&drmDir, "drm-dir", hid.DefaultDRMDir,
+		"Sysfs DRM directory used to map displays to connector names such as DP-1 for the ByConnector methods (empty disables the mapping)")
+	rootCmd.Flags().BoolVar(&migrateSerials, "migrate-serial-changes", false,
+		"Carry a display's brightness mode and last brightness over when it reappears on the same USB port under a new serial, e.g. after a firmware update")
+	rootCmd.Flags().IntVar(&connectBright, "connect-brightness", -1,
+		"Brightness percentage applied immediately when a display connects, to avoid a full-brightness flash (-1 disables)")
+	rootCmd.Flags().IntVar(&resetLevel, "reapply-on-reset-to", -1,
+		"Brightness percentage displays fall back to after a panel-side reset; when --brightness-poll-interval finds a display jumped to it without the daemon setting it, the last known brightness is re-applied (-1 disables)")
+	rootCmd.Flags().Uint32Var(&defaultBright, "default-brightness", dbus.DefaultResetBrightness,
+		"Brightness percentage applied by ResetBrightness and ResetAllBrightness")
+	rootCmd.Flags().Uint32Var(&minBright, "min-brightness", 0,
+		"Brightness percentage every change is clamped up to, so a display never looks switched off")
+	rootCmd.Flags().UintSliceVar(&stops, "brightness-stops", nil,
+		"Percentages brightness snaps to, e.g. 0,25,50,75,100; steps then move one stop at a time (empty disables)")
+	sdr, hdr := dbus.DefaultBrightnessModes()[dbus.ModeSDR], dbus.DefaultBrightnessModes()[dbus.ModeHDR]
+	rootCmd.Flags().Uint32Var(&sdrMax, "sdr-max-brightness", sdr.Max,
+		"Maximum brightness percentage for displays in SDR mode")
+	rootCmd.Flags().Uint32Var(&sdrLevel, "sdr-brightness", sdr.Level,
+		"Brightness percentage applied when a display is switched into SDR mode")
+	rootCmd.Flags().Uint32Var(&hdrMax, "hdr-max-brightness", hdr.Max,
+		"Maximum brightness percentage for displays in HDR mode")
+	rootCmd.Flags().Uint32Var(&hdrLevel, "hdr-brightness", hdr.Level,
+		"Brightness percentage applied when a display is switched into HDR mode")
+	rootCmd.Flags().Uint32Var(&nightMax, "night-max-brightness", dbus.DefaultNightMode.Max,
+		"Maximum brightness percentage for all displays while night mode is on")
+	rootCmd.Flags().Uint32Var(&nightLevel, "night-brightness", dbus.DefaultNightMode.Level,
+		"Brightness percentage applied to all displays when night mode is turned on")
+	rootCmd.Flags().Uint32Var(&extOnlyMax, "external-only-max-brightness", dbus.DefaultExternalOnlyMode.Max,
+		"Maximum brightness percentage for all displays while external-only mode is on")
+	rootCmd.Flags().Uint32Var(&extOnlyLevel, "external-only-brightness", dbus.DefaultExternalOnlyMode.Level,
+		"Brightness percentage applied to all displays when external-only mode is turned on")
+	rootCmd.Flags().BoolVar(&resumeRestore, "restore-on-resume", true,
+		"Quietly re-apply each display's last-known brightness after resume from suspend (requires logind)")
+	rootCmd.Flags().IntVar(&retentionDays, "state-retention-days", defaultStateRetentionDays,
+		"Forget the last-known brightness and mode of displays not connected for this many days (0 keeps them)")
+	rootCmd.Flags().BoolVar(&contentionSig, "contention-signal", false,
+		"Emit BrightnessContention when clients keep setting conflicting brightness values on a display")
+	rootCmd.Flags().BoolVar(&checkedRead, "checked-set-read", false,
+		"Make SetBrightnessChecked read the display's brightness before writing instead of trusting the last known value")
+	rootCmd.Flags().DurationVar(&stepWindow, "step-coalesce-window", 0,
+		"Combine IncreaseBrightness/DecreaseBrightness calls for a display within this window into one write, e.g. 50ms for key repeat (0 disables)")
+	rootCmd.Flags().Uint32Var(&maxStepDelta, "max-coalesced-step", 0,
+		"Most percent a single coalesced write may move brightness; the rest is applied in following windows (0 is unlimited)")
+	rootCmd.Flags().DurationVar(&warmUpPeriod, "warm-up-period", defaultWarmUpPeriod,
+		"How long a newly connected display is considered warming up (0 disables warm-up handling)")
+	rootCmd.Flags().StringVar(&warmUpPolicy, "warm-up-policy", dbus.WarmUpBlock.String(),
+		"How GetBrightness answers for a warming display: block (wait briefly, then read), last-known or error")
+	rootCmd.Flags().BoolVar(&staleFallback, "stale-brightness-fallback", false,
+		"Make GetBrightness return the last known brightness instead of an error when reading a display fails")
+	rootCmd.Flags().BoolVar(&setAllSignals, "set-all-per-display-signals", true,
+		"Emit BrightnessChanged for every display on SetAllBrightness, in addition to AllBrightnessChanged")
+	rootCmd.Flags().BoolVar(&enableRaw, "enable-raw", false,
+		"Allow SendRawFeatureReport to write arbitrary feature reports to displays (debugging only)")
+	rootCmd.Flags().DurationVar(&errorDebounce, "device-error-debounce", dbus.DefaultDeviceErrorDebounce,
+		"Window in which repeated device errors of a display trigger only one recovery (0 recovers on every error)")
+	rootCmd.Flags().IntVar(&maxRecoveries, "max-concurrent-recoveries", dbus.DefaultMaxConcurrentRecoveries,
+		"Maximum number of device error recoveries running at once; further ones wait, at most one per display")
+	rootCmd.Flags().StringVar(&errorCmdPath, "on-device-error-command", "",
+		"Executable to run on device errors, called with the serial and error message (disabled by default)")
+	rootCmd.Flags().DurationVar(&brightPoll, "brightness-poll-interval", 0,
+		"How often to read display brightness to detect changes made outside the daemon (0 disables)")
+	rootCmd.Flags().DurationVar(&cacheTTL, "brightness-cache-ttl", 0,
+		"Answer repeated GetBrightness calls from a cache for this long, e.g. 500ms (0 disables)")
+	rootCmd.Flags().IntVar(&smoothSteps, "smooth-steps", 0,
+		"Report externally made brightness changes as this many interpolated signals (0 disables)")
+	rootCmd.Flags().DurationVar(&stepTimeout, "shutdown-step-timeout", defaultShutdownStepTimeout,
+		"Maximum time to wait for each component to stop on shutdown before moving on to the next")
+	rootCmd.Flags().BoolVar(&exitWhenEmpty, "exit-when-empty", false,
+		"Shut down when no displays have been connected for the grace period")
+	rootCmd.Flags().DurationVar(&emptyGrace, "empty-grace-period", defaultEmptyGracePeriod,
+		"How long no displays must be connected before --exit-when-empty shuts down")
 }
 
-func run() {
+// run runs the daemon until it's stopped by a signal. It returns an error if the
+// daemon fails to start; main maps it to the exit code.
+func run() error {
 	// Configure logging
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	if verbose {
@@ -53,97 +249,48 @@ func run() {
 
 	log.Info().Msg("Starting asd-brightness-daemon")
 
-	// Initialize HID library (recommended for concurrent programs)
-	if err := gohid.Init(); err != nil {
-		log.Fatal().Err(err).Msg("Failed to initialize HID library")
-	}
+	// buildDaemon initializes the HID library, see daemonOptions.initHID
 	defer func() {
 		if err := gohid.Exit(); err != nil {
 			log.Error().Err(err).Msg("Failed to cleanup HID library")
 		}
 	}()
 
-	// Initialize HID manager
-	manager := hid.NewManager()
-	if err := manager.RefreshDisplays(); err != nil {
-		log.Error().Err(err).Msg("Failed to enumerate displays")
-	}
-
-	displayCount := manager.Count()
-	if displayCount == 0 {
-		log.Warn().Msg("No Apple Studio Displays found")
-	} else {
-		log.Info().Int("count", displayCount).Msg("Found Apple Studio Displays")
-	}
-
-	// Initialize D-Bus server
-	server := dbus.NewServer(manager)
-	if err := server.Start(); err != nil {
-		log.Fatal().Err(err).Msg("Failed to start D-Bus server")
-	}
-
-	// Set up device error recovery handler
-	server.SetDeviceErrorHandler(createDeviceErrorHandler(manager, server))
-
-	// Initialize udev monitor for hot-plug detection
-	monitor := udev.NewMonitor(createHotplugHandler(manager, server))
-	monitor.SetRecoveryHandler(createRecoveryHandler(manager, server))
-	if err := monitor.Start(); err != nil {
-		log.Error().Err(err).Msg("Failed to start udev monitor (hot-plug detection disabled)")
+	daemon, err := buildDaemon(optionsFromFlags())
+	if err != nil {
+		return fmt.Errorf("failed to start daemon: %w", err)
 	}
 
 	// Wait for shutdown signal
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
 	log.Info().Msg("Daemon running, press Ctrl+C to stop")
-	<-sigChan
-
-	// Graceful shutdown with timeout
-	log.Info().Msg("Shutting down...")
-
-	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
-	defer cancel()
-
-	shutdownDone := make(chan struct{})
-	go func() {
-		if err := monitor.Stop(); err != nil {
-			log.Error().Err(err).Msg("Failed to stop udev monitor")
-		}
-		if err := server.Stop(); err != nil {
-			log.Error().Err(err).Msg("Failed to stop D-Bus server")
-		}
-		if err := manager.Close(); err != nil {
-			log.Error().Err(err).Msg("Failed to close display manager")
-		}
-		close(shutdownDone)
-	}()
-
-	select {
-	case <-shutdownDone:
-		log.Info().Msg("Daemon stopped gracefully")
-	case <-ctx.Done():
-		log.Warn().Dur("timeout", shutdownTimeout).Msg("Shutdown timed out, forcing exit")
-	}
+	daemon.Run(ctx)
+	return nil
 }
 
 // refreshMu serializes display refresh operations to prevent race conditions
 // between hotplug handlers and recovery handlers.
 //
+// Refreshes are requested through refreshQueue, which takes refreshMu around
+// each one; the health check takes it directly.
+//
 // Design rationale: This is package-level because:
-// 1. The daemon is a single-instance application (only one run() execution)
-// 2. The mutex is shared by closures created in createHotplugHandler,
-//    createDeviceErrorHandler, and createRecoveryHandler
-// 3. Encapsulating in a struct would add complexity without benefit for this use case
-// 4. The handlers need to coordinate access to the shared Manager state
+//  1. The daemon is a single-instance application (only one run() execution)
+//  2. The mutex is shared by closures created in createHotplugHandler,
+//     createDeviceErrorHandler, createRecoveryHandler and createPollHandler
+//  3. Encapsulating in a struct would add complexity without benefit for this use case
+//  4. The handlers need to coordinate access to the shared Manager state
 var refreshMu sync.Mutex
 
 const (
 	// maxBackoffDuration caps the exponential backoff to prevent excessive waits.
 	maxBackoffDuration = 16 * time.Second
 
-	// shutdownTimeout is the maximum time to wait for graceful shutdown.
-	shutdownTimeout = 10 * time.Second
+	// defaultShutdownStepTimeout is the maximum time to wait for each component
+	// to stop on shutdown.
+	defaultShutdownStepTimeout = 5 * time.Second
 
 	// deviceInitializationDelay is the time to wait for a USB device to fully
 	// initialize after a hot-plug add event before attempting enumeration.
@@ -152,8 +299,115 @@ const (
 	// usbSettleTime is the time to wait for USB operations to settle during
 	// recovery after a netlink buffer overflow.
 	usbSettleTime = 2 * time.Second
+
+	// defaultRefreshBudget bounds how long a hot-plug or recovery refresh may keep
+	// retrying, so refreshMu isn't held long enough to stall brightness commands.
+	defaultRefreshBudget = 20 * time.Second
+
+	// deviceErrorRetries is how often a failed device error refresh is retried,
+	// and deviceErrorRetryInterval the delay between the attempts.
+	deviceErrorRetries       = 2
+	deviceErrorRetryInterval = time.Second
+
+	// defaultSiblingWait is how long to keep polling after a display appears, to
+	// catch other displays on the same dock whose HID interfaces are ready later.
+	defaultSiblingWait = time.Second
+
+	// siblingPollInterval is how often displays are re-enumerated during the sibling wait.
+	siblingPollInterval = 250 * time.Millisecond
+
+	// healthCheckJitterDivisor sets the health check jitter to interval/divisor, so
+	// checks run at interval ±10%.
+	healthCheckJitterDivisor = 10
+
+	// defaultReadinessTimeout bounds how long a new display's hidraw node may take
+	// to start serving feature reports after it appears.
+	defaultReadinessTimeout = 2 * time.Second
+
+	// defaultTransientRetries is how often a read or write failing with EIO is retried
+	// before the display is treated as disconnected.
+	defaultTransientRetries = 2
+
+	// defaultWarmUpPeriod is how long a newly connected display is considered
+	// warming up, during which its brightness readings may not be settled.
+	defaultWarmUpPeriod = time.Second
+
+	// defaultStateRetentionDays is how long per-display state of disconnected displays is kept.
+	defaultStateRetentionDays = 30
+
+	// defaultPollInterval is how often displays are re-enumerated when udev
+	// monitoring is disabled or fails to start.
+	defaultPollInterval = 5 * time.Second
 )
 
+// hotplugMonitor is the subset of *udev.Monitor used for hot-plug detection.
+// It allows tests to substitute a fake monitor that doesn't need netlink.
+type hotplugMonitor interface {
+	SetRecoveryHandler(handler udev.RecoveryHandler)
+	Start() error
+	Stop() error
+}
+
+// hotplugStopper is a running hot-plug detection source that must be stopped on shutdown.
+type hotplugStopper interface {
+	Stop() error
+}
+
+// hotplugConfig controls how display connect/disconnect events are detected.
+type hotplugConfig struct {
+	noUdev        bool          // skip the udev monitor entirely
+	pollInterval  time.Duration // polling interval for the fallback; 0 disables polling
+	siblingWait   time.Duration // extra polling window after an add event; 0 disables it
+	refreshBudget time.Duration // total time a single refresh may retry; 0 means no limit
+	debounce      time.Duration // quiet time required before acting on udev events; 0 acts at once
+}
+
+// newUdevMonitor creates the real netlink-backed udev monitor.
+func newUdevMonitor(handler udev.EventHandler) hotplugMonitor {
+	return udev.NewMonitor(handler)
+}
+
+// startHotplugDetection starts the udev monitor, or the polling fallback when udev
+// is disabled or fails to start. It returns the started source, or nil if no
+// hot-plug detection is running (so shutdown never stops a source that wasn't started).
+func startHotplugDetection(
+	cfg hotplugConfig,
+	newMonitor func(udev.EventHandler) hotplugMonitor,
+	manager *hid.Manager,
+	server *dbus.Server,
+) hotplugStopper {
+	if !cfg.noUdev {
+		handler := createHotplugHandler(manager, server, cfg)
+		var debouncer *hotplugDebouncer
+		if cfg.debounce > 0 {
+			debouncer = newHotplugDebouncer(cfg.debounce, handler)
+			handler = debouncer.handle
+		}
+		monitor := newMonitor(handler)
+		monitor.SetRecoveryHandler(createRecoveryHandler(manager, server, cfg))
+		err := monitor.Start()
+		if err == nil && debouncer != nil {
+			return debouncedMonitor{hotplugMonitor: monitor, debouncer: debouncer}
+		}
+		if err == nil {
+			return monitor
+		}
+		log.Error().Err(err).Msg("Failed to start udev monitor, falling back to polling")
+	} else {
+		log.Info().Msg("udev monitoring disabled by --no-udev")
+	}
+
+	if cfg.pollInterval <= 0 {
+		log.Warn().Msg("Display polling disabled, hot-plug detection is off")
+		return nil
+	}
+
+	poller := newDisplayPoller(cfg.pollInterval, createPollHandler(manager, server))
+	poller.Start()
+	log.Info().Dur("interval", cfg.pollInterval).Msg("Polling for display changes")
+	return poller
+}
+
 // displayChanges represents changes detected during a display refresh.
 type displayChanges struct {
 	added   []hid.DeviceInfo // displays that were added
@@ -188,22 +442,30 @@ func diffDisplays(oldDisplays, newDisplays map[string]hid.DeviceInfo) displayCha
 	return changes
 }
 
-// emitDisplayChanges emits D-Bus signals for display changes.
+// emitDisplayChanges emits D-Bus signals for display changes after a refresh,
+// including PropertiesChanged for DisplayCount, and refreshes the connector mapping.
+// It then prunes the state of displays gone for longer than the retention window.
 func emitDisplayChanges(server *dbus.Server, changes displayChanges) {
 	for _, info := range changes.added {
-		server.EmitDisplayAdded(info.Serial, info.Product)
+		server.EmitDisplayAddedInfo(info)
 	}
 	for _, serial := range changes.removed {
 		server.EmitDisplayRemoved(serial)
 	}
+	server.UpdateDisplayCount()
+	server.RefreshConnectors()
+	server.PruneStaleState()
 }
 
 // refreshDisplaysWithRetry attempts to refresh displays with exponential backoff.
 // It retries up to maxRetries times with exponentially increasing delays (1s, 2s, 4s, 8s, 16s).
-// The function checks if displays were found, not just if RefreshDisplays succeeded,
+// It keeps retrying on hid.ErrNoDisplaysFound, not just on enumeration failures,
 // since USB-C dock connected displays may take time for HID interfaces to become ready.
 // Returns (found, err) where found indicates whether any displays were discovered.
-func refreshDisplaysWithRetry(manager *hid.Manager, maxRetries int) (bool, error) {
+//
+// The context bounds the total time spent: once it is done, the function gives up
+// without waiting for the remaining backoffs and returns the context's error.
+func refreshDisplaysWithRetry(ctx context.Context, manager *hid.Manager, maxRetries int) (bool, error) {
 	var lastErr error
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
@@ -217,10 +479,20 @@ func refreshDisplaysWithRetry(manager *hid.Manager, maxRetries int) (bool, error
 				Int("attempt", attempt).
 				Dur("backoff", backoff).
 				Msg("Retrying display refresh")
-			time.Sleep(backoff)
+			if err := sleepContext(ctx, backoff); err != nil {
+				log.Warn().Int("attempts", attempt).Msg("Display refresh budget exhausted, giving up")
+				return false, fmt.Errorf("display refresh aborted: %w", err)
+			}
 		}
 
-		if err := manager.RefreshDisplays(); err != nil {
+		err := manager.RefreshDisplays()
+		if err == nil {
+			if attempt > 0 {
+				log.Info().Int("attempts", attempt+1).Msg("Display refresh succeeded after retry")
+			}
+			return true, nil
+		}
+		if !errors.Is(err, hid.ErrNoDisplaysFound) {
 			lastErr = err
 			log.Warn().
 				Err(err).
@@ -230,15 +502,7 @@ func refreshDisplaysWithRetry(manager *hid.Manager, maxRetries int) (bool, error
 			continue
 		}
 
-		// Check if we actually found displays (HID interface may not be ready yet)
-		if manager.Count() > 0 {
-			if attempt > 0 {
-				log.Info().Int("attempts", attempt+1).Msg("Display refresh succeeded after retry")
-			}
-			return true, nil
-		}
-
-		// RefreshDisplays succeeded but found 0 displays - HID interface not ready yet
+		// Enumeration succeeded but found 0 displays - HID interface not ready yet
 		log.Debug().
 			Int("attempt", attempt+1).
 			Int("maxRetries", maxRetries+1).
@@ -253,43 +517,116 @@ func refreshDisplaysWithRetry(manager *hid.Manager, maxRetries int) (bool, error
 	return false, nil // No error, just no displays found
 }
 
+// sleepContext waits for d or until ctx is done, whichever comes first.
+// It returns the context's error if the wait was cut short.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// refreshContext returns a context bounding a refresh operation by budget.
+// A budget of 0 or less means no limit.
+func refreshContext(budget time.Duration) (context.Context, context.CancelFunc) {
+	if budget <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), budget)
+}
+
 // createHotplugHandler returns an event handler that refreshes displays and emits D-Bus signals.
-// The handler uses the shared refreshMu to prevent race conditions with recovery handlers.
-func createHotplugHandler(manager *hid.Manager, server *dbus.Server) udev.EventHandler {
+// The refresh goes through refreshQueue to serialize with the other refresh paths.
+// Each event's refresh is bounded by cfg.refreshBudget so refreshMu is never held indefinitely.
+// Events arriving while any refresh runs are coalesced into its follow-up refresh.
+func createHotplugHandler(manager *hid.Manager, server *dbus.Server, cfg hotplugConfig) udev.EventHandler {
 	return func(event udev.Event) {
-		// Use shared mutex to serialize with recovery handler
-		refreshMu.Lock()
-		defer refreshMu.Unlock()
+		queueRefresh(refreshWeightHotplug, func() { hotplugRefresh(manager, server, cfg, event) })
+	}
+}
 
-		oldDisplays := getDisplaysSnapshot(manager)
+// hotplugRefresh refreshes displays after a hot-plug event and emits D-Bus signals
+// for the changes. Must be called with refreshMu held.
+func hotplugRefresh(manager *hid.Manager, server *dbus.Server, cfg hotplugConfig, event udev.Event) {
+	ctx, cancel := refreshContext(cfg.refreshBudget)
+	defer cancel()
 
-		// For add events, wait for the device to fully initialize.
-		// USB devices need time to enumerate all interfaces before HID is accessible.
-		// Remove events don't need this delay as the device is already gone.
-		if event.Type == udev.EventAdd {
-			time.Sleep(deviceInitializationDelay)
-		}
+	// For add events, wait for the device to fully initialize.
+	// USB devices need time to enumerate all interfaces before HID is accessible.
+	// Remove events don't need this delay as the device is already gone.
+	if event.Type == udev.EventAdd {
+		time.Sleep(deviceInitializationDelay)
+	}
 
-		// Refresh displays with retry logic for resilience
-		found, err := refreshDisplaysWithRetry(manager, 3)
-		if err != nil {
-			log.Error().Err(err).Msg("Failed to refresh displays after hot-plug event (all retries exhausted)")
-			return
-		}
+	changes, ok := collectHotplugChanges(ctx, manager, event.Type, cfg.siblingWait)
+	if !ok {
+		return
+	}
+	emitDisplayChanges(server, changes)
+}
 
-		// For ADD events: if no displays found, HID interface may not be ready yet.
-		// Skip diff to avoid spurious DisplayRemoved events.
-		// For REMOVE events: always proceed with diff since the device is confirmed gone.
-		if !found && event.Type == udev.EventAdd {
-			log.Debug().
-				Int("previousCount", len(oldDisplays)).
-				Msg("No displays found after add event, skipping diff (HID may not be ready)")
-			return
+// collectHotplugChanges refreshes displays after a hot-plug event and returns what changed.
+// For add events it keeps polling for siblingWait after the first display appears, so
+// displays behind the same dock whose HID interfaces come up slightly later are
+// reported together. It returns false if the changes should not be emitted.
+func collectHotplugChanges(
+	ctx context.Context,
+	manager *hid.Manager,
+	eventType udev.EventType,
+	siblingWait time.Duration,
+) (displayChanges, bool) {
+	oldDisplays := getDisplaysSnapshot(manager)
+
+	// Refresh displays with retry logic for resilience
+	found, err := refreshDisplaysWithRetry(ctx, manager, 3)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to refresh displays after hot-plug event")
+		return displayChanges{}, false
+	}
+
+	// For ADD events: if no displays found, HID interface may not be ready yet.
+	// Skip diff to avoid spurious DisplayRemoved events.
+	// For REMOVE events: always proceed with diff since the device is confirmed gone.
+	if !found && eventType == udev.EventAdd {
+		log.Debug().
+			Int("previousCount", len(oldDisplays)).
+			Msg("No displays found after add event, skipping diff (HID may not be ready)")
+		return displayChanges{}, false
+	}
+
+	if eventType == udev.EventAdd {
+		waitForSiblings(ctx, manager, siblingWait)
+	}
+
+	newDisplays := getDisplaysSnapshot(manager)
+	return diffDisplays(oldDisplays, newDisplays), true
+}
+
+// waitForSiblings re-enumerates displays every siblingPollInterval for the given window,
+// or until ctx is done. A window of 0 disables the extra polling.
+func waitForSiblings(ctx context.Context, manager *hid.Manager, window time.Duration) {
+	if window <= 0 {
+		return
+	}
+
+	before := manager.Count()
+	deadline := time.Now().Add(window)
+	for time.Now().Before(deadline) {
+		if sleepContext(ctx, min(siblingPollInterval, time.Until(deadline))) != nil {
+			break
 		}
+		if err := manager.RefreshDisplays(); err != nil && !errors.Is(err, hid.ErrNoDisplaysFound) {
+			log.Debug().Err(err).Msg("Sibling display poll failed")
+		}
+	}
 
-		newDisplays := getDisplaysSnapshot(manager)
-		changes := diffDisplays(oldDisplays, newDisplays)
-		emitDisplayChanges(server, changes)
+	if after := manager.Count(); after > before {
+		log.Info().Int("siblings", after-before).Msg("Found additional displays after settle window")
 	}
 }
 
@@ -297,11 +634,13 @@ func createHotplugHandler(manager *hid.Manager, server *dbus.Server) udev.EventH
 // When a stale device handle is detected (e.g., "No such device" error), this triggers a display
 // refresh to clean up disconnected displays and discover any newly connected ones.
 // This handles the edge case where disconnect events were missed (e.g., during system suspend).
-func createDeviceErrorHandler(manager *hid.Manager, server *dbus.Server) dbus.DeviceErrorHandler {
-	return func(serial string, err error) {
-		// Use shared mutex to serialize with hotplug and recovery handlers
-		refreshMu.Lock()
-		defer refreshMu.Unlock()
+// Errors reported while any refresh runs are coalesced into its follow-up refresh.
+// A failed refresh is retried, bounded by budget like hot-plug and recovery
+// refreshes, so refreshMu is never held indefinitely.
+func createDeviceErrorHandler(manager *hid.Manager, server *dbus.Server, budget time.Duration) dbus.DeviceErrorHandler {
+	refresh := func(serial string, err error) {
+		ctx, cancel := refreshContext(budget)
+		defer cancel()
 
 		log.Info().
 			Str("serial", serial).
@@ -311,7 +650,7 @@ func createDeviceErrorHandler(manager *hid.Manager, server *dbus.Server) dbus.De
 		oldDisplays := getDisplaysSnapshot(manager)
 
 		// Refresh displays to clean up stale entries and find new ones
-		if refreshErr := manager.RefreshDisplays(); refreshErr != nil {
+		if refreshErr := refreshAfterDeviceError(ctx, manager); refreshErr != nil {
 			log.Error().Err(refreshErr).Msg("Device error recovery: refresh failed")
 			return
 		}
@@ -334,16 +673,40 @@ func createDeviceErrorHandler(manager *hid.Manager, server *dbus.Server) dbus.De
 			Int("after", len(newDisplays)).
 			Msg("Device error recovery completed")
 	}
+	return func(serial string, err error) {
+		// Serialize with hotplug, recovery and poll refreshes
+		queueRefresh(refreshWeightPlain, func() { refresh(serial, err) })
+	}
+}
+
+// refreshAfterDeviceError refreshes displays, retrying a failed enumeration up to
+// deviceErrorRetries times until ctx is done. Unlike refreshDisplaysWithRetry it
+// doesn't retry when no displays are found: after a device error the display is
+// usually gone for good, and its removal shouldn't wait for the backoff.
+func refreshAfterDeviceError(ctx context.Context, manager *hid.Manager) error {
+	for attempt := 0; ; attempt++ {
+		err := manager.RefreshDisplays()
+		if err == nil || errors.Is(err, hid.ErrNoDisplaysFound) {
+			return nil
+		}
+		if attempt == deviceErrorRetries {
+			return err
+		}
+		log.Warn().Err(err).Int("attempt", attempt+1).Msg("Device error recovery: refresh failed, retrying")
+		if sleepErr := sleepContext(ctx, deviceErrorRetryInterval); sleepErr != nil {
+			return fmt.Errorf("%w (refresh budget exhausted: %w)", err, sleepErr)
+		}
+	}
 }
 
 // createRecoveryHandler returns a handler for netlink buffer overflow recovery.
 // It triggers a display refresh to recover from potentially missed udev events.
-// The handler uses the shared refreshMu to prevent race conditions with hotplug handlers.
-func createRecoveryHandler(manager *hid.Manager, server *dbus.Server) udev.RecoveryHandler {
-	return func() {
-		// Use shared mutex to serialize with hotplug handler
-		refreshMu.Lock()
-		defer refreshMu.Unlock()
+// The refresh goes through refreshQueue to serialize with the other refresh paths,
+// and overflows reported while any refresh runs are coalesced into its follow-up refresh.
+func createRecoveryHandler(manager *hid.Manager, server *dbus.Server, cfg hotplugConfig) udev.RecoveryHandler {
+	refresh := func() {
+		ctx, cancel := refreshContext(cfg.refreshBudget)
+		defer cancel()
 
 		log.Info().Msg("Performing recovery refresh after netlink buffer overflow")
 
@@ -351,13 +714,16 @@ func createRecoveryHandler(manager *hid.Manager, server *dbus.Server) udev.Recov
 
 		// Wait for USB operations to settle - USB-C dock connected displays
 		// may take several seconds for HID interfaces to become ready
-		time.Sleep(usbSettleTime)
+		if err := sleepContext(ctx, usbSettleTime); err != nil {
+			return
+		}
 
 		// Refresh with retry using exponential backoff
-		// Total max wait: 2s initial + 1s + 2s + 4s + 8s + 16s = ~33 seconds
-		found, err := refreshDisplaysWithRetry(manager, 5)
+		// Total max wait: 2s initial + 1s + 2s + 4s + 8s + 16s = ~33 seconds,
+		// cut short by the refresh budget
+		found, err := refreshDisplaysWithRetry(ctx, manager, 5)
 		if err != nil {
-			log.Error().Err(err).Msg("Recovery refresh failed (all retries exhausted)")
+			log.Error().Err(err).Msg("Recovery refresh failed")
 			return
 		}
 
@@ -388,10 +754,62 @@ func createRecoveryHandler(manager *hid.Manager, server *dbus.Server) udev.Recov
 
 		log.Info().Int("displays", len(newDisplays)).Msg("Recovery refresh completed")
 	}
+	return func() { queueRefresh(refreshWeightRecovery, refresh) }
+}
+
+// createHealthCheckHandler returns a callback for the periodic health check that
+// re-enumerates displays and emits D-Bus signals for any differences, repairing the
+// manager's view if a udev event was missed. A check is skipped while another
+// refresh (hot-plug, recovery or device error) holds refreshMu, so it never
+// competes with active recovery.
+func createHealthCheckHandler(manager *hid.Manager, server *dbus.Server) func() {
+	return func() {
+		if !refreshMu.TryLock() {
+			log.Debug().Msg("Refresh in progress, skipping health check")
+			return
+		}
+		defer refreshMu.Unlock()
+
+		oldDisplays := getDisplaysSnapshot(manager)
+
+		if err := manager.RefreshDisplays(); err != nil && !errors.Is(err, hid.ErrNoDisplaysFound) {
+			log.Debug().Err(err).Msg("Health check enumeration failed")
+			return
+		}
+
+		changes := diffDisplays(oldDisplays, getDisplaysSnapshot(manager))
+		if len(changes.added) > 0 || len(changes.removed) > 0 {
+			log.Warn().
+				Int("added", len(changes.added)).
+				Int("removed", len(changes.removed)).
+				Msg("Health check found display changes missed by hot-plug detection")
+		}
+		emitDisplayChanges(server, changes)
+	}
+}
+
+// createPollHandler returns a callback for the polling fallback that refreshes
+// displays and emits D-Bus signals for any differences.
+// The refresh goes through refreshQueue to serialize with the other refresh paths.
+func createPollHandler(manager *hid.Manager, server *dbus.Server) func() {
+	refresh := func() {
+		oldDisplays := getDisplaysSnapshot(manager)
+
+		if err := manager.RefreshDisplays(); err != nil && !errors.Is(err, hid.ErrNoDisplaysFound) {
+			log.Debug().Err(err).Msg("Display poll failed")
+			return
+		}
+
+		newDisplays := getDisplaysSnapshot(manager)
+		emitDisplayChanges(server, diffDisplays(oldDisplays, newDisplays))
+	}
+	return func() { queueRefresh(refreshWeightPlain, refresh) }
 }
 
 func main() {
 	if err := rootCmd.Execute(); err != nil {
-		log.Fatal().Err(err).Msg("Failed to execute command")
+		code := exitCode(err)
+		log.Error().Err(err).Int("exitCode", code).Msg("Failed to execute command")
+		os.Exit(code)
 	}
 }
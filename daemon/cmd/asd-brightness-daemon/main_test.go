@@ -3,10 +3,15 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/shini4i/asd-brightness-daemon/internal/dbus"
 	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/shini4i/asd-brightness-daemon/internal/udev"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -50,7 +55,11 @@ func TestGetDisplaysSnapshot(t *testing.T) {
 
 			manager := hid.NewManager(hid.WithEnumerator(enumerator), hid.WithOpener(opener))
 			err := manager.RefreshDisplays()
-			require.NoError(t, err)
+			if len(tt.displays) == 0 {
+				require.ErrorIs(t, err, hid.ErrNoDisplaysFound)
+			} else {
+				require.NoError(t, err)
+			}
 
 			snapshot := getDisplaysSnapshot(manager)
 			assert.Len(t, snapshot, len(tt.displays))
@@ -162,7 +171,7 @@ func TestRefreshDisplaysWithRetry_SuccessOnFirstAttempt(t *testing.T) {
 
 	manager := hid.NewManager(hid.WithEnumerator(enumerator), hid.WithOpener(opener))
 
-	found, err := refreshDisplaysWithRetry(manager, 3)
+	found, err := refreshDisplaysWithRetry(context.Background(), manager, 3)
 
 	assert.NoError(t, err)
 	assert.True(t, found)
@@ -177,13 +186,42 @@ func TestRefreshDisplaysWithRetry_NoDisplaysFound(t *testing.T) {
 	manager := hid.NewManager(hid.WithEnumerator(enumerator))
 
 	// Use 0 retries to make test fast
-	found, err := refreshDisplaysWithRetry(manager, 0)
+	found, err := refreshDisplaysWithRetry(context.Background(), manager, 0)
 
 	assert.NoError(t, err)
 	assert.False(t, found)
 	assert.Equal(t, 0, manager.Count())
 }
 
+func TestRefreshDisplaysWithRetry_DistinguishesNoneFoundFromFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		enumErr   error
+		expectErr bool
+	}{
+		{name: "none found is not an error", enumErr: hid.ErrNoDisplaysFound},
+		{name: "enumeration failure is returned", enumErr: errors.New("hidraw unavailable"), expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := hid.NewManager(hid.WithEnumerator(func() ([]hid.DeviceInfo, error) {
+				return nil, tt.enumErr
+			}))
+
+			found, err := refreshDisplaysWithRetry(context.Background(), manager, 0)
+
+			assert.False(t, found)
+			if tt.expectErr {
+				require.Error(t, err)
+				assert.NotErrorIs(t, err, hid.ErrNoDisplaysFound)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 // mockDevice implements hid.Device for testing
 type mockDevice struct {
 	serial  string
@@ -214,10 +252,10 @@ func (m *mockDevice) Info() hid.DeviceInfo {
 // which is the key behavior that enables the spurious event fix.
 //
 // This tests the fix for spurious DisplayRemoved events that occurred when:
-// 1. Displays were previously connected (oldDisplays > 0)
-// 2. HID enumeration temporarily fails to find displays
-// 3. Without the fix, diffDisplays would be called with empty newDisplays,
-//    causing DisplayRemoved to be emitted for all previous displays
+//  1. Displays were previously connected (oldDisplays > 0)
+//  2. HID enumeration temporarily fails to find displays
+//  3. Without the fix, diffDisplays would be called with empty newDisplays,
+//     causing DisplayRemoved to be emitted for all previous displays
 func TestRefreshDisplaysWithRetry_SkipsWhenNoDisplaysFound(t *testing.T) {
 	// Manager that always returns empty displays
 	enumerator := func() ([]hid.DeviceInfo, error) {
@@ -227,7 +265,7 @@ func TestRefreshDisplaysWithRetry_SkipsWhenNoDisplaysFound(t *testing.T) {
 	manager := hid.NewManager(hid.WithEnumerator(enumerator))
 
 	// Use 0 retries to make test fast
-	found, err := refreshDisplaysWithRetry(manager, 0)
+	found, err := refreshDisplaysWithRetry(context.Background(), manager, 0)
 
 	assert.NoError(t, err)
 	assert.False(t, found, "Should return found=false when no displays found")
@@ -344,6 +382,182 @@ func (m *mockDisplayManager) GetDisplay(serial string) (*hid.Display, error) {
 	return nil, nil
 }
 
+func (m *mockDisplayManager) Snapshot() map[string]*hid.Display {
+	return nil
+}
+
 func (m *mockDisplayManager) RefreshDisplays() error {
 	return nil
 }
+
+func (m *mockDisplayManager) KnownSerials() []string {
+	return nil
+}
+
+// fakeMonitor implements hotplugMonitor for testing startHotplugDetection.
+type fakeMonitor struct {
+	startErr        error
+	started         bool
+	stopped         bool
+	recoveryHandler udev.RecoveryHandler
+}
+
+func (f *fakeMonitor) SetRecoveryHandler(handler udev.RecoveryHandler) {
+	f.recoveryHandler = handler
+}
+
+func (f *fakeMonitor) Start() error {
+	if f.startErr != nil {
+		return f.startErr
+	}
+	f.started = true
+	return nil
+}
+
+func (f *fakeMonitor) Stop() error {
+	f.stopped = true
+	return nil
+}
+
+func TestStartHotplugDetection(t *testing.T) {
+	tests := []struct {
+		name           string
+		cfg            hotplugConfig
+		startErr       error
+		expectMonitor  bool
+		expectPoller   bool
+		expectNoSource bool
+	}{
+		{
+			name:          "udev enabled uses monitor",
+			cfg:           hotplugConfig{pollInterval: time.Hour},
+			expectMonitor: true,
+		},
+		{
+			name:         "no-udev uses poller without creating monitor",
+			cfg:          hotplugConfig{noUdev: true, pollInterval: time.Hour},
+			expectPoller: true,
+		},
+		{
+			name:           "no-udev without polling starts nothing",
+			cfg:            hotplugConfig{noUdev: true},
+			expectNoSource: true,
+		},
+		{
+			name:         "udev start failure falls back to poller",
+			cfg:          hotplugConfig{pollInterval: time.Hour},
+			startErr:     errors.New("netlink unavailable"),
+			expectPoller: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := hid.NewManager(hid.WithEnumerator(func() ([]hid.DeviceInfo, error) {
+				return nil, nil
+			}))
+			server := dbus.NewServer(manager)
+
+			var created *fakeMonitor
+			newMonitor := func(handler udev.EventHandler) hotplugMonitor {
+				created = &fakeMonitor{startErr: tt.startErr}
+				return created
+			}
+
+			source := startHotplugDetection(tt.cfg, newMonitor, manager, server)
+
+			if tt.cfg.noUdev {
+				assert.Nil(t, created, "monitor must not be created with --no-udev")
+			} else {
+				require.NotNil(t, created)
+				assert.NotNil(t, created.recoveryHandler)
+			}
+
+			switch {
+			case tt.expectMonitor:
+				assert.Same(t, created, source)
+				assert.True(t, created.started)
+			case tt.expectPoller:
+				assert.IsType(t, &displayPoller{}, source)
+			case tt.expectNoSource:
+				assert.Nil(t, source)
+			}
+
+			if source != nil {
+				require.NoError(t, source.Stop())
+			}
+			if created != nil && !created.started {
+				assert.False(t, created.stopped, "a monitor that never started must not be stopped")
+			}
+		})
+	}
+}
+
+// TestCollectHotplugChanges_WaitsForSiblings verifies that a second display on the
+// same dock whose HID interface appears a few polls after the first is reported
+// in the same handler invocation.
+func TestCollectHotplugChanges_WaitsForSiblings(t *testing.T) {
+	var mu sync.Mutex
+	polls := 0
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		polls++
+
+		var infos []hid.DeviceInfo
+		if polls >= 2 {
+			infos = append(infos, hid.DeviceInfo{Serial: "A", Product: "Display"})
+		}
+		if polls >= 4 {
+			infos = append(infos, hid.DeviceInfo{Serial: "B", Product: "Display"})
+		}
+		return infos, nil
+	}
+	opener := func(serial string) (hid.Device, error) {
+		return &mockDevice{serial: serial}, nil
+	}
+	manager := hid.NewManager(hid.WithEnumerator(enumerator), hid.WithOpener(opener))
+
+	changes, ok := collectHotplugChanges(context.Background(), manager, udev.EventAdd, 3*siblingPollInterval)
+
+	require.True(t, ok)
+	serials := make([]string, 0, len(changes.added))
+	for _, info := range changes.added {
+		serials = append(serials, info.Serial)
+	}
+	assert.ElementsMatch(t, []string{"A", "B"}, serials)
+	assert.Empty(t, changes.removed)
+}
+
+// TestRefreshDisplaysWithRetry_StopsWhenContextCancelled verifies that a cancelled
+// context cuts the backoff short instead of waiting out all retries.
+func TestRefreshDisplaysWithRetry_StopsWhenContextCancelled(t *testing.T) {
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		return []hid.DeviceInfo{}, nil
+	}
+	manager := hid.NewManager(hid.WithEnumerator(enumerator))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	// Without cancellation 5 retries would take 1+2+4+8+16 = 31 seconds
+	found, err := refreshDisplaysWithRetry(ctx, manager, 5)
+
+	assert.Less(t, time.Since(start), 2*time.Second, "should return promptly after cancellation")
+	assert.False(t, found)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRefreshContext(t *testing.T) {
+	ctx, cancel := refreshContext(0)
+	defer cancel()
+	_, hasDeadline := ctx.Deadline()
+	assert.False(t, hasDeadline, "zero budget means no limit")
+
+	ctx, cancel = refreshContext(time.Minute)
+	defer cancel()
+	deadline, hasDeadline := ctx.Deadline()
+	assert.True(t, hasDeadline)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// displayPoller periodically invokes a callback. It drives the hot-plug detection
+// fallback when udev monitoring is disabled or unavailable, as well as other
+// periodic checks such as brightness polling, the health check and --exit-when-empty.
+type displayPoller struct {
+	interval time.Duration
+	jitter   time.Duration // each wait is randomly shortened or lengthened by up to this much
+	poll     func()
+	mu       sync.Mutex
+	quit     chan struct{}
+	done     chan struct{}
+}
+
+// newDisplayPoller creates a poller that calls poll every interval once started.
+func newDisplayPoller(interval time.Duration, poll func()) *displayPoller {
+	return &displayPoller{
+		interval: interval,
+		poll:     poll,
+	}
+}
+
+// newJitteredPoller creates a poller whose waits vary randomly by up to jitter around
+// interval, so periodic work from several daemons or timers doesn't line up.
+func newJitteredPoller(interval, jitter time.Duration, poll func()) *displayPoller {
+	p := newDisplayPoller(interval, poll)
+	p.jitter = min(jitter, interval)
+	return p
+}
+
+// Start begins polling in a background goroutine.
+// Calling Start on a running poller has no effect.
+func (p *displayPoller) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.quit != nil {
+		return
+	}
+
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	p.quit = quit
+	p.done = done
+
+	go func() {
+		defer close(done)
+
+		timer := time.NewTimer(p.nextDelay())
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-timer.C:
+				p.poll()
+				timer.Reset(p.nextDelay())
+			case <-quit:
+				return
+			}
+		}
+	}()
+}
+
+// nextDelay returns the wait before the next poll.
+func (p *displayPoller) nextDelay() time.Duration {
+	if p.jitter <= 0 {
+		return p.interval
+	}
+	// #nosec G404 -- jitter only spreads timing, it doesn't need a secure source
+	return p.interval - p.jitter + rand.N(2*p.jitter+1)
+}
+
+// Stop stops polling and waits for an in-progress poll to finish.
+// It is safe to call multiple times and before Start.
+func (p *displayPoller) Stop() error {
+	p.mu.Lock()
+	quit, done := p.quit, p.done
+	p.quit, p.done = nil, nil
+	p.mu.Unlock()
+
+	if quit == nil {
+		return nil
+	}
+
+	close(quit)
+	<-done
+	return nil
+}
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisplayPoller_PollsUntilStopped(t *testing.T) {
+	var calls atomic.Int32
+	poller := newDisplayPoller(5*time.Millisecond, func() {
+		calls.Add(1)
+	})
+
+	poller.Start()
+	assert.Eventually(t, func() bool { return calls.Load() >= 2 }, time.Second, time.Millisecond)
+
+	require.NoError(t, poller.Stop())
+	stoppedAt := calls.Load()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, stoppedAt, calls.Load(), "no polls should happen after Stop")
+
+	// Stop is idempotent
+	assert.NoError(t, poller.Stop())
+}
+
+func TestDisplayPoller_StopWithoutStart(t *testing.T) {
+	poller := newDisplayPoller(time.Second, func() {})
+	assert.NoError(t, poller.Stop())
+}
+
+func TestDisplayPoller_JitterStaysInBounds(t *testing.T) {
+	poller := newJitteredPoller(100*time.Millisecond, 10*time.Millisecond, func() {})
+
+	for range 1000 {
+		delay := poller.nextDelay()
+		assert.GreaterOrEqual(t, delay, 90*time.Millisecond)
+		assert.LessOrEqual(t, delay, 110*time.Millisecond)
+	}
+
+	assert.Equal(t, time.Second, newDisplayPoller(time.Second, func() {}).nextDelay(), "no jitter by default")
+}
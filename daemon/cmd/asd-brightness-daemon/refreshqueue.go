@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import "sync"
+
+// refreshCoalescer collapses refresh requests that arrive while a refresh is
+// already running. Instead of each request queueing on refreshMu for a full
+// refresh of its own, they mark the running refresh dirty and return, and the
+// running caller does exactly one more refresh for all of them once it completes.
+// The follow-up refresh is given the argument of the latest request, or the one
+// picked by merge if set.
+type refreshCoalescer[T any] struct {
+	refresh func(T)
+	merge   func(pending, next T) T // picks the follow-up's argument; nil keeps the latest
+
+	mu      sync.Mutex
+	running bool
+	dirty   bool
+	next    T // argument for the follow-up refresh, valid while dirty
+}
+
+// refreshWeight ranks how thorough a refresh is, so coalescing never replaces a
+// thorough refresh with a quicker one.
+type refreshWeight int
+
+const (
+	refreshWeightPlain    refreshWeight = iota // a single re-enumeration: poll or device error
+	refreshWeightHotplug                       // settle delay, retries and sibling wait after a udev event
+	refreshWeightRecovery                      // long settle and retries after missed udev events
+)
+
+// refreshRequest is a display refresh fed through refreshQueue.
+type refreshRequest struct {
+	weight refreshWeight
+	run    func()
+}
+
+// refreshQueue is the single coalescer every refresh trigger (hot-plug, recovery,
+// device error and poll) goes through, so a request arriving while any refresh
+// runs is folded into one follow-up instead of waiting on refreshMu. The
+// follow-up is the heaviest request that arrived meanwhile, the latest among
+// equals. It's package-level for the same reasons as refreshMu.
+var refreshQueue = &refreshCoalescer[refreshRequest]{
+	refresh: func(request refreshRequest) {
+		refreshMu.Lock()
+		defer refreshMu.Unlock()
+		request.run()
+	},
+	merge: func(pending, next refreshRequest) refreshRequest {
+		if pending.weight > next.weight {
+			return pending
+		}
+		return next
+	},
+}
+
+// queueRefresh runs refresh through refreshQueue.
+func queueRefresh(weight refreshWeight, refresh func()) {
+	refreshQueue.request(refreshRequest{weight: weight, run: refresh})
+}
+
+// request runs a refresh for arg, or marks the running one dirty and returns at once.
+func (c *refreshCoalescer[T]) request(arg T) {
+	c.mu.Lock()
+	if c.running {
+		if c.dirty && c.merge != nil {
+			arg = c.merge(c.next, arg)
+		}
+		c.dirty = true
+		c.next = arg
+		c.mu.Unlock()
+		return
+	}
+	c.running = true
+	c.mu.Unlock()
+
+	for {
+		c.runOnce(arg)
+		c.mu.Lock()
+		if !c.dirty {
+			c.running = false
+			c.mu.Unlock()
+			return
+		}
+		arg = c.next
+		c.dirty = false
+		var zero T
+		c.next = zero
+		c.mu.Unlock()
+	}
+}
+
+// runOnce runs a single refresh. If the refresh panics the coalescer is reset on
+// the way out, so later requests aren't swallowed forever.
+func (c *refreshCoalescer[T]) runOnce(arg T) {
+	completed := false
+	defer func() {
+		if !completed {
+			c.mu.Lock()
+			c.running = false
+			c.dirty = false
+			c.mu.Unlock()
+		}
+	}()
+	c.refresh(arg)
+	completed = true
+}
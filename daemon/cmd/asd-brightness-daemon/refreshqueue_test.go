@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package main
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/dbus"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceErrorHandler_CoalescesConcurrentRefreshes(t *testing.T) {
+	const requests = 50
+
+	var enumerations atomic.Int32
+	entered := make(chan struct{}, requests)
+	release := make(chan struct{})
+	manager := hid.NewManager(hid.WithEnumerator(func() ([]hid.DeviceInfo, error) {
+		enumerations.Add(1)
+		entered <- struct{}{}
+		<-release
+		return nil, nil
+	}))
+	handler := createDeviceErrorHandler(manager, dbus.NewServer(manager), 0)
+	deviceErr := errors.New("no such device")
+
+	// The first request starts a refresh and blocks in the enumerator
+	first := make(chan struct{})
+	go func() {
+		defer close(first)
+		handler("A", deviceErr)
+	}()
+	<-entered
+
+	// Everything arriving meanwhile only marks it dirty and returns without waiting
+	var wg sync.WaitGroup
+	for range requests - 1 {
+		wg.Go(func() { handler("A", deviceErr) })
+	}
+	wg.Wait()
+
+	close(release)
+	<-first
+	assert.Equal(t, int32(2), enumerations.Load(), "queued requests must collapse into a single follow-up refresh")
+
+	// Once idle, a new request refreshes again
+	handler("A", deviceErr)
+	assert.Equal(t, int32(3), enumerations.Load())
+}
+
+func TestRefreshQueue_SharedByEveryTrigger(t *testing.T) {
+	var enumerations atomic.Int32
+	entered := make(chan struct{}, 2)
+	release := make(chan struct{})
+	manager := hid.NewManager(hid.WithEnumerator(func() ([]hid.DeviceInfo, error) {
+		if enumerations.Add(1) == 1 {
+			entered <- struct{}{}
+			<-release
+		}
+		return nil, nil
+	}))
+	server := dbus.NewServer(manager)
+
+	first := make(chan struct{})
+	go func() {
+		defer close(first)
+		createDeviceErrorHandler(manager, server, 0)("A", errors.New("no such device"))
+	}()
+	<-entered
+
+	// A poll arriving during a device error refresh doesn't wait for refreshMu
+	poll := createPollHandler(manager, server)
+	poll()
+	poll()
+
+	close(release)
+	<-first
+	assert.Equal(t, int32(2), enumerations.Load(), "requests from other triggers must join the same follow-up refresh")
+}
+
+func TestRefreshCoalescer_FollowUpArgument(t *testing.T) {
+	var got []int
+	var c *refreshCoalescer[int]
+	c = &refreshCoalescer[int]{refresh: func(n int) {
+		got = append(got, n)
+		if n == 1 {
+			c.request(2)
+			c.request(3)
+		}
+	}}
+	c.request(1)
+	assert.Equal(t, []int{1, 3}, got, "the latest request wins without merge")
+
+	got = nil
+	c.merge = func(pending, next int) int { return max(pending, next) }
+	c.refresh = func(n int) {
+		got = append(got, n)
+		if n == 1 {
+			c.request(3)
+			c.request(2)
+		}
+	}
+	c.request(1)
+	assert.Equal(t, []int{1, 3}, got, "merge picks the follow-up")
+
+	calls := 0
+	retry := &refreshCoalescer[struct{}]{refresh: func(struct{}) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+	}}
+	assert.Panics(t, func() { retry.request(struct{}{}) })
+	retry.request(struct{}{})
+	assert.Equal(t, 2, calls, "a panicking refresh must not wedge the coalescer")
+}
+
+func TestDeviceErrorHandler_RetriesWithinBudget(t *testing.T) {
+	var enumerations atomic.Int32
+	manager := hid.NewManager(hid.WithEnumerator(func() ([]hid.DeviceInfo, error) {
+		enumerations.Add(1)
+		return nil, errors.New("enumeration failed")
+	}))
+	handler := createDeviceErrorHandler(manager, dbus.NewServer(manager), 50*time.Millisecond)
+
+	start := time.Now()
+	handler("A", errors.New("no such device"))
+
+	assert.Less(t, time.Since(start), deviceErrorRetryInterval, "the budget must cut the retry backoff short")
+	assert.Equal(t, int32(1), enumerations.Load())
+}
@@ -36,6 +36,25 @@ func PercentToNits(percent uint8) uint32 {
 	return ClampNits(nits)
 }
 
+// NitsToFraction converts a brightness value in nits to a fraction of the display's
+// range, from 0.0 at MinBrightness to 1.0 at MaxBrightness. Values outside the
+// valid range are clamped before conversion.
+func NitsToFraction(nits uint32) float64 {
+	nits = ClampNits(nits)
+	return float64(nits-MinBrightness) / float64(BrightnessRange)
+}
+
+// FractionToNits converts a fraction of the display's range (0.0-1.0) to a
+// brightness value in nits, rounded to the nearest nit. Fractions outside the
+// range, and NaN, are clamped to it.
+func FractionToNits(fraction float64) uint32 {
+	if math.IsNaN(fraction) || fraction < 0 {
+		fraction = 0
+	}
+	fraction = min(fraction, 1)
+	return MinBrightness + uint32(math.Round(fraction*float64(BrightnessRange)))
+}
+
 // ClampNits ensures the brightness value is within the valid range.
 func ClampNits(nits uint32) uint32 {
 	if nits < MinBrightness {
@@ -46,3 +65,58 @@ func ClampNits(nits uint32) uint32 {
 	}
 	return nits
 }
+
+const (
+	// CurveLinear names the mapping implemented by PercentToNits: nits grow linearly
+	// with the percentage across the display's full range.
+	CurveLinear = "linear"
+
+	// CurvePerceptual names a mapping giving more of the percentage range to low
+	// brightness, where the eye is most sensitive: nits grow with the square of the
+	// percentage.
+	CurvePerceptual = "perceptual"
+)
+
+// Curves lists the names of the supported percent-to-nits mappings.
+var Curves = []string{CurveLinear, CurvePerceptual}
+
+// CurvePercentToNits converts a percentage (0-100) to a brightness value in nits
+// along the named curve. Percentages above 100 are treated as 100%; unknown
+// curves, including "", map like CurveLinear.
+func CurvePercentToNits(curve string, percent uint8) uint32 {
+	if curve != CurvePerceptual {
+		return PercentToNits(percent)
+	}
+	fraction := float64(min(percent, 100)) / 100
+	return FractionToNits(fraction * fraction)
+}
+
+// CurveNitsToPercent converts a brightness value in nits to a percentage (0-100)
+// along the named curve, rounding so it round-trips with CurvePercentToNits.
+// Unknown curves, including "", map like CurveLinear.
+func CurveNitsToPercent(curve string, nits uint32) uint8 {
+	if curve != CurvePerceptual {
+		return NitsToPercent(nits)
+	}
+	return uint8(math.Round(math.Sqrt(NitsToFraction(nits)) * 100))
+}
+
+// CurvePoint is a single sample of the percent-to-nits mapping.
+type CurvePoint struct {
+	Percent uint8
+	Nits    uint32
+}
+
+// Curve samples CurvePercentToNits for the named curve every step percent from 0
+// to 100. The last sample is always 100%, even if step doesn't divide 100. A step
+// of 0 is treated as 1.
+func Curve(curve string, step uint8) []CurvePoint {
+	step = max(step, 1)
+
+	points := make([]CurvePoint, 0, 100/int(step)+2)
+	for percent := 0; percent < 100; percent += int(step) {
+		// #nosec G115 -- percent is below 100, safe for uint8
+		points = append(points, CurvePoint{Percent: uint8(percent), Nits: CurvePercentToNits(curve, uint8(percent))})
+	}
+	return append(points, CurvePoint{Percent: 100, Nits: CurvePercentToNits(curve, 100)})
+}
@@ -3,6 +3,7 @@
 package brightness_test
 
 import (
+	"math"
 	"testing"
 
 	"github.com/shini4i/asd-brightness-daemon/internal/brightness"
@@ -142,8 +143,76 @@ func TestRoundTrip(t *testing.T) {
 	}
 }
 
+func TestFractionRoundTrip(t *testing.T) {
+	// Every nits value survives nits -> fraction -> nits
+	for nits := brightness.MinBrightness; nits <= brightness.MaxBrightness; nits++ {
+		fraction := brightness.NitsToFraction(nits)
+		require.GreaterOrEqual(t, fraction, 0.0)
+		require.LessOrEqual(t, fraction, 1.0)
+		require.Equal(t, nits, brightness.FractionToNits(fraction), "round-trip failed for %d nits", nits)
+	}
+
+	// Fractions map onto the same nits as the matching percentage
+	for percent := uint8(0); percent <= 100; percent++ {
+		fraction := float64(percent) / 100
+		assert.InDelta(t, brightness.PercentToNits(percent), brightness.FractionToNits(fraction), 1, "%d%%", percent)
+		assert.InDelta(t, fraction, brightness.NitsToFraction(brightness.FractionToNits(fraction)), 1e-9, "%d%%", percent)
+	}
+}
+
+func TestFractionToNits_Clamps(t *testing.T) {
+	assert.Equal(t, brightness.MinBrightness, brightness.FractionToNits(-0.5))
+	assert.Equal(t, brightness.MinBrightness, brightness.FractionToNits(math.NaN()))
+	assert.Equal(t, brightness.MaxBrightness, brightness.FractionToNits(1.5))
+	assert.Equal(t, brightness.MaxBrightness, brightness.FractionToNits(math.Inf(1)))
+	assert.Equal(t, 0.0, brightness.NitsToFraction(0))
+	assert.Equal(t, 1.0, brightness.NitsToFraction(100000))
+}
+
 func TestConstants(t *testing.T) {
 	require.Equal(t, uint32(400), brightness.MinBrightness, "MinBrightness should be 400 nits")
 	require.Equal(t, uint32(60000), brightness.MaxBrightness, "MaxBrightness should be 60000 nits")
 	require.Equal(t, uint32(59600), brightness.BrightnessRange, "BrightnessRange should be 59600 nits")
 }
+
+func TestCurve(t *testing.T) {
+	points := brightness.Curve(brightness.CurveLinear, 25)
+	assert.Equal(t, []brightness.CurvePoint{
+		{Percent: 0, Nits: 400},
+		{Percent: 25, Nits: 15300},
+		{Percent: 50, Nits: 30200},
+		{Percent: 75, Nits: 45100},
+		{Percent: 100, Nits: 60000},
+	}, points)
+
+	uneven := brightness.Curve(brightness.CurveLinear, 30)
+	require.Len(t, uneven, 5)
+	assert.Equal(t, uint8(90), uneven[3].Percent)
+	assert.Equal(t, uint8(100), uneven[4].Percent, "last sample is always 100%")
+
+	assert.Len(t, brightness.Curve(brightness.CurveLinear, 0), 101, "step 0 samples every percent")
+
+	perceptual := brightness.Curve(brightness.CurvePerceptual, 25)
+	assert.Equal(t, []brightness.CurvePoint{
+		{Percent: 0, Nits: 400},
+		{Percent: 25, Nits: 4125},
+		{Percent: 50, Nits: 15300},
+		{Percent: 75, Nits: 33925},
+		{Percent: 100, Nits: 60000},
+	}, perceptual)
+}
+
+func TestCurvePercentToNits(t *testing.T) {
+	for _, curve := range brightness.Curves {
+		for percent := range uint8(101) {
+			nits := brightness.CurvePercentToNits(curve, percent)
+			assert.Equal(t, percent, brightness.CurveNitsToPercent(curve, nits), "%s curve at %d%% round-trips", curve, percent)
+		}
+		assert.Equal(t, brightness.MaxBrightness, brightness.CurvePercentToNits(curve, 150), "%s curve clamps to 100%%", curve)
+	}
+
+	assert.Less(t, brightness.CurvePercentToNits(brightness.CurvePerceptual, 50), brightness.CurvePercentToNits(brightness.CurveLinear, 50),
+		"the perceptual curve is darker in the middle of the range")
+	assert.Equal(t, brightness.PercentToNits(30), brightness.CurvePercentToNits("", 30), "unknown curves are linear")
+	assert.Equal(t, brightness.NitsToPercent(20000), brightness.CurveNitsToPercent("", 20000))
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+// Package control provides an optional unix socket for clients that stream
+// brightness changes, e.g. sliders, with less overhead than D-Bus round-trips.
+//
+// The protocol is line-based ASCII. Each request is one line:
+//
+//	set <serial> <percent>
+//	get <serial>
+//
+// and is answered by exactly one line, in request order:
+//
+//	ok             set succeeded
+//	ok <percent>   current brightness, for get
+//	err <message>  the request failed; the connection stays usable
+//
+// Blank lines are ignored. Lines longer than MaxLineLength close the connection.
+package control
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxLineLength is the longest request line accepted, including the newline.
+const MaxLineLength = 256
+
+// Commands accepted on the control socket.
+const (
+	CommandSet = "set"
+	CommandGet = "get"
+)
+
+// ErrInvalidRequest is returned when a request line can't be parsed.
+var ErrInvalidRequest = errors.New("invalid request")
+
+// Request is a parsed request line.
+type Request struct {
+	Command    string // CommandSet or CommandGet
+	Serial     string
+	Brightness uint32 // Requested percentage (0-100), for CommandSet
+}
+
+// ParseRequest parses a single request line, without its line terminator.
+func ParseRequest(line string) (Request, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return Request{}, fmt.Errorf("%w: empty line", ErrInvalidRequest)
+	}
+
+	switch command := fields[0]; command {
+	case CommandGet:
+		if len(fields) != 2 {
+			return Request{}, fmt.Errorf("%w: usage: get <serial>", ErrInvalidRequest)
+		}
+		return Request{Command: command, Serial: fields[1]}, nil
+	case CommandSet:
+		if len(fields) != 3 {
+			return Request{}, fmt.Errorf("%w: usage: set <serial> <percent>", ErrInvalidRequest)
+		}
+		percent, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil || percent > 100 {
+			return Request{}, fmt.Errorf("%w: brightness %q is not a percentage (0-100)", ErrInvalidRequest, fields[2])
+		}
+		return Request{Command: command, Serial: fields[1], Brightness: uint32(percent)}, nil
+	default:
+		return Request{}, fmt.Errorf("%w: unknown command %q", ErrInvalidRequest, command)
+	}
+}
+
+// formatOK returns the success response, with the brightness for get requests.
+func formatOK(req Request, brightness uint32) string {
+	if req.Command == CommandGet {
+		return fmt.Sprintf("ok %d\n", brightness)
+	}
+	return "ok\n"
+}
+
+// formatError returns the error response for err, kept on a single line.
+func formatError(err error) string {
+	message := strings.Join(strings.Fields(err.Error()), " ")
+	return "err " + message + "\n"
+}
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package control_test
+
+import (
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/control"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    control.Request
+		wantErr bool
+	}{
+		{name: "set", line: "set ABC123 42", want: control.Request{Command: control.CommandSet, Serial: "ABC123", Brightness: 42}},
+		{name: "set bounds", line: "set ABC123 100", want: control.Request{Command: control.CommandSet, Serial: "ABC123", Brightness: 100}},
+		{name: "get", line: "get ABC123", want: control.Request{Command: control.CommandGet, Serial: "ABC123"}},
+		{name: "extra whitespace and CR", line: "  set\tABC123   0\r", want: control.Request{Command: control.CommandSet, Serial: "ABC123"}},
+		{name: "empty", line: "", wantErr: true},
+		{name: "unknown command", line: "fade ABC123 50", wantErr: true},
+		{name: "commands are case sensitive", line: "SET ABC123 50", wantErr: true},
+		{name: "set without brightness", line: "set ABC123", wantErr: true},
+		{name: "set with extra field", line: "set ABC123 50 1000", wantErr: true},
+		{name: "brightness above 100", line: "set ABC123 101", wantErr: true},
+		{name: "negative brightness", line: "set ABC123 -1", wantErr: true},
+		{name: "non-numeric brightness", line: "set ABC123 half", wantErr: true},
+		{name: "get without serial", line: "get", wantErr: true},
+		{name: "get with extra field", line: "get ABC123 50", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := control.ParseRequest(tt.line)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, control.ErrInvalidRequest)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, req)
+		})
+	}
+}
@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package control
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// socketMode restricts the socket to the user running the daemon, like the session bus.
+const socketMode = 0o600
+
+// Handler carries out requests. The daemon passes its D-Bus server, so writes go
+// through the same rate limits, caps and signals as D-Bus clients.
+type Handler interface {
+	SetBrightness(serial string, brightness uint32) error
+	GetBrightness(serial string) (uint32, error)
+}
+
+// Server accepts control socket connections and serves requests until closed.
+type Server struct {
+	listener net.Listener
+	handler  Handler
+
+	mu     sync.Mutex // Protects conns and closed
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup // Tracks the accept loop and connection goroutines
+}
+
+// Listen creates the socket at path and starts serving requests with handler.
+// A socket left behind at path by an earlier run is replaced; any other file
+// there is an error. The socket is removed again by Close.
+func Listen(path string, handler Handler) (*Server, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale control socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on control socket: %w", err)
+	}
+	if err := os.Chmod(path, socketMode); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to restrict control socket permissions: %w", err)
+	}
+
+	s := &Server{
+		listener: listener,
+		handler:  handler,
+		conns:    make(map[net.Conn]struct{}),
+	}
+	s.wg.Add(1)
+	go s.acceptLoop()
+
+	log.Info().Str("path", path).Msg("Control socket listening")
+	return s, nil
+}
+
+// Close stops accepting connections, closes the open ones, removes the socket
+// and waits for in-flight requests to finish.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+// acceptLoop serves every accepted connection on its own goroutine.
+func (s *Server) acceptLoop() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Error().Err(err).Msg("Control socket stopped accepting connections")
+			}
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go s.serve(conn)
+	}
+}
+
+// serve answers requests on conn until the client disconnects.
+func (s *Server) serve(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		_ = conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, MaxLineLength), MaxLineLength)
+	writer := bufio.NewWriter(conn)
+
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		if _, err := writer.WriteString(s.handle(scanner.Text())); err != nil {
+			return
+		}
+		if err := writer.Flush(); err != nil {
+			return
+		}
+	}
+
+	if err := scanner.Err(); errors.Is(err, bufio.ErrTooLong) {
+		_, _ = writer.WriteString(formatError(fmt.Errorf("%w: line longer than %d bytes", ErrInvalidRequest, MaxLineLength)))
+		_ = writer.Flush()
+	}
+}
+
+// handle carries out a single request line and returns its response.
+func (s *Server) handle(line string) string {
+	req, err := ParseRequest(line)
+	if err != nil {
+		return formatError(err)
+	}
+
+	switch req.Command {
+	case CommandSet:
+		err = s.handler.SetBrightness(req.Serial, req.Brightness)
+		return respond(req, 0, err)
+	default:
+		brightness, err := s.handler.GetBrightness(req.Serial)
+		return respond(req, brightness, err)
+	}
+}
+
+// respond formats the response to req.
+func respond(req Request, brightness uint32, err error) string {
+	if err != nil {
+		log.Debug().Err(err).Str("command", req.Command).Str("serial", req.Serial).Msg("Control request failed")
+		return formatError(err)
+	}
+	return formatOK(req, brightness)
+}
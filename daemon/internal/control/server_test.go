@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package control_test
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/control"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHandler stores brightness per serial and fails for unknown serials.
+type fakeHandler struct {
+	mu         sync.Mutex
+	brightness map[string]uint32
+}
+
+func (h *fakeHandler) SetBrightness(serial string, brightness uint32) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.brightness[serial]; !ok {
+		return errors.New("display not found:\n" + serial)
+	}
+	h.brightness[serial] = brightness
+	return nil
+}
+
+func (h *fakeHandler) GetBrightness(serial string) (uint32, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	brightness, ok := h.brightness[serial]
+	if !ok {
+		return 0, errors.New("display not found")
+	}
+	return brightness, nil
+}
+
+// dial connects to the socket at path and returns a func sending a line and
+// returning the response line.
+func dial(t *testing.T, path string) (conn net.Conn, roundTrip func(line string) string) {
+	t.Helper()
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	reader := bufio.NewReader(conn)
+	return conn, func(line string) string {
+		t.Helper()
+		_, err := conn.Write([]byte(line + "\n"))
+		require.NoError(t, err)
+		response, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		return response
+	}
+}
+
+func TestServer_ServesRequests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "control.sock")
+	handler := &fakeHandler{brightness: map[string]uint32{"ABC123": 50}}
+	server, err := control.Listen(path, handler)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, server.Close()) }()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	_, roundTrip := dial(t, path)
+	assert.Equal(t, "ok 50\n", roundTrip("get ABC123"))
+	assert.Equal(t, "ok\n", roundTrip("set ABC123 80"))
+	assert.Equal(t, "ok 80\n", roundTrip("get ABC123"))
+	assert.Equal(t, uint32(80), handler.brightness["ABC123"])
+
+	// Errors are reported on one line and keep the connection usable
+	assert.Equal(t, "err display not found: XYZ\n", roundTrip("set XYZ 80"))
+	assert.True(t, strings.HasPrefix(roundTrip("set ABC123 200"), "err invalid request"))
+	assert.Equal(t, "ok\n", roundTrip("set ABC123 10"))
+}
+
+func TestServer_PipelinedRequests(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "control.sock")
+	handler := &fakeHandler{brightness: map[string]uint32{"ABC123": 0}}
+	server, err := control.Listen(path, handler)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, server.Close()) }()
+
+	conn, _ := dial(t, path)
+	_, err = conn.Write([]byte("set ABC123 10\n\nset ABC123 20\nget ABC123\n"))
+	require.NoError(t, err)
+
+	reader := bufio.NewReader(conn)
+	for _, want := range []string{"ok\n", "ok\n", "ok 20\n"} {
+		response, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, want, response, "responses arrive in request order, blank lines are skipped")
+	}
+}
+
+func TestServer_LongLineClosesConnection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "control.sock")
+	server, err := control.Listen(path, &fakeHandler{})
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, server.Close()) }()
+
+	conn, _ := dial(t, path)
+	_, err = conn.Write([]byte(strings.Repeat("x", control.MaxLineLength+1) + "\n"))
+	require.NoError(t, err)
+
+	reader := bufio.NewReader(conn)
+	response, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(response, "err invalid request: line longer than"))
+	_, err = reader.ReadString('\n')
+	assert.Error(t, err, "the connection is closed")
+}
+
+func TestListen_SocketPath(t *testing.T) {
+	dir := t.TempDir()
+
+	// A socket left behind by an earlier run is replaced
+	path := filepath.Join(dir, "control.sock")
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	server, err := control.Listen(path, &fakeHandler{})
+	require.NoError(t, err)
+	require.NoError(t, server.Close())
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist, "the socket is removed on close")
+
+	// Other files are never removed
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o600))
+	_, err = control.Listen(file, &fakeHandler{})
+	assert.Error(t, err)
+	_, err = os.Stat(file)
+	assert.NoError(t, err)
+}
+
+func TestServer_CloseDisconnectsClients(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "control.sock")
+	server, err := control.Listen(path, &fakeHandler{brightness: map[string]uint32{"ABC123": 5}})
+	require.NoError(t, err)
+
+	conn, roundTrip := dial(t, path)
+	assert.Equal(t, "ok 5\n", roundTrip("get ABC123"))
+	require.NoError(t, server.Close())
+
+	_, err = bufio.NewReader(conn).ReadString('\n')
+	assert.Error(t, err)
+}
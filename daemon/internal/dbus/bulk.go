@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+)
+
+// SetBrightnessMap sets several displays to individual percentages (0-100) in one call.
+// Displays are written concurrently from a single consistent snapshot, and
+// BrightnessChanged is emitted for each display that was set. The returned map
+// contains an error message for every serial that could not be set; it is empty
+// when all displays were updated. Values above 100 are clamped.
+//
+// Unknown serials are reported according to the not-found policy: with the
+// warn or silent policy they are skipped without an entry in the result.
+func (s *Server) SetBrightnessMap(values map[string]uint32) (map[string]string, *dbus.Error) {
+	s.recordActivity()
+
+	if !s.rateLimits.allowAll() {
+		return nil, s.rateLimitExceeded("SetBrightnessMap")
+	}
+
+	displays := s.manager.Snapshot()
+
+	var mu sync.Mutex
+	failures := make(map[string]string)
+	fail := func(serial string, err error) {
+		mu.Lock()
+		failures[serial] = err.Error()
+		mu.Unlock()
+	}
+
+	targets := make(map[string]uint32, len(values))
+	for serial, brightness := range values {
+		if err := validateSerial(serial); err != nil {
+			fail(serial, err)
+			continue
+		}
+
+		if _, ok := displays[serial]; !ok {
+			err := fmt.Errorf("%w: serial %s", hid.ErrDisplayNotFound, serial)
+			if dbusErr := s.displayLookupFailed("SetBrightnessMap", serial, err); dbusErr != nil {
+				fail(serial, err)
+			}
+			continue
+		}
+
+		targets[serial] = s.capBrightness(serial, s.nearestStop(brightness))
+	}
+
+	// Hold the locks for every targeted display while writing; lock takes them in
+	// sorted order so overlapping bulk operations can't deadlock
+	unlock := s.lockWithoutFades(slices.Collect(maps.Keys(targets))...)
+	defer unlock()
+
+	var wg sync.WaitGroup
+	for serial, brightness := range targets {
+		wg.Add(1)
+		go func(serial string, display *hid.Display, brightness uint32) {
+			defer wg.Done()
+
+			// #nosec G115 -- brightness is clamped to 0-100, safe for uint8
+			if err := display.SetBrightness(uint8(brightness)); err != nil {
+				s.handleDeviceError(serial, err)
+				log.Error().Err(err).Str("serial", serial).Msg("Failed to set brightness")
+				fail(serial, err)
+				return
+			}
+
+			s.stats.sets.Add(1)
+			s.emitBrightnessChanged(serial, brightness)
+		}(serial, displays[serial], brightness)
+	}
+	wg.Wait()
+
+	log.Debug().Int("requested", len(values)).Int("failed", len(failures)).Msg("Set brightness map")
+	return failures, nil
+}
+
+// IncreaseAllBrightness raises the brightness of every display by step percent (1-100),
+// relative to each display's own current value. Displays are adjusted concurrently
+// and BrightnessChanged is emitted for each display whose brightness changed;
+// displays already at the upper bound are left untouched.
+func (s *Server) IncreaseAllBrightness(step uint32) *dbus.Error {
+	return s.stepAllBrightness("IncreaseAllBrightness", step, true)
+}
+
+// DecreaseAllBrightness lowers the brightness of every display by step percent (1-100),
+// relative to each display's own current value. It behaves like IncreaseAllBrightness.
+func (s *Server) DecreaseAllBrightness(step uint32) *dbus.Error {
+	return s.stepAllBrightness("DecreaseAllBrightness", step, false)
+}
+
+// stepAllBrightness implements IncreaseAllBrightness and DecreaseAllBrightness.
+// Failures on individual displays are logged and don't affect the others. The
+// changes are reported like single-display steps, including mirroring, once the
+// locks are released.
+func (s *Server) stepAllBrightness(method string, step uint32, up bool) *dbus.Error {
+	s.recordActivity()
+
+	if !s.rateLimits.allowAll() {
+		return s.rateLimitExceeded(method)
+	}
+
+	if step == 0 || step > 100 {
+		return dbus.MakeFailedError(ErrInvalidStep)
+	}
+
+	displays := s.manager.Snapshot()
+
+	unlock := s.lockWithoutFades(slices.Collect(maps.Keys(displays))...)
+
+	var mu sync.Mutex
+	changed := make(map[string]uint32, len(displays))
+
+	var wg sync.WaitGroup
+	for serial, display := range displays {
+		wg.Add(1)
+		go func(serial string, display *hid.Display) {
+			defer wg.Done()
+
+			current, err := display.GetBrightness()
+			if err != nil {
+				s.handleDeviceError(serial, err)
+				log.Error().Err(err).Str("serial", serial).Str("method", method).Msg("Failed to get brightness")
+				return
+			}
+
+			var target uint32
+			switch {
+			case up:
+				target = uint32(current) + step
+			case uint32(current) > step:
+				target = uint32(current) - step
+			}
+			target = s.capBrightness(serial, target)
+			if target == uint32(current) {
+				log.Debug().Str("serial", serial).Str("method", method).Uint32("brightness", target).Msg("Display already at limit")
+				return
+			}
+
+			if err := s.writeBrightness(serial, display, target); err != nil {
+				s.handleDeviceError(serial, err)
+				log.Error().Err(err).Str("serial", serial).Str("method", method).Msg("Failed to set brightness")
+				return
+			}
+
+			mu.Lock()
+			changed[serial] = target
+			mu.Unlock()
+		}(serial, display)
+	}
+	wg.Wait()
+	unlock()
+
+	// Mirroring takes the locks of the other displays, so notify only now. The
+	// mirror primary goes last, so the followers end up at its brightness.
+	s.mirrorMu.RLock()
+	primary := s.mirrorPrimary
+	s.mirrorMu.RUnlock()
+	for serial, target := range changed {
+		if serial != primary {
+			s.onBrightnessChanged(serial, target)
+		}
+	}
+	if target, ok := changed[primary]; ok {
+		s.onBrightnessChanged(primary, target)
+	}
+
+	log.Debug().Str("method", method).Uint32("step", step).Int("count", len(displays)).Msg("Stepped all brightness")
+	return nil
+}
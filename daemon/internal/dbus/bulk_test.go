@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingDevice is a fakeDevice whose writes always fail.
+type failingDevice struct {
+	*fakeDevice
+	err error
+}
+
+func (d *failingDevice) SendFeatureReport(data []byte) (int, error) {
+	return 0, d.err
+}
+
+func TestServer_SetBrightnessMap(t *testing.T) {
+	displayA := newFakeDevice("A", 10)
+	displayB := newFakeDevice("B", 10)
+	broken := &failingDevice{fakeDevice: newFakeDevice("BROKEN", 10), err: errors.New("write failed")}
+
+	manager := newFakeManager(displayA, displayB)
+	manager.displayMap["BROKEN"] = hid.NewDisplay(broken)
+	server, recorder := newRecordingServer(manager)
+
+	failures, err := server.SetBrightnessMap(map[string]uint32{
+		"A":       60,
+		"B":       150,
+		"BROKEN":  40,
+		"MISSING": 50,
+	})
+	require.Nil(t, err)
+
+	assert.Equal(t, uint8(60), displayA.percent())
+	assert.Equal(t, uint8(100), displayB.percent(), "values above 100 are clamped")
+
+	require.Len(t, failures, 2)
+	assert.Contains(t, failures["BROKEN"], "write failed")
+	assert.Contains(t, failures["MISSING"], "not found")
+
+	signals := recorder.named("BrightnessChanged")
+	emitted := make(map[string]uint32)
+	for _, sig := range signals {
+		emitted[sig.values[0].(string)] = sig.values[1].(uint32)
+	}
+	assert.Equal(t, map[string]uint32{"A": 60, "B": 100}, emitted)
+}
+
+func TestServer_SetBrightnessMap_NotFoundPolicy(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("A", 10)), WithNotFoundPolicy(NotFoundIgnore))
+
+	failures, err := server.SetBrightnessMap(map[string]uint32{"A": 30, "MISSING": 50})
+	require.Nil(t, err)
+	assert.Empty(t, failures)
+}
+
+func TestServer_StepAllBrightness(t *testing.T) {
+	tests := []struct {
+		name      string
+		call      func(*Server) any
+		expected  map[string]uint8
+		signals   int
+		topWrites int
+	}{
+		{
+			name:     "increase steps each display from its own level",
+			call:     func(s *Server) any { return s.IncreaseAllBrightness(10) },
+			expected: map[string]uint8{"LOW": 20, "MID": 60, "TOP": 100},
+			signals:  2,
+		},
+		{
+			name:      "decrease steps each display from its own level",
+			call:      func(s *Server) any { return s.DecreaseAllBrightness(15) },
+			expected:  map[string]uint8{"LOW": 0, "MID": 35, "TOP": 85},
+			signals:   3,
+			topWrites: 1,
+		},
+		{
+			name:     "increase clamps at 100",
+			call:     func(s *Server) any { return s.IncreaseAllBrightness(60) },
+			expected: map[string]uint8{"LOW": 70, "MID": 100, "TOP": 100},
+			signals:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devices := map[string]*fakeDevice{
+				"LOW": newFakeDevice("LOW", 10),
+				"MID": newFakeDevice("MID", 50),
+				"TOP": newFakeDevice("TOP", 100),
+			}
+			server, recorder := newRecordingServer(newFakeManager(devices["LOW"], devices["MID"], devices["TOP"]))
+
+			require.Nil(t, tt.call(server))
+
+			for serial, want := range tt.expected {
+				assert.Equal(t, want, devices[serial].percent(), serial)
+			}
+			assert.Len(t, recorder.named("BrightnessChanged"), tt.signals, "displays at the bound emit no signal")
+			assert.Equal(t, tt.topWrites, devices["TOP"].writeCount(), "a display at the bound is not written")
+		})
+	}
+}
+
+func TestServer_StepAllBrightness_InvalidStep(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("A", 50)))
+
+	for _, step := range []uint32{0, 101} {
+		assert.NotNil(t, server.IncreaseAllBrightness(step))
+		assert.NotNil(t, server.DecreaseAllBrightness(step))
+	}
+}
+
+func TestServer_StepAllBrightness_IsolatesFailures(t *testing.T) {
+	displayA := newFakeDevice("A", 40)
+	broken := &failingDevice{fakeDevice: newFakeDevice("BROKEN", 40), err: errors.New("write failed")}
+
+	manager := newFakeManager(displayA)
+	manager.displayMap["BROKEN"] = hid.NewDisplay(broken)
+	server, recorder := newRecordingServer(manager)
+
+	require.Nil(t, server.IncreaseAllBrightness(10))
+
+	assert.Equal(t, uint8(50), displayA.percent())
+	signals := recorder.named("BrightnessChanged")
+	require.Len(t, signals, 1)
+	assert.Equal(t, "A", signals[0].values[0])
+}
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"time"
+)
+
+// cachedBrightness is a brightness reading and the time it was taken.
+type cachedBrightness struct {
+	percent uint32
+	readAt  time.Time
+}
+
+// WithBrightnessCache makes GetBrightness answer repeated reads of a display from a
+// cache for up to ttl instead of issuing a HID read each time, which helps status bars
+// that poll every second. Any brightness change made or observed by the daemon
+// invalidates the display's entry immediately. A ttl of 0 disables the cache (default).
+func WithBrightnessCache(ttl time.Duration) ServerOption {
+	return func(s *Server) {
+		s.cacheTTL = ttl
+	}
+}
+
+// cachedBrightnessFor returns the cached brightness of serial if it's younger than the TTL.
+func (s *Server) cachedBrightnessFor(serial string) (uint32, bool) {
+	if s.cacheTTL <= 0 {
+		return 0, false
+	}
+
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	entry, ok := s.cache[serial]
+	if !ok || s.now().Sub(entry.readAt) >= s.cacheTTL {
+		return 0, false
+	}
+	return entry.percent, true
+}
+
+// cacheVersion returns the number of times the cached brightness of serial was
+// invalidated. Readers take it before reading the display and pass it to
+// cacheBrightness.
+func (s *Server) cacheVersion(serial string) uint64 {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	return s.cacheVersions[serial]
+}
+
+// cacheBrightness stores a brightness reading for serial taken at version, see
+// cacheVersion. A reading that raced a write is dropped: the entry was invalidated
+// while it was taken, so it may predate the write. Readings are also kept without
+// a TTL when the stale fallback needs them.
+func (s *Server) cacheBrightness(serial string, percent uint32, version uint64) {
+	if s.cacheTTL <= 0 && !s.staleFallback {
+		return
+	}
+
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	if s.cacheVersions[serial] != version {
+		return
+	}
+	if s.cache == nil {
+		s.cache = make(map[string]cachedBrightness)
+	}
+	s.cache[serial] = cachedBrightness{percent: percent, readAt: s.now()}
+}
+
+// invalidateCachedBrightness drops the cached brightness of serial after it was
+// written, along with readings of it still in flight.
+func (s *Server) invalidateCachedBrightness(serial string) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	delete(s.cache, serial)
+	if s.cacheVersions == nil {
+		s.cacheVersions = make(map[string]uint64)
+	}
+	s.cacheVersions[serial]++
+}
@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCachingServer returns a server with a 500ms brightness cache driven by a fake clock.
+func newCachingServer(devices ...*fakeDevice) (*Server, *fakeClock) {
+	clock := newFakeClock()
+	server := NewServer(newFakeManager(devices...), WithBrightnessCache(500*time.Millisecond))
+	server.now = clock.Now
+	return server, clock
+}
+
+func TestServer_GetBrightness_CacheHitWithinTTL(t *testing.T) {
+	display := newFakeDevice("A", 40)
+	server, clock := newCachingServer(display)
+
+	for range 3 {
+		value, err := server.GetBrightness("A")
+		require.Nil(t, err)
+		assert.Equal(t, uint32(40), value)
+		clock.Advance(100 * time.Millisecond)
+	}
+
+	assert.Equal(t, 1, display.readCount(), "repeated reads within the TTL should hit the cache")
+}
+
+func TestServer_GetBrightness_CacheExpiresAfterTTL(t *testing.T) {
+	display := newFakeDevice("A", 40)
+	server, clock := newCachingServer(display)
+
+	_, err := server.GetBrightness("A")
+	require.Nil(t, err)
+
+	clock.Advance(500 * time.Millisecond)
+	_, err = server.GetBrightness("A")
+	require.Nil(t, err)
+
+	assert.Equal(t, 2, display.readCount())
+}
+
+func TestServer_GetBrightness_CacheInvalidatedOnSet(t *testing.T) {
+	display := newFakeDevice("A", 40)
+	server, _ := newCachingServer(display)
+
+	_, err := server.GetBrightness("A")
+	require.Nil(t, err)
+
+	require.Nil(t, server.SetBrightness("A", 70))
+
+	value, err := server.GetBrightness("A")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(70), value, "a set must not be hidden by a cached reading")
+}
+
+func TestServer_GetBrightness_ReadRacingWriteIsNotCached(t *testing.T) {
+	display := newFakeDevice("A", 40)
+	server, _ := newCachingServer(display)
+
+	// A write lands, and invalidates the cache, after the read got the old value
+	display.onGet = func(string) {
+		display.onGet = nil
+		display.setExternally(70)
+		server.invalidateCachedBrightness("A")
+	}
+	value, err := server.GetBrightness("A")
+	require.Nil(t, err)
+	require.Equal(t, uint32(40), value)
+
+	value, err = server.GetBrightness("A")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(70), value, "the reading taken before the write must not be cached")
+}
+
+func TestServer_GetBrightness_CacheDisabledByDefault(t *testing.T) {
+	display := newFakeDevice("A", 40)
+	server := NewServer(newFakeManager(display))
+
+	for range 2 {
+		_, err := server.GetBrightness("A")
+		require.Nil(t, err)
+	}
+
+	assert.Equal(t, 2, display.readCount())
+}
@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// CachedDisplayInfo is the daemon's view of a display, as returned by GetCachedDisplayInfo.
+type CachedDisplayInfo struct {
+	Serial          string
+	ProductName     string
+	Brightness      uint32 // Last brightness the daemon set or observed; 0 if BrightnessKnown is false
+	BrightnessKnown bool   // Whether Brightness holds a value
+	Mode            string // Active brightness mode
+}
+
+// GetCachedDisplayInfo returns what the daemon knows about a display without any
+// HID I/O: the identity captured at enumeration and the last brightness it set or
+// observed. It's cheap enough for clients to call as often as they like; use
+// GetBrightness for a fresh reading.
+func (s *Server) GetCachedDisplayInfo(serial string) (CachedDisplayInfo, *dbus.Error) {
+	if err := validateSerial(serial); err != nil {
+		return CachedDisplayInfo{}, dbus.MakeFailedError(err)
+	}
+
+	display, err := s.manager.GetDisplay(serial)
+	if err != nil {
+		log.Debug().Err(err).Str("serial", serial).Msg("Cached display info requested for unknown display")
+		return CachedDisplayInfo{}, dbus.MakeFailedError(err)
+	}
+
+	brightness, known := s.knownBrightness(serial)
+	return CachedDisplayInfo{
+		Serial:          serial,
+		ProductName:     display.ProductName(),
+		Brightness:      brightness,
+		BrightnessKnown: known,
+		Mode:            s.modeOf(serial),
+	}, nil
+}
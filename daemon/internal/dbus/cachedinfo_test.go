@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestServer_GetCachedDisplayInfo_NoHIDAccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	// Only Info() is expected: any feature report read or write fails the test
+	mockDevice := mocks.NewMockDevice(ctrl)
+	mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123", Product: "Studio Display"}).AnyTimes()
+
+	manager := &mockDisplayManager{
+		displays:   []hid.DeviceInfo{{Serial: "ABC123", Product: "Studio Display"}},
+		displayMap: map[string]*hid.Display{"ABC123": hid.NewDisplay(mockDevice)},
+	}
+	server := NewServer(manager)
+
+	info, err := server.GetCachedDisplayInfo("ABC123")
+	require.Nil(t, err)
+	assert.Equal(t, CachedDisplayInfo{Serial: "ABC123", ProductName: "Studio Display", Mode: ModeSDR}, info)
+
+	server.recordKnownBrightness("ABC123", 65)
+
+	info, err = server.GetCachedDisplayInfo("ABC123")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(65), info.Brightness)
+	assert.True(t, info.BrightnessKnown)
+}
+
+func TestServer_GetCachedDisplayInfo_TracksSets(t *testing.T) {
+	display := newFakeDevice("A", 10)
+	server := NewServer(newFakeManager(display))
+
+	require.Nil(t, server.SetBrightness("A", 80))
+	reads := display.readCount()
+
+	info, err := server.GetCachedDisplayInfo("A")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(80), info.Brightness)
+	assert.True(t, info.BrightnessKnown)
+	assert.Equal(t, reads, display.readCount())
+}
+
+func TestServer_GetCachedDisplayInfo_UnknownSerial(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("A", 10)))
+
+	_, err := server.GetCachedDisplayInfo("MISSING")
+	assert.NotNil(t, err)
+	_, err = server.GetCachedDisplayInfo("")
+	assert.NotNil(t, err)
+}
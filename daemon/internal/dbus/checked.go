@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// WithCheckedSetRead makes SetBrightnessChecked read the display's current brightness
+// before writing, instead of comparing against the last brightness the daemon knows
+// of. This is exact even after changes made outside the daemon, at the cost of an
+// extra HID round-trip per call.
+func WithCheckedSetRead(enabled bool) ServerOption {
+	return func(s *Server) {
+		s.checkedSetRead = enabled
+	}
+}
+
+// SetBrightnessChecked sets the brightness of a display like SetBrightness and
+// reports whether it changed anything. BrightnessChanged is only emitted if it did.
+//
+// The current brightness is the last one the daemon knows of, unless reading it
+// from the display is enabled in the daemon configuration; in that case a display
+// already at the target isn't written to at all. A display whose brightness isn't
+// known yet always counts as changed.
+func (s *Server) SetBrightnessChecked(serial string, brightness uint32) (bool, *dbus.Error) {
+	s.recordActivity()
+
+	if !s.rateLimits.allow(serial) {
+		return false, s.rateLimitExceeded("SetBrightnessChecked")
+	}
+
+	if err := validateSerial(serial); err != nil {
+		return false, dbus.MakeFailedError(err)
+	}
+
+	display, err := s.manager.GetDisplay(serial)
+	if err != nil {
+		return false, s.displayLookupFailed("SetBrightnessChecked", serial, err)
+	}
+
+	brightness = s.capBrightness(serial, s.nearestStop(brightness))
+
+	unlock := s.serialLocks.lock(serial)
+	s.cancelFade(serial)
+
+	var current uint32
+	var known bool
+	if s.checkedSetRead {
+		percent, err := display.GetBrightness()
+		if err != nil {
+			unlock()
+			s.handleDeviceError(serial, err)
+			log.Error().Err(err).Str("serial", serial).Msg("Failed to read brightness before setting it")
+			return false, dbus.MakeFailedError(err)
+		}
+		current, known = uint32(percent), true
+		if current == brightness {
+			unlock()
+			s.recordKnownBrightness(serial, current)
+			log.Debug().Str("serial", serial).Uint32("brightness", brightness).Msg("Brightness already at target")
+			return false, nil
+		}
+	} else {
+		current, known = s.knownBrightness(serial)
+	}
+
+	err = s.writeBrightness(serial, display, brightness)
+	unlock()
+	if err != nil {
+		s.handleDeviceError(serial, err)
+		log.Error().Err(err).Str("serial", serial).Msg("Failed to set brightness")
+		return false, dbus.MakeFailedError(err)
+	}
+
+	changed := !known || current != brightness
+	log.Debug().Str("serial", serial).Uint32("brightness", brightness).Bool("changed", changed).Msg("Set brightness")
+	if changed {
+		s.onBrightnessChanged(serial, brightness)
+	}
+	return changed, nil
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestServer_SetBrightnessChecked(t *testing.T) {
+	tests := []struct {
+		name string
+		read bool
+	}{
+		{name: "compares against known brightness"},
+		{name: "reads the display first", read: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			display := newFakeDevice("A", 30)
+			server, recorder := newRecordingServer(newFakeManager(display), WithCheckedSetRead(tt.read))
+			server.rateLimits = newRateLimiters(rate.Inf, 0)
+			require.Nil(t, server.SetBrightness("A", 30))
+			writes := display.writeCount()
+			signals := len(recorder.named("BrightnessChanged"))
+
+			// No-op: already at target
+			changed, err := server.SetBrightnessChecked("A", 30)
+			require.Nil(t, err)
+			assert.False(t, changed)
+			assert.Len(t, recorder.named("BrightnessChanged"), signals, "no signal for a no-op")
+			if tt.read {
+				assert.Equal(t, writes, display.writeCount(), "a verified no-op shouldn't write")
+			}
+
+			// Real change
+			changed, err = server.SetBrightnessChecked("A", 70)
+			require.Nil(t, err)
+			assert.True(t, changed)
+			assert.Equal(t, uint8(70), display.percent())
+			assert.Len(t, recorder.named("BrightnessChanged"), signals+1)
+		})
+	}
+}
+
+func TestServer_SetBrightnessChecked_ReadDetectsExternalChange(t *testing.T) {
+	display := newFakeDevice("A", 30)
+	server, recorder := newRecordingServer(newFakeManager(display), WithCheckedSetRead(true))
+	require.Nil(t, server.SetBrightness("A", 30))
+
+	// Someone else changed the display; the daemon still believes it's at 30
+	display.setExternally(80)
+
+	changed, err := server.SetBrightnessChecked("A", 30)
+	require.Nil(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, uint8(30), display.percent())
+	assert.Len(t, recorder.named("BrightnessChanged"), 2)
+}
+
+func TestServer_SetBrightnessChecked_UnknownBrightnessCountsAsChanged(t *testing.T) {
+	display := newFakeDevice("A", 30)
+	server := NewServer(newFakeManager(display))
+
+	changed, err := server.SetBrightnessChecked("A", 30)
+	require.Nil(t, err)
+	assert.True(t, changed)
+
+	_, err = server.SetBrightnessChecked("MISSING", 30)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// pendingStep is the cumulative IncreaseBrightness/DecreaseBrightness change
+// queued for a display within the coalescing window.
+type pendingStep struct {
+	delta int
+	timer *time.Timer // applies delta when the window ends
+}
+
+// WithStepCoalescing makes IncreaseBrightness and DecreaseBrightness on the same
+// display within window add up to one cumulative step, applied with a single read
+// and write when the window ends. This cuts HID traffic while a brightness key is
+// held down, and the final value is the same as applying each step in turn unless
+// a step would have hit a bound mid-way. The window starts with the first queued
+// step and doesn't extend, so a held key still updates the display every window.
+//
+// Only the call that starts a window takes a rate limit token; calls joining a
+// pending step ride on it, so a held key or slider drag costs one token per window.
+// Coalesced calls return as soon as the step is queued; a failed write is only
+// logged and reported to the device error handler. A window of 0 applies every
+// step immediately (default). Steps aren't coalesced while brightness stops are
+// configured, since each call moves exactly one stop.
+func WithStepCoalescing(window time.Duration) ServerOption {
+	return func(s *Server) {
+		s.stepWindow = window
+	}
+}
+
+// WithMaxCoalescedStep caps how many percent a single coalesced write moves the
+// brightness. The part of a cumulative step beyond the cap is queued for the next
+// window without taking another rate limit token, so a small cap makes long drags
+// visibly gradual while a large one traverses the range in fewer writes. 0 applies
+// the whole cumulative step at once (default). It only matters with step coalescing.
+func WithMaxCoalescedStep(maxDelta uint32) ServerOption {
+	return func(s *Server) {
+		s.maxStepDelta = int(min(maxDelta, 100))
+	}
+}
+
+// joinPendingStep adds delta to the step pending for serial, if there is one, and
+// reports whether it did.
+func (s *Server) joinPendingStep(serial string, delta int) bool {
+	s.stepMu.Lock()
+	defer s.stepMu.Unlock()
+
+	pending, ok := s.pendingSteps[serial]
+	if ok {
+		pending.delta += delta
+	}
+	return ok
+}
+
+// queueStep adds delta to the step pending for serial, starting its window if none
+// is running.
+func (s *Server) queueStep(serial string, delta int) {
+	s.stepMu.Lock()
+	defer s.stepMu.Unlock()
+
+	if s.pendingSteps == nil {
+		s.pendingSteps = make(map[string]*pendingStep)
+	}
+	pending, ok := s.pendingSteps[serial]
+	if !ok {
+		pending = &pendingStep{}
+		pending.timer = time.AfterFunc(s.stepWindow, func() { s.flushStep(serial, pending) })
+		s.pendingSteps[serial] = pending
+	}
+	pending.delta += delta
+}
+
+// flushStep applies a pending step whose window ended, unless it was already
+// flushed by flushSteps. With a maximum coalesced step, the remainder beyond it is
+// queued for another window.
+func (s *Server) flushStep(serial string, pending *pendingStep) {
+	s.stepMu.Lock()
+	if s.pendingSteps[serial] != pending {
+		s.stepMu.Unlock()
+		return
+	}
+	delete(s.pendingSteps, serial)
+	delta := pending.delta
+	s.stepMu.Unlock()
+
+	if s.maxStepDelta > 0 {
+		applied := min(max(delta, -s.maxStepDelta), s.maxStepDelta)
+		if rest := delta - applied; rest != 0 {
+			s.queueStep(serial, rest)
+		}
+		delta = applied
+	}
+	s.applyStep(serial, delta)
+}
+
+// flushSteps applies all pending steps without waiting for their windows to end,
+// so key presses queued right before shutdown aren't lost. The maximum coalesced
+// step doesn't apply here.
+func (s *Server) flushSteps() {
+	s.stepMu.Lock()
+	pending := s.pendingSteps
+	s.pendingSteps = nil
+	s.stepMu.Unlock()
+
+	for serial, step := range pending {
+		step.timer.Stop()
+		s.applyStep(serial, step.delta)
+	}
+}
+
+// applyStep changes the brightness of serial by delta percent relative to its
+// current value, as one atomic read-modify-write.
+func (s *Server) applyStep(serial string, delta int) {
+	if delta == 0 {
+		return
+	}
+
+	display, err := s.manager.GetDisplay(serial)
+	if err != nil {
+		log.Warn().Err(err).Str("serial", serial).Int("delta", delta).Msg("Dropping coalesced brightness step")
+		return
+	}
+
+	unlock := s.serialLocks.lock(serial)
+	s.cancelFade(serial)
+
+	current, err := display.GetBrightness()
+	if err != nil {
+		unlock()
+		s.handleDeviceError(serial, err)
+		log.Error().Err(err).Str("serial", serial).Msg("Failed to get brightness for coalesced step")
+		return
+	}
+
+	requested := int(current) + delta
+	// #nosec G115 -- the sum is clamped to 0-100 before conversion
+	target := s.capBrightness(serial, uint32(min(max(requested, 0), 100)))
+
+	err = s.writeBrightness(serial, display, target)
+	unlock()
+	if err != nil {
+		s.handleDeviceError(serial, err)
+		log.Error().Err(err).Str("serial", serial).Msg("Failed to apply coalesced step")
+		return
+	}
+
+	log.Debug().Str("serial", serial).Int("delta", delta).Uint32("new", target).Msg("Applied coalesced brightness step")
+	s.onBrightnessChanged(serial, target)
+	s.emitLimitReached(serial, requested, target)
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestServer_StepCoalescing_AppliesSingleCumulativeStep(t *testing.T) {
+	display := newFakeDevice("A", 40)
+	server, recorder := newRecordingServer(newFakeManager(display), WithStepCoalescing(50*time.Millisecond))
+	server.rateLimits = newRateLimiters(rate.Inf, 0)
+
+	for range 10 {
+		require.Nil(t, server.IncreaseBrightness("A", 1))
+	}
+	assert.Equal(t, 0, display.writeCount(), "steps are queued until the window ends")
+
+	require.Eventually(t, func() bool { return display.writeCount() == 1 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, uint8(50), display.percent())
+	assert.Equal(t, 1, display.readCount(), "a single read for the whole burst")
+
+	signals := recorder.named("BrightnessChanged")
+	require.Len(t, signals, 1)
+	assert.Equal(t, uint32(50), signals[0].values[1])
+}
+
+func TestServer_StepCoalescing_MixedDirectionsAndBounds(t *testing.T) {
+	display := newFakeDevice("A", 95)
+	server := NewServer(newFakeManager(display), WithStepCoalescing(time.Hour))
+	server.rateLimits = newRateLimiters(rate.Inf, 0)
+
+	require.Nil(t, server.IncreaseBrightness("A", 10))
+	require.Nil(t, server.IncreaseBrightness("A", 10))
+	require.Nil(t, server.DecreaseBrightness("A", 5))
+
+	server.flushSteps()
+	assert.Equal(t, uint8(100), display.percent(), "net +15 is clamped to 100")
+	assert.Equal(t, 1, display.writeCount())
+
+	require.Nil(t, server.DecreaseBrightness("A", 60))
+	require.Nil(t, server.DecreaseBrightness("A", 60))
+	server.flushSteps()
+	assert.Equal(t, uint8(0), display.percent(), "net -120 is clamped to 0")
+}
+
+func TestServer_StepCoalescing_StopFlushesPendingSteps(t *testing.T) {
+	display := newFakeDevice("A", 40)
+	server := NewServer(newFakeManager(display), WithStepCoalescing(time.Hour))
+
+	require.Nil(t, server.IncreaseBrightness("A", 5))
+	require.NoError(t, server.Stop())
+
+	assert.Equal(t, uint8(45), display.percent())
+}
+
+func TestServer_StepCoalescing_UnknownSerialFailsImmediately(t *testing.T) {
+	server := NewServer(newFakeManager(), WithStepCoalescing(time.Hour))
+
+	assert.NotNil(t, server.IncreaseBrightness("MISSING", 5))
+	assert.Empty(t, server.pendingSteps)
+}
+
+func TestServer_StepCoalescing_DragUnderRateLimit(t *testing.T) {
+	display := newFakeDevice("A", 0)
+	server := NewServer(newFakeManager(display),
+		WithStepCoalescing(20*time.Millisecond),
+		WithMaxCoalescedStep(25),
+	)
+	// Enough tokens for a few windows but not for one call per percent
+	server.rateLimits = newRateLimiters(0, 3)
+
+	for range 100 {
+		require.Nil(t, server.IncreaseBrightness("A", 1), "steps joining a window take no token")
+	}
+
+	require.Eventually(t, func() bool { return display.percent() == 100 }, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, 4, display.writeCount(), "the drag is applied in steps of at most 25%")
+}
+
+func TestWithMaxCoalescedStep_Unlimited(t *testing.T) {
+	display := newFakeDevice("A", 0)
+	server := NewServer(newFakeManager(display), WithStepCoalescing(20*time.Millisecond))
+	server.rateLimits = newRateLimiters(0, 1)
+
+	for range 100 {
+		require.Nil(t, server.IncreaseBrightness("A", 1))
+	}
+
+	require.Eventually(t, func() bool { return display.percent() == 100 }, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, 1, display.writeCount())
+}
@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+)
+
+// FeatureConnectors is advertised when displays can be addressed by DRM connector.
+const FeatureConnectors = "connectors"
+
+// ErrUnknownConnector is returned when no display is known to be on a connector.
+var ErrUnknownConnector = errors.New("no Studio Display found on connector")
+
+// ConnectorLookup maps connector names, e.g. "DP-1", to the serial of the
+// display on them.
+type ConnectorLookup func(displays []hid.DeviceInfo) (map[string]string, error)
+
+// WithConnectorLookup lets clients address displays by the DRM connector name the
+// compositor shows instead of their serial, using lookup to correlate the two.
+// The mapping is refreshed by RefreshConnectors. A nil lookup disables the
+// connector methods, which is the default.
+func WithConnectorLookup(lookup ConnectorLookup) ServerOption {
+	return func(s *Server) {
+		s.connectorLookup = lookup
+		if lookup != nil {
+			s.extraFeatures = append(s.extraFeatures, FeatureConnectors)
+		}
+	}
+}
+
+// RefreshConnectors rebuilds the connector to serial mapping for the connected
+// displays. It's meant to run after every display refresh.
+func (s *Server) RefreshConnectors() {
+	if s.connectorLookup == nil {
+		return
+	}
+
+	connectors, err := s.connectorLookup(s.manager.ListDisplays())
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to map displays to connectors")
+		return
+	}
+
+	s.connectorMu.Lock()
+	s.connectors = connectors
+	s.connectorMu.Unlock()
+	log.Debug().Interface("connectors", connectors).Msg("Mapped displays to connectors")
+}
+
+// SetBrightnessByConnector sets the brightness of the display on a DRM connector,
+// like SetBrightness.
+func (s *Server) SetBrightnessByConnector(connector string, brightness uint32) *dbus.Error {
+	serial, err := s.serialForConnector(connector)
+	if err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	return s.setBrightness("SetBrightnessByConnector", serial, brightness)
+}
+
+// GetBrightnessByConnector reads the brightness of the display on a DRM connector,
+// like GetBrightness.
+func (s *Server) GetBrightnessByConnector(connector string) (uint32, *dbus.Error) {
+	serial, err := s.serialForConnector(connector)
+	if err != nil {
+		return 0, dbus.MakeFailedError(err)
+	}
+	return s.GetBrightness(serial)
+}
+
+// serialForConnector returns the serial of the display on connector. A connector
+// missing from the mapping triggers one refresh first, since monitors can move
+// between connectors without the USB side noticing.
+func (s *Server) serialForConnector(connector string) (string, error) {
+	if s.connectorLookup == nil {
+		return "", fmt.Errorf("%w %q: connector mapping is disabled", ErrUnknownConnector, connector)
+	}
+	if connector == "" {
+		return "", fmt.Errorf("%w: connector name cannot be empty", ErrUnknownConnector)
+	}
+
+	if serial, ok := s.mappedSerial(connector); ok {
+		return serial, nil
+	}
+	s.RefreshConnectors()
+	if serial, ok := s.mappedSerial(connector); ok {
+		return serial, nil
+	}
+
+	s.connectorMu.Lock()
+	known := slices.Sorted(maps.Keys(s.connectors))
+	s.connectorMu.Unlock()
+	return "", fmt.Errorf("%w %q, mapped connectors: %v", ErrUnknownConnector, connector, known)
+}
+
+// mappedSerial looks connector up in the current mapping.
+func (s *Server) mappedSerial(connector string) (string, bool) {
+	s.connectorMu.Lock()
+	defer s.connectorMu.Unlock()
+	serial, ok := s.connectors[connector]
+	return serial, ok
+}
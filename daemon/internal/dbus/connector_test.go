@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_BrightnessByConnector(t *testing.T) {
+	a := newFakeDevice("A", 30)
+	b := newFakeDevice("B", 60)
+	lookups := 0
+	mapping := map[string]string{"DP-1": "A"}
+	server, recorder := newRecordingServer(newFakeManager(a, b), WithConnectorLookup(func(displays []hid.DeviceInfo) (map[string]string, error) {
+		lookups++
+		assert.Len(t, displays, 2, "the lookup gets the connected displays")
+		return mapping, nil
+	}))
+	server.RefreshConnectors()
+
+	require.Nil(t, server.SetBrightnessByConnector("DP-1", 80))
+	assert.Equal(t, uint8(80), a.percent())
+	signals := recorder.named("BrightnessChanged")
+	require.Len(t, signals, 1)
+	assert.Equal(t, []any{"A", uint32(80)}, signals[0].values)
+
+	brightness, err := server.GetBrightnessByConnector("DP-1")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(80), brightness)
+	assert.Equal(t, 1, lookups, "mapped connectors don't trigger a lookup")
+
+	// A connector missing from the mapping refreshes it once before failing
+	mapping = map[string]string{"DP-1": "A", "DP-2": "B"}
+	brightness, err = server.GetBrightnessByConnector("DP-2")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(60), brightness)
+	assert.Equal(t, 2, lookups)
+
+	err = server.SetBrightnessByConnector("HDMI-A-1", 50)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), ErrUnknownConnector.Error())
+	assert.Contains(t, err.Error(), "[DP-1 DP-2]", "the error lists the mapped connectors")
+	assert.Equal(t, 3, lookups)
+
+	features, _ := server.GetSupportedFeatures()
+	assert.Contains(t, features, FeatureConnectors)
+}
+
+func TestServer_BrightnessByConnector_Errors(t *testing.T) {
+	display := newFakeDevice("A", 30)
+
+	server := NewServer(newFakeManager(display))
+	_, err := server.GetBrightnessByConnector("DP-1")
+	require.NotNil(t, err, "disabled without a lookup")
+	assert.Contains(t, err.Error(), "disabled")
+	features, _ := server.GetSupportedFeatures()
+	assert.NotContains(t, features, FeatureConnectors)
+
+	// A failing lookup keeps the previous mapping
+	fail := false
+	server = NewServer(newFakeManager(display), WithConnectorLookup(func([]hid.DeviceInfo) (map[string]string, error) {
+		if fail {
+			return nil, errors.New("sysfs unavailable")
+		}
+		return map[string]string{"DP-1": "A"}, nil
+	}))
+	server.RefreshConnectors()
+	fail = true
+	server.RefreshConnectors()
+	brightness, err := server.GetBrightnessByConnector("DP-1")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(30), brightness)
+
+	require.NotNil(t, server.SetBrightnessByConnector("", 50))
+	assert.Equal(t, uint8(30), display.percent())
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultContentionReversals is how many direction reversals within the window
+	// count as clients fighting over a display's brightness.
+	DefaultContentionReversals = 4
+
+	// DefaultContentionWindow is the window direction reversals are counted in.
+	DefaultContentionWindow = 2 * time.Second
+)
+
+// contentionState tracks the direction of recent client changes to one display.
+type contentionState struct {
+	last      uint32      // last brightness set
+	direction int         // +1 rising, -1 falling, 0 unknown
+	reversals []time.Time // recent direction reversals, oldest first
+}
+
+// WithContentionThreshold sets how many direction reversals of a display's
+// brightness within window are reported as contention, e.g. two clients
+// repeatedly setting conflicting values. Values below 1 disable detection.
+func WithContentionThreshold(reversals int, window time.Duration) ServerOption {
+	return func(s *Server) {
+		s.contentionReversals = reversals
+		s.contentionWindow = window
+	}
+}
+
+// WithContentionSignal emits BrightnessContention when contention is detected, in
+// addition to logging a warning, so clients can back off.
+func WithContentionSignal(enabled bool) ServerOption {
+	return func(s *Server) {
+		s.contentionSignal = enabled
+	}
+}
+
+// trackContention records a client-initiated change to serial and reports
+// contention once the display's brightness reversed direction often enough within
+// the window. Changes are never blocked; the condition is only surfaced.
+func (s *Server) trackContention(serial string, percent uint32) {
+	if s.contentionReversals < 1 {
+		return
+	}
+
+	now := s.now()
+	s.contentionMu.Lock()
+	if s.contention == nil {
+		s.contention = make(map[string]*contentionState)
+	}
+	state, ok := s.contention[serial]
+	if !ok {
+		s.contention[serial] = &contentionState{last: percent}
+		s.contentionMu.Unlock()
+		return
+	}
+
+	direction := 0
+	switch {
+	case percent > state.last:
+		direction = 1
+	case percent < state.last:
+		direction = -1
+	}
+	state.last = percent
+	if direction == 0 {
+		s.contentionMu.Unlock()
+		return
+	}
+	if state.direction != 0 && direction != state.direction {
+		state.reversals = append(state.reversals, now)
+	}
+	state.direction = direction
+
+	// Forget reversals that fell out of the window
+	cutoff := now.Add(-s.contentionWindow)
+	for len(state.reversals) > 0 && state.reversals[0].Before(cutoff) {
+		state.reversals = state.reversals[1:]
+	}
+
+	contended := len(state.reversals) >= s.contentionReversals
+	if contended {
+		// Start counting afresh so a sustained fight is reported once per threshold
+		state.reversals = nil
+	}
+	s.contentionMu.Unlock()
+
+	if !contended {
+		return
+	}
+	log.Warn().
+		Str("serial", serial).
+		Int("reversals", s.contentionReversals).
+		Dur("window", s.contentionWindow).
+		Msg("Brightness is oscillating, clients may be competing over this display")
+	if s.contentionSignal {
+		s.emitSignal("BrightnessContention", serial)
+	}
+}
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestServer_BrightnessContention(t *testing.T) {
+	clock := newFakeClock()
+	server, recorder := newRecordingServer(newFakeManager(newFakeDevice("A", 50)), WithContentionSignal(true))
+	server.rateLimits = newRateLimiters(rate.Inf, 0)
+	server.now = clock.Now
+
+	// Two clients fight: every value after the second reverses direction
+	sequence := []uint32{20, 80, 20, 80, 20}
+	for _, value := range sequence {
+		require.Nil(t, server.SetBrightness("A", value))
+		clock.Advance(100 * time.Millisecond)
+	}
+	assert.Empty(t, recorder.named("BrightnessContention"), "3 reversals are below the threshold")
+
+	require.Nil(t, server.SetBrightness("A", 80))
+	signals := recorder.named("BrightnessContention")
+	require.Len(t, signals, 1)
+	assert.Equal(t, []any{"A"}, signals[0].values)
+
+	// Changes are never blocked
+	assert.Len(t, recorder.named("BrightnessChanged"), len(sequence)+1)
+}
+
+func TestServer_BrightnessContention_IgnoresSlowAndMonotonicChanges(t *testing.T) {
+	clock := newFakeClock()
+	server, recorder := newRecordingServer(newFakeManager(newFakeDevice("A", 50)), WithContentionSignal(true))
+	server.rateLimits = newRateLimiters(rate.Inf, 0)
+	server.now = clock.Now
+
+	// A user dragging a slider back and forth slowly
+	for _, value := range []uint32{20, 80, 20, 80, 20, 80} {
+		require.Nil(t, server.SetBrightness("A", value))
+		clock.Advance(time.Second)
+	}
+	// Stepping in one direction
+	for range 10 {
+		require.Nil(t, server.IncreaseBrightness("A", 1))
+	}
+
+	assert.Empty(t, recorder.named("BrightnessContention"))
+}
+
+func TestServer_BrightnessContention_SignalDisabledByDefault(t *testing.T) {
+	server, recorder := newRecordingServer(newFakeManager(newFakeDevice("A", 50)))
+	server.rateLimits = newRateLimiters(rate.Inf, 0)
+
+	for _, value := range []uint32{20, 80, 20, 80, 20, 80} {
+		require.Nil(t, server.SetBrightness("A", value))
+	}
+
+	assert.Empty(t, recorder.named("BrightnessContention"))
+}
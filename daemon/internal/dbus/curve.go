@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"github.com/godbus/dbus/v5"
+	"github.com/shini4i/asd-brightness-daemon/internal/brightness"
+)
+
+// curveSampleStep is the spacing of GetBrightnessCurve samples, in percent.
+const curveSampleStep = 5
+
+// CurveSample is a point of the percent-to-nits mapping returned by GetBrightnessCurve.
+type CurveSample struct {
+	Percent uint32
+	Nits    uint32
+}
+
+// GetBrightnessCurve returns the curve type and samples of the mapping between a
+// display's brightness percentage and the nits value written to it, every 5% from
+// 0 to 100, so clients can interpolate locally instead of round-tripping. The curve
+// type is the one in effect for the display, see brightness.Curves.
+func (s *Server) GetBrightnessCurve(serial string) (string, []CurveSample, *dbus.Error) {
+	if err := validateSerial(serial); err != nil {
+		return "", nil, dbus.MakeFailedError(err)
+	}
+	if _, err := s.manager.GetDisplay(serial); err != nil {
+		return "", nil, dbus.MakeFailedError(err)
+	}
+
+	curve := s.effectiveConfig(serial).Curve
+	points := brightness.Curve(curve, curveSampleStep)
+	samples := make([]CurveSample, len(points))
+	for i, point := range points {
+		samples[i] = CurveSample{Percent: uint32(point.Percent), Nits: point.Nits}
+	}
+	return curve, samples, nil
+}
@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/brightness"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_GetBrightnessCurve(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("A", 50)))
+
+	curve, samples, err := server.GetBrightnessCurve("A")
+	require.Nil(t, err)
+	assert.Equal(t, brightness.CurveLinear, curve)
+	require.Len(t, samples, 21)
+
+	for i, sample := range samples {
+		assert.Equal(t, uint32(i*curveSampleStep), sample.Percent)
+		assert.Equal(t, brightness.PercentToNits(uint8(sample.Percent)), sample.Nits)
+	}
+	assert.Equal(t, CurveSample{Percent: 0, Nits: brightness.MinBrightness}, samples[0])
+	assert.Equal(t, CurveSample{Percent: 100, Nits: brightness.MaxBrightness}, samples[20])
+}
+
+func TestServer_GetBrightnessCurve_Errors(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("A", 50)))
+
+	_, _, err := server.GetBrightnessCurve("")
+	assert.NotNil(t, err)
+
+	_, _, err = server.GetBrightnessCurve("MISSING")
+	assert.NotNil(t, err)
+}
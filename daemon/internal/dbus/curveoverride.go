@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/brightness"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+)
+
+// ErrUnsupportedCurve is returned when SetCurveOverride names a curve the daemon
+// doesn't implement.
+var ErrUnsupportedCurve = errors.New("unsupported brightness curve")
+
+// SetCurveOverride temporarily replaces the curve configured for a connected
+// display, e.g. so a calibration tool gets a known mapping regardless of the
+// user's choice. The override is reported by GetCurveOverride, GetDisplayConfig
+// and GetBrightnessCurve, and lasts until ClearCurveOverride or until the display
+// disconnects. The display keeps its brightness in nits, so its percentage may
+// change, which is reported by BrightnessChanged.
+func (s *Server) SetCurveOverride(serial, curve string) *dbus.Error {
+	if err := validateSerial(serial); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	if !slices.Contains(brightness.Curves, curve) {
+		return dbus.MakeFailedError(fmt.Errorf("%w %q, expected one of %v", ErrUnsupportedCurve, curve, brightness.Curves))
+	}
+	display, err := s.manager.GetDisplay(serial)
+	if err != nil {
+		return dbus.MakeFailedError(err)
+	}
+
+	s.curveMu.Lock()
+	if s.curveOverrides == nil {
+		s.curveOverrides = make(map[string]string)
+	}
+	s.curveOverrides[serial] = curve
+	s.curveMu.Unlock()
+
+	log.Info().Str("serial", serial).Str("curve", curve).Msg("Brightness curve overridden")
+	s.applyCurve(serial, display)
+	return nil
+}
+
+// ClearCurveOverride restores the configured curve of a display. Clearing a
+// display without an override is not an error.
+func (s *Server) ClearCurveOverride(serial string) *dbus.Error {
+	if err := validateSerial(serial); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+	if !s.clearCurveOverride(serial) {
+		return nil
+	}
+	log.Info().Str("serial", serial).Msg("Brightness curve override cleared")
+	if display, err := s.manager.GetDisplay(serial); err == nil {
+		s.applyCurve(serial, display)
+	}
+	return nil
+}
+
+// applyCurve switches display to the curve in effect for serial. The display keeps
+// its brightness in nits, so its percentage is read back and, if it changed,
+// reported like a client change.
+func (s *Server) applyCurve(serial string, display *hid.Display) {
+	curve := s.effectiveConfig(serial).Curve
+
+	unlock := s.lockWithoutFades(serial)
+	display.SetCurve(curve)
+	percent, err := display.GetBrightness()
+	var previous uint32
+	var seen bool
+	if err == nil {
+		s.cancelSmoothing(serial)
+		previous, seen = s.swapKnownBrightness(serial, uint32(percent))
+	}
+	unlock()
+
+	if err != nil {
+		s.handleDeviceError(serial, err)
+		log.Warn().Err(err).Str("serial", serial).Msg("Failed to read brightness after switching curves")
+		return
+	}
+	if seen && previous != uint32(percent) {
+		s.emitBrightnessSignals(serial, uint32(percent))
+	}
+}
+
+// GetCurveOverride returns the curve in effect for a connected display and
+// whether it comes from SetCurveOverride rather than the configuration.
+func (s *Server) GetCurveOverride(serial string) (string, bool, *dbus.Error) {
+	if err := validateSerial(serial); err != nil {
+		return "", false, dbus.MakeFailedError(err)
+	}
+	if _, err := s.manager.GetDisplay(serial); err != nil {
+		return "", false, dbus.MakeFailedError(err)
+	}
+	_, overridden := s.curveOverride(serial)
+	return s.effectiveConfig(serial).Curve, overridden, nil
+}
+
+// curveOverride returns the curve override of serial, if any.
+func (s *Server) curveOverride(serial string) (string, bool) {
+	s.curveMu.Lock()
+	defer s.curveMu.Unlock()
+	curve, ok := s.curveOverrides[serial]
+	return curve, ok
+}
+
+// clearCurveOverride drops the curve override of serial and reports whether there was one.
+func (s *Server) clearCurveOverride(serial string) bool {
+	s.curveMu.Lock()
+	defer s.curveMu.Unlock()
+	_, ok := s.curveOverrides[serial]
+	delete(s.curveOverrides, serial)
+	return ok
+}
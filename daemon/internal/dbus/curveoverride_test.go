@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/brightness"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_CurveOverride(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("A", 50), newFakeDevice("B", 50)))
+
+	curve, overridden, err := server.GetCurveOverride("A")
+	require.Nil(t, err)
+	assert.Equal(t, brightness.CurveLinear, curve)
+	assert.False(t, overridden)
+
+	require.Nil(t, server.SetCurveOverride("A", brightness.CurvePerceptual))
+	curve, overridden, err = server.GetCurveOverride("A")
+	require.Nil(t, err)
+	assert.Equal(t, brightness.CurvePerceptual, curve)
+	assert.True(t, overridden)
+	_, overridden, _ = server.GetCurveOverride("B")
+	assert.False(t, overridden, "overrides are per display")
+
+	config, err := server.GetDisplayConfig("A")
+	require.Nil(t, err)
+	assert.Equal(t, brightness.CurvePerceptual, config.Curve)
+	reported, samples, err := server.GetBrightnessCurve("A")
+	require.Nil(t, err)
+	assert.Equal(t, brightness.CurvePerceptual, reported)
+	assert.Equal(t, brightness.CurvePercentToNits(brightness.CurvePerceptual, 50), samples[10].Nits)
+
+	require.Nil(t, server.ClearCurveOverride("A"))
+	_, overridden, _ = server.GetCurveOverride("A")
+	assert.False(t, overridden)
+	assert.Nil(t, server.ClearCurveOverride("A"), "clearing twice is harmless")
+
+	err = server.SetCurveOverride("A", "gamma")
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), ErrUnsupportedCurve.Error())
+	assert.NotNil(t, server.SetCurveOverride("MISSING", brightness.CurveLinear))
+	_, _, err = server.GetCurveOverride("MISSING")
+	assert.NotNil(t, err)
+}
+
+func TestServer_CurveOverride_ChangesMapping(t *testing.T) {
+	display := newFakeDevice("A", 50)
+	server, recorder := newRecordingServer(newFakeManager(display))
+	require.Nil(t, server.SetBrightness("A", 50))
+	linearNits := display.nits
+
+	// The display keeps its nits, which are a higher percentage along the perceptual curve
+	require.Nil(t, server.SetCurveOverride("A", brightness.CurvePerceptual))
+	assert.Equal(t, linearNits, display.nits, "switching curves doesn't write to the display")
+	percent, err := server.GetBrightness("A")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(brightness.CurveNitsToPercent(brightness.CurvePerceptual, linearNits)), percent)
+	changes := recorder.named("BrightnessChanged")
+	require.Len(t, changes, 2)
+	assert.Equal(t, []any{"A", percent}, changes[1].values)
+
+	require.Nil(t, server.SetBrightness("A", 50))
+	assert.Equal(t, brightness.CurvePercentToNits(brightness.CurvePerceptual, 50), display.nits)
+	assert.Less(t, display.nits, linearNits)
+
+	require.Nil(t, server.ClearCurveOverride("A"))
+	percent, err = server.GetBrightness("A")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(brightness.NitsToPercent(display.nits)), percent, "the configured linear curve applies again")
+}
+
+func TestServer_CurveOverride_ClearedOnDisconnect(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("A", 50)))
+	require.Nil(t, server.SetCurveOverride("A", brightness.CurvePerceptual))
+
+	server.EmitDisplayRemoved("A")
+
+	_, ok := server.curveOverride("A")
+	assert.False(t, ok, "a reconnecting display starts with its configured curve")
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"github.com/godbus/dbus/v5"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+)
+
+// FeatureDisplayAddedV2 is advertised when DisplayAddedV2 is emitted.
+const FeatureDisplayAddedV2 = "display-added-v2"
+
+// WithDisplayAddedV2 makes the server emit DisplayAddedV2, which carries a display's
+// full device info, after every DisplayAdded, so clients can populate their UI from
+// the signal instead of calling ListDisplays. It's off by default.
+func WithDisplayAddedV2(enabled bool) ServerOption {
+	return func(s *Server) {
+		s.displayAddedV2 = enabled
+		if enabled {
+			s.extraFeatures = append(s.extraFeatures, FeatureDisplayAddedV2)
+		}
+	}
+}
+
+// EmitDisplayAddedInfo emits the signals of EmitDisplayAdded for a newly connected
+// display, followed by DisplayAddedV2 if enabled.
+func (s *Server) EmitDisplayAddedInfo(info hid.DeviceInfo) {
+	s.EmitDisplayAdded(info.Serial, info.Product)
+	if s.displayAddedV2 {
+		s.emitSignal("DisplayAddedV2", info.Serial, deviceInfoProperties(info))
+	}
+}
+
+// deviceInfoProperties returns the DisplayAddedV2 payload for info.
+func deviceInfoProperties(info hid.DeviceInfo) map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"Serial":       dbus.MakeVariant(info.Serial),
+		"ProductName":  dbus.MakeVariant(info.Product),
+		"Manufacturer": dbus.MakeVariant(info.Manufacturer),
+		"Path":         dbus.MakeVariant(info.Path),
+		"VendorID":     dbus.MakeVariant(info.VendorID),
+		"ProductID":    dbus.MakeVariant(info.ProductID),
+		// #nosec G115 -- USB interface numbers fit in a byte
+		"Interface": dbus.MakeVariant(int32(info.Interface)),
+	}
+}
@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_EmitDisplayAddedInfo(t *testing.T) {
+	info := hid.DeviceInfo{
+		Path:         "/dev/hidraw3",
+		VendorID:     hid.AppleVendorID,
+		ProductID:    hid.StudioDisplayProductID,
+		Serial:       "ABC123",
+		Manufacturer: "Apple Inc.",
+		Product:      "Studio Display",
+		Interface:    hid.BrightnessInterface,
+	}
+
+	server, recorder := newRecordingServer(&mockDisplayManager{}, WithDisplayAddedV2(true))
+	server.EmitDisplayAddedInfo(info)
+
+	simple := recorder.named("DisplayAdded")
+	require.Len(t, simple, 1, "the simple signal is kept for compatibility")
+	assert.Equal(t, []any{"ABC123", "Studio Display"}, simple[0].values)
+
+	rich := recorder.named("DisplayAddedV2")
+	require.Len(t, rich, 1)
+	assert.Equal(t, "ABC123", rich[0].values[0])
+	props := rich[0].values[1].(map[string]dbus.Variant)
+	values := make(map[string]any, len(props))
+	for key, value := range props {
+		values[key] = value.Value()
+	}
+	assert.Equal(t, map[string]any{
+		"Serial":       "ABC123",
+		"ProductName":  "Studio Display",
+		"Manufacturer": "Apple Inc.",
+		"Path":         "/dev/hidraw3",
+		"VendorID":     hid.AppleVendorID,
+		"ProductID":    hid.StudioDisplayProductID,
+		"Interface":    int32(hid.BrightnessInterface),
+	}, values)
+
+	features, _ := server.GetSupportedFeatures()
+	assert.Contains(t, features, FeatureDisplayAddedV2)
+}
+
+func TestServer_EmitDisplayAddedInfo_V2Disabled(t *testing.T) {
+	server, recorder := newRecordingServer(&mockDisplayManager{})
+	server.EmitDisplayAddedInfo(hid.DeviceInfo{Serial: "ABC123", Product: "Studio Display"})
+
+	assert.Len(t, recorder.named("DisplayAdded"), 1)
+	assert.Empty(t, recorder.named("DisplayAddedV2"))
+}
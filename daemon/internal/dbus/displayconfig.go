@@ -0,0 +1,160 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/brightness"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+)
+
+// DisplayConfig overrides global settings for a single display. Nil and empty
+// fields keep the global setting.
+type DisplayConfig struct {
+	Alias             string  // Name ResolveSerial accepts for the display
+	Curve             string  // Percent-to-nits mapping, e.g. brightness.CurveLinear
+	MinBrightness     *uint32 // Floor replacing the global minimum brightness
+	MaxBrightness     *uint32 // Cap applied on top of the brightness mode and night mode caps
+	StartupBrightness *int    // Brightness applied when the display connects; negative disables
+}
+
+// EffectiveDisplayConfig is the configuration in effect for a display, with
+// per-display overrides layered over the global settings, as returned by
+// GetDisplayConfig.
+type EffectiveDisplayConfig struct {
+	Serial            string
+	Alias             string // Empty if the display has no alias
+	Curve             string
+	MinBrightness     uint32
+	MaxBrightness     uint32 // 100 if the display has no cap of its own
+	StartupBrightness int32  // -1 if no brightness is applied on connect
+}
+
+// WithDisplayConfigs sets per-display overrides keyed by serial. Percentages above
+// 100 are clamped to 100. Overrides for serials that never connect are harmless;
+// the daemon warns about them at startup (see WarnUnknownDisplayConfigs).
+func WithDisplayConfigs(configs map[string]DisplayConfig) ServerOption {
+	return func(s *Server) {
+		s.displayConfigs = make(map[string]DisplayConfig, len(configs))
+		for serial, config := range configs {
+			if config.MinBrightness != nil {
+				percent := min(*config.MinBrightness, 100)
+				config.MinBrightness = &percent
+			}
+			if config.MaxBrightness != nil {
+				percent := min(*config.MaxBrightness, 100)
+				config.MaxBrightness = &percent
+			}
+			if config.StartupBrightness != nil {
+				percent := max(min(*config.StartupBrightness, 100), -1)
+				config.StartupBrightness = &percent
+			}
+			s.displayConfigs[serial] = config
+		}
+	}
+}
+
+// WithConnectBrightness sets the global brightness ApplyConnectBrightness sets
+// displays to when they connect, reported by GetDisplayConfig. Per-display
+// StartupBrightness overrides replace it. Negative values mean none, the default.
+func WithConnectBrightness(percent int) ServerOption {
+	return func(s *Server) {
+		s.connectBrightness = min(percent, 100)
+	}
+}
+
+// ApplyConnectBrightness sets a newly connected display to its startup brightness
+// (see WithConnectBrightness), so a display that powers up at full brightness
+// doesn't flash. It's meant to be called before clients are notified of the display
+// (see hid.WithConnectHandler), so no signal is emitted. Like client requests it's
+// kept within the display's floor and mode caps and recorded as its known
+// brightness. Nothing is written while automatic changes are paused.
+func (s *Server) ApplyConnectBrightness(serial string, display *hid.Display) {
+	startup := s.effectiveConfig(serial).StartupBrightness
+	if startup < 0 {
+		return
+	}
+	if s.paused.Load() {
+		log.Info().Str("serial", serial).Msg("Automatic brightness changes paused, not applying connect brightness")
+		return
+	}
+
+	percent := s.capBrightness(serial, uint32(startup))
+	unlock := s.lockWithoutFades(serial)
+	err := s.writeBrightness(serial, display, percent)
+	unlock()
+	if err != nil {
+		log.Warn().Err(err).Str("serial", serial).Msg("Failed to apply connect brightness")
+		return
+	}
+	log.Info().Str("serial", serial).Uint32("brightness", percent).Msg("Applied connect brightness")
+}
+
+// GetDisplayConfig returns the configuration in effect for a connected display:
+// its per-display overrides from the daemon configuration layered over the global
+// settings.
+func (s *Server) GetDisplayConfig(serial string) (EffectiveDisplayConfig, *dbus.Error) {
+	if err := validateSerial(serial); err != nil {
+		return EffectiveDisplayConfig{}, dbus.MakeFailedError(err)
+	}
+	if _, err := s.manager.GetDisplay(serial); err != nil {
+		return EffectiveDisplayConfig{}, dbus.MakeFailedError(err)
+	}
+	return s.effectiveConfig(serial), nil
+}
+
+// WarnUnknownDisplayConfigs logs a warning for every per-display override whose
+// serial doesn't belong to a connected display, which usually means a typo. It's
+// meant to run once after the initial enumeration; the overrides stay in place in
+// case the display connects later.
+func (s *Server) WarnUnknownDisplayConfigs() {
+	for serial := range s.displayConfigs {
+		if _, err := s.manager.GetDisplay(serial); err != nil {
+			log.Warn().Str("serial", serial).Msg("Config has settings for a display that isn't connected")
+		}
+	}
+}
+
+// effectiveConfig layers the overrides for serial over the global settings.
+func (s *Server) effectiveConfig(serial string) EffectiveDisplayConfig {
+	config := s.displayConfigs[serial]
+	startup := max(s.connectBrightness, -1)
+	if config.StartupBrightness != nil {
+		startup = *config.StartupBrightness
+	}
+	effective := EffectiveDisplayConfig{
+		Serial:        serial,
+		Alias:         config.Alias,
+		Curve:         brightness.CurveLinear,
+		MinBrightness: s.minBrightness,
+		MaxBrightness: 100,
+		// #nosec G115 -- startup brightness is clamped to -1..100
+		StartupBrightness: int32(startup),
+	}
+	if config.Curve != "" {
+		effective.Curve = config.Curve
+	}
+	if curve, ok := s.curveOverride(serial); ok {
+		effective.Curve = curve
+	}
+	if config.MinBrightness != nil {
+		effective.MinBrightness = *config.MinBrightness
+	}
+	if config.MaxBrightness != nil {
+		effective.MaxBrightness = *config.MaxBrightness
+	}
+	return effective
+}
+
+// serialForAlias returns the serial whose configured alias equals name, ignoring case.
+func (s *Server) serialForAlias(name string) (string, bool) {
+	for serial, config := range s.displayConfigs {
+		if config.Alias != "" && strings.EqualFold(config.Alias, name) {
+			return serial, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/brightness"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func percentOf(v uint32) *uint32 { return &v }
+
+func TestServer_GetDisplayConfig_LayersOverGlobals(t *testing.T) {
+	startup := 70
+	server := NewServer(newFakeManager(newFakeDevice("A", 50), newFakeDevice("B", 50)),
+		WithMinBrightness(10),
+		WithConnectBrightness(40),
+		WithDisplayConfigs(map[string]DisplayConfig{
+			"A": {Alias: "left", MinBrightness: percentOf(20), MaxBrightness: percentOf(150), StartupBrightness: &startup},
+		}),
+	)
+
+	config, err := server.GetDisplayConfig("A")
+	require.Nil(t, err)
+	assert.Equal(t, EffectiveDisplayConfig{
+		Serial:            "A",
+		Alias:             "left",
+		Curve:             brightness.CurveLinear,
+		MinBrightness:     20,
+		MaxBrightness:     100,
+		StartupBrightness: 70,
+	}, config, "overrides replace the globals and are clamped")
+
+	config, err = server.GetDisplayConfig("B")
+	require.Nil(t, err)
+	assert.Equal(t, EffectiveDisplayConfig{
+		Serial:            "B",
+		Curve:             brightness.CurveLinear,
+		MinBrightness:     10,
+		MaxBrightness:     100,
+		StartupBrightness: 40,
+	}, config, "displays without overrides get the globals")
+
+	_, err = server.GetDisplayConfig("missing")
+	assert.NotNil(t, err)
+
+	config, _ = NewServer(newFakeManager(newFakeDevice("A", 50))).GetDisplayConfig("A")
+	assert.Equal(t, int32(-1), config.StartupBrightness, "no connect brightness by default")
+}
+
+func TestServer_ApplyConnectBrightness(t *testing.T) {
+	startup, disabled := 70, -1
+	devices := []*fakeDevice{newFakeDevice("A", 100), newFakeDevice("B", 100), newFakeDevice("C", 100)}
+	manager := newFakeManager(devices...)
+	server, recorder := newRecordingServer(manager,
+		WithMinBrightness(10),
+		WithConnectBrightness(5),
+		WithDisplayConfigs(map[string]DisplayConfig{
+			"B": {StartupBrightness: &startup, MaxBrightness: percentOf(60)},
+			"C": {StartupBrightness: &disabled},
+		}),
+	)
+
+	for _, serial := range []string{"A", "B", "C"} {
+		server.ApplyConnectBrightness(serial, manager.displayMap[serial])
+	}
+
+	assert.Equal(t, uint8(10), devices[0].percent(), "the global value is raised to the floor")
+	assert.Equal(t, uint8(60), devices[1].percent(), "the per-display value is kept within the display's cap")
+	assert.Equal(t, uint8(100), devices[2].percent(), "a negative override disables it for the display")
+	assert.Zero(t, devices[2].writeCount())
+
+	known, ok := server.knownBrightness("B")
+	require.True(t, ok)
+	assert.Equal(t, uint32(60), known, "polls must not report connect brightness as an external change")
+	assert.Empty(t, recorder.named("BrightnessChanged"), "clients haven't been told about the display yet")
+}
+
+func TestServer_SetBrightness_PerDisplayLimits(t *testing.T) {
+	capped := newFakeDevice("A", 50)
+	other := newFakeDevice("B", 50)
+	server := NewServer(newFakeManager(capped, other),
+		WithMinBrightness(5),
+		WithDisplayConfigs(map[string]DisplayConfig{
+			"A": {MinBrightness: percentOf(30), MaxBrightness: percentOf(80)},
+		}),
+	)
+	server.rateLimits = newRateLimiters(rate.Inf, 0)
+
+	require.Nil(t, server.SetBrightness("A", 100))
+	assert.Equal(t, uint8(80), capped.percent(), "per-display cap")
+	require.Nil(t, server.SetBrightness("A", 0))
+	assert.Equal(t, uint8(30), capped.percent(), "per-display floor replaces the global one")
+
+	require.Nil(t, server.SetBrightness("B", 100))
+	assert.Equal(t, uint8(100), other.percent())
+	require.Nil(t, server.SetBrightness("B", 0))
+	assert.Equal(t, uint8(5), other.percent(), "global floor")
+}
+
+func TestServer_ResolveSerial_Alias(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("H1234567890", 50), newFakeDevice("H0987654321", 50)),
+		WithDisplayConfigs(map[string]DisplayConfig{
+			"H0987654321": {Alias: "Left"},
+			"H5555555555": {Alias: "gone"},
+		}),
+	)
+
+	serial, err := server.ResolveSerial("left")
+	require.Nil(t, err)
+	assert.Equal(t, "H0987654321", serial, "aliases match ignoring case")
+
+	serial, err = server.ResolveSerial("7890")
+	require.Nil(t, err)
+	assert.Equal(t, "H1234567890", serial, "serial matching still works")
+
+	_, err = server.ResolveSerial("gone")
+	assert.NotNil(t, err, "the aliased display isn't connected")
+}
+
+func TestServer_WarnUnknownDisplayConfigs(t *testing.T) {
+	var buf bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&buf)
+	t.Cleanup(func() { log.Logger = previous })
+
+	server := NewServer(newFakeManager(newFakeDevice("A", 50)),
+		WithDisplayConfigs(map[string]DisplayConfig{
+			"A":     {Alias: "main"},
+			"TYPO1": {MaxBrightness: percentOf(50)},
+		}),
+	)
+	server.WarnUnknownDisplayConfigs()
+
+	assert.Contains(t, buf.String(), "TYPO1")
+	assert.NotContains(t, buf.String(), `"serial":"A"`)
+
+	config, err := server.GetDisplayConfig("A")
+	require.Nil(t, err)
+	assert.Equal(t, "main", config.Alias, "known overrides still apply")
+}
@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultDeviceErrorDebounce is the default window in which repeated device errors
+// of a display trigger only one recovery.
+const DefaultDeviceErrorDebounce = 2 * time.Second
+
+// WithDeviceErrorDebounce makes device errors of a display within window of the
+// recovery they triggered coalesce into it instead of starting recoveries of their
+// own, so a bad stretch of failing operations doesn't launch overlapping
+// recoveries. The errors are still returned to clients. A window of 0 triggers a
+// recovery for every error. Defaults to DefaultDeviceErrorDebounce.
+func WithDeviceErrorDebounce(window time.Duration) ServerOption {
+	return func(s *Server) {
+		s.errorDebounce = max(window, 0)
+	}
+}
+
+// recoveryDue reports whether a device error of serial should trigger a recovery,
+// and if so records that one was triggered now.
+func (s *Server) recoveryDue(serial string) bool {
+	now := s.now()
+
+	s.recoveryMu.Lock()
+	defer s.recoveryMu.Unlock()
+
+	if last, ok := s.lastRecovery[serial]; ok && now.Sub(last) < s.errorDebounce {
+		log.Debug().Str("serial", serial).Msg("Device error within debounce window, recovery already triggered")
+		return false
+	}
+	if s.lastRecovery == nil {
+		s.lastRecovery = make(map[string]time.Time)
+	}
+	s.lastRecovery[serial] = now
+	return true
+}
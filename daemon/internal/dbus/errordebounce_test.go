@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"fmt"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_DeviceErrorDebounce(t *testing.T) {
+	now := time.Unix(1000, 0)
+	server := NewServer(newFakeManager(), WithDeviceErrorDebounce(time.Second))
+	server.now = func() time.Time { return now }
+
+	recoveries := make(chan string, 10)
+	server.SetDeviceErrorHandler(func(serial string, _ error) { recoveries <- serial })
+
+	enodev := fmt.Errorf("failed to send feature report: %w", syscall.ENODEV)
+	for range 5 {
+		assert.True(t, server.handleDeviceError("A", enodev), "debounced errors are still device errors")
+		now = now.Add(100 * time.Millisecond)
+	}
+	assert.True(t, server.handleDeviceError("B", enodev), "displays are debounced separately")
+
+	assert.ElementsMatch(t, []string{"A", "B"}, []string{<-recoveries, <-recoveries})
+	assert.Never(t, func() bool { return len(recoveries) > 0 }, 50*time.Millisecond, 5*time.Millisecond,
+		"errors within the window coalesce into the first recovery")
+	stats, _ := server.GetStats()
+	assert.Equal(t, uint64(6), stats.Errors)
+	assert.Equal(t, uint64(2), stats.Recoveries)
+
+	// Once the window has passed, the next error triggers a recovery again
+	now = now.Add(time.Second)
+	server.handleDeviceError("A", enodev)
+	assert.Equal(t, "A", <-recoveries)
+}
+
+func TestServer_DeviceErrorDebounce_Disabled(t *testing.T) {
+	server := NewServer(newFakeManager(), WithDeviceErrorDebounce(0))
+	var recoveries atomic.Int32
+	server.SetDeviceErrorHandler(func(string, error) { recoveries.Add(1) })
+
+	for range 3 {
+		server.handleDeviceError("A", syscall.ENODEV)
+	}
+	assert.Eventually(t, func() bool { return recoveries.Load() == 3 }, time.Second, time.Millisecond)
+}
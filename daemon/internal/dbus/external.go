@@ -0,0 +1,212 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+)
+
+// DefaultSmoothingInterval is the default spacing between smoothed BrightnessChanged signals.
+const DefaultSmoothingInterval = 50 * time.Millisecond
+
+// smoothJob is an in-progress smoothed report of an external change on a single display.
+type smoothJob struct {
+	quit chan struct{} // closed to stop emitting
+	done chan struct{} // closed when the smoothing goroutine exits
+}
+
+// WithBrightnessSmoothing makes PollBrightness report an externally made brightness
+// change as steps BrightnessChanged signals spaced interval apart, interpolating from
+// the previous value to the new one, so slider UIs animate instead of jumping.
+// It only affects emitted signals; the display is never written. A newer change of
+// the display, external or made by the daemon, stops the remaining steps. Steps
+// below 2 disable smoothing.
+func WithBrightnessSmoothing(steps int, interval time.Duration) ServerOption {
+	return func(s *Server) {
+		s.smoothSteps = steps
+		s.smoothInterval = interval
+	}
+}
+
+// recordKnownBrightness remembers the last brightness reported for a display,
+// so PollBrightness only reports changes the daemon didn't make itself. A smoothed
+// report of an earlier external change is stopped, since it's out of date.
+func (s *Server) recordKnownBrightness(serial string, percent uint32) {
+	s.cancelSmoothing(serial)
+	s.swapKnownBrightness(serial, percent)
+}
+
+// knownBrightness returns the last brightness reported for a display, with ok set
+// to false if none was recorded yet.
+func (s *Server) knownBrightness(serial string) (percent uint32, ok bool) {
+	s.knownMu.Lock()
+	defer s.knownMu.Unlock()
+
+	percent, ok = s.known[serial]
+	return percent, ok
+}
+
+// swapKnownBrightness stores the brightness for serial and returns the previous
+// value, with seen set to false if none was recorded yet. It supersedes a silent
+// daemon write recorded by recordDaemonWrite.
+func (s *Server) swapKnownBrightness(serial string, percent uint32) (previous uint32, seen bool) {
+	s.invalidateCachedBrightness(serial)
+
+	s.knownMu.Lock()
+	defer s.knownMu.Unlock()
+
+	delete(s.daemonWritten, serial)
+	if s.known == nil {
+		s.known = make(map[string]uint32)
+	}
+	if s.lastSeen == nil {
+		s.lastSeen = make(map[string]time.Time)
+	}
+	previous, seen = s.known[serial]
+	s.known[serial] = percent
+	s.lastSeen[serial] = s.now()
+	return previous, seen
+}
+
+// recordDaemonWrite remembers a brightness the daemon wrote without reporting it, so
+// PollBrightness doesn't mistake it for an external change while the known
+// brightness keeps matching what clients were last told.
+func (s *Server) recordDaemonWrite(serial string, percent uint32) {
+	s.invalidateCachedBrightness(serial)
+
+	s.knownMu.Lock()
+	defer s.knownMu.Unlock()
+
+	if s.daemonWritten == nil {
+		s.daemonWritten = make(map[string]uint32)
+	}
+	s.daemonWritten[serial] = percent
+}
+
+// isDaemonWrite reports whether percent is the brightness the daemon last wrote
+// silently to serial, see recordDaemonWrite.
+func (s *Server) isDaemonWrite(serial string, percent uint32) bool {
+	s.knownMu.Lock()
+	defer s.knownMu.Unlock()
+
+	written, ok := s.daemonWritten[serial]
+	return ok && written == percent
+}
+
+// PollBrightness reads the brightness of every display and emits BrightnessChanged
+// for values changed outside the daemon, e.g. by another tool or the display itself.
+// The first reading of a display only establishes its baseline. Displays with a
+// fade in progress are skipped, since their value is expected to change. Spontaneous
+// resets are undone instead of reported if configured, see WithResetReapply.
+func (s *Server) PollBrightness() {
+	for serial, display := range s.manager.Snapshot() {
+		if s.fadeRunning(serial) {
+			continue
+		}
+
+		// Hold the display's write lock so a concurrent client write can't be
+		// mistaken for an external change
+		unlock := s.serialLocks.lock(serial)
+		current, err := display.GetBrightness()
+		if err != nil {
+			unlock()
+			if !errors.Is(err, hid.ErrDisplayClosed) {
+				s.handleDeviceError(serial, err)
+				log.Debug().Err(err).Str("serial", serial).Msg("Failed to poll brightness")
+			}
+			continue
+		}
+		if s.isDaemonWrite(serial, uint32(current)) {
+			unlock()
+			continue
+		}
+
+		previous, seen := s.swapKnownBrightness(serial, uint32(current))
+		if seen && s.spontaneousReset(previous, uint32(current)) && s.reapplyAfterReset(serial, display, previous) {
+			unlock()
+			continue
+		}
+		unlock()
+
+		if !seen || previous == uint32(current) {
+			continue
+		}
+
+		log.Debug().
+			Str("serial", serial).
+			Uint32("from", previous).
+			Uint8("to", current).
+			Msg("Detected external brightness change")
+		s.reportExternalChange(serial, previous, uint32(current))
+		s.mirrorIfPrimary(serial, uint32(current))
+	}
+}
+
+// reportExternalChange emits BrightnessChanged for an external change, smoothed if
+// configured. A smoothed report still running for the display is stopped first.
+func (s *Server) reportExternalChange(serial string, from, to uint32) {
+	s.cancelSmoothing(serial)
+
+	if s.smoothSteps < 2 {
+		s.emitBrightnessSignals(serial, to)
+		return
+	}
+
+	job := &smoothJob{quit: make(chan struct{}), done: make(chan struct{})}
+	s.smoothMu.Lock()
+	if s.smoothing == nil {
+		s.smoothing = make(map[string]*smoothJob)
+	}
+	s.smoothing[serial] = job
+	s.smoothMu.Unlock()
+
+	go func() {
+		defer close(job.done)
+		defer func() {
+			s.smoothMu.Lock()
+			if s.smoothing[serial] == job {
+				delete(s.smoothing, serial)
+			}
+			s.smoothMu.Unlock()
+		}()
+
+		for i := 1; i <= s.smoothSteps; i++ {
+			if i > 1 {
+				select {
+				case <-job.quit:
+					return
+				case <-time.After(s.smoothInterval):
+				}
+			}
+			// #nosec G115 -- interpolated between two 0-100 percentages
+			value := uint32(int(from) + (int(to)-int(from))*i/s.smoothSteps)
+			s.emitBrightnessSignals(serial, value)
+		}
+	}()
+}
+
+// cancelSmoothing stops a smoothed report running for serial, if any, and waits
+// until it emitted its last signal.
+func (s *Server) cancelSmoothing(serial string) {
+	s.smoothMu.Lock()
+	job := s.smoothing[serial]
+	delete(s.smoothing, serial)
+	s.smoothMu.Unlock()
+
+	if job != nil {
+		close(job.quit)
+		<-job.done
+	}
+}
+
+// fadeRunning reports whether a fade is in progress on serial.
+func (s *Server) fadeRunning(serial string) bool {
+	s.fadeMu.Lock()
+	defer s.fadeMu.Unlock()
+	_, ok := s.fades[serial]
+	return ok
+}
@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/brightness"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setExternally changes the brightness as if by something other than the daemon.
+func (d *fakeDevice) setExternally(percent uint8) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.nits = brightness.PercentToNits(percent)
+}
+
+func TestServer_PollBrightness_ReportsExternalChanges(t *testing.T) {
+	display := newFakeDevice("A", 50)
+	server, recorder := newRecordingServer(newFakeManager(display))
+
+	// First poll only establishes the baseline
+	server.PollBrightness()
+	assert.Empty(t, recorder.named("BrightnessChanged"))
+
+	display.setExternally(70)
+	server.PollBrightness()
+
+	signals := recorder.named("BrightnessChanged")
+	require.Len(t, signals, 1)
+	assert.Equal(t, []any{"A", uint32(70)}, signals[0].values)
+
+	// No change, no signal
+	server.PollBrightness()
+	assert.Len(t, recorder.named("BrightnessChanged"), 1)
+}
+
+func TestServer_PollBrightness_IgnoresDaemonWrites(t *testing.T) {
+	display := newFakeDevice("A", 50)
+	server, recorder := newRecordingServer(newFakeManager(display))
+
+	server.PollBrightness()
+	require.Nil(t, server.SetBrightness("A", 80))
+	server.PollBrightness()
+
+	assert.Len(t, recorder.named("BrightnessChanged"), 1, "only the SetBrightness signal is expected")
+}
+
+func TestServer_PollBrightness_SmoothsLargeJump(t *testing.T) {
+	display := newFakeDevice("A", 40)
+	server, recorder := newRecordingServer(newFakeManager(display),
+		WithBrightnessSmoothing(4, time.Millisecond))
+
+	server.PollBrightness()
+	display.setExternally(60)
+	writes := display.writeCount()
+	server.PollBrightness()
+
+	require.Eventually(t, func() bool { return len(recorder.named("BrightnessChanged")) == 4 },
+		time.Second, 5*time.Millisecond)
+
+	var values []uint32
+	for _, sig := range recorder.named("BrightnessChanged") {
+		values = append(values, sig.values[1].(uint32))
+	}
+	assert.Equal(t, []uint32{45, 50, 55, 60}, values)
+	assert.Equal(t, writes, display.writeCount(), "smoothing must never write to the display")
+}
+
+func TestServer_PollBrightness_RacingClientWriteIsNotExternal(t *testing.T) {
+	display := newFakeDevice("A", 50)
+	server, recorder := newRecordingServer(newFakeManager(display))
+	server.PollBrightness()
+
+	// Start a poll while SetBrightness is mid-write: it waits for the serial lock
+	// and must then find the new value already recorded
+	polled := make(chan struct{})
+	var once sync.Once
+	display.onSend = func(string) {
+		once.Do(func() {
+			go func() {
+				server.PollBrightness()
+				close(polled)
+			}()
+		})
+	}
+
+	require.Nil(t, server.SetBrightness("A", 80))
+	<-polled
+
+	signals := recorder.named("BrightnessChanged")
+	require.Len(t, signals, 1, "only the SetBrightness signal is expected")
+	assert.Equal(t, []any{"A", uint32(80)}, signals[0].values)
+}
+
+func TestServer_PollBrightness_MirrorsExternalChangeOfPrimary(t *testing.T) {
+	primary := newFakeDevice("A", 50)
+	follower := newFakeDevice("B", 50)
+	server := NewServer(newFakeManager(primary, follower))
+	require.Nil(t, server.EnableMirror("A"))
+	server.PollBrightness()
+
+	primary.setExternally(30)
+	server.PollBrightness()
+	assert.Equal(t, uint8(30), follower.percent())
+
+	// The follower's write isn't reported back as an external change of its own
+	writes := follower.writeCount()
+	server.PollBrightness()
+	assert.Equal(t, writes, follower.writeCount())
+}
+
+func TestServer_PollBrightness_ClientWriteStopsSmoothing(t *testing.T) {
+	display := newFakeDevice("A", 40)
+	server, recorder := newRecordingServer(newFakeManager(display),
+		WithBrightnessSmoothing(4, time.Hour))
+
+	server.PollBrightness()
+	display.setExternally(60)
+	server.PollBrightness()
+	require.Eventually(t, func() bool { return len(recorder.named("BrightnessChanged")) == 1 },
+		time.Second, 5*time.Millisecond)
+
+	// The remaining steps would otherwise report 50, 55 and 60 after the client's 20
+	require.Nil(t, server.SetBrightness("A", 20))
+
+	signals := recorder.named("BrightnessChanged")
+	require.Len(t, signals, 2)
+	assert.Equal(t, []any{"A", uint32(45)}, signals[0].values)
+	assert.Equal(t, []any{"A", uint32(20)}, signals[1].values)
+}
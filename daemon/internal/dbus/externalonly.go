@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// propertyExternalOnly is the service property reporting whether external-only mode is on.
+const propertyExternalOnly = "ExternalOnly"
+
+// DefaultExternalOnlyMode raises displays to full brightness and doesn't cap them
+// while external-only mode is on, since the Studio Display is then the main screen.
+var DefaultExternalOnlyMode = BrightnessMode{Max: 100, Level: 100}
+
+// WithExternalOnlyMode configures external-only mode: its Level is applied to every
+// display when the mode is turned on, and its Max caps all brightness changes while
+// it's on. Values above 100 are clamped.
+func WithExternalOnlyMode(mode BrightnessMode) ServerOption {
+	return func(s *Server) {
+		s.externalOnlyMode = BrightnessMode{Max: min(mode.Max, 100), Level: min(mode.Level, mode.Max, 100)}
+	}
+}
+
+// SetExternalOnlyMode turns external-only mode on or off for all displays. The
+// daemon doesn't manage a laptop's internal panel, so an external integration,
+// e.g. a lid switch or display configuration hook, calls this when the Studio
+// Displays become the only screens in use or stop being so. Turning it on saves
+// each display's brightness and applies the external-only profile, whose cap
+// applies until the mode is turned off, which restores the saved brightness.
+// Requesting the current state again is a no-op.
+func (s *Server) SetExternalOnlyMode(enabled bool) *dbus.Error {
+	s.recordActivity()
+
+	s.externalOnlyMu.Lock()
+	defer s.externalOnlyMu.Unlock()
+
+	if s.externalOnlyOn.Load() == enabled {
+		return nil
+	}
+
+	displays := s.manager.Snapshot()
+
+	unlock := s.lockWithoutFades(slices.Collect(maps.Keys(displays))...)
+
+	var changed map[string]uint32
+	s.externalOnlyOn.Store(enabled)
+	if enabled {
+		s.externalOnlySaved, changed = s.applyProfileLevel(displays, s.externalOnlyMode.Level, "external-only mode")
+		log.Info().Int("displays", len(changed)).Uint32("brightness", s.externalOnlyMode.Level).Msg("External-only mode enabled")
+	} else {
+		changed = s.restoreSavedBrightness(displays, s.externalOnlySaved, "external-only mode")
+		s.externalOnlySaved = nil
+		log.Info().Int("displays", len(changed)).Msg("External-only mode disabled")
+	}
+	unlock()
+
+	for serial, brightness := range changed {
+		s.emitBrightnessChanged(serial, brightness)
+	}
+	s.emitSignal("ExternalOnlyModeChanged", enabled)
+	s.emitInterfaceSignal(PropertiesInterface, "PropertiesChanged", InterfaceName,
+		map[string]dbus.Variant{propertyExternalOnly: dbus.MakeVariant(enabled)}, []string{})
+	return nil
+}
+
+// GetExternalOnlyMode reports whether external-only mode is on.
+func (s *Server) GetExternalOnlyMode() (bool, *dbus.Error) {
+	return s.externalOnlyOn.Load(), nil
+}
@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_SetExternalOnlyMode_AppliesProfile(t *testing.T) {
+	a := newFakeDevice("A", 40)
+	b := newFakeDevice("B", 90)
+	server, recorder := newRecordingServer(newFakeManager(a, b), WithExternalOnlyMode(BrightnessMode{Max: 70, Level: 60}))
+
+	require.Nil(t, server.SetExternalOnlyMode(true))
+	assert.Equal(t, uint8(60), a.percent())
+	assert.Equal(t, uint8(60), b.percent())
+	assert.Len(t, recorder.named("BrightnessChanged"), 2)
+
+	enabled, _ := server.GetExternalOnlyMode()
+	assert.True(t, enabled)
+	signals := recorder.named("ExternalOnlyModeChanged")
+	require.Len(t, signals, 1)
+	assert.Equal(t, true, signals[0].values[0])
+
+	// The mode is part of the reported status
+	changes := recorder.namedOn(PropertiesInterface, "PropertiesChanged")
+	require.Len(t, changes, 1)
+	assert.Equal(t, map[string]dbus.Variant{"ExternalOnly": dbus.MakeVariant(true)}, changes[0].values[1])
+	value, err := properties{server: server}.Get(InterfaceName, "ExternalOnly")
+	require.Nil(t, err)
+	assert.Equal(t, dbus.MakeVariant(true), value)
+
+	require.Nil(t, server.SetBrightness("A", 100))
+	assert.Equal(t, uint8(70), a.percent(), "the profile's cap applies while the mode is on")
+}
+
+func TestServer_SetExternalOnlyMode_DisableRestoresPriorBrightness(t *testing.T) {
+	a := newFakeDevice("A", 40)
+	b := newFakeDevice("B", 90)
+	server, recorder := newRecordingServer(newFakeManager(a, b), WithExternalOnlyMode(BrightnessMode{Max: 70, Level: 60}))
+
+	require.Nil(t, server.SetExternalOnlyMode(true))
+	require.Nil(t, server.SetBrightness("B", 65))
+	require.Nil(t, server.SetExternalOnlyMode(false))
+
+	assert.Equal(t, uint8(40), a.percent())
+	assert.Equal(t, uint8(90), b.percent(), "restores the value from before the mode")
+
+	enabled, _ := server.GetExternalOnlyMode()
+	assert.False(t, enabled)
+	signals := recorder.named("ExternalOnlyModeChanged")
+	require.Len(t, signals, 2)
+	assert.Equal(t, false, signals[1].values[0])
+
+	require.Nil(t, server.SetBrightness("A", 100))
+	assert.Equal(t, uint8(100), a.percent(), "the cap is lifted")
+}
+
+func TestServer_SetExternalOnlyMode_RepeatedStateIsNoop(t *testing.T) {
+	display := newFakeDevice("A", 50)
+	server, recorder := newRecordingServer(newFakeManager(display))
+
+	require.Nil(t, server.SetExternalOnlyMode(false))
+	assert.Empty(t, recorder.named("ExternalOnlyModeChanged"))
+
+	require.Nil(t, server.SetExternalOnlyMode(true))
+	require.Nil(t, server.SetExternalOnlyMode(true))
+	assert.Equal(t, uint8(DefaultExternalOnlyMode.Level), display.percent())
+	assert.Len(t, recorder.named("ExternalOnlyModeChanged"), 1)
+
+	require.Nil(t, server.SetExternalOnlyMode(false))
+	assert.Equal(t, uint8(50), display.percent(), "second enable doesn't overwrite the saved brightness")
+}
+
+func TestServer_SetExternalOnlyMode_CombinesWithNightMode(t *testing.T) {
+	display := newFakeDevice("A", 50)
+	server := NewServer(newFakeManager(display),
+		WithExternalOnlyMode(BrightnessMode{Max: 100, Level: 90}),
+		WithNightMode(BrightnessMode{Max: 30, Level: 20}))
+
+	require.Nil(t, server.SetNightMode(true))
+	require.Nil(t, server.SetExternalOnlyMode(true))
+	assert.Equal(t, uint8(30), display.percent(), "the night cap still applies")
+
+	require.Nil(t, server.SetExternalOnlyMode(false))
+	require.Nil(t, server.SetNightMode(false))
+	assert.Equal(t, uint8(50), display.percent())
+}
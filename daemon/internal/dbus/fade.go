@@ -0,0 +1,253 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+)
+
+// ErrInvalidFadeDuration is returned when a fade duration exceeds maxFadeDuration.
+var ErrInvalidFadeDuration = errors.New("fade duration must be at most 60000 ms")
+
+const (
+	// fadeStepInterval is the time between intermediate writes during a fade.
+	fadeStepInterval = 50 * time.Millisecond
+
+	// maxFadeDuration caps how long a single fade may run.
+	maxFadeDuration = time.Minute
+)
+
+// fadeJob is an in-progress fade on a single display.
+type fadeJob struct {
+	target uint32
+	quit   chan struct{} // closed to interrupt the fade
+	snap   bool          // set before quit is closed: write target when interrupted
+	done   chan struct{} // closed when the fade goroutine exits
+}
+
+// FadeBrightness gradually changes the brightness of a display to a percentage (0-100)
+// over durationMs milliseconds. The call returns immediately; BrightnessChanged is
+// emitted once the target is reached. A duration of 0 sets the brightness instantly.
+// Starting a new fade or setting the brightness directly cancels a running fade.
+func (s *Server) FadeBrightness(serial string, brightness uint32, durationMs uint32) *dbus.Error {
+	return s.fadeBrightness("FadeBrightness", serial, brightness, durationMs)
+}
+
+// SetBrightnessTimed sets the brightness of a display to a percentage (0-100),
+// fading over durationMs milliseconds. A duration of 0 behaves exactly like
+// SetBrightness; any other duration behaves like FadeBrightness.
+func (s *Server) SetBrightnessTimed(serial string, brightness uint32, durationMs uint32) *dbus.Error {
+	if durationMs == 0 {
+		return s.setBrightness("SetBrightnessTimed", serial, brightness)
+	}
+	return s.fadeBrightness("SetBrightnessTimed", serial, brightness, durationMs)
+}
+
+// fadeBrightness implements FadeBrightness on behalf of the named D-Bus method,
+// which is used for rate limit and not-found reporting.
+func (s *Server) fadeBrightness(method, serial string, brightness uint32, durationMs uint32) *dbus.Error {
+	s.recordActivity()
+
+	if !s.rateLimits.allow(serial) {
+		return s.rateLimitExceeded(method)
+	}
+
+	if err := validateSerial(serial); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+
+	duration := time.Duration(durationMs) * time.Millisecond
+	if duration > maxFadeDuration {
+		return dbus.MakeFailedError(ErrInvalidFadeDuration)
+	}
+
+	display, err := s.manager.GetDisplay(serial)
+	if err != nil {
+		return s.displayLookupFailed(method, serial, err)
+	}
+
+	brightness = s.capBrightness(serial, s.nearestStop(brightness))
+
+	unlock := s.serialLocks.lock(serial)
+	s.cancelFade(serial)
+
+	if duration < fadeStepInterval {
+		err := s.writeBrightness(serial, display, brightness)
+		unlock()
+		if err != nil {
+			s.handleDeviceError(serial, err)
+			return dbus.MakeFailedError(err)
+		}
+		s.onBrightnessChanged(serial, brightness)
+		return nil
+	}
+
+	current, err := display.GetBrightness()
+	if err != nil {
+		unlock()
+		s.handleDeviceError(serial, err)
+		return dbus.MakeFailedError(err)
+	}
+
+	s.startFade(serial, display, uint32(current), brightness, duration)
+	unlock()
+	log.Debug().
+		Str("serial", serial).
+		Uint8("from", current).
+		Uint32("to", brightness).
+		Dur("duration", duration).
+		Msg("Started brightness fade")
+	return nil
+}
+
+// startFade replaces any running fade on serial with a new one.
+func (s *Server) startFade(serial string, display *hid.Display, from, to uint32, duration time.Duration) {
+	job := &fadeJob{
+		target: to,
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	s.fadeMu.Lock()
+	previous := s.fades[serial]
+	if s.fades == nil {
+		s.fades = make(map[string]*fadeJob)
+	}
+	s.fades[serial] = job
+	s.fadeMu.Unlock()
+
+	if previous != nil {
+		close(previous.quit)
+	}
+
+	go s.runFade(serial, display, job, from, to, duration)
+}
+
+// runFade writes linearly interpolated steps until the target is reached or the job is interrupted.
+// Each step is written under the display's serial lock, checking for interruption
+// while holding it, so a fade never writes after a client's write took over.
+func (s *Server) runFade(serial string, display *hid.Display, job *fadeJob, from, to uint32, duration time.Duration) {
+	defer close(job.done)
+	defer func() {
+		s.fadeMu.Lock()
+		if s.fades[serial] == job {
+			delete(s.fades, serial)
+		}
+		s.fadeMu.Unlock()
+	}()
+
+	steps := int(duration / fadeStepInterval)
+	ticker := time.NewTicker(fadeStepInterval)
+	defer ticker.Stop()
+
+	for i := 1; i <= steps; i++ {
+		select {
+		case <-job.quit:
+			s.snapFade(serial, display, job)
+			return
+		case <-ticker.C:
+		}
+
+		unlock := s.serialLocks.lock(serial)
+		if fadeInterrupted(job) {
+			unlock()
+			s.snapFade(serial, display, job)
+			return
+		}
+		var err error
+		if i == steps {
+			err = s.writeBrightness(serial, display, to)
+		} else {
+			value := int(from) + (int(to)-int(from))*i/steps
+			// #nosec G115 -- value is interpolated between two 0-100 percentages
+			if err = display.SetBrightness(uint8(value)); err == nil {
+				s.invalidateCachedBrightness(serial)
+			}
+		}
+		unlock()
+		if err != nil {
+			s.handleDeviceError(serial, err)
+			log.Error().Err(err).Str("serial", serial).Msg("Fade step failed")
+			return
+		}
+	}
+
+	if !fadeInterrupted(job) {
+		s.onBrightnessChanged(serial, to)
+	}
+}
+
+// fadeInterrupted reports whether job was interrupted.
+func fadeInterrupted(job *fadeJob) bool {
+	select {
+	case <-job.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// snapFade writes the target of an interrupted fade if it was interrupted by
+// finishFades; a fade cancelled by a client's write is left alone.
+func (s *Server) snapFade(serial string, display *hid.Display, job *fadeJob) {
+	if !job.snap {
+		return
+	}
+	unlock := s.serialLocks.lock(serial)
+	err := s.writeBrightness(serial, display, job.target)
+	unlock()
+	if err != nil {
+		log.Warn().Err(err).Str("serial", serial).Msg("Failed to complete interrupted fade")
+		return
+	}
+	s.emitBrightnessSignals(serial, job.target)
+	log.Debug().Str("serial", serial).Uint32("brightness", job.target).Msg("Snapped interrupted fade to target")
+}
+
+// cancelFade abandons a running fade on serial at its current intermediate value.
+// It is used when a client sets the brightness directly, usually with serial's
+// lock held. It doesn't wait for the fade goroutine, which may be waiting for that
+// lock; the goroutine checks for interruption under the lock, so it writes nothing
+// once the caller holds the lock.
+func (s *Server) cancelFade(serial string) {
+	s.fadeMu.Lock()
+	job := s.fades[serial]
+	delete(s.fades, serial)
+	s.fadeMu.Unlock()
+
+	if job != nil {
+		close(job.quit)
+	}
+}
+
+// lockWithoutFades cancels the fades running on serials, then takes their serial
+// locks. Fades are cancelled before locking so a fade that started before the
+// call stops at its next step instead of writing after the caller.
+func (s *Server) lockWithoutFades(serials ...string) (unlock func()) {
+	for _, serial := range serials {
+		s.cancelFade(serial)
+	}
+	return s.serialLocks.lock(serials...)
+}
+
+// finishFades interrupts all running fades and writes each fade's target value,
+// so displays end in the intended state when the daemon shuts down mid-fade.
+func (s *Server) finishFades() {
+	s.fadeMu.Lock()
+	jobs := s.fades
+	s.fades = nil
+	s.fadeMu.Unlock()
+
+	for _, job := range jobs {
+		job.snap = true
+		close(job.quit)
+	}
+	for _, job := range jobs {
+		<-job.done
+	}
+}
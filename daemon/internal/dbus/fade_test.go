@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_FadeBrightness_ReachesTarget(t *testing.T) {
+	display := newFakeDevice("A", 20)
+	server, recorder := newRecordingServer(newFakeManager(display))
+
+	require.Nil(t, server.FadeBrightness("A", 80, 200))
+
+	assert.Eventually(t, func() bool { return display.percent() == 80 }, 2*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return len(recorder.named("BrightnessChanged")) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Greater(t, display.writeCount(), 1, "fade should write intermediate steps")
+}
+
+func TestServer_FadeBrightness_ZeroDurationIsInstant(t *testing.T) {
+	display := newFakeDevice("A", 20)
+	server, recorder := newRecordingServer(newFakeManager(display))
+
+	require.Nil(t, server.FadeBrightness("A", 80, 0))
+
+	assert.Equal(t, uint8(80), display.percent())
+	assert.Equal(t, 1, display.writeCount())
+	assert.Len(t, recorder.named("BrightnessChanged"), 1)
+}
+
+func TestServer_SetBrightnessTimed(t *testing.T) {
+	t.Run("zero duration sets instantly", func(t *testing.T) {
+		display := newFakeDevice("A", 20)
+		server, recorder := newRecordingServer(newFakeManager(display))
+
+		require.Nil(t, server.SetBrightnessTimed("A", 80, 0))
+
+		assert.Equal(t, uint8(80), display.percent())
+		assert.Equal(t, 1, display.writeCount())
+		assert.Len(t, recorder.named("BrightnessChanged"), 1)
+	})
+
+	t.Run("positive duration fades in steps", func(t *testing.T) {
+		display := newFakeDevice("A", 20)
+		server, recorder := newRecordingServer(newFakeManager(display))
+
+		require.Nil(t, server.SetBrightnessTimed("A", 80, 200))
+
+		assert.Eventually(t, func() bool { return display.percent() == 80 }, 2*time.Second, 10*time.Millisecond)
+		assert.Eventually(t, func() bool { return len(recorder.named("BrightnessChanged")) == 1 }, time.Second, 10*time.Millisecond)
+		assert.Greater(t, display.writeCount(), 1, "fade should write intermediate steps")
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		server := NewServer(newFakeManager(newFakeDevice("A", 50)))
+
+		assert.NotNil(t, server.SetBrightnessTimed("", 50, 0))
+		assert.NotNil(t, server.SetBrightnessTimed("A", 50, 60001))
+		assert.NotNil(t, server.SetBrightnessTimed("MISSING", 50, 100))
+	})
+}
+
+func TestServer_FadeBrightness_Validation(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("A", 50)))
+
+	assert.NotNil(t, server.FadeBrightness("", 50, 100))
+	assert.NotNil(t, server.FadeBrightness("A", 50, 60001))
+	assert.NotNil(t, server.FadeBrightness("MISSING", 50, 100))
+}
+
+func TestServer_Stop_SnapsInterruptedFadeToTarget(t *testing.T) {
+	display := newFakeDevice("A", 0)
+	server, recorder := newRecordingServer(newFakeManager(display))
+
+	require.Nil(t, server.FadeBrightness("A", 100, 10000))
+	require.Eventually(t, func() bool { return display.writeCount() >= 1 }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, server.Stop())
+
+	assert.Equal(t, uint8(100), display.percent())
+	assert.Len(t, recorder.named("BrightnessChanged"), 1)
+}
+
+func TestServer_SetBrightness_CancelsRunningFade(t *testing.T) {
+	display := newFakeDevice("A", 0)
+	server := NewServer(newFakeManager(display))
+
+	require.Nil(t, server.FadeBrightness("A", 100, 10000))
+	require.Eventually(t, func() bool { return display.writeCount() >= 1 }, time.Second, 5*time.Millisecond)
+
+	require.Nil(t, server.SetBrightness("A", 30))
+	writes := display.writeCount()
+
+	time.Sleep(3 * fadeStepInterval)
+	assert.Equal(t, uint8(30), display.percent())
+	assert.Equal(t, writes, display.writeCount(), "cancelled fade must not keep writing")
+
+	// A cancelled fade is not snapped on shutdown
+	require.NoError(t, server.Stop())
+	assert.Equal(t, uint8(30), display.percent())
+}
+
+func TestServer_FadeStepsTakeSerialLock(t *testing.T) {
+	display := newFakeDevice("A", 0)
+	server := NewServer(newFakeManager(display))
+
+	require.Nil(t, server.FadeBrightness("A", 100, 10000))
+	require.Eventually(t, func() bool { return display.writeCount() >= 1 }, time.Second, 5*time.Millisecond)
+
+	// A writer holding the lock is never interleaved with fade steps
+	unlock := server.serialLocks.lock("A")
+	writes := display.writeCount()
+	time.Sleep(3 * fadeStepInterval)
+	assert.Equal(t, writes, display.writeCount(), "fade steps must wait for the serial lock")
+
+	// Cancelling with the lock held doesn't wait for the blocked fade
+	server.cancelFade("A")
+	handle, err := server.manager.GetDisplay("A")
+	require.NoError(t, err)
+	require.NoError(t, server.writeBrightness("A", handle, 30))
+	unlock()
+
+	time.Sleep(3 * fadeStepInterval)
+	assert.Equal(t, uint8(30), display.percent())
+	assert.Equal(t, writes+1, display.writeCount(), "the cancelled fade must not write after the lock holder")
+}
+
+func TestServer_BulkAndMirrorWritesCancelRunningFade(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(t *testing.T, server *Server)
+	}{
+		{name: "SetAllBrightness", write: func(t *testing.T, server *Server) {
+			require.Nil(t, server.SetAllBrightness(30))
+		}},
+		{name: "mirroring", write: func(t *testing.T, server *Server) {
+			require.Nil(t, server.EnableMirror("P"))
+			require.Nil(t, server.SetBrightness("P", 30))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := newFakeDevice("P", 50)
+			display := newFakeDevice("A", 0)
+			server := NewServer(newFakeManager(primary, display))
+
+			require.Nil(t, server.FadeBrightness("A", 100, 10000))
+			require.Eventually(t, func() bool { return display.writeCount() >= 1 }, time.Second, 5*time.Millisecond)
+
+			tt.write(t, server)
+			writes := display.writeCount()
+
+			time.Sleep(3 * fadeStepInterval)
+			assert.Equal(t, uint8(30), display.percent())
+			assert.Equal(t, writes, display.writeCount(), "cancelled fade must not keep writing")
+		})
+	}
+}
+
+func TestServer_IdleDim_CancelsRunningFade(t *testing.T) {
+	display := newFakeDevice("A", 50)
+	server := NewServer(newFakeManager(display))
+
+	clock := newFakeClock()
+	server.now = clock.Now
+	require.Nil(t, server.EnableIdleDim(60, 10))
+	defer server.DisableIdleDim()
+
+	require.Nil(t, server.FadeBrightness("A", 100, 10000))
+	require.Eventually(t, func() bool { return display.writeCount() >= 1 }, time.Second, 5*time.Millisecond)
+
+	clock.Advance(2 * time.Minute)
+	server.checkIdle()
+	writes := display.writeCount()
+
+	time.Sleep(3 * fadeStepInterval)
+	assert.Equal(t, uint8(10), display.percent())
+	assert.Equal(t, writes, display.writeCount(), "cancelled fade must not keep writing")
+}
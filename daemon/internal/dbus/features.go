@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Feature names reported by GetSupportedFeatures.
+// Names are stable: clients compare against them to detect capabilities.
+const (
+	FeatureBrightness    = "brightness"
+	FeatureStep          = "step"
+	FeatureSetAll        = "set-all"
+	FeatureStepAll       = "step-all"
+	FeatureBrightnessMap = "brightness-map"
+	FeatureFade          = "fade"
+	FeatureMirror        = "mirror"
+	FeatureIdleDim       = "idle-dim"
+	FeatureReset         = "reset"
+	FeaturePause         = "pause"
+	FeatureRateLimited   = "rate-limited-signal"
+	FeatureHotplug       = "hotplug"
+	FeatureModes         = "brightness-modes"
+	FeatureNightMode     = "night-mode"
+	FeatureExternalOnly  = "external-only-mode"
+	FeatureStandby       = "standby"
+)
+
+// coreFeatures are compiled into every build of the daemon.
+var coreFeatures = []string{
+	FeatureBrightness,
+	FeatureStep,
+	FeatureSetAll,
+	FeatureStepAll,
+	FeatureBrightnessMap,
+	FeatureFade,
+	FeatureMirror,
+	FeatureIdleDim,
+	FeatureReset,
+	FeaturePause,
+	FeatureRateLimited,
+	FeatureModes,
+	FeatureNightMode,
+	FeatureExternalOnly,
+	FeatureStandby,
+}
+
+// WithFeatures advertises additional features that depend on runtime configuration.
+func WithFeatures(names ...string) ServerOption {
+	return func(s *Server) {
+		s.extraFeatures = append(s.extraFeatures, names...)
+	}
+}
+
+// GetSupportedFeatures returns the sorted names of the features this daemon supports,
+// independent of any connected display.
+func (s *Server) GetSupportedFeatures() ([]string, *dbus.Error) {
+	features := make([]string, 0, len(coreFeatures)+len(s.extraFeatures))
+	features = append(features, coreFeatures...)
+	features = append(features, s.extraFeatures...)
+	slices.Sort(features)
+	return slices.Compact(features), nil
+}
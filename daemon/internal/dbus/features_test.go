@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_GetSupportedFeatures(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []ServerOption
+		expected []string
+		absent   []string
+	}{
+		{
+			name:     "core features always present",
+			expected: coreFeatures,
+			absent:   []string{FeatureHotplug},
+		},
+		{
+			name:     "runtime features are added",
+			opts:     []ServerOption{WithFeatures(FeatureHotplug)},
+			expected: append(slices.Clone(coreFeatures), FeatureHotplug),
+		},
+		{
+			name:     "duplicates are collapsed",
+			opts:     []ServerOption{WithFeatures(FeatureFade, FeatureFade)},
+			expected: coreFeatures,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer(&mockDisplayManager{}, tt.opts...)
+
+			features, err := server.GetSupportedFeatures()
+			require.Nil(t, err)
+
+			assert.ElementsMatch(t, tt.expected, features)
+			assert.True(t, slices.IsSorted(features))
+			for _, name := range tt.absent {
+				assert.NotContains(t, features, name)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"github.com/godbus/dbus/v5"
+)
+
+// WithMinBrightness sets a floor percentage that every brightness change is clamped
+// up to, so a low request, brightness mode or idle dim level can't make a display
+// look switched off. Values above 100 are clamped to 100. The default is 0 (no floor).
+func WithMinBrightness(percent uint32) ServerOption {
+	return func(s *Server) {
+		s.minBrightness = min(percent, 100)
+	}
+}
+
+// GetMinBrightness returns the effective brightness floor as a percentage (0-100).
+func (s *Server) GetMinBrightness() (uint32, *dbus.Error) {
+	return s.minBrightness, nil
+}
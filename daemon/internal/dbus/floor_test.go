@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_MinBrightness(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []ServerOption
+		call     func(*Server) any
+		start    uint8
+		expected uint8
+	}{
+		{
+			name:     "no floor by default",
+			call:     func(s *Server) any { return s.SetBrightness("A", 0) },
+			start:    50,
+			expected: 0,
+		},
+		{
+			name:     "set is clamped to the floor",
+			opts:     []ServerOption{WithMinBrightness(20)},
+			call:     func(s *Server) any { return s.SetBrightness("A", 5) },
+			start:    50,
+			expected: 20,
+		},
+		{
+			name:     "decrease stops at the floor",
+			opts:     []ServerOption{WithMinBrightness(20)},
+			call:     func(s *Server) any { return s.DecreaseBrightness("A", 40) },
+			start:    50,
+			expected: 20,
+		},
+		{
+			name:     "decrease above the floor is unaffected",
+			opts:     []ServerOption{WithMinBrightness(20)},
+			call:     func(s *Server) any { return s.DecreaseBrightness("A", 10) },
+			start:    50,
+			expected: 40,
+		},
+		{
+			name:     "set all is clamped to the floor",
+			opts:     []ServerOption{WithMinBrightness(30)},
+			call:     func(s *Server) any { return s.SetAllBrightness(0) },
+			start:    50,
+			expected: 30,
+		},
+		{
+			name: "floor wins over a lower mode cap",
+			opts: []ServerOption{
+				WithMinBrightness(30),
+				WithBrightnessMode(ModeSDR, BrightnessMode{Max: 10, Level: 10}),
+			},
+			call:     func(s *Server) any { return s.SetBrightness("A", 100) },
+			start:    50,
+			expected: 30,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			display := newFakeDevice("A", tt.start)
+			server := NewServer(newFakeManager(display), tt.opts...)
+
+			require.Nil(t, tt.call(server))
+			assert.Equal(t, tt.expected, display.percent())
+		})
+	}
+}
+
+func TestServer_GetMinBrightness(t *testing.T) {
+	floor, err := NewServer(&mockDisplayManager{}).GetMinBrightness()
+	require.Nil(t, err)
+	assert.Zero(t, floor)
+
+	floor, err = NewServer(&mockDisplayManager{}, WithMinBrightness(150)).GetMinBrightness()
+	require.Nil(t, err)
+	assert.Equal(t, uint32(100), floor, "floor is clamped to 100")
+}
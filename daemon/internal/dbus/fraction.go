@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"math"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/brightness"
+)
+
+// GetBrightnessFraction reads the brightness of a display as a fraction of its nits
+// range (0.0-1.0), without rounding to whole percentages. It always reads the
+// display, bypassing the GetBrightness cache.
+func (s *Server) GetBrightnessFraction(serial string) (float64, *dbus.Error) {
+	s.recordActivity()
+	s.stats.gets.Add(1)
+
+	if err := validateSerial(serial); err != nil {
+		return 0, dbus.MakeFailedError(err)
+	}
+
+	display, err := s.manager.GetDisplay(serial)
+	if err != nil {
+		return 0, dbus.MakeFailedError(err)
+	}
+
+	fraction, err := display.GetBrightnessFraction()
+	if err != nil {
+		s.handleDeviceError(serial, err)
+		log.Error().Err(err).Str("serial", serial).Msg("Failed to get brightness fraction")
+		return 0, dbus.MakeFailedError(err)
+	}
+	return fraction, nil
+}
+
+// SetBrightnessFraction sets the brightness of a display to a fraction of its nits
+// range, clamped to 0.0-1.0, for clients such as compositors that want continuous
+// control. Brightness floors and caps still apply, and with brightness stops the
+// fraction snaps to the nearest stop like SetBrightness. BrightnessChanged reports
+// the result rounded to a percentage.
+func (s *Server) SetBrightnessFraction(serial string, fraction float64) *dbus.Error {
+	s.recordActivity()
+
+	if !s.rateLimits.allow(serial) {
+		return s.rateLimitExceeded("SetBrightnessFraction")
+	}
+
+	if err := validateSerial(serial); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+
+	display, err := s.manager.GetDisplay(serial)
+	if err != nil {
+		return s.displayLookupFailed("SetBrightnessFraction", serial, err)
+	}
+
+	// The percentage bounds of capBrightness, as fractions
+	curve := s.effectiveConfig(serial).Curve
+	// #nosec G115 -- capBrightness returns 0-100, safe for uint8
+	lowest := brightness.NitsToFraction(brightness.CurvePercentToNits(curve, uint8(s.capBrightness(serial, 0))))
+	// #nosec G115 -- capBrightness returns 0-100, safe for uint8
+	highest := brightness.NitsToFraction(brightness.CurvePercentToNits(curve, uint8(s.capBrightness(serial, 100))))
+	if math.IsNaN(fraction) {
+		fraction = 0
+	}
+	fraction = min(max(fraction, lowest), highest)
+
+	percent := uint32(brightness.CurveNitsToPercent(curve, brightness.FractionToNits(fraction)))
+	if len(s.stops) > 0 {
+		percent = s.capBrightness(serial, s.nearestStop(percent))
+		// #nosec G115 -- capBrightness returns 0-100, safe for uint8
+		fraction = brightness.NitsToFraction(brightness.CurvePercentToNits(curve, uint8(percent)))
+	}
+
+	unlock := s.serialLocks.lock(serial)
+	s.cancelFade(serial)
+	err = display.SetBrightnessFraction(fraction)
+	if err == nil {
+		// Recorded under the lock, see writeBrightness
+		s.recordKnownBrightness(serial, percent)
+	}
+	unlock()
+	if err != nil {
+		s.handleDeviceError(serial, err)
+		log.Error().Err(err).Str("serial", serial).Msg("Failed to set brightness fraction")
+		return dbus.MakeFailedError(err)
+	}
+
+	log.Debug().Str("serial", serial).Float64("fraction", fraction).Uint32("brightness", percent).Msg("Set brightness fraction")
+	s.onBrightnessChanged(serial, percent)
+
+	return nil
+}
@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"math"
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/brightness"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestServer_BrightnessFraction(t *testing.T) {
+	display := newFakeDevice("A", 0)
+	server, recorder := newRecordingServer(newFakeManager(display))
+	server.rateLimits = newRateLimiters(rate.Inf, 0)
+
+	require.Nil(t, server.SetBrightnessFraction("A", 0.333))
+	assert.Equal(t, brightness.FractionToNits(0.333), display.nits, "no rounding to whole percentages")
+
+	fraction, err := server.GetBrightnessFraction("A")
+	require.Nil(t, err)
+	assert.InDelta(t, 0.333, fraction, 1/float64(brightness.BrightnessRange))
+
+	signals := recorder.named("BrightnessChanged")
+	require.Len(t, signals, 1)
+	assert.Equal(t, uint32(33), signals[0].values[1], "signals carry the rounded percentage")
+
+	require.Nil(t, server.SetBrightnessFraction("A", 7))
+	assert.Equal(t, brightness.MaxBrightness, display.nits)
+	require.Nil(t, server.SetBrightnessFraction("A", math.NaN()))
+	assert.Equal(t, brightness.MinBrightness, display.nits)
+
+	_, err = server.GetBrightnessFraction("MISSING")
+	assert.NotNil(t, err)
+	assert.NotNil(t, server.SetBrightnessFraction("MISSING", 0.5))
+}
+
+func TestServer_SetBrightnessFraction_AppliesFloorAndCap(t *testing.T) {
+	display := newFakeDevice("A", 50)
+	server := NewServer(newFakeManager(display),
+		WithMinBrightness(20),
+		WithDisplayConfigs(map[string]DisplayConfig{"A": {MaxBrightness: percentOf(60)}}),
+	)
+	server.rateLimits = newRateLimiters(rate.Inf, 0)
+
+	require.Nil(t, server.SetBrightnessFraction("A", 0.05))
+	assert.Equal(t, brightness.PercentToNits(20), display.nits)
+	require.Nil(t, server.SetBrightnessFraction("A", 0.95))
+	assert.Equal(t, brightness.PercentToNits(60), display.nits)
+}
@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// ListHealthyDisplays returns the displays ListDisplays would, minus those whose
+// last brightness read or write failed, e.g. while they're being recovered, so
+// clients can avoid offering controls that won't work. A display becomes healthy
+// again after its next successful operation. Displays are sorted by serial.
+func (s *Server) ListHealthyDisplays() ([]DisplayInfo, *dbus.Error) {
+	displays := s.manager.Snapshot()
+
+	result := make([]DisplayInfo, 0, len(displays))
+	for serial, display := range displays {
+		if !display.Healthy() {
+			continue
+		}
+		result = append(result, DisplayInfo{Serial: serial, ProductName: display.ProductName()})
+	}
+	slices.SortFunc(result, func(a, b DisplayInfo) int { return cmp.Compare(a.Serial, b.Serial) })
+
+	log.Debug().Int("count", len(result)).Int("tracked", len(displays)).Msg("Listed healthy displays")
+	return result, nil
+}
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ListHealthyDisplays_ExcludesFailingDisplays(t *testing.T) {
+	broken := &glitchingDevice{fakeDevice: newFakeDevice("B", 40)}
+	manager := newFakeManager(newFakeDevice("C", 40), newFakeDevice("A", 40))
+	manager.displays = append(manager.displays, broken.Info())
+	manager.displayMap["B"] = hid.NewDisplay(broken)
+	server := NewServer(manager)
+
+	healthy, err := server.ListHealthyDisplays()
+	require.Nil(t, err)
+	assert.Equal(t, []string{"A", "B", "C"}, serialsOf(healthy), "unused displays are healthy")
+
+	broken.broken.Store(true)
+	_, getErr := server.GetBrightness("B")
+	require.NotNil(t, getErr)
+
+	healthy, err = server.ListHealthyDisplays()
+	require.Nil(t, err)
+	assert.Equal(t, []string{"A", "C"}, serialsOf(healthy))
+
+	all, err := server.ListDisplays()
+	require.Nil(t, err)
+	assert.Len(t, all, 3, "ListDisplays still includes the failing display")
+
+	broken.broken.Store(false)
+	_, getErr = server.GetBrightness("B")
+	require.Nil(t, getErr)
+
+	healthy, err = server.ListHealthyDisplays()
+	require.Nil(t, err)
+	assert.Equal(t, []string{"A", "B", "C"}, serialsOf(healthy), "a successful read makes it healthy again")
+}
+
+// serialsOf returns the serials of displays in order.
+func serialsOf(displays []DisplayInfo) []string {
+	serials := make([]string, len(displays))
+	for i, display := range displays {
+		serials[i] = display.Serial
+	}
+	return serials
+}
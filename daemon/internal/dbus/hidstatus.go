@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// SetHIDError records whether the HID library is usable. A non-nil err puts the
+// server in degraded mode, where it stays on the bus but has no displays to
+// offer; nil leaves it again. HIDStatusChanged is emitted whenever the status
+// changes.
+func (s *Server) SetHIDError(err error) {
+	message := ""
+	if err != nil {
+		message = err.Error()
+	}
+
+	s.hidMu.Lock()
+	changed := s.hidError != message
+	s.hidError = message
+	s.hidMu.Unlock()
+
+	if !changed {
+		return
+	}
+	if message != "" {
+		log.Warn().Str("error", message).Msg("HID unavailable, serving no displays")
+	} else {
+		log.Info().Msg("HID available again")
+	}
+	s.emitSignal("HIDStatusChanged", message == "", message)
+}
+
+// GetHIDStatus reports whether the HID library is usable and, if it isn't, why.
+// While it's unavailable the daemon reports no displays.
+func (s *Server) GetHIDStatus() (bool, string, *dbus.Error) {
+	s.hidMu.Lock()
+	defer s.hidMu.Unlock()
+	return s.hidError == "", s.hidError, nil
+}
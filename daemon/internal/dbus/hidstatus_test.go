@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_SetHIDError(t *testing.T) {
+	server, recorder := newRecordingServer(newFakeManager())
+
+	available, message, err := server.GetHIDStatus()
+	require.Nil(t, err)
+	assert.True(t, available, "available by default")
+	assert.Empty(t, message)
+
+	server.SetHIDError(errors.New("hid_init failed"))
+	server.SetHIDError(errors.New("hid_init failed"))
+	available, message, _ = server.GetHIDStatus()
+	assert.False(t, available)
+	assert.Equal(t, "hid_init failed", message)
+
+	server.SetHIDError(nil)
+	available, message, _ = server.GetHIDStatus()
+	assert.True(t, available)
+	assert.Empty(t, message)
+
+	signals := recorder.named("HIDStatusChanged")
+	require.Len(t, signals, 2, "only changes are signalled")
+	assert.Equal(t, []any{false, "hid_init failed"}, signals[0].values)
+	assert.Equal(t, []any{true, ""}, signals[1].values)
+}
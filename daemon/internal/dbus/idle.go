@@ -0,0 +1,204 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrInvalidIdleTimeout is returned when idle dimming is enabled with a zero timeout.
+var ErrInvalidIdleTimeout = errors.New("idle timeout must be at least 1 second")
+
+// idleCheckInterval is how often the idle dimming loop checks for inactivity.
+const idleCheckInterval = time.Second
+
+// idleDimState tracks idle dimming configuration and the brightness to restore.
+type idleDimState struct {
+	enabled      bool
+	timeout      time.Duration
+	dimPercent   uint32
+	lastActivity time.Time
+	dimmed       bool
+	saved        map[string]uint32 // serial -> brightness before dimming
+	inStandby    bool              // checkIdle went on to put displays in standby
+	standby      []string          // serials put in standby, woken before restoring
+	quit         chan struct{}
+}
+
+// EnableIdleDim dims all displays to dimPercent after timeoutSec seconds without
+// activity. Activity is any brightness method call or NotifyActivity; the first
+// activity after dimming restores each display to its previous brightness.
+// Calling it again replaces the current configuration.
+func (s *Server) EnableIdleDim(timeoutSec uint32, dimPercent uint32) *dbus.Error {
+	if timeoutSec == 0 {
+		return dbus.MakeFailedError(ErrInvalidIdleTimeout)
+	}
+	dimPercent = max(min(dimPercent, 100), s.minBrightness)
+
+	s.stopIdleDim()
+
+	quit := make(chan struct{})
+	s.idleMu.Lock()
+	s.idle = idleDimState{
+		enabled:      true,
+		timeout:      time.Duration(timeoutSec) * time.Second,
+		dimPercent:   dimPercent,
+		lastActivity: s.now(),
+		quit:         quit,
+	}
+	s.idleMu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(idleCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.checkIdle()
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	log.Info().Uint32("timeoutSec", timeoutSec).Uint32("dimPercent", dimPercent).Msg("Idle dimming enabled")
+	return nil
+}
+
+// DisableIdleDim stops idle dimming and restores displays that are currently dimmed.
+func (s *Server) DisableIdleDim() *dbus.Error {
+	s.stopIdleDim()
+	return nil
+}
+
+// stopIdleDim stops the idle dimming loop, if running, and restores dimmed displays.
+func (s *Server) stopIdleDim() {
+	s.idleMu.Lock()
+	defer s.idleMu.Unlock()
+
+	if !s.idle.enabled {
+		return
+	}
+
+	close(s.idle.quit)
+	s.restoreFromIdleLocked()
+	s.idle = idleDimState{}
+
+	log.Info().Msg("Idle dimming disabled")
+}
+
+// NotifyActivity records user activity, restoring displays dimmed by idle dimming.
+// Clients that observe user input (e.g. the shell extension) can call it to keep
+// displays from dimming while the brightness API itself is not being used.
+func (s *Server) NotifyActivity() *dbus.Error {
+	s.recordActivity()
+	return nil
+}
+
+// recordActivity marks the current time as the last activity and restores
+// any idle-dimmed displays. It is a no-op when idle dimming is disabled.
+func (s *Server) recordActivity() {
+	s.idleMu.Lock()
+	defer s.idleMu.Unlock()
+
+	if !s.idle.enabled {
+		return
+	}
+
+	s.idle.lastActivity = s.now()
+	s.restoreFromIdleLocked()
+}
+
+// checkIdle dims all displays if the idle timeout has elapsed since the last activity,
+// and puts them in standby once the idle standby delay has elapsed as well.
+func (s *Server) checkIdle() {
+	s.idleMu.Lock()
+	defer s.idleMu.Unlock()
+
+	if !s.idle.enabled || s.paused.Load() {
+		return
+	}
+	idleFor := s.now().Sub(s.idle.lastActivity)
+	if !s.idle.dimmed {
+		if idleFor < s.idle.timeout {
+			return
+		}
+		s.dimIdleLocked()
+	}
+	if s.idleStandbyAfter > 0 && !s.idle.inStandby && idleFor >= s.idleStandbyAfter {
+		s.enterIdleStandbyLocked()
+	}
+}
+
+// dimIdleLocked dims all displays and saves their brightness for restoreFromIdleLocked.
+// Must be called with idleMu held.
+func (s *Server) dimIdleLocked() {
+	displays := s.manager.Snapshot()
+	unlock := s.lockWithoutFades(slices.Collect(maps.Keys(displays))...)
+	defer unlock()
+
+	saved := make(map[string]uint32)
+	for serial, display := range displays {
+		current, err := display.GetBrightness()
+		if err != nil {
+			s.handleDeviceError(serial, err)
+			log.Warn().Err(err).Str("serial", serial).Msg("Failed to read brightness for idle dimming")
+			continue
+		}
+
+		// Never brighten a display that is already below the dim level, or below
+		// its own configured floor
+		target := s.capBrightness(serial, s.idle.dimPercent)
+		if uint32(current) <= target {
+			continue
+		}
+
+		if err := s.applyDaemonBrightness(serial, display, target); err != nil {
+			s.handleDeviceError(serial, err)
+			log.Warn().Err(err).Str("serial", serial).Msg("Failed to dim idle display")
+			continue
+		}
+
+		saved[serial] = uint32(current)
+	}
+
+	s.idle.dimmed = true
+	s.idle.saved = saved
+	log.Info().Int("displays", len(saved)).Msg("Displays dimmed after idle timeout")
+}
+
+// restoreFromIdleLocked restores brightness saved by checkIdle.
+// Must be called with idleMu held.
+func (s *Server) restoreFromIdleLocked() {
+	if !s.idle.dimmed {
+		return
+	}
+	s.wakeFromIdleStandbyLocked()
+
+	displays := s.manager.Snapshot()
+	unlock := s.lockWithoutFades(slices.Collect(maps.Keys(s.idle.saved))...)
+	defer unlock()
+
+	for serial, brightness := range s.idle.saved {
+		display, ok := displays[serial]
+		if !ok {
+			continue
+		}
+
+		if err := s.applyDaemonBrightness(serial, display, brightness); err != nil {
+			s.handleDeviceError(serial, err)
+			log.Warn().Err(err).Str("serial", serial).Msg("Failed to restore brightness after idle")
+			continue
+		}
+	}
+
+	s.idle.dimmed = false
+	s.idle.saved = nil
+	log.Info().Msg("Displays restored from idle dimming")
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_IdleDim_DimsAfterTimeoutAndRestoresOnActivity(t *testing.T) {
+	bright := newFakeDevice("BRIGHT", 50)
+	dim := newFakeDevice("DIM", 5)
+	server, recorder := newRecordingServer(newFakeManager(bright, dim))
+
+	clock := newFakeClock()
+	server.now = clock.Now
+
+	require.Nil(t, server.EnableIdleDim(60, 10))
+	defer server.DisableIdleDim()
+
+	// Before the timeout nothing happens
+	clock.Advance(59 * time.Second)
+	server.checkIdle()
+	assert.Equal(t, uint8(50), bright.percent())
+
+	// After the timeout bright displays dim, already-dim ones are left alone
+	clock.Advance(2 * time.Second)
+	server.checkIdle()
+	assert.Equal(t, uint8(10), bright.percent())
+	assert.Equal(t, uint8(5), dim.percent())
+	assert.Equal(t, 0, dim.writeCount())
+	assert.Len(t, recorder.named("BrightnessChanged"), 1)
+
+	// Activity restores the pre-dim value
+	require.Nil(t, server.NotifyActivity())
+	assert.Equal(t, uint8(50), bright.percent())
+	assert.Len(t, recorder.named("BrightnessChanged"), 2)
+}
+
+func TestServer_IdleDim_BrightnessRequestsCountAsActivity(t *testing.T) {
+	display := newFakeDevice("A", 50)
+	server := NewServer(newFakeManager(display))
+
+	clock := newFakeClock()
+	server.now = clock.Now
+
+	require.Nil(t, server.EnableIdleDim(60, 10))
+	defer server.DisableIdleDim()
+
+	clock.Advance(40 * time.Second)
+	_, err := server.GetBrightness("A")
+	require.Nil(t, err)
+
+	// 80s since enable but only 40s since the last request
+	clock.Advance(40 * time.Second)
+	server.checkIdle()
+	assert.Equal(t, uint8(50), display.percent())
+
+	// A request after dimming restores first, then applies the request
+	clock.Advance(time.Minute)
+	server.checkIdle()
+	require.Equal(t, uint8(10), display.percent())
+
+	require.Nil(t, server.IncreaseBrightness("A", 5))
+	assert.Equal(t, uint8(55), display.percent())
+}
+
+func TestServer_IdleDim_DisableRestores(t *testing.T) {
+	display := newFakeDevice("A", 80)
+	server := NewServer(newFakeManager(display))
+
+	clock := newFakeClock()
+	server.now = clock.Now
+
+	require.Nil(t, server.EnableIdleDim(1, 0))
+	clock.Advance(time.Hour)
+	server.checkIdle()
+	require.Equal(t, uint8(0), display.percent())
+
+	require.Nil(t, server.DisableIdleDim())
+	assert.Equal(t, uint8(80), display.percent())
+
+	// Disabled: no further dimming
+	clock.Advance(time.Hour)
+	server.checkIdle()
+	assert.Equal(t, uint8(80), display.percent())
+}
+
+func TestServer_EnableIdleDim_InvalidTimeout(t *testing.T) {
+	server := NewServer(newFakeManager())
+	err := server.EnableIdleDim(0, 10)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), ErrInvalidIdleTimeout.Error())
+}
@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// DisplayState is the current state of a display, as returned by GetInitialState.
+type DisplayState struct {
+	Serial          string
+	ProductName     string
+	Brightness      uint32 // Current brightness percentage; 0 if BrightnessKnown is false
+	BrightnessKnown bool   // Whether Brightness holds a value
+	Mode            string // Active brightness mode
+}
+
+// GetInitialState returns every connected display with its current brightness in
+// one call, so a freshly started client can populate its UI without a call per
+// display. Displays are read as described for sweepBrightness and sorted by serial.
+// A display that fails to read reports the last brightness the daemon set or
+// observed, or none.
+func (s *Server) GetInitialState() ([]DisplayState, *dbus.Error) {
+	s.recordActivity()
+
+	displays := s.manager.Snapshot()
+
+	readings := s.sweepBrightness(displays)
+
+	states := make([]DisplayState, 0, len(displays))
+	for serial, display := range displays {
+		state := DisplayState{Serial: serial, ProductName: display.ProductName(), Mode: s.modeOf(serial)}
+		if reading := readings[serial]; reading.err == nil {
+			state.Brightness, state.BrightnessKnown = reading.percent, true
+		} else {
+			log.Warn().Err(reading.err).Str("serial", serial).Msg("Failed to read brightness for initial state")
+			state.Brightness, state.BrightnessKnown = s.knownBrightness(serial)
+		}
+		states = append(states, state)
+	}
+
+	slices.SortFunc(states, func(a, b DisplayState) int { return cmp.Compare(a.Serial, b.Serial) })
+	log.Debug().Int("count", len(states)).Msg("Got initial state")
+	return states, nil
+}
@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_GetInitialState(t *testing.T) {
+	broken := &glitchingDevice{fakeDevice: newFakeDevice("C", 10)}
+	broken.broken.Store(true)
+	neverSeen := &glitchingDevice{fakeDevice: newFakeDevice("D", 10)}
+	neverSeen.broken.Store(true)
+
+	manager := newFakeManager(newFakeDevice("B", 70), newFakeDevice("A", 25))
+	manager.displayMap["C"] = hid.NewDisplay(broken)
+	manager.displayMap["D"] = hid.NewDisplay(neverSeen)
+	server := NewServer(manager)
+	server.displayModes = map[string]string{"B": ModeHDR}
+	server.recordKnownBrightness("C", 55)
+
+	states, err := server.GetInitialState()
+	require.Nil(t, err)
+	assert.Equal(t, []DisplayState{
+		{Serial: "A", Brightness: 25, BrightnessKnown: true, Mode: ModeSDR},
+		{Serial: "B", Brightness: 70, BrightnessKnown: true, Mode: ModeHDR},
+		{Serial: "C", Brightness: 55, BrightnessKnown: true, Mode: ModeSDR},
+		{Serial: "D", Mode: ModeSDR},
+	}, states)
+}
+
+func TestServer_GetInitialState_NoDisplays(t *testing.T) {
+	server := NewServer(newFakeManager())
+
+	states, err := server.GetInitialState()
+	require.Nil(t, err)
+	assert.Empty(t, states)
+}
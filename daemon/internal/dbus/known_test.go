@@ -0,0 +1,30 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_GetKnownSerials(t *testing.T) {
+	manager := newFakeManager(newFakeDevice("B", 50))
+	manager.known = []string{"A"}
+	server := NewServer(manager)
+
+	serials, err := server.GetKnownSerials()
+	require.Nil(t, err)
+	assert.Equal(t, []string{"A", "B"}, serials)
+
+	displays, err := server.ListDisplays()
+	require.Nil(t, err)
+	require.Len(t, displays, 1, "disconnected displays are only known, not listed")
+	assert.Equal(t, "B", displays[0].Serial)
+
+	serials, err = NewServer(&mockDisplayManager{}).GetKnownSerials()
+	require.Nil(t, err)
+	assert.NotNil(t, serials)
+	assert.Empty(t, serials)
+}
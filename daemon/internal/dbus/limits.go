@@ -0,0 +1,31 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"github.com/rs/zerolog/log"
+)
+
+// Limits reported by the BrightnessLimitReached signal.
+const (
+	BrightnessLimitMin = "min"
+	BrightnessLimitMax = "max"
+)
+
+// emitLimitReached emits BrightnessLimitReached if a step that asked for requested
+// percent ended at target because it was clamped to a bound: 0 or 100, the
+// brightness floor, a brightness mode or night mode cap, or the last stop.
+func (s *Server) emitLimitReached(serial string, requested int, target uint32) {
+	var limit string
+	switch {
+	case requested > int(target):
+		limit = BrightnessLimitMax
+	case requested < int(target):
+		limit = BrightnessLimitMin
+	default:
+		return
+	}
+
+	log.Debug().Str("serial", serial).Str("limit", limit).Uint32("brightness", target).Msg("Brightness limit reached")
+	s.emitSignal("BrightnessLimitReached", serial, limit)
+}
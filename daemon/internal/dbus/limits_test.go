@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestServer_StepBrightness_LimitReached(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  uint8
+		up       bool
+		step     uint32
+		opts     []ServerOption
+		expected []any // BrightnessLimitReached arguments; nil if not emitted
+	}{
+		{name: "increase within range", current: 50, up: true, step: 10},
+		{name: "increase exactly to 100", current: 90, up: true, step: 10},
+		{name: "increase past 100", current: 95, up: true, step: 10, expected: []any{"A", BrightnessLimitMax}},
+		{name: "increase at 100", current: 100, up: true, step: 5, expected: []any{"A", BrightnessLimitMax}},
+		{name: "decrease within range", current: 50, step: 10},
+		{name: "decrease past 0", current: 5, step: 10, expected: []any{"A", BrightnessLimitMin}},
+		{name: "decrease past the floor", current: 25, step: 10, opts: []ServerOption{WithMinBrightness(20)}, expected: []any{"A", BrightnessLimitMin}},
+		{name: "increase past a mode cap", current: 75, up: true, step: 10, opts: []ServerOption{WithBrightnessMode(ModeSDR, BrightnessMode{Max: 80, Level: 50})}, expected: []any{"A", BrightnessLimitMax}},
+		{name: "increase to the last stop", current: 60, up: true, step: 1, opts: []ServerOption{WithBrightnessStops([]uint32{0, 50, 100})}},
+		{name: "increase past the last stop", current: 100, up: true, step: 1, opts: []ServerOption{WithBrightnessStops([]uint32{0, 50, 100})}, expected: []any{"A", BrightnessLimitMax}},
+		{name: "decrease past the first stop", current: 0, step: 1, opts: []ServerOption{WithBrightnessStops([]uint32{0, 50, 100})}, expected: []any{"A", BrightnessLimitMin}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			display := newFakeDevice("A", tt.current)
+			server, recorder := newRecordingServer(newFakeManager(display), tt.opts...)
+			server.rateLimits = newRateLimiters(rate.Inf, 0)
+
+			if tt.up {
+				require.Nil(t, server.IncreaseBrightness("A", tt.step), "a clamped step still succeeds")
+			} else {
+				require.Nil(t, server.DecreaseBrightness("A", tt.step), "a clamped step still succeeds")
+			}
+
+			signals := recorder.named("BrightnessLimitReached")
+			if tt.expected == nil {
+				assert.Empty(t, signals)
+				return
+			}
+			require.Len(t, signals, 1)
+			assert.Equal(t, tt.expected, signals[0].values)
+		})
+	}
+}
+
+func TestServer_CoalescedStep_LimitReached(t *testing.T) {
+	display := newFakeDevice("A", 90)
+	server, recorder := newRecordingServer(newFakeManager(display), WithStepCoalescing(time.Hour))
+	server.rateLimits = newRateLimiters(rate.Inf, 0)
+
+	require.Nil(t, server.IncreaseBrightness("A", 10))
+	require.Nil(t, server.IncreaseBrightness("A", 10))
+	server.flushSteps()
+
+	assert.Equal(t, uint8(100), display.percent())
+	signals := recorder.named("BrightnessLimitReached")
+	require.Len(t, signals, 1)
+	assert.Equal(t, []any{"A", BrightnessLimitMax}, signals[0].values)
+}
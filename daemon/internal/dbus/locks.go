@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"slices"
+	"sync"
+)
+
+// serialLocks serializes brightness writes per display.
+//
+// Lock ordering: a goroutine must acquire all the per-serial locks it needs in a
+// single call to lock, which takes them in sorted serial order, and must release
+// them before acquiring any others. Since every caller follows the same global
+// order, overlapping bulk operations (SetAllBrightness, SetBrightnessMap, mirroring)
+// can't deadlock. Signals that may trigger further writes, such as mirroring via
+// onBrightnessChanged, must be emitted after the locks are released.
+type serialLocks struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+// get returns the mutex for serial, creating it on first use.
+func (l *serialLocks) get(serial string) *sync.Mutex {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.locks == nil {
+		l.locks = make(map[string]*sync.Mutex)
+	}
+	m, ok := l.locks[serial]
+	if !ok {
+		m = &sync.Mutex{}
+		l.locks[serial] = m
+	}
+	return m
+}
+
+// lock acquires the locks for all serials in sorted order and returns a function
+// that releases them. Duplicate serials are locked once.
+func (l *serialLocks) lock(serials ...string) (unlock func()) {
+	sorted := slices.Clone(serials)
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+
+	held := make([]*sync.Mutex, 0, len(sorted))
+	for _, serial := range sorted {
+		m := l.get(serial)
+		m.Lock()
+		held = append(held, m)
+	}
+
+	return func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			held[i].Unlock()
+		}
+	}
+}
@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestSerialLocks_DuplicatesLockedOnce(t *testing.T) {
+	var locks serialLocks
+
+	unlock := locks.lock("B", "A", "B")
+	unlock()
+
+	// All locks were released, so they can be taken again
+	unlock = locks.lock("A", "B")
+	unlock()
+}
+
+func TestSerialLocks_ExcludesConcurrentHolders(t *testing.T) {
+	var locks serialLocks
+	unlock := locks.lock("A")
+
+	acquired := make(chan struct{})
+	go func() {
+		defer close(acquired)
+		locks.lock("B", "A")()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("lock on A should be held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+	<-acquired
+}
+
+// TestServer_ConcurrentBulkOperations_NoDeadlock runs overlapping bulk operations
+// against the same displays. Run with -race to also check for data races.
+func TestServer_ConcurrentBulkOperations_NoDeadlock(t *testing.T) {
+	devices := make([]*fakeDevice, 0, 6)
+	for i := range 6 {
+		devices = append(devices, newFakeDevice(fmt.Sprintf("D%d", i), 50))
+	}
+	server := NewServer(newFakeManager(devices...))
+	server.rateLimits = newRateLimiters(rate.Inf, 0)
+	require.Nil(t, server.EnableMirror("D0"))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		var wg sync.WaitGroup
+		for i := range 20 {
+			wg.Add(4)
+			go func() {
+				defer wg.Done()
+				_ = server.SetAllBrightness(uint32(i))
+			}()
+			go func() {
+				defer wg.Done()
+				// Overlapping subsets given in reverse order
+				_, _ = server.SetBrightnessMap(map[string]uint32{"D5": 10, "D3": 20, "D1": 30})
+			}()
+			go func() {
+				defer wg.Done()
+				_, _ = server.SetBrightnessMap(map[string]uint32{"D1": 40, "D2": 50, "D4": 60})
+			}()
+			go func() {
+				defer wg.Done()
+				// Mirrors to every other display after releasing the primary's lock
+				_ = server.IncreaseBrightness("D0", 1)
+			}()
+		}
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("concurrent bulk operations deadlocked")
+	}
+}
+
+func TestServer_IncreaseBrightness_IsAtomic(t *testing.T) {
+	display := newFakeDevice("A", 0)
+	server := NewServer(newFakeManager(display))
+	server.rateLimits = newRateLimiters(rate.Inf, 0)
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = server.IncreaseBrightness("A", 1)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, uint8(50), display.percent(), "no increments should be lost")
+}
@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// logindPath is the object path of the logind manager.
+	logindPath = "/org/freedesktop/login1"
+
+	// logindManagerInterface is the logind manager interface emitting PrepareForSleep.
+	logindManagerInterface = "org.freedesktop.login1.Manager"
+
+	// prepareForSleepMember is emitted with true before suspend and false after resume.
+	prepareForSleepMember = "PrepareForSleep"
+)
+
+// SleepWatcher calls a handler whenever the system resumes from suspend, as
+// reported by logind's PrepareForSleep signal on the system bus.
+type SleepWatcher struct {
+	conn    *dbus.Conn
+	signals chan *dbus.Signal
+	quit    chan struct{}
+	done    chan struct{}
+}
+
+// WatchSleep connects to the system bus and calls onResume after every resume.
+// onResume runs on the watcher's goroutine, so it should return quickly.
+func WatchSleep(onResume func()) (*SleepWatcher, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to system bus: %w", err)
+	}
+
+	err = conn.AddMatchSignal(
+		dbus.WithMatchObjectPath(logindPath),
+		dbus.WithMatchInterface(logindManagerInterface),
+		dbus.WithMatchMember(prepareForSleepMember),
+	)
+	if err != nil {
+		if closeErr := conn.Close(); closeErr != nil {
+			log.Error().Err(closeErr).Msg("Failed to close system bus connection during cleanup")
+		}
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", prepareForSleepMember, err)
+	}
+
+	w := &SleepWatcher{
+		conn:    conn,
+		signals: make(chan *dbus.Signal, 4),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	conn.Signal(w.signals)
+
+	go func() {
+		defer close(w.done)
+		for {
+			select {
+			case sig, ok := <-w.signals:
+				if !ok {
+					return
+				}
+				handleSleepSignal(sig, onResume)
+			case <-w.quit:
+				return
+			}
+		}
+	}()
+
+	log.Info().Msg("Watching logind for resume from suspend")
+	return w, nil
+}
+
+// Stop stops watching and closes the system bus connection.
+func (w *SleepWatcher) Stop() error {
+	close(w.quit)
+	<-w.done
+	w.conn.RemoveSignal(w.signals)
+	return w.conn.Close()
+}
+
+// handleSleepSignal calls onResume if sig is a PrepareForSleep(false) signal.
+func handleSleepSignal(sig *dbus.Signal, onResume func()) {
+	if sig == nil || sig.Name != logindManagerInterface+"."+prepareForSleepMember || len(sig.Body) != 1 {
+		return
+	}
+	sleeping, ok := sig.Body[0].(bool)
+	if !ok {
+		return
+	}
+	if sleeping {
+		log.Debug().Msg("System is suspending")
+		return
+	}
+	log.Info().Msg("System resumed from suspend")
+	onResume()
+}
@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"github.com/rs/zerolog/log"
+)
+
+// MigrateDisplayState moves the per-display state the daemon keeps for oldSerial,
+// such as the last known brightness and the brightness mode, to newSerial. It's
+// meant for displays that reappear under a new serial, e.g. after a firmware update
+// (see hid.WithSerialMigration). State already recorded for newSerial is kept.
+func (s *Server) MigrateDisplayState(oldSerial, newSerial string) {
+	if oldSerial == newSerial {
+		return
+	}
+
+	s.knownMu.Lock()
+	brightness, hadBrightness := s.known[oldSerial]
+	if _, exists := s.known[newSerial]; hadBrightness && !exists {
+		s.known[newSerial] = brightness
+	}
+	delete(s.known, oldSerial)
+	delete(s.daemonWritten, oldSerial)
+	if seen, ok := s.lastSeen[oldSerial]; ok {
+		if seen.After(s.lastSeen[newSerial]) {
+			s.lastSeen[newSerial] = seen
+		}
+		delete(s.lastSeen, oldSerial)
+	}
+	s.knownMu.Unlock()
+
+	s.modesMu.Lock()
+	mode, hadMode := s.displayModes[oldSerial]
+	if _, exists := s.displayModes[newSerial]; hadMode && !exists {
+		s.displayModes[newSerial] = mode
+	}
+	delete(s.displayModes, oldSerial)
+	s.modesMu.Unlock()
+
+	s.nightMu.Lock()
+	if saved, ok := s.nightSaved[oldSerial]; ok {
+		if _, exists := s.nightSaved[newSerial]; !exists {
+			s.nightSaved[newSerial] = saved
+		}
+		delete(s.nightSaved, oldSerial)
+	}
+	s.nightMu.Unlock()
+
+	s.invalidateCachedBrightness(oldSerial)
+
+	log.Info().
+		Str("from", oldSerial).
+		Str("to", newSerial).
+		Bool("brightness", hadBrightness).
+		Bool("mode", hadMode).
+		Msg("Migrated display state to new serial")
+}
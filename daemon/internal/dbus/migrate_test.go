@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_MigrateDisplayState(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("OLD", 40), newFakeDevice("NEW", 40)))
+
+	require.Nil(t, server.SetBrightnessMode("OLD", ModeHDR))
+	require.Nil(t, server.SetBrightness("OLD", 65))
+
+	server.MigrateDisplayState("OLD", "NEW")
+
+	mode, err := server.GetBrightnessMode("NEW")
+	require.Nil(t, err)
+	assert.Equal(t, ModeHDR, mode)
+
+	info, err := server.GetCachedDisplayInfo("NEW")
+	require.Nil(t, err)
+	assert.True(t, info.BrightnessKnown)
+	assert.Equal(t, uint32(65), info.Brightness)
+
+	_, known := server.knownBrightness("OLD")
+	assert.False(t, known, "old serial's state is dropped")
+	assert.Equal(t, ModeSDR, server.modeOf("OLD"))
+}
+
+func TestServer_MigrateDisplayState_KeepsExistingState(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("OLD", 40), newFakeDevice("NEW", 40)))
+	server.recordKnownBrightness("OLD", 10)
+	server.recordKnownBrightness("NEW", 90)
+
+	server.MigrateDisplayState("OLD", "NEW")
+
+	brightness, _ := server.knownBrightness("NEW")
+	assert.Equal(t, uint32(90), brightness)
+}
@@ -0,0 +1,100 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+	"maps"
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+)
+
+// EnableMirror makes every other display track the brightness of the primary display.
+// Each time the primary's brightness is changed through the daemon, all other
+// connected displays are set to the same percentage.
+func (s *Server) EnableMirror(primarySerial string) *dbus.Error {
+	if err := validateSerial(primarySerial); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+
+	if _, err := s.manager.GetDisplay(primarySerial); err != nil {
+		log.Error().Err(err).Str("serial", primarySerial).Msg("Failed to enable mirror")
+		return dbus.MakeFailedError(err)
+	}
+
+	s.mirrorMu.Lock()
+	s.mirrorPrimary = primarySerial
+	s.mirrorMu.Unlock()
+
+	log.Info().Str("primary", primarySerial).Msg("Brightness mirroring enabled")
+	return nil
+}
+
+// DisableMirror stops mirroring brightness from the primary display.
+func (s *Server) DisableMirror() *dbus.Error {
+	s.mirrorMu.Lock()
+	s.mirrorPrimary = ""
+	s.mirrorMu.Unlock()
+
+	log.Info().Msg("Brightness mirroring disabled")
+	return nil
+}
+
+// onBrightnessChanged emits BrightnessChanged for a change made on behalf of a client,
+// counts it for GetStats, tracks it for contention detection and propagates it to the other displays if
+// serial is the mirror primary.
+// Changes applied to followers by mirroring only emit the signal and never
+// re-enter this method, which prevents feedback loops.
+func (s *Server) onBrightnessChanged(serial string, brightness uint32) {
+	s.stats.sets.Add(1)
+	s.emitBrightnessChanged(serial, brightness)
+	s.trackContention(serial, brightness)
+	s.mirrorIfPrimary(serial, brightness)
+}
+
+// mirrorIfPrimary propagates a brightness change of serial to the other displays
+// if serial is the mirror primary and the daemon isn't paused.
+func (s *Server) mirrorIfPrimary(serial string, brightness uint32) {
+	s.mirrorMu.RLock()
+	primary := s.mirrorPrimary
+	s.mirrorMu.RUnlock()
+
+	if primary == "" || serial != primary {
+		return
+	}
+	if s.paused.Load() {
+		log.Debug().Str("primary", primary).Msg("Mirroring skipped while paused")
+		return
+	}
+
+	s.mirrorBrightness(primary, brightness)
+}
+
+// mirrorBrightness sets every display except primary to brightness.
+func (s *Server) mirrorBrightness(primary string, brightness uint32) {
+	displays := s.manager.Snapshot()
+	delete(displays, primary)
+
+	unlock := s.lockWithoutFades(slices.Collect(maps.Keys(displays))...)
+	defer unlock()
+
+	for serial, display := range displays {
+		value := s.capBrightness(serial, brightness)
+		// #nosec G115 -- value is clamped to 0-100, safe for uint8
+		err := display.SetBrightness(uint8(value))
+		if errors.Is(err, hid.ErrDisplayClosed) {
+			continue
+		}
+		if err != nil {
+			s.handleDeviceError(serial, err)
+			log.Error().Err(err).Str("serial", serial).Msg("Failed to mirror brightness")
+			continue
+		}
+
+		log.Debug().Str("primary", primary).Str("serial", serial).Uint32("brightness", value).Msg("Mirrored brightness")
+		s.emitBrightnessChanged(serial, value)
+	}
+}
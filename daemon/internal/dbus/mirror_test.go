@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_EnableMirror_PropagatesPrimaryChanges(t *testing.T) {
+	primary := newFakeDevice("PRIMARY", 10)
+	follower1 := newFakeDevice("FOLLOWER1", 20)
+	follower2 := newFakeDevice("FOLLOWER2", 30)
+	server := NewServer(newFakeManager(primary, follower1, follower2))
+
+	require.Nil(t, server.EnableMirror("PRIMARY"))
+
+	require.Nil(t, server.SetBrightness("PRIMARY", 70))
+	assert.Equal(t, uint8(70), primary.percent())
+	assert.Equal(t, uint8(70), follower1.percent())
+	assert.Equal(t, uint8(70), follower2.percent())
+
+	require.Nil(t, server.IncreaseBrightness("PRIMARY", 10))
+	assert.Equal(t, uint8(80), follower1.percent())
+	assert.Equal(t, uint8(80), follower2.percent())
+
+	require.Nil(t, server.DisableMirror())
+	require.Nil(t, server.SetBrightness("PRIMARY", 40))
+	assert.Equal(t, uint8(40), primary.percent())
+	assert.Equal(t, uint8(80), follower1.percent(), "followers must not change after DisableMirror")
+}
+
+func TestServer_EnableMirror_NoFeedbackLoop(t *testing.T) {
+	primary := newFakeDevice("PRIMARY", 50)
+	follower := newFakeDevice("FOLLOWER", 50)
+	server := NewServer(newFakeManager(primary, follower))
+
+	require.Nil(t, server.EnableMirror("PRIMARY"))
+
+	// A primary change writes each display exactly once
+	require.Nil(t, server.SetBrightness("PRIMARY", 60))
+	assert.Equal(t, 1, primary.writeCount())
+	assert.Equal(t, 1, follower.writeCount())
+
+	// A follower change is never mirrored back to the primary
+	require.Nil(t, server.SetBrightness("FOLLOWER", 20))
+	assert.Equal(t, 1, primary.writeCount())
+	assert.Equal(t, uint8(60), primary.percent())
+	assert.Equal(t, uint8(20), follower.percent())
+}
+
+func TestServer_EnableMirror_MirrorsBulkSteps(t *testing.T) {
+	primary := newFakeDevice("PRIMARY", 10)
+	follower := newFakeDevice("FOLLOWER", 50)
+	server, recorder := newRecordingServer(newFakeManager(primary, follower))
+
+	require.Nil(t, server.EnableMirror("PRIMARY"))
+	require.Nil(t, server.IncreaseAllBrightness(10))
+
+	assert.Equal(t, uint8(20), primary.percent())
+	assert.Equal(t, uint8(20), follower.percent(), "the stepped primary is mirrored to the follower")
+
+	signals := recorder.named("BrightnessChanged")
+	require.NotEmpty(t, signals)
+	last := signals[len(signals)-1]
+	assert.Equal(t, []any{"FOLLOWER", uint32(20)}, last.values, "the follower's last signal is the mirrored value")
+	assert.Equal(t, uint64(2), server.stats.sets.Load())
+}
+
+func TestServer_EnableMirror_Validation(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("PRIMARY", 50)))
+
+	err := server.EnableMirror("")
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), ErrEmptySerial.Error())
+
+	err = server.EnableMirror("UNKNOWN")
+	assert.NotNil(t, err)
+	assert.Empty(t, server.mirrorPrimary)
+}
@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// Brightness mode names accepted by SetBrightnessMode.
+const (
+	ModeSDR = "sdr"
+	ModeHDR = "hdr"
+)
+
+// ErrUnknownMode is returned when SetBrightnessMode is called with an unconfigured mode.
+var ErrUnknownMode = errors.New("unknown brightness mode")
+
+// BrightnessMode is a brightness profile a display can be switched into, e.g. by a
+// compositor when HDR content starts or stops showing.
+type BrightnessMode struct {
+	Max   uint32 // Cap applied to every brightness change, as a percentage (0-100)
+	Level uint32 // Brightness applied when switching into the mode, capped by Max
+}
+
+// DefaultBrightnessModes returns the built-in modes. Neither caps brightness;
+// SDR switches to a comfortable level and HDR to full brightness.
+func DefaultBrightnessModes() map[string]BrightnessMode {
+	return map[string]BrightnessMode{
+		ModeSDR: {Max: 100, Level: 75},
+		ModeHDR: {Max: 100, Level: 100},
+	}
+}
+
+// WithBrightnessMode configures (or replaces) a brightness mode. Values above 100
+// are clamped. Displays start in ModeSDR.
+func WithBrightnessMode(name string, mode BrightnessMode) ServerOption {
+	return func(s *Server) {
+		s.modes[name] = BrightnessMode{Max: min(mode.Max, 100), Level: min(mode.Level, mode.Max, 100)}
+	}
+}
+
+// SetBrightnessMode switches a display into a configured brightness mode: the mode's
+// cap applies to all further brightness changes and the display is set to the mode's level.
+func (s *Server) SetBrightnessMode(serial string, mode string) *dbus.Error {
+	if err := validateSerial(serial); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+
+	profile, ok := s.modes[mode]
+	if !ok {
+		return dbus.MakeFailedError(fmt.Errorf("%w %q, expected one of %v", ErrUnknownMode, mode, s.modeNames()))
+	}
+
+	if _, err := s.manager.GetDisplay(serial); err != nil {
+		return s.displayLookupFailed("SetBrightnessMode", serial, err)
+	}
+
+	s.modesMu.Lock()
+	if s.displayModes == nil {
+		s.displayModes = make(map[string]string)
+	}
+	s.displayModes[serial] = mode
+	s.modesMu.Unlock()
+
+	log.Info().Str("serial", serial).Str("mode", mode).Uint32("max", profile.Max).Msg("Brightness mode changed")
+	return s.setBrightness("SetBrightnessMode", serial, profile.Level)
+}
+
+// GetBrightnessMode returns the active brightness mode of a display.
+func (s *Server) GetBrightnessMode(serial string) (string, *dbus.Error) {
+	if err := validateSerial(serial); err != nil {
+		return "", dbus.MakeFailedError(err)
+	}
+	if _, err := s.manager.GetDisplay(serial); err != nil {
+		return "", dbus.MakeFailedError(err)
+	}
+	return s.modeOf(serial), nil
+}
+
+// modeOf returns the active mode name of a display, ModeSDR if none was set.
+func (s *Server) modeOf(serial string) string {
+	s.modesMu.RLock()
+	defer s.modesMu.RUnlock()
+
+	if mode, ok := s.displayModes[serial]; ok {
+		return mode
+	}
+	return ModeSDR
+}
+
+// capBrightness limits a brightness percentage to 100, to the cap of the display's
+// active mode, to the night mode and external-only mode caps while those are on
+// and to the display's own configured cap, then raises it to the display's floor. The floor wins over a lower
+// cap, since a display that looks switched off is worse than an exceeded cap.
+func (s *Server) capBrightness(serial string, percent uint32) uint32 {
+	percent = min(percent, 100)
+	if profile, ok := s.modes[s.modeOf(serial)]; ok {
+		percent = min(percent, profile.Max)
+	}
+	if s.nightOn.Load() {
+		percent = min(percent, s.nightMode.Max)
+	}
+	if s.externalOnlyOn.Load() {
+		percent = min(percent, s.externalOnlyMode.Max)
+	}
+	floor := s.minBrightness
+	if config, ok := s.displayConfigs[serial]; ok {
+		if config.MaxBrightness != nil {
+			percent = min(percent, *config.MaxBrightness)
+		}
+		if config.MinBrightness != nil {
+			floor = *config.MinBrightness
+		}
+	}
+	return max(percent, floor)
+}
+
+// modeNames returns the configured mode names in sorted order.
+func (s *Server) modeNames() []string {
+	return slices.Sorted(maps.Keys(s.modes))
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cappedModes limits SDR to 60% and leaves HDR uncapped.
+var cappedModes = []ServerOption{
+	WithBrightnessMode(ModeSDR, BrightnessMode{Max: 60, Level: 50}),
+	WithBrightnessMode(ModeHDR, BrightnessMode{Max: 100, Level: 90}),
+}
+
+func TestServer_SetBrightnessMode_AppliesLevelAndCap(t *testing.T) {
+	display := newFakeDevice("A", 10)
+	server, _ := newRecordingServer(newFakeManager(display), cappedModes...)
+
+	require.Nil(t, server.SetBrightness("A", 100))
+	assert.Equal(t, uint8(60), display.percent(), "SDR cap applies by default")
+
+	require.Nil(t, server.SetBrightnessMode("A", ModeHDR))
+	assert.Equal(t, uint8(90), display.percent(), "switching applies the HDR level")
+	require.Nil(t, server.SetBrightness("A", 100))
+	assert.Equal(t, uint8(100), display.percent(), "HDR is not capped")
+
+	require.Nil(t, server.SetBrightnessMode("A", ModeSDR))
+	assert.Equal(t, uint8(50), display.percent(), "switching back applies the SDR level")
+	require.Nil(t, server.IncreaseBrightness("A", 30))
+	assert.Equal(t, uint8(60), display.percent(), "increase stops at the SDR cap")
+}
+
+func TestServer_SetBrightnessMode_IsPerDisplay(t *testing.T) {
+	a := newFakeDevice("A", 10)
+	b := newFakeDevice("B", 10)
+	server, recorder := newRecordingServer(newFakeManager(a, b), cappedModes...)
+
+	require.Nil(t, server.SetBrightnessMode("A", ModeHDR))
+	require.Nil(t, server.SetAllBrightness(100))
+
+	assert.Equal(t, uint8(100), a.percent())
+	assert.Equal(t, uint8(60), b.percent())
+
+	values := map[any]any{}
+	for _, sig := range recorder.named("BrightnessChanged") {
+		values[sig.values[0]] = sig.values[1]
+	}
+	assert.Equal(t, uint32(60), values["B"], "signal reports the capped value")
+}
+
+func TestServer_GetBrightnessMode(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("A", 10)))
+
+	mode, err := server.GetBrightnessMode("A")
+	require.Nil(t, err)
+	assert.Equal(t, ModeSDR, mode)
+
+	require.Nil(t, server.SetBrightnessMode("A", ModeHDR))
+	mode, err = server.GetBrightnessMode("A")
+	require.Nil(t, err)
+	assert.Equal(t, ModeHDR, mode)
+}
+
+func TestServer_SetBrightnessMode_Errors(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("A", 10)))
+
+	tests := []struct {
+		name   string
+		serial string
+		mode   string
+	}{
+		{name: "empty serial", serial: "", mode: ModeHDR},
+		{name: "unknown mode", serial: "A", mode: "cinema"},
+		{name: "unknown serial", serial: "MISSING", mode: ModeHDR},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NotNil(t, server.SetBrightnessMode(tt.serial, tt.mode))
+		})
+	}
+
+	mode, err := server.GetBrightnessMode("A")
+	require.Nil(t, err)
+	assert.Equal(t, ModeSDR, mode, "failed switches leave the mode unchanged")
+}
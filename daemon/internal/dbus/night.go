@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+)
+
+// DefaultNightMode dims displays to 20% and caps them at 40% while night mode is on.
+var DefaultNightMode = BrightnessMode{Max: 40, Level: 20}
+
+// WithNightMode configures night mode: its Level is applied to every display when
+// night mode is turned on, and its Max caps all brightness changes while it's on.
+// Values above 100 are clamped.
+func WithNightMode(mode BrightnessMode) ServerOption {
+	return func(s *Server) {
+		s.nightMode = BrightnessMode{Max: min(mode.Max, 100), Level: min(mode.Level, mode.Max, 100)}
+	}
+}
+
+// SetNightMode turns night mode on or off for all displays. Turning it on saves each
+// display's brightness and dims it to the night level; the night cap then applies on
+// top of the brightness mode cap until night mode is turned off, which restores the
+// saved brightness. The Studio Display exposes no color temperature control, so night
+// mode only changes brightness. Requesting the current state again is a no-op.
+func (s *Server) SetNightMode(enabled bool) *dbus.Error {
+	s.recordActivity()
+
+	s.nightMu.Lock()
+	defer s.nightMu.Unlock()
+
+	if s.nightOn.Load() == enabled {
+		return nil
+	}
+
+	displays := s.manager.Snapshot()
+
+	unlock := s.lockWithoutFades(slices.Collect(maps.Keys(displays))...)
+
+	var changed map[string]uint32
+	if enabled {
+		changed = s.enterNightMode(displays)
+	} else {
+		changed = s.leaveNightMode(displays)
+	}
+	unlock()
+
+	for serial, brightness := range changed {
+		s.emitBrightnessChanged(serial, brightness)
+	}
+	s.emitSignal("NightModeChanged", enabled)
+	return nil
+}
+
+// GetNightMode reports whether night mode is on.
+func (s *Server) GetNightMode() (bool, *dbus.Error) {
+	return s.nightOn.Load(), nil
+}
+
+// enterNightMode saves every display's brightness and dims it to the night level,
+// returning the brightness written per display. Must be called with nightMu and
+// the displays' serial locks held.
+func (s *Server) enterNightMode(displays map[string]*hid.Display) map[string]uint32 {
+	s.nightOn.Store(true)
+	var changed map[string]uint32
+	s.nightSaved, changed = s.applyProfileLevel(displays, s.nightMode.Level, "night mode")
+
+	log.Info().Int("displays", len(changed)).Uint32("brightness", s.nightMode.Level).Msg("Night mode enabled")
+	return changed
+}
+
+// leaveNightMode restores the brightness saved by enterNightMode, returning the
+// brightness written per display. Must be called with nightMu and the displays'
+// serial locks held.
+func (s *Server) leaveNightMode(displays map[string]*hid.Display) map[string]uint32 {
+	saved := s.nightSaved
+	s.nightSaved = nil
+	s.nightOn.Store(false)
+	changed := s.restoreSavedBrightness(displays, saved, "night mode")
+
+	log.Info().Int("displays", len(changed)).Msg("Night mode disabled")
+	return changed
+}
+
+// applyProfileLevel saves every display's brightness and sets it to level, subject
+// to the caps in effect, returning the saved and the written brightness per display.
+// Displays that can't be read are left alone. reason names the profile in logs.
+// Must be called with the displays' serial locks held, after the profile's cap
+// took effect.
+func (s *Server) applyProfileLevel(displays map[string]*hid.Display, level uint32, reason string) (saved, changed map[string]uint32) {
+	saved = make(map[string]uint32, len(displays))
+	changed = make(map[string]uint32, len(displays))
+	for serial, display := range displays {
+		current, err := display.GetBrightness()
+		if err != nil {
+			s.handleDeviceError(serial, err)
+			log.Warn().Err(err).Str("serial", serial).Str("profile", reason).Msg("Failed to read brightness before applying profile")
+			continue
+		}
+		saved[serial] = uint32(current)
+
+		target := s.capBrightness(serial, level)
+		if err := s.writeBrightness(serial, display, target); err != nil {
+			s.handleDeviceError(serial, err)
+			log.Warn().Err(err).Str("serial", serial).Str("profile", reason).Msg("Failed to apply profile")
+			continue
+		}
+		changed[serial] = target
+	}
+	return saved, changed
+}
+
+// restoreSavedBrightness writes back brightness saved by applyProfileLevel,
+// returning the brightness written per display. Displays connected after it was
+// saved keep their brightness. reason names the profile in logs. Must be called
+// with the displays' serial locks held, after the profile's cap was lifted.
+func (s *Server) restoreSavedBrightness(displays map[string]*hid.Display, saved map[string]uint32, reason string) map[string]uint32 {
+	changed := make(map[string]uint32, len(saved))
+	for serial, brightness := range saved {
+		display, ok := displays[serial]
+		if !ok {
+			continue
+		}
+
+		target := s.capBrightness(serial, brightness)
+		if err := s.writeBrightness(serial, display, target); err != nil {
+			s.handleDeviceError(serial, err)
+			log.Warn().Err(err).Str("serial", serial).Str("profile", reason).Msg("Failed to restore brightness after profile")
+			continue
+		}
+		changed[serial] = target
+	}
+	return changed
+}
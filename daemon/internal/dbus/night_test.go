@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_SetNightMode_AppliesNightConfig(t *testing.T) {
+	a := newFakeDevice("A", 80)
+	b := newFakeDevice("B", 60)
+	server, recorder := newRecordingServer(newFakeManager(a, b), WithNightMode(BrightnessMode{Max: 30, Level: 10}))
+
+	require.Nil(t, server.SetNightMode(true))
+	assert.Equal(t, uint8(10), a.percent())
+	assert.Equal(t, uint8(10), b.percent())
+	assert.Len(t, recorder.named("BrightnessChanged"), 2)
+
+	enabled, _ := server.GetNightMode()
+	assert.True(t, enabled)
+	signals := recorder.named("NightModeChanged")
+	require.Len(t, signals, 1)
+	assert.Equal(t, true, signals[0].values[0])
+
+	require.Nil(t, server.SetBrightness("A", 100))
+	assert.Equal(t, uint8(30), a.percent(), "night cap applies while night mode is on")
+}
+
+func TestServer_SetNightMode_DisableRestoresPriorBrightness(t *testing.T) {
+	a := newFakeDevice("A", 80)
+	b := newFakeDevice("B", 60)
+	server, recorder := newRecordingServer(newFakeManager(a, b), WithNightMode(BrightnessMode{Max: 30, Level: 10}))
+
+	require.Nil(t, server.SetNightMode(true))
+	require.Nil(t, server.SetBrightness("B", 25))
+	require.Nil(t, server.SetNightMode(false))
+
+	assert.Equal(t, uint8(80), a.percent())
+	assert.Equal(t, uint8(60), b.percent(), "restores the value from before night mode")
+
+	enabled, _ := server.GetNightMode()
+	assert.False(t, enabled)
+	signals := recorder.named("NightModeChanged")
+	require.Len(t, signals, 2)
+	assert.Equal(t, false, signals[1].values[0])
+
+	require.Nil(t, server.SetBrightness("A", 100))
+	assert.Equal(t, uint8(100), a.percent(), "night cap is lifted")
+}
+
+func TestServer_SetNightMode_RepeatedStateIsNoop(t *testing.T) {
+	display := newFakeDevice("A", 80)
+	server, recorder := newRecordingServer(newFakeManager(display))
+
+	require.Nil(t, server.SetNightMode(false))
+	assert.Empty(t, recorder.named("NightModeChanged"))
+
+	require.Nil(t, server.SetNightMode(true))
+	require.Nil(t, server.SetNightMode(true))
+	assert.Equal(t, uint8(DefaultNightMode.Level), display.percent())
+	assert.Len(t, recorder.named("NightModeChanged"), 1)
+
+	require.Nil(t, server.SetNightMode(false))
+	assert.Equal(t, uint8(80), display.percent(), "second enable doesn't overwrite the saved brightness")
+}
+
+func TestServer_SetNightMode_KeepsDisplaysConnectedDuringNightMode(t *testing.T) {
+	a := newFakeDevice("A", 80)
+	manager := newFakeManager(a)
+	server := NewServer(manager)
+
+	require.Nil(t, server.SetNightMode(true))
+
+	b := newFakeDevice("B", 15)
+	manager.displays = append(manager.displays, b.Info())
+	manager.displayMap["B"] = hid.NewDisplay(b)
+
+	require.Nil(t, server.SetNightMode(false))
+	assert.Equal(t, uint8(80), a.percent())
+	assert.Equal(t, uint8(15), b.percent(), "no saved value to restore")
+	assert.Equal(t, 0, b.writeCount())
+}
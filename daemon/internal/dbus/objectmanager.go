@@ -0,0 +1,201 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+)
+
+const (
+	// ObjectManagerInterface is the standard interface for enumerating child objects.
+	ObjectManagerInterface = "org.freedesktop.DBus.ObjectManager"
+
+	// DisplayInterfaceName is the interface reported for per-display child objects.
+	DisplayInterfaceName = InterfaceName + ".Display"
+
+	// DisplaysPath is the parent of the per-display child objects.
+	DisplaysPath = ObjectPath + "/displays"
+)
+
+// DisplayIntrospectXML is the D-Bus introspection XML for a per-display child object.
+const DisplayIntrospectXML = `
+<node>
+  <interface name="` + DisplayInterfaceName + `">
+    <property name="Serial" type="s" access="read"/>
+    <property name="ProductName" type="s" access="read"/>
+    <property name="Brightness" type="u" access="read">
+      <doc:doc xmlns:doc="` + docNamespace + `"><doc:description><doc:para>Brightness as a percentage (0-100); omitted if the display fails to respond.</doc:para></doc:description></doc:doc>
+    </property>
+    <property name="BrightnessMode" type="s" access="read"/>
+    <property name="USBSpeed" type="s" access="read"/>
+  </interface>
+  ` + prop.IntrospectDataString + `
+  ` + introspect.IntrospectDataString + `
+</node>
+`
+
+// managedObjects maps object paths to their interfaces and properties,
+// matching the a{oa{sa{sv}}} signature of GetManagedObjects.
+type managedObjects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+
+// objectManager implements org.freedesktop.DBus.ObjectManager on the service root path.
+// It is a separate type so its method set doesn't leak into the service interface.
+type objectManager struct {
+	server *Server
+}
+
+// GetManagedObjects returns every display as a child object of the service root
+// with the Display interface and its properties.
+func (o objectManager) GetManagedObjects() (managedObjects, *dbus.Error) {
+	return o.server.managedObjects(), nil
+}
+
+// DisplayObjectPath returns the child object path for a display serial.
+// Characters not allowed in object paths are escaped as _XX hex sequences.
+func DisplayObjectPath(serial string) dbus.ObjectPath {
+	var b strings.Builder
+	for i := 0; i < len(serial); i++ {
+		c := serial[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "_%02x", c)
+		}
+	}
+	return dbus.ObjectPath(DisplaysPath + "/" + b.String())
+}
+
+// displayObjects implements org.freedesktop.DBus.Properties for the child objects
+// below DisplaysPath, so the objects GetManagedObjects reports can be queried
+// directly. It's exported for the whole subtree and finds the display from the
+// path each call was made on.
+type displayObjects struct {
+	server *Server
+}
+
+// displayIntrospection implements org.freedesktop.DBus.Introspectable for the
+// subtree below DisplaysPath. Like displayObjects, it is a separate type so each
+// interface only gets its own methods.
+type displayIntrospection struct {
+	server *Server
+}
+
+// Get returns a single property of a display child object.
+func (o displayObjects) Get(msg dbus.Message, iface, name string) (dbus.Variant, *dbus.Error) {
+	props, err := o.GetAll(msg, iface)
+	if err != nil {
+		return dbus.Variant{}, err
+	}
+	value, ok := props[name]
+	if !ok {
+		return dbus.Variant{}, dbus.NewError("org.freedesktop.DBus.Error.UnknownProperty",
+			[]any{fmt.Sprintf("unknown property %s.%s", iface, name)})
+	}
+	return value, nil
+}
+
+// GetAll returns every property of a display child object, as GetManagedObjects
+// reports them.
+func (o displayObjects) GetAll(msg dbus.Message, iface string) (map[string]dbus.Variant, *dbus.Error) {
+	serial, display, ok := o.server.displayAt(messagePath(msg))
+	if !ok {
+		return nil, dbus.NewError("org.freedesktop.DBus.Error.UnknownObject",
+			[]any{fmt.Sprintf("no display at %s", messagePath(msg))})
+	}
+	if iface != DisplayInterfaceName {
+		return nil, dbus.NewError("org.freedesktop.DBus.Error.UnknownInterface",
+			[]any{fmt.Sprintf("unknown interface %s", iface)})
+	}
+	return o.server.displayObjectProperties(serial, display), nil
+}
+
+// Set rejects every write; all display properties are read-only.
+func (o displayObjects) Set(_ dbus.Message, iface, name string, _ dbus.Variant) *dbus.Error {
+	return dbus.NewError("org.freedesktop.DBus.Error.PropertyReadOnly",
+		[]any{fmt.Sprintf("property %s.%s is read-only", iface, name)})
+}
+
+// Introspect describes a display child object, or lists the child objects when
+// called on DisplaysPath itself.
+func (i displayIntrospection) Introspect(msg dbus.Message) (string, *dbus.Error) {
+	path := messagePath(msg)
+	if path != DisplaysPath {
+		if _, _, ok := i.server.displayAt(path); !ok {
+			return "", dbus.NewError("org.freedesktop.DBus.Error.UnknownObject",
+				[]any{fmt.Sprintf("no display at %s", path)})
+		}
+		return DisplayIntrospectXML, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("<node>\n")
+	for serial := range i.server.manager.Snapshot() {
+		name := strings.TrimPrefix(string(DisplayObjectPath(serial)), DisplaysPath+"/")
+		fmt.Fprintf(&b, "  <node name=%q/>\n", name)
+	}
+	b.WriteString("  " + introspect.IntrospectDataString + "\n</node>\n")
+	return b.String(), nil
+}
+
+// messagePath returns the object path a method call was made on.
+func messagePath(msg dbus.Message) dbus.ObjectPath {
+	path, _ := msg.Headers[dbus.FieldPath].Value().(dbus.ObjectPath)
+	return path
+}
+
+// displayAt returns the display whose child object is at path.
+func (s *Server) displayAt(path dbus.ObjectPath) (string, *hid.Display, bool) {
+	for serial, display := range s.manager.Snapshot() {
+		if DisplayObjectPath(serial) == path {
+			return serial, display, true
+		}
+	}
+	return "", nil, false
+}
+
+// managedObjects builds the GetManagedObjects result from the current displays.
+// Brightness is read from each display; it is omitted for displays that fail to respond.
+// BrightnessMode reports the display's active mode (see SetBrightnessMode), and
+// USBSpeed the link speed of its USB connection, if known (see hid.USBSpeed).
+func (s *Server) managedObjects() managedObjects {
+	objects := make(managedObjects)
+	for serial, display := range s.manager.Snapshot() {
+		objects[DisplayObjectPath(serial)] = map[string]map[string]dbus.Variant{
+			DisplayInterfaceName: s.displayObjectProperties(serial, display),
+		}
+	}
+	return objects
+}
+
+// displayObjectProperties returns the properties of a display's child object.
+func (s *Server) displayObjectProperties(serial string, display *hid.Display) map[string]dbus.Variant {
+	props := displayProperties(serial, display.ProductName())
+	props["BrightnessMode"] = dbus.MakeVariant(s.modeOf(serial))
+	if speed := s.usbSpeed(display.Info()); speed != "" {
+		props["USBSpeed"] = dbus.MakeVariant(speed)
+	}
+
+	current, err := display.GetBrightness()
+	if err != nil {
+		log.Debug().Err(err).Str("serial", serial).Msg("Omitting brightness from managed object")
+	} else {
+		props["Brightness"] = dbus.MakeVariant(uint32(current))
+	}
+	return props
+}
+
+// displayProperties returns the identity properties of a display child object.
+func displayProperties(serial, productName string) map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"Serial":      dbus.MakeVariant(serial),
+		"ProductName": dbus.MakeVariant(productName),
+	}
+}
@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObjectManager_GetManagedObjects(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("ABC123", 40), newFakeDevice("DEF456", 80)))
+	server.displayModes = map[string]string{"DEF456": ModeHDR}
+	server.usbSpeed = func(info hid.DeviceInfo) string {
+		if info.Serial == "DEF456" {
+			return hid.USBSpeedHigh
+		}
+		return ""
+	}
+
+	objects, err := objectManager{server: server}.GetManagedObjects()
+	require.Nil(t, err)
+	require.Len(t, objects, 2)
+
+	tests := []struct {
+		serial     string
+		brightness uint32
+		mode       string
+		usbSpeed   string
+	}{
+		{serial: "ABC123", brightness: 40, mode: ModeSDR},
+		{serial: "DEF456", brightness: 80, mode: ModeHDR, usbSpeed: hid.USBSpeedHigh},
+	}
+	for _, tt := range tests {
+		path := dbus.ObjectPath(ObjectPath + "/displays/" + tt.serial)
+		require.Contains(t, objects, path)
+
+		ifaces := objects[path]
+		require.Len(t, ifaces, 1)
+		props := ifaces[DisplayInterfaceName]
+		require.NotNil(t, props)
+
+		assert.Equal(t, tt.serial, props["Serial"].Value())
+		assert.Equal(t, "", props["ProductName"].Value())
+		assert.Equal(t, tt.brightness, props["Brightness"].Value())
+		assert.Equal(t, tt.mode, props["BrightnessMode"].Value())
+		if tt.usbSpeed == "" {
+			assert.NotContains(t, props, "USBSpeed", "unknown speed is omitted")
+		} else {
+			assert.Equal(t, tt.usbSpeed, props["USBSpeed"].Value())
+		}
+	}
+}
+
+func TestObjectManager_Empty(t *testing.T) {
+	server := NewServer(&mockDisplayManager{})
+
+	objects, err := objectManager{server: server}.GetManagedObjects()
+	require.Nil(t, err)
+	assert.Empty(t, objects)
+}
+
+// callOn returns a method call message made on path.
+func callOn(path dbus.ObjectPath) dbus.Message {
+	return dbus.Message{Headers: map[dbus.HeaderField]dbus.Variant{dbus.FieldPath: dbus.MakeVariant(path)}}
+}
+
+func TestDisplayObjects_Properties(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("ABC123", 40)))
+	objects := displayObjects{server: server}
+	path := DisplayObjectPath("ABC123")
+
+	props, err := objects.GetAll(callOn(path), DisplayInterfaceName)
+	require.Nil(t, err)
+	assert.Equal(t, server.managedObjects()[path][DisplayInterfaceName], props, "child objects match GetManagedObjects")
+
+	value, err := objects.Get(callOn(path), DisplayInterfaceName, "Brightness")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(40), value.Value())
+
+	_, err = objects.Get(callOn(path), DisplayInterfaceName, "Missing")
+	require.NotNil(t, err)
+	assert.Equal(t, "org.freedesktop.DBus.Error.UnknownProperty", err.Name)
+
+	_, err = objects.GetAll(callOn(path), InterfaceName)
+	require.NotNil(t, err)
+	assert.Equal(t, "org.freedesktop.DBus.Error.UnknownInterface", err.Name)
+
+	_, err = objects.GetAll(callOn(DisplayObjectPath("GONE")), DisplayInterfaceName)
+	require.NotNil(t, err)
+	assert.Equal(t, "org.freedesktop.DBus.Error.UnknownObject", err.Name)
+
+	err = objects.Set(callOn(path), DisplayInterfaceName, "Brightness", dbus.MakeVariant(uint32(10)))
+	require.NotNil(t, err)
+	assert.Equal(t, "org.freedesktop.DBus.Error.PropertyReadOnly", err.Name)
+}
+
+func TestDisplayIntrospection(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("ABC123", 40), newFakeDevice("A-B", 40)))
+	introspection := displayIntrospection{server: server}
+
+	data, err := introspection.Introspect(callOn(DisplayObjectPath("ABC123")))
+	require.Nil(t, err)
+	var node introspect.Node
+	require.NoError(t, xml.Unmarshal([]byte(data), &node))
+	names := make([]string, len(node.Interfaces))
+	for i, iface := range node.Interfaces {
+		names[i] = iface.Name
+	}
+	assert.Contains(t, names, DisplayInterfaceName)
+
+	data, err = introspection.Introspect(callOn(DisplaysPath))
+	require.Nil(t, err)
+	node = introspect.Node{}
+	require.NoError(t, xml.Unmarshal([]byte(data), &node))
+	children := make([]string, len(node.Children))
+	for i, child := range node.Children {
+		children[i] = child.Name
+	}
+	assert.ElementsMatch(t, []string{"ABC123", "A_2dB"}, children)
+
+	_, err = introspection.Introspect(callOn(DisplayObjectPath("GONE")))
+	assert.NotNil(t, err)
+}
+
+func TestDisplayObjectPath(t *testing.T) {
+	tests := []struct {
+		serial   string
+		expected dbus.ObjectPath
+	}{
+		{serial: "ABC123", expected: ObjectPath + "/displays/ABC123"},
+		{serial: "A-B.C", expected: ObjectPath + "/displays/A_2dB_2eC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.serial, func(t *testing.T) {
+			path := DisplayObjectPath(tt.serial)
+			assert.Equal(t, tt.expected, path)
+			assert.True(t, path.IsValid())
+		})
+	}
+}
+
+func TestServer_DisplaySignals_EmitObjectManagerSignals(t *testing.T) {
+	server, recorder := newRecordingServer(&mockDisplayManager{})
+
+	server.EmitDisplayAdded("ABC123", "Studio Display")
+	server.EmitDisplayRemoved("ABC123")
+
+	added := recorder.namedOn(ObjectManagerInterface, "InterfacesAdded")
+	require.Len(t, added, 1)
+	assert.Equal(t, DisplayObjectPath("ABC123"), added[0].values[0])
+	ifaces := added[0].values[1].(map[string]map[string]dbus.Variant)
+	assert.Equal(t, "Studio Display", ifaces[DisplayInterfaceName]["ProductName"].Value())
+
+	removed := recorder.namedOn(ObjectManagerInterface, "InterfacesRemoved")
+	require.Len(t, removed, 1)
+	assert.Equal(t, []any{DisplayObjectPath("ABC123"), []string{DisplayInterfaceName}}, removed[0].values)
+}
@@ -0,0 +1,34 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// Pause suspends the daemon's automatic brightness changes without disabling them,
+// e.g. while a calibration tool or presentation runs: idle dimming, mirroring,
+// re-applying brightness after spontaneous resets or after resume, and the connect
+// brightness of newly connected displays. Explicit client requests such as
+// SetBrightness keep working, and displays already dimmed by idle dimming are still
+// restored on activity.
+func (s *Server) Pause() *dbus.Error {
+	if !s.paused.Swap(true) {
+		log.Info().Msg("Automatic brightness changes paused")
+	}
+	return nil
+}
+
+// Resume re-enables automatic brightness changes suspended by Pause.
+func (s *Server) Resume() *dbus.Error {
+	if s.paused.Swap(false) {
+		log.Info().Msg("Automatic brightness changes resumed")
+	}
+	return nil
+}
+
+// IsPaused reports whether automatic brightness changes are paused.
+func (s *Server) IsPaused() (bool, *dbus.Error) {
+	return s.paused.Load(), nil
+}
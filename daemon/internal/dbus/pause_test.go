@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Pause_SuppressesIdleDim(t *testing.T) {
+	display := newFakeDevice("A", 50)
+	server := NewServer(newFakeManager(display))
+
+	clock := newFakeClock()
+	server.now = clock.Now
+
+	require.Nil(t, server.EnableIdleDim(60, 10))
+	defer server.DisableIdleDim()
+
+	require.Nil(t, server.Pause())
+	paused, err := server.IsPaused()
+	require.Nil(t, err)
+	assert.True(t, paused)
+
+	clock.Advance(2 * time.Minute)
+	server.checkIdle()
+	assert.Equal(t, uint8(50), display.percent(), "idle dim must not run while paused")
+
+	require.Nil(t, server.Resume())
+	paused, _ = server.IsPaused()
+	assert.False(t, paused)
+
+	server.checkIdle()
+	assert.Equal(t, uint8(10), display.percent(), "idle dim resumes after Resume")
+}
+
+func TestServer_Pause_SuppressesMirroringButNotExplicitSets(t *testing.T) {
+	primary := newFakeDevice("P", 50)
+	follower := newFakeDevice("F", 50)
+	server := NewServer(newFakeManager(primary, follower))
+	require.Nil(t, server.EnableMirror("P"))
+
+	require.Nil(t, server.Pause())
+	require.Nil(t, server.SetBrightness("P", 80))
+	assert.Equal(t, uint8(80), primary.percent(), "explicit sets still apply while paused")
+	assert.Equal(t, uint8(50), follower.percent(), "mirroring must not run while paused")
+
+	require.Nil(t, server.Resume())
+	require.Nil(t, server.SetBrightness("P", 30))
+	assert.Equal(t, uint8(30), follower.percent())
+}
+
+func TestServer_Pause_SuppressesResumeRestoreAndConnectBrightness(t *testing.T) {
+	display := newFakeDevice("A", 50)
+	manager := newFakeManager(display)
+	server := NewServer(manager, WithConnectBrightness(20))
+	require.Nil(t, server.SetBrightness("A", 30))
+	display.setExternally(100)
+
+	require.Nil(t, server.Pause())
+	server.ReapplyKnownBrightness(DefaultResumeAttempts, time.Millisecond)
+	assert.Equal(t, uint8(100), display.percent(), "brightness must not be restored after resume while paused")
+
+	server.ApplyConnectBrightness("A", manager.displayMap["A"])
+	assert.Equal(t, uint8(100), display.percent(), "connect brightness must not be applied while paused")
+
+	require.Nil(t, server.Resume())
+	server.ApplyConnectBrightness("A", manager.displayMap["A"])
+	assert.Equal(t, uint8(20), display.percent())
+}
+
+func TestServer_PauseResume_Idempotent(t *testing.T) {
+	server := NewServer(&mockDisplayManager{})
+
+	require.Nil(t, server.Pause())
+	require.Nil(t, server.Pause())
+	paused, _ := server.IsPaused()
+	assert.True(t, paused)
+
+	require.Nil(t, server.Resume())
+	require.Nil(t, server.Resume())
+	paused, _ = server.IsPaused()
+	assert.False(t, paused)
+}
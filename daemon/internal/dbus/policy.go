@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+)
+
+// NotFoundPolicy controls how brightness changes targeting an unknown serial are handled.
+// It applies to SetBrightness, IncreaseBrightness and DecreaseBrightness; GetBrightness
+// always returns an error because there is no meaningful value to report.
+type NotFoundPolicy int
+
+const (
+	// NotFoundError returns a D-Bus error to the caller (default).
+	NotFoundError NotFoundPolicy = iota
+	// NotFoundWarn logs a warning and reports success to the caller.
+	NotFoundWarn
+	// NotFoundIgnore silently reports success to the caller.
+	NotFoundIgnore
+)
+
+// String returns the flag value for the policy.
+func (p NotFoundPolicy) String() string {
+	switch p {
+	case NotFoundWarn:
+		return "warn"
+	case NotFoundIgnore:
+		return "silent"
+	default:
+		return "error"
+	}
+}
+
+// ParseNotFoundPolicy parses a policy name ("error", "warn" or "silent").
+func ParseNotFoundPolicy(name string) (NotFoundPolicy, error) {
+	switch name {
+	case "error":
+		return NotFoundError, nil
+	case "warn":
+		return NotFoundWarn, nil
+	case "silent":
+		return NotFoundIgnore, nil
+	default:
+		return NotFoundError, fmt.Errorf("invalid not-found policy %q (expected error, warn or silent)", name)
+	}
+}
+
+// displayLookupFailed converts a failed display lookup into the D-Bus reply for method.
+// Errors other than hid.ErrDisplayNotFound are always returned to the caller.
+// Returns nil when the configured policy says the call should succeed as a no-op.
+func (s *Server) displayLookupFailed(method, serial string, err error) *dbus.Error {
+	if !errors.Is(err, hid.ErrDisplayNotFound) {
+		log.Error().Err(err).Str("serial", serial).Str("method", method).Msg("Failed to get display")
+		return dbus.MakeFailedError(err)
+	}
+
+	switch s.notFoundPolicy {
+	case NotFoundWarn:
+		log.Warn().Str("serial", serial).Str("method", method).Msg("Display not found, ignoring request")
+		return nil
+	case NotFoundIgnore:
+		log.Debug().Str("serial", serial).Str("method", method).Msg("Display not found, ignoring request")
+		return nil
+	default:
+		log.Error().Err(err).Str("serial", serial).Str("method", method).Msg("Failed to get display")
+		return dbus.MakeFailedError(err)
+	}
+}
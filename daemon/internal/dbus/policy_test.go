@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNotFoundPolicy(t *testing.T) {
+	for _, policy := range []NotFoundPolicy{NotFoundError, NotFoundWarn, NotFoundIgnore} {
+		parsed, err := ParseNotFoundPolicy(policy.String())
+		require.NoError(t, err)
+		assert.Equal(t, policy, parsed)
+	}
+
+	_, err := ParseNotFoundPolicy("explode")
+	assert.Error(t, err)
+}
+
+func TestServer_NotFoundPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      NotFoundPolicy
+		expectError bool
+	}{
+		{name: "error policy returns error", policy: NotFoundError, expectError: true},
+		{name: "warn policy succeeds", policy: NotFoundWarn, expectError: false},
+		{name: "silent policy succeeds", policy: NotFoundIgnore, expectError: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer(newFakeManager(), WithNotFoundPolicy(tt.policy))
+
+			calls := map[string]func() error{
+				"SetBrightness": func() error {
+					return toError(server.SetBrightness("MISSING", 50))
+				},
+				"IncreaseBrightness": func() error {
+					return toError(server.IncreaseBrightness("MISSING", 10))
+				},
+				"DecreaseBrightness": func() error {
+					return toError(server.DecreaseBrightness("MISSING", 10))
+				},
+			}
+
+			for method, call := range calls {
+				err := call()
+				if tt.expectError {
+					assert.Error(t, err, method)
+					assert.Contains(t, err.Error(), "not found", method)
+				} else {
+					assert.NoError(t, err, method)
+				}
+			}
+
+			// GetBrightness has no meaningful value to return, so it always errors
+			_, getErr := server.GetBrightness("MISSING")
+			assert.NotNil(t, getErr)
+		})
+	}
+}
+
+func TestServer_NotFoundPolicy_OtherErrorsAlwaysReturned(t *testing.T) {
+	manager := newFakeManager()
+	manager.getErr = errors.New("manager unavailable")
+	server := NewServer(manager, WithNotFoundPolicy(NotFoundIgnore))
+
+	err := server.SetBrightness("MISSING", 50)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), "manager unavailable")
+}
+
+// toError converts a *dbus.Error into an error, keeping nil results untyped.
+func toError(err *dbus.Error) error {
+	if err == nil {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// PropertiesInterface is the standard interface for reading object properties.
+const PropertiesInterface = "org.freedesktop.DBus.Properties"
+
+// propertyDisplayCount is the service property holding the number of connected displays.
+const propertyDisplayCount = "DisplayCount"
+
+// properties implements org.freedesktop.DBus.Properties for the read-only
+// properties of the service interface. Like objectManager, it is a separate type
+// so its method set doesn't leak into the service interface.
+type properties struct {
+	server *Server
+}
+
+// Get returns a single property of the service interface.
+func (p properties) Get(iface, name string) (dbus.Variant, *dbus.Error) {
+	props, err := p.GetAll(iface)
+	if err != nil {
+		return dbus.Variant{}, err
+	}
+	value, ok := props[name]
+	if !ok {
+		return dbus.Variant{}, dbus.NewError("org.freedesktop.DBus.Error.UnknownProperty",
+			[]any{fmt.Sprintf("unknown property %s.%s", iface, name)})
+	}
+	return value, nil
+}
+
+// GetAll returns every property of the service interface.
+func (p properties) GetAll(iface string) (map[string]dbus.Variant, *dbus.Error) {
+	if iface != InterfaceName {
+		return nil, dbus.NewError("org.freedesktop.DBus.Error.UnknownInterface",
+			[]any{fmt.Sprintf("unknown interface %s", iface)})
+	}
+	return map[string]dbus.Variant{
+		propertyDisplayCount: dbus.MakeVariant(p.server.displayCount()),
+		propertyExternalOnly: dbus.MakeVariant(p.server.externalOnlyOn.Load()),
+	}, nil
+}
+
+// Set rejects every write; all properties are read-only.
+func (p properties) Set(iface, name string, _ dbus.Variant) *dbus.Error {
+	return dbus.NewError("org.freedesktop.DBus.Error.PropertyReadOnly",
+		[]any{fmt.Sprintf("property %s.%s is read-only", iface, name)})
+}
+
+// displayCount returns the number of connected displays.
+func (s *Server) displayCount() uint32 {
+	// #nosec G115 -- the manager caps the number of displays far below 2^32
+	return uint32(len(s.manager.ListDisplays()))
+}
+
+// UpdateDisplayCount emits PropertiesChanged for DisplayCount if the number of
+// connected displays changed since it was last reported. It's meant to run after
+// every display refresh.
+func (s *Server) UpdateDisplayCount() {
+	count := s.displayCount()
+	if s.reportedCount.Swap(count) == count {
+		return
+	}
+	s.emitInterfaceSignal(PropertiesInterface, "PropertiesChanged", InterfaceName,
+		map[string]dbus.Variant{propertyDisplayCount: dbus.MakeVariant(count)}, []string{})
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_DisplayCountProperty(t *testing.T) {
+	manager := newFakeManager(newFakeDevice("A", 50))
+	server, recorder := newRecordingServer(manager)
+	props := properties{server: server}
+
+	value, err := props.Get(InterfaceName, "DisplayCount")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(1), value.Value())
+
+	server.UpdateDisplayCount()
+	changes := recorder.namedOn(PropertiesInterface, "PropertiesChanged")
+	require.Len(t, changes, 1)
+	assert.Equal(t, InterfaceName, changes[0].values[0])
+	assert.Equal(t, map[string]dbus.Variant{"DisplayCount": dbus.MakeVariant(uint32(1))}, changes[0].values[1])
+	assert.Equal(t, []string{}, changes[0].values[2])
+
+	// A refresh that leaves the count alone stays quiet
+	server.UpdateDisplayCount()
+	assert.Len(t, recorder.namedOn(PropertiesInterface, "PropertiesChanged"), 1)
+
+	manager.displays = append(manager.displays, hid.DeviceInfo{Serial: "B"})
+	server.UpdateDisplayCount()
+	manager.displays = nil
+	server.UpdateDisplayCount()
+	changes = recorder.namedOn(PropertiesInterface, "PropertiesChanged")
+	require.Len(t, changes, 3)
+	assert.Equal(t, map[string]dbus.Variant{"DisplayCount": dbus.MakeVariant(uint32(2))}, changes[1].values[1])
+	assert.Equal(t, map[string]dbus.Variant{"DisplayCount": dbus.MakeVariant(uint32(0))}, changes[2].values[1])
+
+	all, err := props.GetAll(InterfaceName)
+	require.Nil(t, err)
+	assert.Equal(t, map[string]dbus.Variant{
+		"DisplayCount": dbus.MakeVariant(uint32(0)),
+		"ExternalOnly": dbus.MakeVariant(false),
+	}, all)
+}
+
+func TestProperties_Errors(t *testing.T) {
+	props := properties{server: NewServer(newFakeManager())}
+
+	_, err := props.Get(InterfaceName, "Missing")
+	require.NotNil(t, err)
+	assert.Equal(t, "org.freedesktop.DBus.Error.UnknownProperty", err.Name)
+	_, err = props.GetAll("org.example.Other")
+	require.NotNil(t, err)
+	assert.Equal(t, "org.freedesktop.DBus.Error.UnknownInterface", err.Name)
+	err = props.Set(InterfaceName, "DisplayCount", dbus.MakeVariant(uint32(3)))
+	require.NotNil(t, err)
+	assert.Equal(t, "org.freedesktop.DBus.Error.PropertyReadOnly", err.Name)
+
+	var node introspect.Node
+	require.NoError(t, xml.Unmarshal([]byte(IntrospectXML), &node))
+	var found bool
+	for _, iface := range node.Interfaces {
+		for _, property := range iface.Properties {
+			found = found || (iface.Name == InterfaceName && property.Name == "DisplayCount" && property.Access == "read")
+		}
+	}
+	assert.True(t, found, "DisplayCount should be introspectable")
+}
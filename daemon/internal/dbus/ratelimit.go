@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// maxIdleRateLimiters is how many per-display limiters are kept before idle ones
+// are dropped. Dropping a limiter with a full bucket loses nothing, since a new
+// one starts full too, and it bounds memory if clients send arbitrary serials.
+const maxIdleRateLimiters = 32
+
+// rateLimiters hands out a token bucket per display, plus one shared by operations
+// spanning all displays, so a client adjusting one display can't starve another.
+// Every bucket uses the same rate and burst. A nil *rateLimiters allows everything
+// without creating or consulting any bucket.
+type rateLimiters struct {
+	limit rate.Limit
+	burst int
+
+	mu        sync.Mutex
+	perSerial map[string]*rate.Limiter // created lazily
+	all       *rate.Limiter            // SetAllBrightness and other multi-display operations
+}
+
+// WithRateLimiting controls whether brightness changes are rate limited, which they
+// are by default. Turning it off lets trusted local scripts change brightness as fast
+// as they like, but also lets any client on the session bus flood the displays with
+// HID writes.
+func WithRateLimiting(enabled bool) ServerOption {
+	return func(s *Server) {
+		if !enabled {
+			s.rateLimits = nil
+		}
+	}
+}
+
+// newRateLimiters creates limiters allowing limit events per second with the given burst.
+func newRateLimiters(limit rate.Limit, burst int) *rateLimiters {
+	return &rateLimiters{
+		limit:     limit,
+		burst:     burst,
+		perSerial: make(map[string]*rate.Limiter),
+		all:       rate.NewLimiter(limit, burst),
+	}
+}
+
+// allow reports whether a change to serial may happen now, consuming a token if so.
+func (l *rateLimiters) allow(serial string) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	limiter, ok := l.perSerial[serial]
+	if !ok {
+		l.pruneIdleLocked()
+		limiter = rate.NewLimiter(l.limit, l.burst)
+		l.perSerial[serial] = limiter
+	}
+	l.mu.Unlock()
+
+	return limiter.Allow()
+}
+
+// allowAll reports whether an operation on all displays may happen now.
+func (l *rateLimiters) allowAll() bool {
+	if l == nil {
+		return true
+	}
+	return l.all.Allow()
+}
+
+// forget drops the limiter of a display, e.g. once it's disconnected.
+func (l *rateLimiters) forget(serial string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.perSerial, serial)
+}
+
+// pruneIdleLocked drops limiters whose bucket is full once there are too many.
+// Must be called with l.mu held.
+func (l *rateLimiters) pruneIdleLocked() {
+	if len(l.perSerial) < maxIdleRateLimiters {
+		return
+	}
+	for serial, limiter := range l.perSerial {
+		if limiter.Tokens() >= float64(l.burst) {
+			delete(l.perSerial, serial)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_RateLimiting_PerDisplay(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("A", 50), newFakeDevice("B", 50)))
+
+	// Exhaust display A's budget
+	var limited bool
+	for range 20 {
+		if err := server.SetBrightness("A", 50); err != nil {
+			assert.Contains(t, err.Error(), ErrRateLimitExceeded.Error())
+			limited = true
+			break
+		}
+	}
+	require.True(t, limited, "display A should hit its rate limit")
+
+	// Display B and operations on all displays have budgets of their own
+	assert.Nil(t, server.SetBrightness("B", 60))
+	assert.Nil(t, server.IncreaseBrightness("B", 5))
+	assert.Nil(t, server.SetAllBrightness(40))
+}
+
+func TestRateLimiters_ForgetAndPrune(t *testing.T) {
+	limits := newRateLimiters(1, 1)
+
+	require.True(t, limits.allow("A"))
+	assert.False(t, limits.allow("A"))
+
+	// A disconnected display starts over when it comes back
+	limits.forget("A")
+	assert.True(t, limits.allow("A"))
+
+	// Limiters with a full bucket are dropped once too many accumulate; busy ones are kept
+	for i := range maxIdleRateLimiters {
+		limits.perSerial[fmt.Sprint(i)] = newRateLimiters(1, 1).all
+	}
+	assert.True(t, limits.allow("NEW"))
+	assert.Contains(t, limits.perSerial, "A")
+	assert.Contains(t, limits.perSerial, "NEW")
+	assert.Len(t, limits.perSerial, 2)
+}
+
+func TestServer_RateLimiting_Disabled(t *testing.T) {
+	display := newFakeDevice("A", 50)
+	server := NewServer(newFakeManager(display), WithRateLimiting(false))
+	require.Nil(t, server.rateLimits)
+
+	for i := range 200 {
+		require.Nil(t, server.SetBrightness("A", uint32(i%101)), "call %d", i)
+	}
+	for range 50 {
+		require.Nil(t, server.SetAllBrightness(30))
+	}
+	assert.Equal(t, uint8(30), display.percent())
+
+	server.rateLimits.forget("A")
+	assert.NotNil(t, NewServer(newFakeManager(), WithRateLimiting(true)).rateLimits, "enabled by default")
+}
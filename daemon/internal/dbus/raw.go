@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// FeatureRawReports is advertised when SendRawFeatureReport is enabled.
+const FeatureRawReports = "raw-feature-reports"
+
+// ErrRawReportsDisabled is returned by SendRawFeatureReport unless enabled in the daemon configuration.
+var ErrRawReportsDisabled = errors.New("raw feature reports are disabled, start the daemon with --enable-raw")
+
+// WithRawFeatureReports enables SendRawFeatureReport. Raw reports bypass every
+// safety check of the brightness API, so it's off by default.
+func WithRawFeatureReports(enabled bool) ServerOption {
+	return func(s *Server) {
+		s.rawReports = enabled
+		if enabled {
+			s.extraFeatures = append(s.extraFeatures, FeatureRawReports)
+		}
+	}
+}
+
+// SendRawFeatureReport writes data, starting with the report ID, as a feature report
+// to a display and returns the report read back with the same ID. It's a debugging
+// aid for probing firmware features and must be enabled explicitly.
+func (s *Server) SendRawFeatureReport(serial string, data []byte) ([]byte, *dbus.Error) {
+	if !s.rawReports {
+		return nil, dbus.MakeFailedError(ErrRawReportsDisabled)
+	}
+	if err := validateSerial(serial); err != nil {
+		return nil, dbus.MakeFailedError(err)
+	}
+
+	display, err := s.manager.GetDisplay(serial)
+	if err != nil {
+		return nil, s.displayLookupFailed("SendRawFeatureReport", serial, err)
+	}
+
+	unlock := s.serialLocks.lock(serial)
+	s.cancelFade(serial)
+	log.Warn().Str("serial", serial).Hex("data", data).Msg("Sending raw feature report")
+	reply, err := display.RawFeatureReport(data)
+	// The report may have changed anything, including the brightness
+	s.invalidateCachedBrightness(serial)
+	unlock()
+	if err != nil {
+		s.handleDeviceError(serial, err)
+		log.Error().Err(err).Str("serial", serial).Msg("Raw feature report failed")
+		return nil, dbus.MakeFailedError(err)
+	}
+
+	log.Warn().Str("serial", serial).Hex("reply", reply).Msg("Received raw feature report")
+	return reply, nil
+}
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoDevice answers feature report reads with the last report written.
+type echoDevice struct {
+	*fakeDevice
+	last []byte
+}
+
+func (d *echoDevice) SendFeatureReport(data []byte) (int, error) {
+	d.last = append([]byte(nil), data...)
+	return len(data), nil
+}
+
+func (d *echoDevice) GetFeatureReport(data []byte) (int, error) {
+	return copy(data, d.last), nil
+}
+
+func newEchoServer(opts ...ServerOption) (*Server, *echoDevice) {
+	device := &echoDevice{fakeDevice: newFakeDevice("A", 50)}
+	manager := newFakeManager()
+	manager.displayMap["A"] = hid.NewDisplay(device)
+	return NewServer(manager, opts...), device
+}
+
+func TestServer_SendRawFeatureReport(t *testing.T) {
+	server, device := newEchoServer(WithRawFeatureReports(true))
+	payload := []byte{0x05, 0xDE, 0xAD, 0xBE, 0xEF}
+
+	reply, err := server.SendRawFeatureReport("A", payload)
+	require.Nil(t, err)
+	assert.Equal(t, payload, reply)
+	assert.Equal(t, payload, device.last)
+
+	features, _ := server.GetSupportedFeatures()
+	assert.Contains(t, features, FeatureRawReports)
+}
+
+func TestServer_SendRawFeatureReport_DisabledByDefault(t *testing.T) {
+	server, device := newEchoServer()
+
+	_, err := server.SendRawFeatureReport("A", []byte{0x05, 0x01})
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), ErrRawReportsDisabled.Error())
+	assert.Nil(t, device.last, "nothing should be sent to the display")
+
+	features, _ := server.GetSupportedFeatures()
+	assert.NotContains(t, features, FeatureRawReports)
+}
+
+func TestServer_SendRawFeatureReport_Validation(t *testing.T) {
+	server, device := newEchoServer(WithRawFeatureReports(true))
+
+	tests := []struct {
+		name   string
+		serial string
+		data   []byte
+	}{
+		{name: "empty serial", data: []byte{0x05, 0x01}},
+		{name: "unknown serial", serial: "MISSING", data: []byte{0x05, 0x01}},
+		{name: "report ID only", serial: "A", data: []byte{0x05}},
+		{name: "oversized", serial: "A", data: make([]byte, hid.MaxRawReportSize+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := server.SendRawFeatureReport(tt.serial, tt.data)
+			assert.NotNil(t, err)
+			assert.Nil(t, device.last)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"slices"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultMaxConcurrentRecoveries is the default number of device error recoveries
+// that run at once.
+const DefaultMaxConcurrentRecoveries = 2
+
+// pendingRecovery is a recovery waiting for a free recovery worker.
+type pendingRecovery struct {
+	handler DeviceErrorHandler
+	serial  string
+	err     error
+}
+
+// WithMaxConcurrentRecoveries bounds how many device error handlers run at once.
+// Recoveries beyond the bound wait in a queue holding at most one per display, so
+// a burst of device errors, even with the debounce disabled, can't spawn an
+// unbounded number of goroutines. Values below 1 use DefaultMaxConcurrentRecoveries.
+func WithMaxConcurrentRecoveries(n int) ServerOption {
+	return func(s *Server) {
+		if n < 1 {
+			n = DefaultMaxConcurrentRecoveries
+		}
+		s.maxRecoveries = n
+	}
+}
+
+// runRecovery runs handler for a device error of serial on a recovery worker,
+// starting one if fewer than the maximum are running and queueing it otherwise.
+// A recovery already queued for serial absorbs the new one, in which case it
+// reports false.
+func (s *Server) runRecovery(handler DeviceErrorHandler, serial string, err error) bool {
+	recovery := pendingRecovery{handler: handler, serial: serial, err: err}
+
+	s.recoveryMu.Lock()
+	if s.recoveryWorkers >= s.maxRecoveries {
+		defer s.recoveryMu.Unlock()
+		if slices.ContainsFunc(s.pendingRecoveries, func(p pendingRecovery) bool { return p.serial == serial }) {
+			log.Debug().Str("serial", serial).Msg("Recovery already queued")
+			return false
+		}
+		s.pendingRecoveries = append(s.pendingRecoveries, recovery)
+		log.Debug().Str("serial", serial).Int("queued", len(s.pendingRecoveries)).Msg("Recovery queued, too many running")
+		return true
+	}
+	s.recoveryWorkers++
+	s.recoveryMu.Unlock()
+
+	go s.recoveryWorker(recovery)
+	return true
+}
+
+// recoveryWorker runs recovery, then queued recoveries until the queue is empty.
+func (s *Server) recoveryWorker(recovery pendingRecovery) {
+	for {
+		recovery.handler(recovery.serial, recovery.err)
+
+		s.recoveryMu.Lock()
+		if len(s.pendingRecoveries) == 0 {
+			s.recoveryWorkers--
+			s.recoveryMu.Unlock()
+			return
+		}
+		recovery = s.pendingRecoveries[0]
+		s.pendingRecoveries = slices.Delete(s.pendingRecoveries, 0, 1)
+		s.recoveryMu.Unlock()
+	}
+}
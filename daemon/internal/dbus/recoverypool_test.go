@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_RecoveryConcurrencyIsBounded(t *testing.T) {
+	server := NewServer(newFakeManager(), WithDeviceErrorDebounce(0), WithMaxConcurrentRecoveries(3))
+
+	var running, peak atomic.Int32
+	var mu sync.Mutex
+	recovered := make(map[string]int)
+	release := make(chan struct{})
+	server.SetDeviceErrorHandler(func(serial string, _ error) {
+		current := running.Add(1)
+		for {
+			old := peak.Load()
+			if current <= old || peak.CompareAndSwap(old, current) {
+				break
+			}
+		}
+		<-release
+		running.Add(-1)
+
+		mu.Lock()
+		recovered[serial]++
+		mu.Unlock()
+	})
+
+	// Many errors from many goroutines, several per display
+	var wg sync.WaitGroup
+	for i := range 200 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			server.handleDeviceError(fmt.Sprintf("SN%d", i%20), syscall.ENODEV)
+		}()
+	}
+	wg.Wait()
+
+	assert.Eventually(t, func() bool { return running.Load() == 3 }, time.Second, time.Millisecond)
+	server.recoveryMu.Lock()
+	queued := len(server.pendingRecoveries)
+	server.recoveryMu.Unlock()
+	assert.LessOrEqual(t, queued, 20, "at most one recovery per display waits")
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		server.recoveryMu.Lock()
+		defer server.recoveryMu.Unlock()
+		return server.recoveryWorkers == 0
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(t, int32(3), peak.Load(), "no more handlers than the bound ran at once")
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Len(t, recovered, 20, "every display is recovered")
+}
+
+func TestServer_QueuedRecoveriesCoalescePerDisplay(t *testing.T) {
+	server := NewServer(newFakeManager(), WithDeviceErrorDebounce(0), WithMaxConcurrentRecoveries(1))
+
+	recoveries := make(chan string, 10)
+	release := make(chan struct{})
+	server.SetDeviceErrorHandler(func(serial string, _ error) {
+		if serial == "A" {
+			<-release
+		}
+		recoveries <- serial
+	})
+
+	require.True(t, server.handleDeviceError("A", syscall.ENODEV))
+	assert.Eventually(t, func() bool {
+		server.recoveryMu.Lock()
+		defer server.recoveryMu.Unlock()
+		return server.recoveryWorkers == 1
+	}, time.Second, time.Millisecond)
+
+	// With the only worker busy, errors queue up, one per display
+	for range 5 {
+		server.handleDeviceError("B", syscall.ENODEV)
+		server.handleDeviceError("C", syscall.ENODEV)
+	}
+	close(release)
+
+	got := []string{<-recoveries, <-recoveries, <-recoveries}
+	assert.Equal(t, []string{"A", "B", "C"}, got, "queued recoveries run in order on the busy worker")
+	assert.Never(t, func() bool { return len(recoveries) > 0 }, 50*time.Millisecond, 5*time.Millisecond)
+
+	stats, _ := server.GetStats()
+	assert.Equal(t, uint64(3), stats.Recoveries, "recoveries absorbed by a queued one aren't counted")
+}
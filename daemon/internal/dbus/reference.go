@@ -0,0 +1,40 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+)
+
+// GetReferenceBrightness returns the factory-calibrated reference white luminance of
+// a display in nits, so color-critical users can check for calibration drift.
+// Displays without calibration data return an error wrapping hid.ErrCalibrationUnsupported.
+func (s *Server) GetReferenceBrightness(serial string) (uint32, *dbus.Error) {
+	if err := validateSerial(serial); err != nil {
+		return 0, dbus.MakeFailedError(err)
+	}
+
+	display, err := s.manager.GetDisplay(serial)
+	if err != nil {
+		log.Error().Err(err).Str("serial", serial).Msg("Failed to get display")
+		return 0, dbus.MakeFailedError(err)
+	}
+
+	nits, err := display.GetReferenceBrightness()
+	if errors.Is(err, hid.ErrCalibrationUnsupported) {
+		log.Debug().Err(err).Str("serial", serial).Msg("Reference brightness not available")
+		return 0, dbus.MakeFailedError(err)
+	}
+	if err != nil {
+		s.handleDeviceError(serial, err)
+		log.Error().Err(err).Str("serial", serial).Msg("Failed to get reference brightness")
+		return 0, dbus.MakeFailedError(err)
+	}
+
+	log.Debug().Str("serial", serial).Uint32("nits", nits).Msg("Got reference brightness")
+	return nits, nil
+}
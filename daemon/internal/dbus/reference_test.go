@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestServer_GetReferenceBrightness(t *testing.T) {
+	tests := []struct {
+		name      string
+		report    []byte
+		readErr   error
+		expected  uint32
+		expectErr bool
+	}{
+		{name: "returns calibrated luminance", report: []byte{hid.CalibrationReportID, 0xF4, 0x01, 0x00, 0x00}, expected: 500},
+		{name: "unsupported display", readErr: syscall.EPIPE, expectErr: true},
+		{name: "empty report", report: []byte{}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockDevice := mocks.NewMockDevice(ctrl)
+			mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"}).AnyTimes()
+			mockDevice.EXPECT().GetFeatureReport(gomock.Any()).DoAndReturn(func(data []byte) (int, error) {
+				if tt.readErr != nil {
+					return 0, tt.readErr
+				}
+				return copy(data, tt.report), nil
+			})
+
+			display := hid.NewDisplay(mockDevice)
+			display.SetExperimentalReports(true)
+			manager := &mockDisplayManager{
+				displays:   []hid.DeviceInfo{{Serial: "ABC123"}},
+				displayMap: map[string]*hid.Display{"ABC123": display},
+			}
+			server := NewServer(manager)
+
+			nits, err := server.GetReferenceBrightness("ABC123")
+			if tt.expectErr {
+				assert.NotNil(t, err)
+				return
+			}
+			require.Nil(t, err)
+			assert.Equal(t, tt.expected, nits)
+		})
+	}
+}
+
+func TestServer_GetReferenceBrightness_InvalidSerial(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("A", 50)))
+
+	_, err := server.GetReferenceBrightness("")
+	assert.NotNil(t, err)
+	_, err = server.GetReferenceBrightness("MISSING")
+	assert.NotNil(t, err)
+}
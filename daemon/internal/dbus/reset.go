@@ -0,0 +1,30 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"github.com/godbus/dbus/v5"
+)
+
+// DefaultResetBrightness is the percentage ResetBrightness applies unless configured otherwise.
+// The Studio Display does not report a factory default brightness over HID, so the
+// daemon relies on this configurable value instead.
+const DefaultResetBrightness uint32 = 75
+
+// WithDefaultBrightness sets the percentage applied by ResetBrightness and ResetAllBrightness.
+// Values above 100 are clamped to 100.
+func WithDefaultBrightness(percent uint32) ServerOption {
+	return func(s *Server) {
+		s.defaultBrightness = min(percent, 100)
+	}
+}
+
+// ResetBrightness sets a display to the configured default brightness.
+func (s *Server) ResetBrightness(serial string) *dbus.Error {
+	return s.setBrightness("ResetBrightness", serial, s.defaultBrightness)
+}
+
+// ResetAllBrightness sets all displays to the configured default brightness.
+func (s *Server) ResetAllBrightness() *dbus.Error {
+	return s.setAllBrightness("ResetAllBrightness", s.defaultBrightness)
+}
@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ResetBrightness(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []ServerOption
+		expected uint8
+	}{
+		{name: "uses built-in default", expected: uint8(DefaultResetBrightness)},
+		{name: "uses configured default", opts: []ServerOption{WithDefaultBrightness(40)}, expected: 40},
+		{name: "clamps configured default", opts: []ServerOption{WithDefaultBrightness(150)}, expected: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			display := newFakeDevice("A", 10)
+			server, recorder := newRecordingServer(newFakeManager(display), tt.opts...)
+
+			require.Nil(t, server.ResetBrightness("A"))
+
+			assert.Equal(t, tt.expected, display.percent())
+			signals := recorder.named("BrightnessChanged")
+			require.Len(t, signals, 1)
+			assert.Equal(t, []any{"A", uint32(tt.expected)}, signals[0].values)
+		})
+	}
+}
+
+func TestServer_ResetBrightness_UnknownSerial(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("A", 10)))
+
+	assert.NotNil(t, server.ResetBrightness("MISSING"))
+	assert.NotNil(t, server.ResetBrightness(""))
+}
+
+func TestServer_ResetAllBrightness(t *testing.T) {
+	a := newFakeDevice("A", 10)
+	b := newFakeDevice("B", 90)
+	server, recorder := newRecordingServer(newFakeManager(a, b), WithDefaultBrightness(60))
+
+	require.Nil(t, server.ResetAllBrightness())
+
+	assert.Equal(t, uint8(60), a.percent())
+	assert.Equal(t, uint8(60), b.percent())
+	assert.Len(t, recorder.named("BrightnessChanged"), 2)
+}
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+)
+
+// WithResetReapply makes PollBrightness undo spontaneous resets: some displays fall
+// back to a fixed brightness after power events on the panel side, and when a poll
+// finds a display jumped to resetLevel without the daemon setting it, its last known
+// brightness is written back. Any other external change, e.g. from the display's own
+// buttons, is reported as usual; only pick a level the user doesn't choose by hand.
+// Automatic re-applying is suspended while paused. A negative level disables it,
+// which is the default.
+func WithResetReapply(resetLevel int) ServerOption {
+	return func(s *Server) {
+		s.resetLevel = min(resetLevel, 100)
+	}
+}
+
+// spontaneousReset reports whether a display found at current after previous was
+// reset by the panel rather than changed on purpose.
+func (s *Server) spontaneousReset(previous, current uint32) bool {
+	// #nosec G115 -- resetLevel is non-negative here and at most 100
+	return s.resetLevel >= 0 && current == uint32(s.resetLevel) && previous != current && !s.paused.Load()
+}
+
+// reapplyAfterReset writes the brightness a display had before a spontaneous reset
+// back and records it as known again. It reports whether the write succeeded. Must
+// be called with the display's serial lock held.
+func (s *Server) reapplyAfterReset(serial string, display *hid.Display, previous uint32) bool {
+	target := s.capBrightness(serial, previous)
+	if err := s.writeBrightness(serial, display, target); err != nil {
+		s.handleDeviceError(serial, err)
+		log.Warn().Err(err).Str("serial", serial).Msg("Failed to re-apply brightness after spontaneous reset")
+		return false
+	}
+
+	log.Info().
+		Str("serial", serial).
+		Int("reset_to", s.resetLevel).
+		Uint32("brightness", target).
+		Msg("Re-applied brightness after spontaneous reset")
+	return true
+}
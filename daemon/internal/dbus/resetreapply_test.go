@@ -0,0 +1,125 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_PollBrightness_ReappliesAfterSpontaneousReset(t *testing.T) {
+	display := newFakeDevice("A", 30)
+	server, recorder := newRecordingServer(newFakeManager(display), WithResetReapply(100))
+
+	server.PollBrightness()
+	display.setExternally(100)
+	writes := display.writeCount()
+	server.PollBrightness()
+
+	assert.Equal(t, uint8(30), display.percent(), "the brightness from before the reset is re-applied")
+	assert.Equal(t, writes+1, display.writeCount())
+	assert.Empty(t, recorder.named("BrightnessChanged"), "clients already know the re-applied value")
+
+	// The re-applied value is the new baseline
+	server.PollBrightness()
+	assert.Equal(t, writes+1, display.writeCount())
+	assert.Empty(t, recorder.named("BrightnessChanged"))
+}
+
+func TestServer_PollBrightness_DaemonChangeToResetLevelIsKept(t *testing.T) {
+	display := newFakeDevice("A", 30)
+	server, recorder := newRecordingServer(newFakeManager(display), WithResetReapply(100))
+
+	server.PollBrightness()
+	require.Nil(t, server.SetBrightness("A", 100))
+	writes := display.writeCount()
+	server.PollBrightness()
+
+	assert.Equal(t, uint8(100), display.percent(), "a change the daemon made isn't undone")
+	assert.Equal(t, writes, display.writeCount())
+	assert.Len(t, recorder.named("BrightnessChanged"), 1, "only the SetBrightness signal is expected")
+}
+
+func TestServer_PollBrightness_PollRacingSetToResetLevelKeepsIt(t *testing.T) {
+	display := newFakeDevice("A", 30)
+	server, recorder := newRecordingServer(newFakeManager(display), WithResetReapply(100))
+	server.PollBrightness()
+
+	// A poll started mid-write waits for the serial lock; by then the client's
+	// value must already be known, or the poll takes it for a reset and writes 30 back
+	polled := make(chan struct{})
+	var once sync.Once
+	display.onSend = func(string) {
+		once.Do(func() {
+			go func() {
+				server.PollBrightness()
+				close(polled)
+			}()
+		})
+	}
+
+	require.Nil(t, server.SetBrightness("A", 100))
+	<-polled
+
+	assert.Equal(t, uint8(100), display.percent(), "the client's choice isn't reverted")
+	assert.Equal(t, 1, display.writeCount())
+	assert.Len(t, recorder.named("BrightnessChanged"), 1, "only the SetBrightness signal is expected")
+}
+
+func TestServer_PollBrightness_ExternalOnlyLevelAtResetLevelIsKept(t *testing.T) {
+	display := newFakeDevice("A", 30)
+	server := NewServer(newFakeManager(display),
+		WithResetReapply(100), WithExternalOnlyMode(BrightnessMode{Max: 100, Level: 100}))
+
+	server.PollBrightness()
+	require.Nil(t, server.SetExternalOnlyMode(true))
+	writes := display.writeCount()
+	server.PollBrightness()
+
+	assert.Equal(t, uint8(100), display.percent())
+	assert.Equal(t, writes, display.writeCount())
+}
+
+func TestServer_PollBrightness_OtherExternalChangesAreReported(t *testing.T) {
+	display := newFakeDevice("A", 30)
+	server, recorder := newRecordingServer(newFakeManager(display), WithResetReapply(100))
+
+	server.PollBrightness()
+	display.setExternally(60)
+	server.PollBrightness()
+
+	assert.Equal(t, uint8(60), display.percent(), "a change to another level, e.g. by the buttons, is kept")
+	signals := recorder.named("BrightnessChanged")
+	require.Len(t, signals, 1)
+	assert.Equal(t, []any{"A", uint32(60)}, signals[0].values)
+}
+
+func TestServer_PollBrightness_ResetReapplyGates(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		display := newFakeDevice("A", 30)
+		server, recorder := newRecordingServer(newFakeManager(display))
+
+		server.PollBrightness()
+		display.setExternally(100)
+		server.PollBrightness()
+
+		assert.Equal(t, uint8(100), display.percent())
+		assert.Len(t, recorder.named("BrightnessChanged"), 1)
+	})
+
+	t.Run("suspended while paused", func(t *testing.T) {
+		display := newFakeDevice("A", 30)
+		server, recorder := newRecordingServer(newFakeManager(display), WithResetReapply(100))
+		require.Nil(t, server.Pause())
+
+		server.PollBrightness()
+		display.setExternally(100)
+		server.PollBrightness()
+
+		assert.Equal(t, uint8(100), display.percent())
+		assert.Len(t, recorder.named("BrightnessChanged"), 1)
+	})
+}
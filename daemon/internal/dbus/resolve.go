@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"github.com/godbus/dbus/v5"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+)
+
+// ResolveSerial returns the full serial of the display matching query, so scripts
+// can address a display by its configured alias (see DisplayConfig) or a unique
+// prefix, suffix or other part of its serial. An alias or exact match takes
+// precedence; a query matching several displays fails with hid.ErrAmbiguousSerial.
+func (s *Server) ResolveSerial(query string) (string, *dbus.Error) {
+	if err := validateSerial(query); err != nil {
+		return "", dbus.MakeFailedError(err)
+	}
+	if serial, ok := s.serialForAlias(query); ok {
+		if _, err := s.manager.GetDisplay(serial); err != nil {
+			return "", dbus.MakeFailedError(err)
+		}
+		return serial, nil
+	}
+
+	displays := s.manager.ListDisplays()
+	serials := make([]string, len(displays))
+	for i, info := range displays {
+		serials[i] = info.Serial
+	}
+
+	serial, err := hid.MatchSerial(query, serials)
+	if err != nil {
+		return "", dbus.MakeFailedError(err)
+	}
+	return serial, nil
+}
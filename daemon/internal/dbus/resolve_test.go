@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ResolveSerial(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("C02XK1Y2Q6NV", 50), newFakeDevice("C02XK1Y2Q7AB", 50)))
+
+	serial, err := server.ResolveSerial("7ab")
+	require.Nil(t, err)
+	assert.Equal(t, "C02XK1Y2Q7AB", serial)
+
+	_, err = server.ResolveSerial("C02")
+	require.NotNil(t, err)
+	assert.Contains(t, err.Body[0], hid.ErrAmbiguousSerial.Error())
+
+	_, err = server.ResolveSerial("C02\n")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultResumeAttempts is how many times a display is tried after resume
+	// before its brightness is left as the display restored it.
+	DefaultResumeAttempts = 10
+
+	// DefaultResumeRetryInterval is the delay between attempts after resume, giving
+	// displays time to be re-enumerated and serve feature reports again.
+	DefaultResumeRetryInterval = 500 * time.Millisecond
+)
+
+// ReapplyKnownBrightness writes the last-known brightness back to every display,
+// e.g. after resume, when displays may come back at full brightness. Each display
+// is retried up to attempts times, interval apart, until it's reachable, and all
+// displays are handled in parallel. It's quiet: no signals are emitted because the
+// brightness clients know about doesn't change. It returns once every display was
+// restored or ran out of attempts. Nothing is written while automatic changes are
+// paused, see Pause.
+func (s *Server) ReapplyKnownBrightness(attempts int, interval time.Duration) {
+	if s.paused.Load() {
+		log.Info().Msg("Automatic brightness changes paused, not restoring brightness after resume")
+		return
+	}
+
+	s.knownMu.Lock()
+	known := make(map[string]uint32, len(s.known))
+	for serial, percent := range s.known {
+		known[serial] = percent
+	}
+	s.knownMu.Unlock()
+
+	var wg sync.WaitGroup
+	for serial, percent := range known {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.reapplyBrightness(serial, percent, max(attempts, 1), interval)
+		}()
+	}
+	wg.Wait()
+}
+
+// reapplyBrightness retries writing percent to serial until it succeeds or attempts run out.
+func (s *Server) reapplyBrightness(serial string, percent uint32, attempts int, interval time.Duration) {
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(interval)
+		}
+		if s.paused.Load() {
+			log.Info().Str("serial", serial).Msg("Automatic brightness changes paused, stopped restoring brightness after resume")
+			return
+		}
+		if err = s.writeKnownBrightness(serial, percent); err == nil {
+			log.Info().Str("serial", serial).Uint32("brightness", percent).Int("attempt", attempt).
+				Msg("Restored brightness after resume")
+			return
+		}
+		log.Debug().Err(err).Str("serial", serial).Int("attempt", attempt).Msg("Display not reachable yet after resume")
+	}
+	log.Warn().Err(err).Str("serial", serial).Int("attempts", attempts).Msg("Gave up restoring brightness after resume")
+}
+
+// writeKnownBrightness writes percent to serial, within its floor and mode caps,
+// without emitting signals.
+func (s *Server) writeKnownBrightness(serial string, percent uint32) error {
+	display, err := s.manager.GetDisplay(serial)
+	if err != nil {
+		return err
+	}
+
+	unlock := s.lockWithoutFades(serial)
+	defer unlock()
+	return s.writeBrightness(serial, display, s.capBrightness(serial, percent))
+}
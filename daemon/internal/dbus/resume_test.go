@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wakingDevice fails writes with ENODEV until it has been written to a number of
+// times, like a display that is still coming back after resume.
+type wakingDevice struct {
+	*fakeDevice
+	failures atomic.Int32
+}
+
+func (d *wakingDevice) SendFeatureReport(data []byte) (int, error) {
+	if d.failures.Add(-1) >= 0 {
+		return 0, syscall.ENODEV
+	}
+	return d.fakeDevice.SendFeatureReport(data)
+}
+
+func TestServer_ReapplyKnownBrightness(t *testing.T) {
+	displayA := newFakeDevice("A", 10)
+	displayB := newFakeDevice("B", 10)
+	server, recorder := newRecordingServer(newFakeManager(displayA, displayB))
+
+	require.Nil(t, server.SetBrightness("A", 30))
+	require.Nil(t, server.SetBrightness("B", 70))
+	signals := len(recorder.named("BrightnessChanged"))
+
+	// Both displays come back from suspend at full brightness
+	displayA.setExternally(100)
+	displayB.setExternally(100)
+
+	server.ReapplyKnownBrightness(DefaultResumeAttempts, time.Millisecond)
+
+	assert.Equal(t, uint8(30), displayA.percent())
+	assert.Equal(t, uint8(70), displayB.percent())
+	assert.Len(t, recorder.named("BrightnessChanged"), signals, "restoring should be quiet")
+}
+
+func TestServer_ReapplyKnownBrightness_RetriesUntilReachable(t *testing.T) {
+	waking := &wakingDevice{fakeDevice: newFakeDevice("A", 100)}
+	waking.failures.Store(3)
+
+	manager := newFakeManager()
+	manager.displayMap["A"] = hid.NewDisplay(waking)
+	server := NewServer(manager)
+	server.recordKnownBrightness("A", 40)
+
+	server.ReapplyKnownBrightness(DefaultResumeAttempts, time.Millisecond)
+	assert.Equal(t, uint8(40), waking.percent())
+}
+
+func TestServer_ReapplyKnownBrightness_GivesUp(t *testing.T) {
+	waking := &wakingDevice{fakeDevice: newFakeDevice("A", 100)}
+	waking.failures.Store(100)
+
+	manager := newFakeManager()
+	manager.displayMap["A"] = hid.NewDisplay(waking)
+	server := NewServer(manager)
+	server.recordKnownBrightness("A", 40)
+	// Displays the daemon no longer tracks are given up on too
+	server.recordKnownBrightness("GONE", 60)
+
+	server.ReapplyKnownBrightness(2, time.Millisecond)
+	assert.Equal(t, uint8(100), waking.percent())
+	assert.Equal(t, int32(98), waking.failures.Load(), "only the given number of attempts should be made")
+}
+
+func TestHandleSleepSignal(t *testing.T) {
+	name := logindManagerInterface + "." + prepareForSleepMember
+
+	tests := []struct {
+		name    string
+		signal  *dbus.Signal
+		resumed bool
+	}{
+		{name: "resume", signal: &dbus.Signal{Name: name, Body: []any{false}}, resumed: true},
+		{name: "suspend", signal: &dbus.Signal{Name: name, Body: []any{true}}},
+		{name: "other signal", signal: &dbus.Signal{Name: logindManagerInterface + ".SessionNew", Body: []any{false}}},
+		{name: "malformed body", signal: &dbus.Signal{Name: name, Body: []any{"false"}}},
+		{name: "nil signal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resumed := false
+			handleSleepSignal(tt.signal, func() { resumed = true })
+			assert.Equal(t, tt.resumed, resumed)
+		})
+	}
+}
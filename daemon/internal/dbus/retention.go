@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// WithStateRetention makes PruneStaleState forget the per-display state (last-known
+// brightness and brightness mode) of displays that haven't been connected for longer
+// than retention, so state for displays the user no longer owns doesn't pile up.
+// 0, the default, keeps state for as long as the daemon runs.
+func WithStateRetention(retention time.Duration) ServerOption {
+	return func(s *Server) {
+		s.retention = max(retention, 0)
+	}
+}
+
+// PruneStaleState marks the currently connected displays as seen and drops the
+// state of displays not seen within the retention window. It's meant to run at
+// startup and after every display refresh, and returns the pruned serials.
+func (s *Server) PruneStaleState() []string {
+	now := s.now()
+
+	s.knownMu.Lock()
+	if s.lastSeen == nil {
+		s.lastSeen = make(map[string]time.Time)
+	}
+	for _, info := range s.manager.ListDisplays() {
+		s.lastSeen[info.Serial] = now
+	}
+
+	var pruned []string
+	if s.retention > 0 {
+		for serial, seen := range s.lastSeen {
+			if now.Sub(seen) > s.retention {
+				delete(s.lastSeen, serial)
+				delete(s.known, serial)
+				delete(s.daemonWritten, serial)
+				pruned = append(pruned, serial)
+			}
+		}
+	}
+	s.knownMu.Unlock()
+
+	if len(pruned) == 0 {
+		return nil
+	}
+
+	s.modesMu.Lock()
+	for _, serial := range pruned {
+		delete(s.displayModes, serial)
+	}
+	s.modesMu.Unlock()
+
+	for _, serial := range pruned {
+		s.invalidateCachedBrightness(serial)
+		log.Info().Str("serial", serial).Dur("retention", s.retention).Msg("Forgot state of long-gone display")
+	}
+	return pruned
+}
@@ -0,0 +1,59 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_PruneStaleState(t *testing.T) {
+	const retention = 30 * 24 * time.Hour
+
+	clock := newFakeClock()
+	manager := newFakeManager(newFakeDevice("OLD", 10), newFakeDevice("RECENT", 10))
+	server := NewServer(manager, WithStateRetention(retention))
+	server.now = clock.Now
+
+	require.Nil(t, server.SetBrightness("OLD", 40))
+	require.Nil(t, server.SetBrightnessMode("OLD", ModeHDR))
+	require.Nil(t, server.SetBrightness("RECENT", 60))
+	assert.Empty(t, server.PruneStaleState())
+
+	// OLD is unplugged for good; RECENT stays connected for a while, then goes too
+	delete(manager.displayMap, "OLD")
+	manager.displays = manager.displays[1:]
+	clock.Advance(retention - time.Hour)
+	assert.Empty(t, server.PruneStaleState())
+	manager.displays = nil
+	delete(manager.displayMap, "RECENT")
+
+	clock.Advance(2 * time.Hour)
+	assert.Equal(t, []string{"OLD"}, server.PruneStaleState())
+
+	_, known := server.knownBrightness("OLD")
+	assert.False(t, known, "state older than the retention window should be pruned")
+	assert.Equal(t, ModeSDR, server.modeOf("OLD"))
+
+	brightness, known := server.knownBrightness("RECENT")
+	assert.True(t, known, "recently seen state should be kept")
+	assert.Equal(t, uint32(60), brightness)
+}
+
+func TestServer_PruneStaleState_DisabledByDefault(t *testing.T) {
+	clock := newFakeClock()
+	manager := newFakeManager(newFakeDevice("A", 10))
+	server := NewServer(manager)
+	server.now = clock.Now
+
+	require.Nil(t, server.SetBrightness("A", 40))
+	manager.displays = nil
+	clock.Advance(365 * 24 * time.Hour)
+
+	assert.Empty(t, server.PruneStaleState())
+	_, known := server.knownBrightness("A")
+	assert.True(t, known)
+}
@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidSerial is returned when a serial number is too long or contains
+// characters a display serial never has.
+var ErrInvalidSerial = errors.New("invalid serial")
+
+// maxSerialLength is the longest serial accepted from clients. Studio Display
+// serials are 12 characters; the margin leaves room for other HID serial formats.
+const maxSerialLength = 64
+
+// ValidateSerial reports whether serial would be accepted by the D-Bus methods,
+// e.g. to check a display alias from the daemon configuration.
+func ValidateSerial(serial string) error {
+	return validateSerial(serial)
+}
+
+// validateSerial checks a client-supplied serial before it reaches the manager,
+// logs or any per-display map. Serials may contain ASCII letters, digits, '-',
+// '_' and '.'. The error doesn't quote the serial, so control characters never
+// end up in replies or logs.
+func validateSerial(serial string) error {
+	if serial == "" {
+		return ErrEmptySerial
+	}
+	if len(serial) > maxSerialLength {
+		return fmt.Errorf("%w: longer than %d characters", ErrInvalidSerial, maxSerialLength)
+	}
+	for i := range len(serial) {
+		if !isSerialChar(serial[i]) {
+			return fmt.Errorf("%w: unexpected character at position %d", ErrInvalidSerial, i)
+		}
+	}
+	return nil
+}
+
+// isSerialChar reports whether c may appear in a serial.
+func isSerialChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	case c == '-', c == '_', c == '.':
+		return true
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSerial(t *testing.T) {
+	tests := []struct {
+		name    string
+		serial  string
+		wantErr error
+	}{
+		{name: "studio display serial", serial: "C02XK1Y2Q6NV"},
+		{name: "punctuation", serial: "usb-1.2_A"},
+		{name: "maximum length", serial: strings.Repeat("A", maxSerialLength)},
+		{name: "empty", serial: "", wantErr: ErrEmptySerial},
+		{name: "too long", serial: strings.Repeat("A", maxSerialLength+1), wantErr: ErrInvalidSerial},
+		{name: "newline", serial: "ABC\nDEF", wantErr: ErrInvalidSerial},
+		{name: "escape sequence", serial: "ABC\x1b[2J", wantErr: ErrInvalidSerial},
+		{name: "nul byte", serial: "ABC\x00", wantErr: ErrInvalidSerial},
+		{name: "space", serial: "ABC DEF", wantErr: ErrInvalidSerial},
+		{name: "non-ascii", serial: "ABCÉ", wantErr: ErrInvalidSerial},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSerial(tt.serial)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+			if tt.serial == "" {
+				return
+			}
+			assert.NotContains(t, err.Error(), tt.serial, "error must not echo the serial")
+		})
+	}
+}
+
+func TestServer_RejectsMalformedSerials(t *testing.T) {
+	display := newFakeDevice("A", 50)
+	manager := newFakeManager(display)
+	server := NewServer(manager)
+
+	_, err := server.GetBrightness("A\n")
+	require.NotNil(t, err)
+	assert.Contains(t, err.Body[0], ErrInvalidSerial.Error())
+
+	err = server.SetBrightness("A\r\nINJECTED", 10)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Body[0], ErrInvalidSerial.Error())
+	assert.Equal(t, 0, display.writeCount())
+
+	failures, err := server.SetBrightnessMap(map[string]uint32{"A": 20, "B\x00": 30})
+	require.Nil(t, err)
+	assert.Len(t, failures, 1)
+	assert.Contains(t, failures["B\x00"], ErrInvalidSerial.Error())
+	assert.Equal(t, uint8(20), display.percent())
+}
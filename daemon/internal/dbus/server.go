@@ -6,13 +6,18 @@ package dbus
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/godbus/dbus/v5"
 	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
 	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/brightness"
 	"github.com/shini4i/asd-brightness-daemon/internal/hid"
-	"golang.org/x/time/rate"
 )
 
 // ErrEmptySerial is returned when an empty serial number is provided.
@@ -21,15 +26,23 @@ var ErrEmptySerial = errors.New("serial cannot be empty")
 // ErrRateLimitExceeded is returned when brightness change requests exceed the rate limit.
 var ErrRateLimitExceeded = errors.New("rate limit exceeded")
 
+// ErrAlreadyStarted is returned by Start when the server is already running.
+var ErrAlreadyStarted = errors.New("server already started")
+
 // ErrInvalidStep is returned when an invalid brightness step value is provided.
 var ErrInvalidStep = errors.New("step must be between 1 and 100")
 
 const (
-	// rateLimitPerSecond is the maximum number of brightness changes per second.
+	// rateLimitPerSecond is the maximum number of brightness changes per second,
+	// per display and separately for operations on all displays.
 	rateLimitPerSecond = 20
 
-	// rateLimitBurst is the maximum burst size for brightness changes.
+	// rateLimitBurst is the maximum burst size for brightness changes, per display.
 	rateLimitBurst = 5
+
+	// rateLimitSignalInterval is the minimum time between RateLimited signals,
+	// so a client hammering the limiter doesn't also flood the bus with signals.
+	rateLimitSignalInterval = time.Second
 )
 
 const (
@@ -43,45 +56,555 @@ const (
 	InterfaceName = "io.github.shini4i.AsdBrightness"
 )
 
+// docNamespace is the XML namespace for freedesktop.org documentation elements
+// embedded in the introspection data.
+const docNamespace = "http://www.freedesktop.org/dbus/1.0/doc.dtd"
+
+// ReadOnlyAnnotation marks methods that only query state and never change
+// display brightness. Clients may use it to decide which calls are safe to
+// issue speculatively (e.g. for polling or UI refresh).
+const ReadOnlyAnnotation = InterfaceName + ".ReadOnly"
+
 // IntrospectXML is the D-Bus introspection XML for the service.
+// Arguments carry doc:doc elements describing units and valid ranges so that
+// D-Bus browsers (d-feet, D-Spy) and generated bindings can surface them.
 const IntrospectXML = `
-<node name="` + ObjectPath + `">
+<node name="` + ObjectPath + `" xmlns:doc="` + docNamespace + `">
   <interface name="` + InterfaceName + `">
+    <property name="DisplayCount" type="u" access="read">
+      <annotation name="org.freedesktop.DBus.Property.EmitsChangedSignal" value="true"/>
+      <doc:doc><doc:description><doc:para>Number of connected displays. PropertiesChanged is emitted when displays connect or disconnect.</doc:para></doc:description></doc:doc>
+    </property>
+    <property name="ExternalOnly" type="b" access="read">
+      <annotation name="org.freedesktop.DBus.Property.EmitsChangedSignal" value="true"/>
+      <doc:doc><doc:description><doc:para>Whether external-only mode is on. See SetExternalOnlyMode.</doc:para></doc:description></doc:doc>
+    </property>
     <method name="ListDisplays">
-      <arg name="displays" type="a(ss)" direction="out"/>
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>List all connected Apple Studio Displays.</doc:para></doc:description></doc:doc>
+      <arg name="displays" type="a(ss)" direction="out">
+        <doc:doc><doc:summary>Array of (serial, productName) structs</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="GetKnownSerials">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>List the serial of every display seen since the daemon started, including displays that have since disconnected.</doc:para></doc:description></doc:doc>
+      <arg name="serials" type="as" direction="out">
+        <doc:doc><doc:summary>Serial numbers in ascending order</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="ListHealthyDisplays">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>List connected displays whose last brightness read or write succeeded, leaving out displays that are failing or being recovered.</doc:para></doc:description></doc:doc>
+      <arg name="displays" type="a(ss)" direction="out">
+        <doc:doc><doc:summary>Array of (serial, productName) structs, sorted by serial</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="ResolveSerial">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Resolve a unique part of a display serial, e.g. a prefix or suffix, to the full serial. An exact match takes precedence; a query matching several displays fails.</doc:para></doc:description></doc:doc>
+      <arg name="query" type="s" direction="in"/>
+      <arg name="serial" type="s" direction="out"/>
+    </method>
+    <method name="GetInitialState">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Return every connected display with its current brightness in one call, for client bootstrap. Displays that fail to read report the last known brightness, if any.</doc:para></doc:description></doc:doc>
+      <arg name="displays" type="a(ssubs)" direction="out">
+        <doc:doc><doc:summary>Array of (serial, productName, brightness, brightnessKnown, mode) structs, sorted by serial</doc:summary></doc:doc>
+      </arg>
     </method>
     <method name="GetBrightness">
-      <arg name="serial" type="s" direction="in"/>
-      <arg name="brightness" type="u" direction="out"/>
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Read the current brightness of a display.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="brightness" type="u" direction="out">
+        <doc:doc><doc:summary>Brightness as a percentage (0-100)</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="GetBrightnessByConnector">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Read the current brightness of the display on a DRM connector, like GetBrightness.</doc:para></doc:description></doc:doc>
+      <arg name="connector" type="s" direction="in">
+        <doc:doc><doc:summary>Connector name as shown by the compositor, e.g. DP-1</doc:summary></doc:doc>
+      </arg>
+      <arg name="brightness" type="u" direction="out">
+        <doc:doc><doc:summary>Brightness as a percentage (0-100)</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="GetBrightnessFraction">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Read the current brightness of a display as a fraction of its nits range, without rounding to whole percentages.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="fraction" type="d" direction="out">
+        <doc:doc><doc:summary>Brightness from 0.0 (minimum nits) to 1.0 (maximum nits)</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="SetBrightnessFraction">
+      <doc:doc><doc:description><doc:para>Set the brightness of a display to a fraction of its nits range, for continuous control. Brightness floors, caps and stops apply.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="fraction" type="d" direction="in">
+        <doc:doc><doc:summary>Brightness from 0.0 (minimum nits) to 1.0 (maximum nits); values outside are clamped</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="GetBrightnessDetailed">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Read the current brightness of a display, reporting whether the value is the last known brightness returned because the read failed (only with --stale-brightness-fallback or while the display warms up).</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="brightness" type="u" direction="out">
+        <doc:doc><doc:summary>Brightness as a percentage (0-100)</doc:summary></doc:doc>
+      </arg>
+      <arg name="stale" type="b" direction="out">
+        <doc:doc><doc:summary>True if the read failed and the last known brightness was returned</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="GetReferenceBrightness">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Read the factory-calibrated reference white luminance of a display. Fails on displays that don't provide calibration data.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="nits" type="u" direction="out">
+        <doc:doc><doc:summary>Reference white luminance in nits</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="GetBrightnessStops">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Read the brightness stops. When set, every absolute brightness set rounds to the nearest stop and IncreaseBrightness and DecreaseBrightness move between stops.</doc:para></doc:description></doc:doc>
+      <arg name="stops" type="au" direction="out">
+        <doc:doc><doc:summary>Stops as ascending percentages (0-100); empty if snapping is disabled</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="GetDisplayConfig">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Read the configuration in effect for a display: its per-display overrides from the daemon configuration layered over the global settings.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="config" type="(sssuui)" direction="out">
+        <doc:doc><doc:summary>Serial, alias (empty if none), curve, minimum brightness, maximum brightness and startup brightness (-1 if none), as percentages</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="GetStats">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Read cumulative operation counters since the daemon started or ResetStats was last called.</doc:para></doc:description></doc:doc>
+      <arg name="stats" type="(ttttt)" direction="out">
+        <doc:doc><doc:summary>Brightness sets, brightness gets, device errors, device recoveries and rate limit hits</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="ResetStats">
+      <doc:doc><doc:description><doc:para>Reset every counter returned by GetStats to zero.</doc:para></doc:description></doc:doc>
+    </method>
+    <method name="GetMinBrightness">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Read the configured brightness floor. Every brightness change is clamped up to it.</doc:para></doc:description></doc:doc>
+      <arg name="brightness" type="u" direction="out">
+        <doc:doc><doc:summary>Minimum brightness as a percentage (0-100)</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="GetBrightnessCurve">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Return the mapping between brightness percentage and the nits value written to a display, sampled every 5% from 0 to 100, so clients can interpolate locally.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="curve" type="s" direction="out">
+        <doc:doc><doc:summary>Curve type in effect: "linear" or "perceptual"</doc:summary></doc:doc>
+      </arg>
+      <arg name="samples" type="a(uu)" direction="out">
+        <doc:doc><doc:summary>Percentage and nits of each sample, in ascending order</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="SetCurveOverride">
+      <doc:doc><doc:description><doc:para>Temporarily replace the curve configured for a display, e.g. for calibration. The override lasts until ClearCurveOverride or until the display disconnects. The display keeps its brightness in nits; a resulting change of its percentage is reported by BrightnessChanged.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="curve" type="s" direction="in">
+        <doc:doc><doc:summary>Curve type: "linear" or "perceptual"</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="ClearCurveOverride">
+      <doc:doc><doc:description><doc:para>Restore the configured curve of a display. Displays without an override are left alone.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="GetCurveOverride">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Return the curve in effect for a display and whether it was set by SetCurveOverride.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="curve" type="s" direction="out">
+        <doc:doc><doc:summary>Curve type in effect</doc:summary></doc:doc>
+      </arg>
+      <arg name="overridden" type="b" direction="out">
+        <doc:doc><doc:summary>True if the curve is a temporary override</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="GetWarmUpPolicy">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Report how GetBrightness answers for displays that connected within the warm-up period.</doc:para></doc:description></doc:doc>
+      <arg name="policy" type="s" direction="out">
+        <doc:doc><doc:summary>"block", "last-known" or "error"</doc:summary></doc:doc>
+      </arg>
+      <arg name="periodMs" type="u" direction="out">
+        <doc:doc><doc:summary>Warm-up period in milliseconds; 0 if warm-up handling is disabled</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="GetAllBrightness">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Read the brightness of every display in one sweep. Displays on the same USB controller are read one after another when controller write queuing is enabled; others are read in parallel. Displays that fail to read are left out.</doc:para></doc:description></doc:doc>
+      <arg name="brightness" type="a{su}" direction="out">
+        <doc:doc><doc:summary>Map of serial to brightness percentage (0-100)</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="GetBrightnessSummary">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Read all displays like GetAllBrightness and summarize their brightness. Displays that fail to read are left out; all fields are 0 when no displays are connected.</doc:para></doc:description></doc:doc>
+      <arg name="summary" type="(uuuu)" direction="out">
+        <doc:doc><doc:summary>Number of displays read, minimum, maximum and average brightness percentage</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="SendRawFeatureReport">
+      <doc:doc><doc:description><doc:para>Debugging aid: write an arbitrary feature report to a display and read the report with the same ID back. Fails unless the daemon was started with --enable-raw.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="data" type="ay" direction="in">
+        <doc:doc><doc:summary>Feature report starting with the report ID, 2-4096 bytes</doc:summary></doc:doc>
+      </arg>
+      <arg name="reply" type="ay" direction="out">
+        <doc:doc><doc:summary>Feature report read back, starting with the report ID</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="GetCachedDisplayInfo">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Read the daemon's cached view of a display without any HID I/O. The brightness is the last value the daemon set or observed, not a fresh reading.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="info" type="(ssubs)" direction="out">
+        <doc:doc><doc:summary>Serial, product name, last-known brightness percentage, whether the brightness is known, and active brightness mode</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="GetSupportedFeatures">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>List the features supported by this daemon, independent of any display.</doc:para></doc:description></doc:doc>
+      <arg name="features" type="as" direction="out">
+        <doc:doc><doc:summary>Sorted feature names, e.g. "fade", "mirror", "idle-dim"</doc:summary></doc:doc>
+      </arg>
     </method>
     <method name="SetBrightness">
-      <arg name="serial" type="s" direction="in"/>
-      <arg name="brightness" type="u" direction="in"/>
+      <doc:doc><doc:description><doc:para>Set the brightness of a display. Subject to rate limiting.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="brightness" type="u" direction="in">
+        <doc:doc><doc:summary>Brightness as a percentage (0-100); larger values are clamped to 100</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="SetBrightnessByConnector">
+      <doc:doc><doc:description><doc:para>Set the brightness of the display on a DRM connector, like SetBrightness. Only available if the daemon maps displays to connectors (feature "connectors").</doc:para></doc:description></doc:doc>
+      <arg name="connector" type="s" direction="in">
+        <doc:doc><doc:summary>Connector name as shown by the compositor, e.g. DP-1</doc:summary></doc:doc>
+      </arg>
+      <arg name="brightness" type="u" direction="in">
+        <doc:doc><doc:summary>Brightness as a percentage (0-100); larger values are clamped to 100</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="SetBrightnessChecked">
+      <doc:doc><doc:description><doc:para>Set the brightness like SetBrightness and report whether anything changed. BrightnessChanged is only emitted if it did. The current brightness is the last one the daemon knows of unless the daemon is configured to read it from the display first.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="brightness" type="u" direction="in">
+        <doc:doc><doc:summary>Brightness as a percentage (0-100); larger values are clamped to 100</doc:summary></doc:doc>
+      </arg>
+      <arg name="changed" type="b" direction="out">
+        <doc:doc><doc:summary>False if the display was already at the requested brightness</doc:summary></doc:doc>
+      </arg>
     </method>
     <method name="IncreaseBrightness">
-      <arg name="serial" type="s" direction="in"/>
-      <arg name="step" type="u" direction="in"/>
+      <doc:doc><doc:description><doc:para>Increase the brightness of a display by a step. Subject to rate limiting.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="step" type="u" direction="in">
+        <doc:doc><doc:summary>Step in percentage points (1-100); the result is clamped to 100</doc:summary></doc:doc>
+      </arg>
     </method>
     <method name="DecreaseBrightness">
-      <arg name="serial" type="s" direction="in"/>
-      <arg name="step" type="u" direction="in"/>
+      <doc:doc><doc:description><doc:para>Decrease the brightness of a display by a step. Subject to rate limiting.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="step" type="u" direction="in">
+        <doc:doc><doc:summary>Step in percentage points (1-100); the result is clamped to 0</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="ResetBrightness">
+      <doc:doc><doc:description><doc:para>Set a display to the default brightness configured with --default-brightness. Subject to rate limiting.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="ResetAllBrightness">
+      <doc:doc><doc:description><doc:para>Set all displays to the default brightness configured with --default-brightness. Subject to rate limiting.</doc:para></doc:description></doc:doc>
     </method>
     <method name="SetAllBrightness">
-      <arg name="brightness" type="u" direction="in"/>
+      <doc:doc><doc:description><doc:para>Set the brightness of every connected display. Subject to rate limiting.</doc:para></doc:description></doc:doc>
+      <arg name="brightness" type="u" direction="in">
+        <doc:doc><doc:summary>Brightness as a percentage (0-100); larger values are clamped to 100</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="IncreaseAllBrightness">
+      <doc:doc><doc:description><doc:para>Raise the brightness of every connected display by a step, relative to each display's current value. Subject to rate limiting.</doc:para></doc:description></doc:doc>
+      <arg name="step" type="u" direction="in">
+        <doc:doc><doc:summary>Step in percentage points (1-100); each result is clamped to 0-100</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="DecreaseAllBrightness">
+      <doc:doc><doc:description><doc:para>Lower the brightness of every connected display by a step, relative to each display's current value. Subject to rate limiting.</doc:para></doc:description></doc:doc>
+      <arg name="step" type="u" direction="in">
+        <doc:doc><doc:summary>Step in percentage points (1-100); each result is clamped to 0-100</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="FadeBrightness">
+      <doc:doc><doc:description><doc:para>Gradually change the brightness of a display. Returns immediately; BrightnessChanged is emitted when the fade completes. Subject to rate limiting.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="brightness" type="u" direction="in">
+        <doc:doc><doc:summary>Target brightness as a percentage (0-100); larger values are clamped to 100</doc:summary></doc:doc>
+      </arg>
+      <arg name="durationMs" type="u" direction="in">
+        <doc:doc><doc:summary>Fade duration in milliseconds (0-60000); 0 sets the brightness instantly</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="SetBrightnessTimed">
+      <doc:doc><doc:description><doc:para>Set the brightness of a display, optionally fading to it. With a duration of 0 this is SetBrightness; otherwise it is FadeBrightness and returns immediately. Subject to rate limiting.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="brightness" type="u" direction="in">
+        <doc:doc><doc:summary>Target brightness as a percentage (0-100); larger values are clamped to 100</doc:summary></doc:doc>
+      </arg>
+      <arg name="durationMs" type="u" direction="in">
+        <doc:doc><doc:summary>Fade duration in milliseconds (0-60000); 0 sets the brightness instantly</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="SetBrightnessMap">
+      <doc:doc><doc:description><doc:para>Set several displays to individual brightness values in one call. Subject to rate limiting.</doc:para></doc:description></doc:doc>
+      <arg name="values" type="a{su}" direction="in">
+        <doc:doc><doc:summary>Map of serial to brightness percentage (0-100); larger values are clamped to 100</doc:summary></doc:doc>
+      </arg>
+      <arg name="errors" type="a{ss}" direction="out">
+        <doc:doc><doc:summary>Map of serial to error message for displays that could not be set; empty on full success</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="EnableIdleDim">
+      <doc:doc><doc:description><doc:para>Dim all displays after a period without activity and restore them on the next activity. BrightnessChanged is emitted for dimmed and restored displays unless silenced in the daemon configuration.</doc:para></doc:description></doc:doc>
+      <arg name="timeoutSec" type="u" direction="in">
+        <doc:doc><doc:summary>Seconds without activity before dimming (at least 1)</doc:summary></doc:doc>
+      </arg>
+      <arg name="dimPercent" type="u" direction="in">
+        <doc:doc><doc:summary>Brightness percentage (0-100) to dim to; brighter displays are never raised</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="DisableIdleDim">
+      <doc:doc><doc:description><doc:para>Disable idle dimming, restoring dimmed displays.</doc:para></doc:description></doc:doc>
+    </method>
+    <method name="NotifyActivity">
+      <doc:doc><doc:description><doc:para>Report user activity, restoring displays dimmed by idle dimming and waking displays it put in standby.</doc:para></doc:description></doc:doc>
+    </method>
+    <method name="SetStandby">
+      <doc:doc><doc:description><doc:para>Put a display's panel into standby or wake it. Fails on displays without standby control; see GetStandbySupported. Subject to rate limiting.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="enabled" type="b" direction="in">
+        <doc:doc><doc:summary>True to enter standby, false to wake</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="GetStandbySupported">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Report whether a display provides standby control. The display is queried once and the answer remembered.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="supported" type="b" direction="out">
+        <doc:doc><doc:summary>True if SetStandby works on the display</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="SetBrightnessMode">
+      <doc:doc><doc:description><doc:para>Switch a display into a brightness mode. The mode's cap applies to all further brightness changes and the display is set to the mode's level. Subject to rate limiting.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="mode" type="s" direction="in">
+        <doc:doc><doc:summary>Mode name: "sdr" or "hdr"</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="GetBrightnessMode">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Read the active brightness mode of a display.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s" direction="in">
+        <doc:doc><doc:summary>Display serial number as returned by ListDisplays</doc:summary></doc:doc>
+      </arg>
+      <arg name="mode" type="s" direction="out">
+        <doc:doc><doc:summary>Mode name; "sdr" unless changed with SetBrightnessMode</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="Pause">
+      <doc:doc><doc:description><doc:para>Suspend automatic brightness changes (idle dimming and mirroring) until Resume is called. Explicit brightness requests still work.</doc:para></doc:description></doc:doc>
+    </method>
+    <method name="Resume">
+      <doc:doc><doc:description><doc:para>Re-enable automatic brightness changes suspended by Pause.</doc:para></doc:description></doc:doc>
+    </method>
+    <method name="GetHIDStatus">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Report whether the HID library is usable. While it isn't, the daemon stays on the bus in degraded mode, reports no displays and keeps retrying.</doc:para></doc:description></doc:doc>
+      <arg name="available" type="b" direction="out"/>
+      <arg name="error" type="s" direction="out">
+        <doc:doc><doc:summary>Why HID is unavailable; empty while it's available</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="IsPaused">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Report whether automatic brightness changes are paused.</doc:para></doc:description></doc:doc>
+      <arg name="paused" type="b" direction="out"/>
+    </method>
+    <method name="SetNightMode">
+      <doc:doc><doc:description><doc:para>Dim all displays to the night brightness and cap them until night mode is turned off, which restores the previous brightness.</doc:para></doc:description></doc:doc>
+      <arg name="enabled" type="b" direction="in"/>
+    </method>
+    <method name="GetNightMode">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Report whether night mode is on.</doc:para></doc:description></doc:doc>
+      <arg name="enabled" type="b" direction="out"/>
+    </method>
+    <method name="SetExternalOnlyMode">
+      <doc:doc><doc:description><doc:para>Apply the external-only brightness profile to all displays and cap them until the mode is turned off, which restores the previous brightness. Meant for integrations that know when the Studio Displays are the only screens in use, e.g. on lid close.</doc:para></doc:description></doc:doc>
+      <arg name="enabled" type="b" direction="in"/>
+    </method>
+    <method name="GetExternalOnlyMode">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Report whether external-only mode is on. Also available as the ExternalOnly property.</doc:para></doc:description></doc:doc>
+      <arg name="enabled" type="b" direction="out"/>
+    </method>
+    <method name="EnableMirror">
+      <doc:doc><doc:description><doc:para>Make all other displays follow the brightness of a primary display.</doc:para></doc:description></doc:doc>
+      <arg name="primarySerial" type="s" direction="in">
+        <doc:doc><doc:summary>Serial of the display whose brightness is mirrored</doc:summary></doc:doc>
+      </arg>
+    </method>
+    <method name="DisableMirror">
+      <doc:doc><doc:description><doc:para>Stop mirroring brightness between displays.</doc:para></doc:description></doc:doc>
     </method>
     <signal name="DisplayAdded">
+      <doc:doc><doc:description><doc:para>Emitted when a display is connected.</doc:para></doc:description></doc:doc>
       <arg name="serial" type="s"/>
       <arg name="productName" type="s"/>
     </signal>
+    <signal name="DisplayAddedV2">
+      <doc:doc><doc:description><doc:para>Emitted after DisplayAdded with the display's full device info, if enabled in the daemon configuration (feature display-added-v2).</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s"/>
+      <arg name="info" type="a{sv}">
+        <doc:doc><doc:summary>Serial (s), ProductName (s), Manufacturer (s), Path (s), VendorID (q), ProductID (q) and Interface (i)</doc:summary></doc:doc>
+      </arg>
+    </signal>
     <signal name="DisplayRemoved">
+      <doc:doc><doc:description><doc:para>Emitted when a display is disconnected.</doc:para></doc:description></doc:doc>
       <arg name="serial" type="s"/>
     </signal>
+    <signal name="HIDStatusChanged">
+      <doc:doc><doc:description><doc:para>Emitted when the HID library becomes unusable or usable again. See GetHIDStatus.</doc:para></doc:description></doc:doc>
+      <arg name="available" type="b"/>
+      <arg name="error" type="s">
+        <doc:doc><doc:summary>Why HID is unavailable; empty while it's available</doc:summary></doc:doc>
+      </arg>
+    </signal>
+    <signal name="RateLimited">
+      <doc:doc><doc:description><doc:para>Emitted (at most once per second) when a call is rejected by the rate limiter. Clients should slow down their updates.</doc:para></doc:description></doc:doc>
+      <arg name="method" type="s">
+        <doc:doc><doc:summary>Name of the rejected method</doc:summary></doc:doc>
+      </arg>
+    </signal>
     <signal name="BrightnessChanged">
+      <doc:doc><doc:description><doc:para>Emitted after the daemon changes the brightness of a display.</doc:para></doc:description></doc:doc>
       <arg name="serial" type="s"/>
-      <arg name="brightness" type="u"/>
+      <arg name="brightness" type="u">
+        <doc:doc><doc:summary>Brightness as a percentage (0-100)</doc:summary></doc:doc>
+      </arg>
+    </signal>
+    <signal name="BrightnessContention">
+      <doc:doc><doc:description><doc:para>Emitted when a display's brightness keeps reversing direction in a short time, which usually means several clients are setting conflicting values. Clients should back off. Only emitted if enabled in the daemon configuration; the changes themselves are never blocked.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s"/>
+    </signal>
+    <signal name="BrightnessLimitReached">
+      <doc:doc><doc:description><doc:para>Emitted after IncreaseBrightness or DecreaseBrightness succeeded but was clamped at a bound, so clients can give feedback such as a bump animation. Bounds include a brightness mode or night mode cap, the brightness floor and the outermost brightness stop.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s"/>
+      <arg name="limit" type="s">
+        <doc:doc><doc:summary>"min" or "max"</doc:summary></doc:doc>
+      </arg>
+    </signal>
+    <signal name="NightModeChanged">
+      <doc:doc><doc:description><doc:para>Emitted when night mode is turned on or off.</doc:para></doc:description></doc:doc>
+      <arg name="enabled" type="b"/>
+    </signal>
+    <signal name="StandbyChanged">
+      <doc:doc><doc:description><doc:para>Emitted when a display enters or leaves standby, through SetStandby or idle standby.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s"/>
+      <arg name="standby" type="b"/>
+    </signal>
+    <signal name="ExternalOnlyModeChanged">
+      <doc:doc><doc:description><doc:para>Emitted when external-only mode is turned on or off.</doc:para></doc:description></doc:doc>
+      <arg name="enabled" type="b"/>
+    </signal>
+    <signal name="AllBrightnessChanged">
+      <doc:doc><doc:description><doc:para>Emitted once after SetAllBrightness or ResetAllBrightness changed at least one display. Per-display BrightnessChanged signals are emitted as well unless disabled in the daemon configuration.</doc:para></doc:description></doc:doc>
+      <arg name="brightness" type="u">
+        <doc:doc><doc:summary>Requested brightness as a percentage (0-100); displays in a capped brightness mode may be lower</doc:summary></doc:doc>
+      </arg>
+    </signal>
+    <signal name="BrightnessChangedV2">
+      <doc:doc><doc:description><doc:para>Emitted alongside BrightnessChanged with additional detail. Signal signatures are never changed once published; richer payloads are added as a new signal with a V&lt;n&gt; suffix and both are emitted until the older one is retired.</doc:para></doc:description></doc:doc>
+      <arg name="serial" type="s"/>
+      <arg name="brightness" type="u">
+        <doc:doc><doc:summary>Brightness as a percentage (0-100)</doc:summary></doc:doc>
+      </arg>
+      <arg name="nits" type="u">
+        <doc:doc><doc:summary>Brightness in nits, as written to the display</doc:summary></doc:doc>
+      </arg>
+    </signal>
+  </interface>
+  <interface name="` + ObjectManagerInterface + `">
+    <method name="GetManagedObjects">
+      <annotation name="` + ReadOnlyAnnotation + `" value="true"/>
+      <doc:doc><doc:description><doc:para>Return every display as a child object implementing ` + DisplayInterfaceName + ` with its Serial, ProductName, Brightness, BrightnessMode and, if known, USBSpeed properties.</doc:para></doc:description></doc:doc>
+      <arg name="objects" type="a{oa{sa{sv}}}" direction="out"/>
+    </method>
+    <signal name="InterfacesAdded">
+      <arg name="object" type="o"/>
+      <arg name="interfaces" type="a{sa{sv}}"/>
+    </signal>
+    <signal name="InterfacesRemoved">
+      <arg name="object" type="o"/>
+      <arg name="interfaces" type="as"/>
     </signal>
   </interface>
+  ` + prop.IntrospectDataString + `
   ` + introspect.IntrospectDataString + `
+  <node name="displays"/>
 </node>
 `
 
@@ -94,8 +617,15 @@ type DisplayManager interface {
 	// GetDisplay returns a display by serial number.
 	GetDisplay(serial string) (*hid.Display, error)
 
+	// Snapshot returns a consistent copy of all tracked displays keyed by serial.
+	Snapshot() map[string]*hid.Display
+
 	// RefreshDisplays re-enumerates connected displays.
 	RefreshDisplays() error
+
+	// KnownSerials returns the serials of all displays tracked since startup,
+	// connected or not.
+	KnownSerials() []string
 }
 
 // DeviceErrorHandler is called when a device error (e.g., device disconnected) is detected.
@@ -113,30 +643,186 @@ type DisplayInfo struct {
 //
 // Thread safety:
 //   - The underlying Manager and Display types are individually thread-safe.
-//   - The connMu mutex protects the D-Bus connection field for signal emission.
+//   - The startMu mutex protects the started flag and serializes Start and Stop.
+//   - The connMu mutex protects the D-Bus connection and emitter fields for signal emission.
 //   - The handlerMu mutex protects the deviceErrorHandler field.
-//   - Note: IncreaseBrightness and DecreaseBrightness perform non-atomic
-//     read-modify-write operations. Concurrent calls may result in missed
-//     increments. This is acceptable for typical keyboard shortcut usage.
+//   - The mirrorMu mutex protects the mirror primary serial.
+//   - The idleMu mutex protects idle dimming state and serializes dim/restore.
+//   - The fadeMu mutex protects the set of running fades.
+//   - The smoothMu mutex protects the set of running smoothed external change reports.
+//   - The modesMu mutex protects the active brightness mode per display.
+//   - The nightMu mutex protects the brightness saved by night mode and serializes toggling it.
+//   - The externalOnlyMu mutex does the same for external-only mode.
+//   - The knownMu mutex protects the last reported brightness, the brightness the
+//     daemon silently wrote and the last-seen time per display.
+//   - The cacheMu mutex protects the GetBrightness cache and its invalidation counts.
+//   - The contentionMu mutex protects brightness oscillation tracking.
+//   - The warmUpMu mutex protects the connect times of warming displays.
+//   - The stepMu mutex protects steps queued by step coalescing.
+//   - The hidMu mutex protects the HID library status.
+//   - The curveMu mutex protects curve overrides.
+//   - The connectorMu mutex protects the connector to serial mapping.
+//   - The recoveryMu mutex protects the time of the last recovery per display and
+//     the recovery workers and queue.
+//   - Per-serial locks (see serialLocks) serialize client-initiated writes to a
+//     display, making IncreaseBrightness and DecreaseBrightness atomic
+//     read-modify-write operations. Bulk operations take them in sorted order.
 type Server struct {
-	conn               *dbus.Conn
-	connMu             sync.RWMutex // Protects conn field only
-	manager            DisplayManager
-	rateLimiter        *rate.Limiter
-	handlerMu          sync.RWMutex // Protects deviceErrorHandler
-	deviceErrorHandler DeviceErrorHandler
+	startMu             sync.Mutex // Protects started
+	started             bool       // Whether Start succeeded and Stop hasn't run since
+	conn                *dbus.Conn
+	emitter             signalEmitter // Signal sink; set to conn while started
+	connMu              sync.RWMutex  // Protects conn and emitter fields
+	manager             DisplayManager
+	rateLimits          *rateLimiters // Per-display and all-display rate limits; nil if disabled
+	handlerMu           sync.RWMutex  // Protects deviceErrorHandler
+	deviceErrorHandler  DeviceErrorHandler
+	mirrorMu            sync.RWMutex // Protects mirrorPrimary
+	mirrorPrimary       string       // Serial whose brightness is mirrored; empty if disabled
+	notFoundPolicy      NotFoundPolicy
+	now                 func() time.Time
+	rateSignalMu        sync.Mutex // Protects lastRateSignal
+	lastRateSignal      time.Time  // When RateLimited was last emitted
+	idleMu              sync.Mutex // Protects idle
+	idle                idleDimState
+	serialLocks         serialLocks // Per-display write locks
+	fadeMu              sync.Mutex  // Protects fades
+	fades               map[string]*fadeJob
+	extraFeatures       []string                  // Runtime features reported by GetSupportedFeatures
+	defaultBrightness   uint32                    // Target of ResetBrightness, as a percentage
+	minBrightness       uint32                    // Floor applied to every brightness change, as a percentage
+	stops               []uint32                  // Sorted percentages brightness snaps to; empty if disabled
+	silentDaemonChanges bool                      // Don't emit BrightnessChanged for idle dimming and its restore
+	idleStandbyAfter    time.Duration             // Idle time before dimmed displays are put in standby; 0 disables
+	displayConfigs      map[string]DisplayConfig  // Per-display overrides of global settings, keyed by serial
+	connectBrightness   int                       // Global brightness applied on connect; negative if none
+	resetLevel          int                       // Brightness a display falls back to on spontaneous reset; negative if not re-applied
+	modes               map[string]BrightnessMode // Configured brightness modes by name
+	modesMu             sync.RWMutex              // Protects displayModes
+	displayModes        map[string]string         // Active mode per serial; ModeSDR if absent
+	nightMode           BrightnessMode            // Level and cap applied while night mode is on
+	nightOn             atomic.Bool               // Night mode is on
+	nightMu             sync.Mutex                // Protects nightSaved; serializes SetNightMode
+	nightSaved          map[string]uint32         // Brightness per serial before night mode
+	externalOnlyMode    BrightnessMode            // Level and cap applied while external-only mode is on
+	externalOnlyOn      atomic.Bool               // External-only mode is on
+	externalOnlyMu      sync.Mutex                // Protects externalOnlySaved; serializes SetExternalOnlyMode
+	externalOnlySaved   map[string]uint32         // Brightness per serial before external-only mode
+	knownMu             sync.Mutex                // Protects known, daemonWritten and lastSeen
+	known               map[string]uint32         // Last brightness reported per serial
+	daemonWritten       map[string]uint32         // Brightness written silently since, per serial; see applyDaemonBrightness
+	lastSeen            map[string]time.Time      // When each serial was last known to be connected
+	retention           time.Duration             // How long state of disconnected displays is kept; 0 keeps it
+	smoothSteps         int                       // Signals per smoothed external change; <2 disables
+	smoothInterval      time.Duration             // Spacing between smoothed signals
+	smoothMu            sync.Mutex                // Protects smoothing
+	smoothing           map[string]*smoothJob     // Running smoothed external change per serial
+	paused              atomic.Bool               // Suspends automatic brightness changes
+	setAllPerDisplay    bool                      // Emit BrightnessChanged per display on SetAllBrightness
+	cacheTTL            time.Duration             // How long GetBrightness may reuse a reading; 0 disables
+	cacheMu             sync.Mutex                // Protects cache and cacheVersions
+	cacheVersions       map[string]uint64         // Invalidations per serial, see cacheVersion
+	cache               map[string]cachedBrightness
+	contentionMu        sync.Mutex // Protects contention
+	contention          map[string]*contentionState
+	contentionReversals int                         // Direction reversals reported as contention; <1 disables
+	contentionWindow    time.Duration               // Window reversals are counted in
+	contentionSignal    bool                        // Emit BrightnessContention when contention is detected
+	rawReports          bool                        // Allow SendRawFeatureReport
+	checkedSetRead      bool                        // SetBrightnessChecked reads the display instead of using the known value
+	warmUpPeriod        time.Duration               // How long displays warm up after DisplayAdded; 0 disables
+	warmUpPolicy        WarmUpPolicy                // How GetBrightness answers for warming displays
+	warmUpMaxBlock      time.Duration               // Longest wait under WarmUpBlock
+	warmUpMu            sync.Mutex                  // Protects connectedAt
+	connectedAt         map[string]time.Time        // When each warming display connected
+	sleep               func(time.Duration)         // Waits under WarmUpBlock; time.Sleep outside tests
+	stepWindow          time.Duration               // Window Increase/DecreaseBrightness steps are coalesced in; 0 disables
+	stepMu              sync.Mutex                  // Protects pendingSteps
+	pendingSteps        map[string]*pendingStep     // Coalesced step waiting to be applied, per serial
+	maxStepDelta        int                         // Most percent a coalesced write moves brightness; 0 is unlimited
+	staleFallback       bool                        // GetBrightness returns the last known value when a read fails
+	usbSpeed            func(hid.DeviceInfo) string // Reports a display's USB link speed for GetManagedObjects
+	stats               serverStats                 // Counters reported by GetStats
+	hidMu               sync.Mutex                  // Protects hidError
+	hidError            string                      // Why the HID library is unusable; empty if it's usable
+	displayAddedV2      bool                        // Emit DisplayAddedV2 after DisplayAdded
+	curveMu             sync.Mutex                  // Protects curveOverrides
+	curveOverrides      map[string]string           // Curve set by SetCurveOverride, per serial
+	connectorLookup     ConnectorLookup             // Maps DRM connectors to serials; nil disables
+	connectorMu         sync.Mutex                  // Protects connectors
+	connectors          map[string]string           // Serial per connector name, see RefreshConnectors
+	errorDebounce       time.Duration               // Window device errors coalesce into one recovery in; 0 disables
+	recoveryMu          sync.Mutex                  // Protects lastRecovery, recoveryWorkers and pendingRecoveries
+	lastRecovery        map[string]time.Time        // When a device error last triggered a recovery, per serial
+	maxRecoveries       int                         // Device error handlers run at once
+	recoveryWorkers     int                         // Device error handlers running
+	pendingRecoveries   []pendingRecovery           // Recoveries waiting for a worker, at most one per serial
+	reportedCount       atomic.Uint32               // DisplayCount last announced by PropertiesChanged
+}
+
+// signalEmitter sends D-Bus signals. *dbus.Conn implements it.
+type signalEmitter interface {
+	Emit(path dbus.ObjectPath, name string, values ...any) error
+}
+
+// ServerOption is a functional option for configuring a Server.
+type ServerOption func(*Server)
+
+// WithNotFoundPolicy sets how brightness changes targeting an unknown serial are reported.
+func WithNotFoundPolicy(policy NotFoundPolicy) ServerOption {
+	return func(s *Server) {
+		s.notFoundPolicy = policy
+	}
+}
+
+// WithSetAllPerDisplaySignals controls whether SetAllBrightness and ResetAllBrightness
+// emit BrightnessChanged for every display (the default) in addition to the single
+// AllBrightnessChanged signal. Clients that only track a global brightness can turn
+// them off to avoid one signal per display.
+func WithSetAllPerDisplaySignals(enabled bool) ServerOption {
+	return func(s *Server) {
+		s.setAllPerDisplay = enabled
+	}
 }
 
 // NewServer creates a new D-Bus server with the given display manager.
-func NewServer(manager DisplayManager) *Server {
-	return &Server{
-		manager:     manager,
-		rateLimiter: rate.NewLimiter(rateLimitPerSecond, rateLimitBurst),
+func NewServer(manager DisplayManager, opts ...ServerOption) *Server {
+	s := &Server{
+		manager:    manager,
+		rateLimits: newRateLimiters(rateLimitPerSecond, rateLimitBurst),
+		now:        time.Now,
+		usbSpeed:   hid.USBSpeed,
+		sleep:      time.Sleep,
+
+		defaultBrightness: DefaultResetBrightness,
+		connectBrightness: -1,
+		resetLevel:        -1,
+		modes:             DefaultBrightnessModes(),
+		nightMode:         DefaultNightMode,
+		externalOnlyMode:  DefaultExternalOnlyMode,
+		setAllPerDisplay:  true,
+
+		contentionReversals: DefaultContentionReversals,
+		contentionWindow:    DefaultContentionWindow,
+		errorDebounce:       DefaultDeviceErrorDebounce,
+		maxRecoveries:       DefaultMaxConcurrentRecoveries,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // Start connects to the session bus and exports the service.
+// Calling Start on a running server returns ErrAlreadyStarted without touching
+// the bus; a stopped server may be started again.
 func (s *Server) Start() error {
+	s.startMu.Lock()
+	defer s.startMu.Unlock()
+	if s.started {
+		return ErrAlreadyStarted
+	}
+
 	conn, err := dbus.ConnectSessionBus()
 	if err != nil {
 		return fmt.Errorf("failed to connect to session bus: %w", err)
@@ -158,7 +844,27 @@ func (s *Server) Start() error {
 		return fmt.Errorf("failed to export server: %w", err)
 	}
 
-	// Export introspectable interface
+	// Export the object manager and the display child objects it reports
+	err = conn.Export(objectManager{server: s}, ObjectPath, ObjectManagerInterface)
+	if err != nil {
+		return fmt.Errorf("failed to export object manager: %w", err)
+	}
+
+	err = conn.ExportSubtree(displayObjects{server: s}, DisplaysPath, PropertiesInterface)
+	if err != nil {
+		return fmt.Errorf("failed to export display objects: %w", err)
+	}
+
+	err = conn.ExportSubtree(displayIntrospection{server: s}, DisplaysPath, "org.freedesktop.DBus.Introspectable")
+	if err != nil {
+		return fmt.Errorf("failed to export display introspection: %w", err)
+	}
+
+	err = conn.Export(properties{server: s}, ObjectPath, PropertiesInterface)
+	if err != nil {
+		return fmt.Errorf("failed to export properties: %w", err)
+	}
+
 	err = conn.Export(introspect.Introspectable(IntrospectXML), ObjectPath, "org.freedesktop.DBus.Introspectable")
 	if err != nil {
 		return fmt.Errorf("failed to export introspectable: %w", err)
@@ -170,24 +876,61 @@ func (s *Server) Start() error {
 		return fmt.Errorf("failed to request name: %w", err)
 	}
 	if reply != dbus.RequestNameReplyPrimaryOwner {
-		return fmt.Errorf("name %s already taken", ServiceName)
+		return fmt.Errorf("name %s already taken by %s", ServiceName, describeNameOwner(conn.BusObject(), ServiceName))
 	}
 
 	// Store connection with mutex protection
 	s.connMu.Lock()
 	s.conn = conn
+	s.emitter = conn
 	s.connMu.Unlock()
 
 	success = true
+	s.started = true
 	log.Info().Str("service", ServiceName).Msg("D-Bus service started")
 	return nil
 }
 
+// busCaller is the subset of dbus.BusObject used to query the bus daemon.
+type busCaller interface {
+	Call(method string, flags dbus.Flags, args ...any) *dbus.Call
+}
+
+// describeNameOwner returns a human-readable description of the connection that
+// currently owns name, e.g. "PID 1234 (:1.42)", so a failed name request can tell
+// the user which process to look at. Lookup failures degrade to less detail.
+func describeNameOwner(bus busCaller, name string) string {
+	var owner string
+	if err := bus.Call("org.freedesktop.DBus.GetNameOwner", 0, name).Store(&owner); err != nil {
+		log.Debug().Err(err).Str("name", name).Msg("Failed to look up name owner")
+		return "another process"
+	}
+
+	var pid uint32
+	if err := bus.Call("org.freedesktop.DBus.GetConnectionUnixProcessID", 0, owner).Store(&pid); err != nil {
+		log.Debug().Err(err).Str("owner", owner).Msg("Failed to look up owner PID")
+		return owner
+	}
+
+	return fmt.Sprintf("PID %d (%s)", pid, owner)
+}
+
 // Stop disconnects from the session bus.
+// In-progress fades are completed by writing their target value, so Stop must be
+// called before the display manager is closed.
 func (s *Server) Stop() error {
+	s.finishFades()
+	s.flushSteps()
+	s.stopIdleDim()
+
+	s.startMu.Lock()
+	s.started = false
+	s.startMu.Unlock()
+
 	s.connMu.Lock()
 	conn := s.conn
 	s.conn = nil
+	s.emitter = nil
 	s.connMu.Unlock()
 
 	if conn != nil {
@@ -208,25 +951,35 @@ func (s *Server) SetDeviceErrorHandler(handler DeviceErrorHandler) {
 }
 
 // handleDeviceError checks if the error indicates a disconnected device and triggers recovery.
-// Returns true if the error was a device error and recovery was triggered.
+// Returns true if the error was a device error, whether it triggered a recovery or
+// was coalesced into a recent one (see WithDeviceErrorDebounce). Every non-nil
+// error is counted for GetStats.
 func (s *Server) handleDeviceError(serial string, err error) bool {
-	if err == nil || !hid.IsDeviceGoneError(err) {
+	if err == nil {
 		return false
 	}
-
-	log.Warn().
-		Err(err).
-		Str("serial", serial).
-		Msg("Device error detected, triggering recovery")
+	s.stats.errors.Add(1)
+	if !hid.IsDeviceGoneError(err) {
+		return false
+	}
+	if !s.recoveryDue(serial) {
+		return true
+	}
 
 	s.handlerMu.RLock()
 	handler := s.deviceErrorHandler
 	s.handlerMu.RUnlock()
 
-	if handler != nil {
-		// Run recovery asynchronously to not block the D-Bus response
-		go handler(serial, err)
+	// Run recovery asynchronously to not block the D-Bus response
+	if handler == nil || !s.runRecovery(handler, serial, err) {
+		return true
 	}
+	s.stats.recoveries.Add(1)
+
+	log.Warn().
+		Err(err).
+		Str("serial", serial).
+		Msg("Device error detected, triggering recovery")
 
 	return true
 }
@@ -244,52 +997,96 @@ func (s *Server) ListDisplays() ([]DisplayInfo, *dbus.Error) {
 	return result, nil
 }
 
+// GetKnownSerials returns the serial of every display seen since startup, sorted.
+// Unlike ListDisplays it includes displays that have since disconnected, so UIs
+// can keep showing them.
+func (s *Server) GetKnownSerials() ([]string, *dbus.Error) {
+	serials := s.manager.KnownSerials()
+	if serials == nil {
+		serials = []string{}
+	}
+	return serials, nil
+}
+
 // GetBrightness returns the brightness of a display as a percentage (0-100).
+// With the stale fallback enabled, a failed read returns the last known brightness
+// instead of an error (see WithStaleFallback).
 func (s *Server) GetBrightness(serial string) (uint32, *dbus.Error) {
-	if serial == "" {
-		return 0, dbus.MakeFailedError(ErrEmptySerial)
+	brightness, _, err := s.getBrightness(serial)
+	return brightness, err
+}
+
+// getBrightness implements GetBrightness and GetBrightnessDetailed. The returned
+// flag is set when the value is a stale fallback rather than a fresh reading.
+func (s *Server) getBrightness(serial string) (uint32, bool, *dbus.Error) {
+	s.recordActivity()
+	s.stats.gets.Add(1)
+
+	if err := validateSerial(serial); err != nil {
+		return 0, false, dbus.MakeFailedError(err)
 	}
 
 	display, err := s.manager.GetDisplay(serial)
 	if err != nil {
 		log.Error().Err(err).Str("serial", serial).Msg("Failed to get display")
-		return 0, dbus.MakeFailedError(err)
+		return 0, false, dbus.MakeFailedError(err)
+	}
+
+	if known, answered, dbusErr := s.warmUpBrightness(serial); answered {
+		return known, dbusErr == nil, dbusErr
 	}
 
+	if cached, ok := s.cachedBrightnessFor(serial); ok {
+		log.Debug().Str("serial", serial).Uint32("brightness", cached).Msg("Got cached brightness")
+		return cached, false, nil
+	}
+
+	version := s.cacheVersion(serial)
 	brightness, err := display.GetBrightness()
 	if err != nil {
 		s.handleDeviceError(serial, err)
+		if stale, ok := s.staleBrightness(serial); ok {
+			log.Warn().Err(err).Str("serial", serial).Uint32("brightness", stale).Msg("Failed to get brightness, returning last known value")
+			return stale, true, nil
+		}
 		log.Error().Err(err).Str("serial", serial).Msg("Failed to get brightness")
-		return 0, dbus.MakeFailedError(err)
+		return 0, false, dbus.MakeFailedError(err)
 	}
 
+	s.cacheBrightness(serial, uint32(brightness), version)
 	log.Debug().Str("serial", serial).Uint8("brightness", brightness).Msg("Got brightness")
-	return uint32(brightness), nil
+	return uint32(brightness), false, nil
 }
 
 // SetBrightness sets the brightness of a display to a percentage (0-100).
 func (s *Server) SetBrightness(serial string, brightness uint32) *dbus.Error {
-	if !s.rateLimiter.Allow() {
-		log.Warn().Msg("Rate limit exceeded for SetBrightness")
-		return dbus.MakeFailedError(ErrRateLimitExceeded)
+	return s.setBrightness("SetBrightness", serial, brightness)
+}
+
+// setBrightness implements SetBrightness on behalf of the named D-Bus method,
+// which is used for rate limit and not-found reporting.
+func (s *Server) setBrightness(method, serial string, brightness uint32) *dbus.Error {
+	s.recordActivity()
+
+	if !s.rateLimits.allow(serial) {
+		return s.rateLimitExceeded(method)
 	}
 
-	if serial == "" {
-		return dbus.MakeFailedError(ErrEmptySerial)
+	if err := validateSerial(serial); err != nil {
+		return dbus.MakeFailedError(err)
 	}
 
 	display, err := s.manager.GetDisplay(serial)
 	if err != nil {
-		log.Error().Err(err).Str("serial", serial).Msg("Failed to get display")
-		return dbus.MakeFailedError(err)
+		return s.displayLookupFailed(method, serial, err)
 	}
 
-	if brightness > 100 {
-		brightness = 100
-	}
+	brightness = s.capBrightness(serial, s.nearestStop(brightness))
 
-	// #nosec G115 -- brightness is clamped to 0-100, safe for uint8
-	err = display.SetBrightness(uint8(brightness))
+	unlock := s.serialLocks.lock(serial)
+	s.cancelFade(serial)
+	err = s.writeBrightness(serial, display, brightness)
+	unlock()
 	if err != nil {
 		s.handleDeviceError(serial, err)
 		log.Error().Err(err).Str("serial", serial).Msg("Failed to set brightness")
@@ -299,183 +1096,302 @@ func (s *Server) SetBrightness(serial string, brightness uint32) *dbus.Error {
 	log.Debug().Str("serial", serial).Uint32("brightness", brightness).Msg("Set brightness")
 
 	// Emit signal
-	s.emitBrightnessChanged(serial, brightness)
+	s.onBrightnessChanged(serial, brightness)
 
 	return nil
 }
 
+// writeBrightness writes percent to a display and records it as the display's known
+// brightness. Recording it before the caller releases the serial lock keeps
+// PollBrightness from mistaking the write for an external change. Must be called
+// with the display's serial lock held.
+func (s *Server) writeBrightness(serial string, display *hid.Display, percent uint32) error {
+	// #nosec G115 -- callers pass a percentage clamped to 0-100
+	if err := display.SetBrightness(uint8(min(percent, 100))); err != nil {
+		return err
+	}
+	s.recordKnownBrightness(serial, percent)
+	return nil
+}
+
 // IncreaseBrightness increases the brightness of a display by a step.
-// The step parameter must be between 1 and 100.
+// The step parameter must be between 1 and 100. With step coalescing enabled the
+// step is queued and applied later (see WithStepCoalescing). With brightness stops
+// the display moves to the next stop instead, and steps are never coalesced.
+// BrightnessLimitReached is emitted if the step was clamped at the maximum.
 func (s *Server) IncreaseBrightness(serial string, step uint32) *dbus.Error {
-	if !s.rateLimiter.Allow() {
-		log.Warn().Msg("Rate limit exceeded for IncreaseBrightness")
-		return dbus.MakeFailedError(ErrRateLimitExceeded)
-	}
+	s.recordActivity()
 
-	if serial == "" {
-		return dbus.MakeFailedError(ErrEmptySerial)
+	if err := validateSerial(serial); err != nil {
+		return dbus.MakeFailedError(err)
 	}
 
 	if step == 0 || step > 100 {
 		return dbus.MakeFailedError(ErrInvalidStep)
 	}
 
+	// Joining a step queued by step coalescing doesn't take a token of its own
+	if s.joinPendingStep(serial, int(step)) {
+		return nil
+	}
+
+	if !s.rateLimits.allow(serial) {
+		return s.rateLimitExceeded("IncreaseBrightness")
+	}
+
 	display, err := s.manager.GetDisplay(serial)
 	if err != nil {
-		return dbus.MakeFailedError(err)
+		return s.displayLookupFailed("IncreaseBrightness", serial, err)
 	}
 
+	if s.stepWindow > 0 && len(s.stops) == 0 {
+		s.queueStep(serial, int(step))
+		return nil
+	}
+
+	unlock := s.serialLocks.lock(serial)
+	s.cancelFade(serial)
+
 	current, err := display.GetBrightness()
 	if err != nil {
+		unlock()
 		s.handleDeviceError(serial, err)
 		return dbus.MakeFailedError(err)
 	}
 
 	newBrightness := uint32(current) + step
-	if newBrightness > 100 {
-		newBrightness = 100
+	requested := int(newBrightness)
+	if len(s.stops) > 0 {
+		newBrightness = s.nextStop(uint32(current))
+		// With no stop above, the step asks for more than any stop offers
+		requested = max(int(newBrightness), int(current)+1)
 	}
+	newBrightness = s.capBrightness(serial, newBrightness)
 
-	// #nosec G115 -- newBrightness is clamped to 0-100, safe for uint8
-	err = display.SetBrightness(uint8(newBrightness))
+	err = s.writeBrightness(serial, display, newBrightness)
+	unlock()
 	if err != nil {
 		s.handleDeviceError(serial, err)
 		return dbus.MakeFailedError(err)
 	}
 
 	log.Debug().Str("serial", serial).Uint32("step", step).Uint32("new", newBrightness).Msg("Increased brightness")
-	s.emitBrightnessChanged(serial, newBrightness)
+	s.onBrightnessChanged(serial, newBrightness)
+	s.emitLimitReached(serial, requested, newBrightness)
 
 	return nil
 }
 
 // DecreaseBrightness decreases the brightness of a display by a step.
-// The step parameter must be between 1 and 100.
+// The step parameter must be between 1 and 100. With step coalescing enabled the
+// step is queued and applied later (see WithStepCoalescing). With brightness stops
+// the display moves to the previous stop instead, and steps are never coalesced.
+// BrightnessLimitReached is emitted if the step was clamped at the minimum.
 func (s *Server) DecreaseBrightness(serial string, step uint32) *dbus.Error {
-	if !s.rateLimiter.Allow() {
-		log.Warn().Msg("Rate limit exceeded for DecreaseBrightness")
-		return dbus.MakeFailedError(ErrRateLimitExceeded)
-	}
+	s.recordActivity()
 
-	if serial == "" {
-		return dbus.MakeFailedError(ErrEmptySerial)
+	if err := validateSerial(serial); err != nil {
+		return dbus.MakeFailedError(err)
 	}
 
 	if step == 0 || step > 100 {
 		return dbus.MakeFailedError(ErrInvalidStep)
 	}
 
+	// Joining a step queued by step coalescing doesn't take a token of its own
+	if s.joinPendingStep(serial, -int(step)) {
+		return nil
+	}
+
+	if !s.rateLimits.allow(serial) {
+		return s.rateLimitExceeded("DecreaseBrightness")
+	}
+
 	display, err := s.manager.GetDisplay(serial)
 	if err != nil {
-		return dbus.MakeFailedError(err)
+		return s.displayLookupFailed("DecreaseBrightness", serial, err)
+	}
+
+	if s.stepWindow > 0 && len(s.stops) == 0 {
+		s.queueStep(serial, -int(step))
+		return nil
 	}
 
+	unlock := s.serialLocks.lock(serial)
+	s.cancelFade(serial)
+
 	current, err := display.GetBrightness()
 	if err != nil {
+		unlock()
 		s.handleDeviceError(serial, err)
 		return dbus.MakeFailedError(err)
 	}
 
 	var newBrightness uint32
-	if uint32(current) > step {
-		newBrightness = uint32(current) - step
-	} else {
-		newBrightness = 0
+	requested := int(current) - int(step)
+	switch {
+	case len(s.stops) > 0:
+		newBrightness = s.previousStop(uint32(current))
+		// With no stop below, the step asks for less than any stop offers
+		requested = min(int(newBrightness), int(current)-1)
+	case requested > 0:
+		newBrightness = uint32(requested)
 	}
+	newBrightness = s.capBrightness(serial, newBrightness)
 
-	// #nosec G115 -- newBrightness is clamped to 0-100, safe for uint8
-	err = display.SetBrightness(uint8(newBrightness))
+	err = s.writeBrightness(serial, display, newBrightness)
+	unlock()
 	if err != nil {
 		s.handleDeviceError(serial, err)
 		return dbus.MakeFailedError(err)
 	}
 
 	log.Debug().Str("serial", serial).Uint32("step", step).Uint32("new", newBrightness).Msg("Decreased brightness")
-	s.emitBrightnessChanged(serial, newBrightness)
+	s.onBrightnessChanged(serial, newBrightness)
+	s.emitLimitReached(serial, requested, newBrightness)
 
 	return nil
 }
 
 // SetAllBrightness sets the brightness of all displays to a percentage (0-100).
+// AllBrightnessChanged is emitted once afterwards if at least one display was set.
 func (s *Server) SetAllBrightness(brightness uint32) *dbus.Error {
-	if !s.rateLimiter.Allow() {
-		log.Warn().Msg("Rate limit exceeded for SetAllBrightness")
-		return dbus.MakeFailedError(ErrRateLimitExceeded)
-	}
+	return s.setAllBrightness("SetAllBrightness", brightness)
+}
+
+// setAllBrightness implements SetAllBrightness on behalf of the named D-Bus method.
+func (s *Server) setAllBrightness(method string, brightness uint32) *dbus.Error {
+	s.recordActivity()
 
-	if brightness > 100 {
-		brightness = 100
+	if !s.rateLimits.allowAll() {
+		return s.rateLimitExceeded(method)
 	}
 
-	displays := s.manager.ListDisplays()
-	for _, info := range displays {
-		display, err := s.manager.GetDisplay(info.Serial)
-		if err != nil {
-			log.Error().Err(err).Str("serial", info.Serial).Msg("Failed to get display")
+	brightness = s.nearestStop(min(brightness, 100))
+
+	// Take a single consistent snapshot so a concurrent refresh can't make
+	// displays disappear between listing and lookup
+	displays := s.manager.Snapshot()
+	unlock := s.lockWithoutFades(slices.Collect(maps.Keys(displays))...)
+	defer unlock()
+
+	set := 0
+	for serial, display := range displays {
+		value := s.capBrightness(serial, brightness)
+		// #nosec G115 -- value is clamped to 0-100, safe for uint8
+		err := display.SetBrightness(uint8(value))
+		if errors.Is(err, hid.ErrDisplayClosed) {
+			// Removed by a concurrent refresh after the snapshot was taken
+			log.Debug().Str("serial", serial).Msg("Display closed during SetAllBrightness, skipping")
 			continue
 		}
-
-		// #nosec G115 -- brightness is clamped to 0-100, safe for uint8
-		err = display.SetBrightness(uint8(brightness))
 		if err != nil {
-			s.handleDeviceError(info.Serial, err)
-			log.Error().Err(err).Str("serial", info.Serial).Msg("Failed to set brightness")
+			s.handleDeviceError(serial, err)
+			log.Error().Err(err).Str("serial", serial).Msg("Failed to set brightness")
 			continue
 		}
 
-		s.emitBrightnessChanged(info.Serial, brightness)
+		set++
+		s.stats.sets.Add(1)
+		if s.setAllPerDisplay {
+			s.emitBrightnessChanged(serial, value)
+		} else {
+			s.recordKnownBrightness(serial, value)
+		}
+	}
+
+	if set > 0 {
+		s.emitSignal("AllBrightnessChanged", brightness)
 	}
 
-	log.Debug().Uint32("brightness", brightness).Int("count", len(displays)).Msg("Set all brightness")
+	log.Debug().Str("method", method).Uint32("brightness", brightness).Int("count", len(displays)).Msg("Set all brightness")
 	return nil
 }
 
-// emitBrightnessChanged emits the BrightnessChanged signal.
-func (s *Server) emitBrightnessChanged(serial string, brightness uint32) {
+// rateLimitExceeded logs a rejected call, emits a throttled RateLimited signal
+// so well-behaved clients can back off, and returns the error for the caller.
+func (s *Server) rateLimitExceeded(method string) *dbus.Error {
+	log.Warn().Str("method", method).Msg("Rate limit exceeded")
+	s.stats.rateLimitHits.Add(1)
+
+	now := s.now()
+	s.rateSignalMu.Lock()
+	shouldEmit := s.lastRateSignal.IsZero() || now.Sub(s.lastRateSignal) >= rateLimitSignalInterval
+	if shouldEmit {
+		s.lastRateSignal = now
+	}
+	s.rateSignalMu.Unlock()
+
+	if shouldEmit {
+		s.emitSignal("RateLimited", method)
+	}
+
+	return dbus.MakeFailedError(ErrRateLimitExceeded)
+}
+
+// emitSignal emits a signal on the service interface.
+// Returns false without emitting if the server is not connected to the bus.
+func (s *Server) emitSignal(member string, values ...any) bool {
+	return s.emitInterfaceSignal(InterfaceName, member, values...)
+}
+
+// emitInterfaceSignal emits a signal on the given interface at the service root path.
+// Returns false without emitting if the server is not connected to the bus.
+func (s *Server) emitInterfaceSignal(iface, member string, values ...any) bool {
 	s.connMu.RLock()
-	conn := s.conn
+	emitter := s.emitter
 	s.connMu.RUnlock()
 
-	if conn == nil {
-		return
+	if emitter == nil {
+		return false
 	}
 
-	err := conn.Emit(ObjectPath, InterfaceName+".BrightnessChanged", serial, brightness)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to emit BrightnessChanged signal")
+	if err := emitter.Emit(ObjectPath, iface+"."+member, values...); err != nil {
+		log.Error().Err(err).Str("signal", member).Msg("Failed to emit signal")
 	}
+	return true
 }
 
-// EmitDisplayAdded emits the DisplayAdded signal.
-func (s *Server) EmitDisplayAdded(serial, productName string) {
-	s.connMu.RLock()
-	conn := s.conn
-	s.connMu.RUnlock()
+// emitBrightnessChanged records a brightness the daemon has set and emits
+// BrightnessChanged for it.
+func (s *Server) emitBrightnessChanged(serial string, percent uint32) {
+	s.recordKnownBrightness(serial, percent)
+	s.emitBrightnessSignals(serial, percent)
+}
 
-	if conn == nil {
+// emitBrightnessSignals emits the BrightnessChanged signal and its versioned
+// successor BrightnessChangedV2, so clients can migrate at their own pace.
+func (s *Server) emitBrightnessSignals(serial string, percent uint32) {
+	if !s.emitSignal("BrightnessChanged", serial, percent) {
 		return
 	}
+	// #nosec G115 -- percent is clamped to 0-100, safe for uint8
+	nits := brightness.CurvePercentToNits(s.effectiveConfig(serial).Curve, uint8(min(percent, 100)))
+	s.emitSignal("BrightnessChangedV2", serial, percent, nits)
+}
 
-	err := conn.Emit(ObjectPath, InterfaceName+".DisplayAdded", serial, productName)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to emit DisplayAdded signal")
+// EmitDisplayAdded emits the DisplayAdded signal and the matching
+// ObjectManager InterfacesAdded signal for the display's child object.
+func (s *Server) EmitDisplayAdded(serial, productName string) {
+	s.startWarmUp(serial)
+	if !s.emitSignal("DisplayAdded", serial, productName) {
+		return
 	}
+	s.emitInterfaceSignal(ObjectManagerInterface, "InterfacesAdded", DisplayObjectPath(serial),
+		map[string]map[string]dbus.Variant{DisplayInterfaceName: displayProperties(serial, productName)})
 	log.Info().Str("serial", serial).Str("product", productName).Msg("Display added")
 }
 
-// EmitDisplayRemoved emits the DisplayRemoved signal.
+// EmitDisplayRemoved emits the DisplayRemoved signal and the matching
+// ObjectManager InterfacesRemoved signal for the display's child object.
 func (s *Server) EmitDisplayRemoved(serial string) {
-	s.connMu.RLock()
-	conn := s.conn
-	s.connMu.RUnlock()
-
-	if conn == nil {
+	s.rateLimits.forget(serial)
+	s.endWarmUp(serial)
+	s.clearCurveOverride(serial)
+	if !s.emitSignal("DisplayRemoved", serial) {
 		return
 	}
-
-	err := conn.Emit(ObjectPath, InterfaceName+".DisplayRemoved", serial)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to emit DisplayRemoved signal")
-	}
+	s.emitInterfaceSignal(ObjectManagerInterface, "InterfacesRemoved", DisplayObjectPath(serial),
+		[]string{DisplayInterfaceName})
 	log.Info().Str("serial", serial).Msg("Display removed")
 }
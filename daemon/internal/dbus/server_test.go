@@ -3,25 +3,35 @@
 package dbus
 
 import (
+	"encoding/binary"
+	"encoding/xml"
 	"errors"
+	"fmt"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"testing"
 	"time"
 
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/shini4i/asd-brightness-daemon/internal/brightness"
 	"github.com/shini4i/asd-brightness-daemon/internal/hid"
 	"github.com/shini4i/asd-brightness-daemon/internal/hid/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"golang.org/x/time/rate"
 )
 
 // mockDisplayManager implements DisplayManager for testing.
 type mockDisplayManager struct {
-	displays    []hid.DeviceInfo
-	displayMap  map[string]*hid.Display
-	refreshErr  error
-	getErr      error
+	displays   []hid.DeviceInfo
+	displayMap map[string]*hid.Display
+	refreshErr error
+	getErr     error
+	known      []string // serials of displays that were seen but disconnected
 }
 
 func (m *mockDisplayManager) ListDisplays() []hid.DeviceInfo {
@@ -34,15 +44,32 @@ func (m *mockDisplayManager) GetDisplay(serial string) (*hid.Display, error) {
 	}
 	display, ok := m.displayMap[serial]
 	if !ok {
-		return nil, errors.New("display not found")
+		return nil, fmt.Errorf("%w: serial %s", hid.ErrDisplayNotFound, serial)
 	}
 	return display, nil
 }
 
+func (m *mockDisplayManager) Snapshot() map[string]*hid.Display {
+	snapshot := make(map[string]*hid.Display, len(m.displayMap))
+	for serial, d := range m.displayMap {
+		snapshot[serial] = d
+	}
+	return snapshot
+}
+
 func (m *mockDisplayManager) RefreshDisplays() error {
 	return m.refreshErr
 }
 
+func (m *mockDisplayManager) KnownSerials() []string {
+	serials := slices.Clone(m.known)
+	for _, info := range m.displays {
+		serials = append(serials, info.Serial)
+	}
+	slices.Sort(serials)
+	return slices.Compact(serials)
+}
+
 func TestNewServer(t *testing.T) {
 	manager := &mockDisplayManager{}
 	server := NewServer(manager)
@@ -50,6 +77,19 @@ func TestNewServer(t *testing.T) {
 	assert.Equal(t, manager, server.manager)
 }
 
+func TestServer_Start_AlreadyStarted(t *testing.T) {
+	server, recorder := newRecordingServer(newFakeManager())
+	server.started = true
+
+	err := server.Start()
+	assert.ErrorIs(t, err, ErrAlreadyStarted)
+	assert.Nil(t, server.conn, "a second Start must not connect to the bus")
+	assert.Same(t, recorder, server.emitter, "a second Start must not replace the signal sink")
+
+	require.NoError(t, server.Stop())
+	assert.False(t, server.started, "a stopped server may be started again")
+}
+
 func TestServer_ListDisplays(t *testing.T) {
 	manager := &mockDisplayManager{
 		displays: []hid.DeviceInfo{
@@ -366,12 +406,338 @@ func TestServer_SetAllBrightness_ClampsOver100(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestServer_SetAllBrightness_UsesSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDevice := mocks.NewMockDevice(ctrl)
+	mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"}).AnyTimes()
+	mockDevice.EXPECT().SendFeatureReport(gomock.Any()).Return(7, nil).Times(1)
+
+	// Per-serial lookups fail as if a refresh removed the display between
+	// listing and lookup; SetAll must not depend on them
+	manager := &mockDisplayManager{
+		displays:   []hid.DeviceInfo{{Serial: "ABC123"}},
+		displayMap: map[string]*hid.Display{"ABC123": hid.NewDisplay(mockDevice)},
+		getErr:     errors.New("display with serial ABC123 not found"),
+	}
+	server := NewServer(manager)
+
+	err := server.SetAllBrightness(60)
+	assert.Nil(t, err)
+}
+
+func TestServer_SetAllBrightness_ConcurrentRefresh(t *testing.T) {
+	// Display A stays connected, display B flaps in and out on every refresh
+	var writesA atomic.Int32
+	var notFound atomic.Int32
+	var calls atomic.Int32
+
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		if calls.Add(1)%2 == 0 {
+			return []hid.DeviceInfo{{Serial: "A"}}, nil
+		}
+		return []hid.DeviceInfo{{Serial: "A"}, {Serial: "B"}}, nil
+	}
+	opener := func(serial string) (hid.Device, error) {
+		return &fakeDevice{serial: serial, onSend: func(serial string) {
+			if serial == "A" {
+				writesA.Add(1)
+			}
+		}}, nil
+	}
+
+	manager := hid.NewManager(hid.WithEnumerator(enumerator), hid.WithOpener(opener))
+	require.NoError(t, manager.RefreshDisplays())
+
+	server := NewServer(&notFoundTrackingManager{Manager: manager, notFound: &notFound})
+	server.rateLimits = newRateLimiters(rate.Inf, 0)
+
+	const iterations = 200
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			_ = manager.RefreshDisplays()
+		}
+	}()
+
+	for i := 0; i < iterations; i++ {
+		assert.Nil(t, server.SetAllBrightness(50))
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(iterations), writesA.Load(), "stable display must be written on every SetAll")
+	assert.Zero(t, notFound.Load(), "SetAll must not look up displays by serial")
+}
+
+func TestServer_SetAllBrightness_AllBrightnessChanged(t *testing.T) {
+	tests := []struct {
+		name       string
+		devices    []*fakeDevice
+		opts       []ServerOption
+		brightness uint32
+		perDisplay int
+		aggregate  []any
+	}{
+		{
+			name:       "emitted alongside per-display signals",
+			devices:    []*fakeDevice{newFakeDevice("A", 10), newFakeDevice("B", 20)},
+			brightness: 65,
+			perDisplay: 2,
+			aggregate:  []any{uint32(65)},
+		},
+		{
+			name:       "replaces per-display signals when configured",
+			devices:    []*fakeDevice{newFakeDevice("A", 10), newFakeDevice("B", 20)},
+			opts:       []ServerOption{WithSetAllPerDisplaySignals(false)},
+			brightness: 150,
+			aggregate:  []any{uint32(100)},
+		},
+		{
+			name:       "not emitted when no display was set",
+			brightness: 40,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, recorder := newRecordingServer(newFakeManager(tt.devices...), tt.opts...)
+
+			require.Nil(t, server.SetAllBrightness(tt.brightness))
+
+			assert.Len(t, recorder.named("BrightnessChanged"), tt.perDisplay)
+			signals := recorder.named("AllBrightnessChanged")
+			if tt.aggregate == nil {
+				assert.Empty(t, signals)
+				return
+			}
+			require.Len(t, signals, 1)
+			assert.Equal(t, tt.aggregate, signals[0].values)
+			for _, d := range tt.devices {
+				assert.Equal(t, uint8(min(tt.brightness, 100)), d.percent())
+			}
+		})
+	}
+}
+
+// fakeDevice is an in-memory hid.Device that stores the last written brightness.
+type fakeDevice struct {
+	mu     sync.Mutex
+	serial string
+	nits   uint32
+	reads  int
+	writes int
+	onSend func(serial string)
+	onGet  func(serial string) // called after each brightness read
+}
+
+func newFakeDevice(serial string, percent uint8) *fakeDevice {
+	return &fakeDevice{serial: serial, nits: brightness.PercentToNits(percent)}
+}
+
+func (d *fakeDevice) GetFeatureReport(data []byte) (int, error) {
+	// Like firmware without optional reports, stall anything but brightness
+	if data[0] != hid.ReportID {
+		return 0, syscall.EPIPE
+	}
+
+	d.mu.Lock()
+	d.reads++
+	binary.LittleEndian.PutUint32(data[hid.ReportOffsetNits:], d.nits)
+	d.mu.Unlock()
+	if d.onGet != nil {
+		d.onGet(d.serial)
+	}
+	return len(data), nil
+}
+
+func (d *fakeDevice) SendFeatureReport(data []byte) (int, error) {
+	d.mu.Lock()
+	d.nits = binary.LittleEndian.Uint32(data[hid.ReportOffsetNits:])
+	d.writes++
+	d.mu.Unlock()
+	if d.onSend != nil {
+		d.onSend(d.serial)
+	}
+	return len(data), nil
+}
+
+func (d *fakeDevice) Close() error { return nil }
+
+func (d *fakeDevice) Info() hid.DeviceInfo { return hid.DeviceInfo{Serial: d.serial} }
+
+// percent returns the last written brightness as a percentage.
+func (d *fakeDevice) percent() uint8 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return brightness.NitsToPercent(d.nits)
+}
+
+// readCount returns the number of feature reports read.
+func (d *fakeDevice) readCount() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.reads
+}
+
+// writeCount returns the number of feature reports written.
+func (d *fakeDevice) writeCount() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.writes
+}
+
+// newFakeManager returns a mockDisplayManager backed by the given fake devices.
+func newFakeManager(devices ...*fakeDevice) *mockDisplayManager {
+	m := &mockDisplayManager{displayMap: make(map[string]*hid.Display)}
+	for _, d := range devices {
+		m.displays = append(m.displays, d.Info())
+		m.displayMap[d.serial] = hid.NewDisplay(d)
+	}
+	return m
+}
+
+// recordedSignal is a signal captured by signalRecorder.
+type recordedSignal struct {
+	name   string
+	values []any
+}
+
+// signalRecorder implements signalEmitter and records every emitted signal.
+type signalRecorder struct {
+	mu      sync.Mutex
+	signals []recordedSignal
+}
+
+func (r *signalRecorder) Emit(path dbus.ObjectPath, name string, values ...any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.signals = append(r.signals, recordedSignal{name: name, values: values})
+	return nil
+}
+
+// named returns the recorded service interface signals with the given member name.
+func (r *signalRecorder) named(member string) []recordedSignal {
+	return r.namedOn(InterfaceName, member)
+}
+
+// namedOn returns the recorded signals with the given interface and member name.
+func (r *signalRecorder) namedOn(iface, member string) []recordedSignal {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []recordedSignal
+	for _, sig := range r.signals {
+		if sig.name == iface+"."+member {
+			result = append(result, sig)
+		}
+	}
+	return result
+}
+
+// fakeClock is a manually advanced clock safe for use from background goroutines.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(1000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newRecordingServer creates a Server whose signals are captured by the returned recorder.
+func newRecordingServer(manager DisplayManager, opts ...ServerOption) (*Server, *signalRecorder) {
+	server := NewServer(manager, opts...)
+	recorder := &signalRecorder{}
+	server.emitter = recorder
+	return server, recorder
+}
+
+// notFoundTrackingManager wraps a real Manager and counts failed per-serial lookups.
+type notFoundTrackingManager struct {
+	*hid.Manager
+	notFound *atomic.Int32
+}
+
+func (m *notFoundTrackingManager) GetDisplay(serial string) (*hid.Display, error) {
+	d, err := m.Manager.GetDisplay(serial)
+	if err != nil {
+		m.notFound.Add(1)
+	}
+	return d, err
+}
+
 func TestServer_Constants(t *testing.T) {
 	assert.Equal(t, "io.github.shini4i.AsdBrightness", ServiceName)
 	assert.Equal(t, "/io/github/shini4i/AsdBrightness", ObjectPath)
 	assert.Equal(t, "io.github.shini4i.AsdBrightness", InterfaceName)
 }
 
+func TestIntrospectXML_Parses(t *testing.T) {
+	var node introspect.Node
+	require.NoError(t, xml.Unmarshal([]byte(IntrospectXML), &node))
+
+	var iface *introspect.Interface
+	for i := range node.Interfaces {
+		if node.Interfaces[i].Name == InterfaceName {
+			iface = &node.Interfaces[i]
+		}
+	}
+	require.NotNil(t, iface, "service interface should be present")
+
+	methods := make(map[string]introspect.Method)
+	for _, m := range iface.Methods {
+		methods[m.Name] = m
+	}
+	for _, name := range []string{
+		"ListDisplays", "GetBrightness", "SetBrightness",
+		"IncreaseBrightness", "DecreaseBrightness", "SetAllBrightness",
+	} {
+		assert.Contains(t, methods, name)
+	}
+
+	// Query methods are annotated as read-only, mutating ones are not
+	readOnly := func(m introspect.Method) bool {
+		for _, a := range m.Annotations {
+			if a.Name == ReadOnlyAnnotation && a.Value == "true" {
+				return true
+			}
+		}
+		return false
+	}
+	assert.True(t, readOnly(methods["ListDisplays"]))
+	assert.True(t, readOnly(methods["GetBrightness"]))
+	assert.False(t, readOnly(methods["SetBrightness"]))
+
+	// Doc elements must not disturb argument parsing
+	require.Len(t, methods["SetBrightness"].Args, 2)
+	assert.Equal(t, "brightness", methods["SetBrightness"].Args[1].Name)
+	assert.Equal(t, "u", methods["SetBrightness"].Args[1].Type)
+	assert.Equal(t, "in", methods["SetBrightness"].Args[1].Direction)
+
+	signals := make(map[string]bool)
+	for _, sig := range iface.Signals {
+		signals[sig.Name] = true
+	}
+	for _, name := range []string{"DisplayAdded", "DisplayRemoved", "BrightnessChanged", "BrightnessChangedV2"} {
+		assert.True(t, signals[name], "signal %s should be present", name)
+	}
+}
+
 func TestServer_RateLimiting(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -401,6 +767,89 @@ func TestServer_RateLimiting(t *testing.T) {
 	assert.True(t, rateLimitHit, "Rate limiter should have been triggered")
 }
 
+func TestServer_RateLimiting_EmitsThrottledSignal(t *testing.T) {
+	server, recorder := newRecordingServer(newFakeManager(newFakeDevice("ABC123", 50)))
+
+	clock := newFakeClock()
+	server.now = clock.Now
+
+	// Exhaust the burst, then keep hammering within the throttle window
+	var rejected int
+	for i := 0; i < 20; i++ {
+		if err := server.SetBrightness("ABC123", 50); err != nil {
+			rejected++
+			assert.Contains(t, err.Error(), ErrRateLimitExceeded.Error())
+		}
+	}
+	require.Greater(t, rejected, 1)
+
+	signals := recorder.named("RateLimited")
+	require.Len(t, signals, 1, "RateLimited must be emitted once per throttle window")
+	assert.Equal(t, []any{"SetBrightness"}, signals[0].values)
+
+	// After the throttle window a new rejection emits again
+	clock.Advance(rateLimitSignalInterval)
+	server.rateLimits = newRateLimiters(0, 0)
+	assert.NotNil(t, server.IncreaseBrightness("ABC123", 5))
+
+	signals = recorder.named("RateLimited")
+	require.Len(t, signals, 2)
+	assert.Equal(t, []any{"IncreaseBrightness"}, signals[1].values)
+}
+
+// fakeBus implements busCaller with canned replies keyed by method name.
+type fakeBus struct {
+	replies map[string]*dbus.Call
+	calls   []string
+}
+
+func (b *fakeBus) Call(method string, flags dbus.Flags, args ...any) *dbus.Call {
+	b.calls = append(b.calls, method)
+	if call, ok := b.replies[method]; ok {
+		return call
+	}
+	return &dbus.Call{Err: errors.New("unexpected call " + method)}
+}
+
+func TestDescribeNameOwner(t *testing.T) {
+	tests := []struct {
+		name     string
+		replies  map[string]*dbus.Call
+		expected string
+	}{
+		{
+			name: "owner and PID known",
+			replies: map[string]*dbus.Call{
+				"org.freedesktop.DBus.GetNameOwner":               {Body: []any{":1.42"}},
+				"org.freedesktop.DBus.GetConnectionUnixProcessID": {Body: []any{uint32(1234)}},
+			},
+			expected: "PID 1234 (:1.42)",
+		},
+		{
+			name: "PID lookup fails",
+			replies: map[string]*dbus.Call{
+				"org.freedesktop.DBus.GetNameOwner":               {Body: []any{":1.42"}},
+				"org.freedesktop.DBus.GetConnectionUnixProcessID": {Err: errors.New("access denied")},
+			},
+			expected: ":1.42",
+		},
+		{
+			name: "owner lookup fails",
+			replies: map[string]*dbus.Call{
+				"org.freedesktop.DBus.GetNameOwner": {Err: errors.New("name has no owner")},
+			},
+			expected: "another process",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := &fakeBus{replies: tt.replies}
+			assert.Equal(t, tt.expected, describeNameOwner(bus, ServiceName))
+		})
+	}
+}
+
 func TestServer_SetDeviceErrorHandler(t *testing.T) {
 	manager := &mockDisplayManager{}
 	server := NewServer(manager)
@@ -616,3 +1065,19 @@ func TestServer_ConcurrentStopAndEmit(t *testing.T) {
 	wg.Wait()
 	// If we get here without a race detector complaint, the test passes
 }
+
+func TestServer_BrightnessChanged_EmitsV2WithConsistentValues(t *testing.T) {
+	display := newFakeDevice("A", 10)
+	server, recorder := newRecordingServer(newFakeManager(display))
+
+	require.Nil(t, server.SetBrightness("A", 50))
+
+	v1 := recorder.named("BrightnessChanged")
+	v2 := recorder.named("BrightnessChangedV2")
+	require.Len(t, v1, 1)
+	require.Len(t, v2, 1)
+
+	assert.Equal(t, []any{"A", uint32(50)}, v1[0].values)
+	assert.Equal(t, []any{"A", uint32(50), brightness.PercentToNits(50)}, v2[0].values)
+	assert.Equal(t, brightness.PercentToNits(display.percent()), v2[0].values[2])
+}
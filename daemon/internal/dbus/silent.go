@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+)
+
+// WithSilentDaemonChanges stops brightness changes the daemon makes on its own,
+// rather than for a client, from emitting BrightnessChanged: idle dimming and the
+// restore after it. A burst of signals for every display would make clients think
+// the user changed something; with this option clients keep showing the brightness
+// they last saw instead. Restoring brightness after resume is always silent.
+// Client-initiated changes always emit. Disabled by default.
+func WithSilentDaemonChanges(enabled bool) ServerOption {
+	return func(s *Server) {
+		s.silentDaemonChanges = enabled
+	}
+}
+
+// applyDaemonBrightness writes a brightness change the daemon makes on its own and
+// emits BrightnessChanged for it, unless daemon changes are silent (see
+// WithSilentDaemonChanges). The silent path leaves the known brightness alone so
+// it keeps matching what clients were last told, and records the write separately
+// so PollBrightness doesn't report it. Must be called with the serial lock held.
+func (s *Server) applyDaemonBrightness(serial string, display *hid.Display, percent uint32) error {
+	// #nosec G115 -- callers pass a 0-100 percentage
+	if err := display.SetBrightness(uint8(min(percent, 100))); err != nil {
+		return err
+	}
+
+	if s.silentDaemonChanges {
+		s.recordDaemonWrite(serial, percent)
+		return nil
+	}
+	s.emitBrightnessChanged(serial, percent)
+	return nil
+}
@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_SilentDaemonChanges_IdleDim(t *testing.T) {
+	display := newFakeDevice("A", 50)
+	server, recorder := newRecordingServer(newFakeManager(display), WithSilentDaemonChanges(true))
+
+	clock := newFakeClock()
+	server.now = clock.Now
+
+	require.Nil(t, server.SetBrightness("A", 60))
+	require.Len(t, recorder.named("BrightnessChanged"), 1, "client-initiated changes still emit")
+
+	require.Nil(t, server.EnableIdleDim(60, 10))
+	defer server.DisableIdleDim()
+
+	clock.Advance(61 * time.Second)
+	server.checkIdle()
+	assert.Equal(t, uint8(10), display.percent(), "the dim level should still be written")
+
+	require.Nil(t, server.NotifyActivity())
+	assert.Equal(t, uint8(60), display.percent(), "the saved brightness should still be restored")
+
+	assert.Len(t, recorder.named("BrightnessChanged"), 1, "daemon-initiated changes must not emit")
+	known, ok := server.knownBrightness("A")
+	assert.True(t, ok)
+	assert.Equal(t, uint32(60), known, "the brightness clients were told about is kept")
+}
+
+func TestServer_SilentDaemonChanges_PollIgnoresIdleDim(t *testing.T) {
+	display := newFakeDevice("A", 50)
+	server, recorder := newRecordingServer(newFakeManager(display), WithSilentDaemonChanges(true), WithResetReapply(10))
+
+	clock := newFakeClock()
+	server.now = clock.Now
+
+	require.Nil(t, server.SetBrightness("A", 60))
+	require.Nil(t, server.EnableIdleDim(60, 10))
+	defer server.DisableIdleDim()
+
+	clock.Advance(61 * time.Second)
+	server.checkIdle()
+	require.Equal(t, uint8(10), display.percent())
+
+	// The dim level is the reset level here; neither may make the poll undo or report it
+	server.PollBrightness()
+	assert.Equal(t, uint8(10), display.percent(), "the idle dim isn't mistaken for a reset")
+	assert.Len(t, recorder.named("BrightnessChanged"), 1, "the idle dim isn't reported as an external change")
+
+	// Other tools changing the dimmed display are still reported
+	display.setExternally(25)
+	server.PollBrightness()
+	changes := recorder.named("BrightnessChanged")
+	require.Len(t, changes, 2)
+	assert.Equal(t, []any{"A", uint32(25)}, changes[1].values)
+}
@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"github.com/godbus/dbus/v5"
+)
+
+// WithStaleFallback makes GetBrightness return the last known brightness of a
+// display when reading it fails, instead of an error, so a client's slider stays
+// usable during a glitch. Device error recovery still runs. The last known value is
+// the latest successful read or the latest brightness the daemon set or observed.
+// Reads still fail if no value is known yet. Disabled by default.
+func WithStaleFallback(enabled bool) ServerOption {
+	return func(s *Server) {
+		s.staleFallback = enabled
+	}
+}
+
+// GetBrightnessDetailed returns the brightness of a display like GetBrightness,
+// plus whether the value is a stale fallback because the read failed or the
+// display is warming up under WarmUpLastKnown.
+func (s *Server) GetBrightnessDetailed(serial string) (uint32, bool, *dbus.Error) {
+	return s.getBrightness(serial)
+}
+
+// staleBrightness returns the value GetBrightness falls back to when a read fails,
+// with ok set to false if the fallback is disabled or no value is known.
+func (s *Server) staleBrightness(serial string) (percent uint32, ok bool) {
+	if !s.staleFallback {
+		return 0, false
+	}
+
+	// A reading is only cached after the last known change, so it's more recent
+	s.cacheMu.Lock()
+	entry, cached := s.cache[serial]
+	s.cacheMu.Unlock()
+	if cached {
+		return entry.percent, true
+	}
+	return s.knownBrightness(serial)
+}
@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// glitchingDevice is a fakeDevice whose reads fail with EIO while broken is set.
+type glitchingDevice struct {
+	*fakeDevice
+	broken atomic.Bool
+}
+
+func (d *glitchingDevice) GetFeatureReport(data []byte) (int, error) {
+	if d.broken.Load() {
+		return 0, syscall.EIO
+	}
+	return d.fakeDevice.GetFeatureReport(data)
+}
+
+func newUnreadableServer(opts ...ServerOption) (*Server, *glitchingDevice, *atomic.Int32) {
+	device := &glitchingDevice{fakeDevice: newFakeDevice("A", 40)}
+	manager := &mockDisplayManager{displayMap: map[string]*hid.Display{"A": hid.NewDisplay(device)}}
+	server := NewServer(manager, opts...)
+
+	var recoveries atomic.Int32
+	server.SetDeviceErrorHandler(func(string, error) { recoveries.Add(1) })
+	return server, device, &recoveries
+}
+
+func TestServer_GetBrightness_StaleFallback(t *testing.T) {
+	server, device, recoveries := newUnreadableServer(WithStaleFallback(true))
+
+	value, err := server.GetBrightness("A")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(40), value)
+
+	device.broken.Store(true)
+	value, err = server.GetBrightness("A")
+	require.Nil(t, err, "failed read falls back to the last reading")
+	assert.Equal(t, uint32(40), value)
+
+	value, stale, err := server.GetBrightnessDetailed("A")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(40), value)
+	assert.True(t, stale)
+	assert.Eventually(t, func() bool { return recoveries.Load() == 1 }, time.Second, time.Millisecond, "recovery still runs, once for both failed reads")
+
+	require.Nil(t, server.SetBrightness("A", 70))
+	value, stale, err = server.GetBrightnessDetailed("A")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(70), value, "falls back to the last value the daemon set")
+	assert.True(t, stale)
+
+	device.broken.Store(false)
+	value, stale, err = server.GetBrightnessDetailed("A")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(70), value)
+	assert.False(t, stale)
+}
+
+func TestServer_GetBrightness_StaleFallbackWithoutKnownValue(t *testing.T) {
+	server, device, _ := newUnreadableServer(WithStaleFallback(true))
+	device.broken.Store(true)
+
+	_, err := server.GetBrightness("A")
+	assert.NotNil(t, err, "nothing to fall back to")
+}
+
+func TestServer_GetBrightness_StrictByDefault(t *testing.T) {
+	server, device, recoveries := newUnreadableServer()
+
+	_, err := server.GetBrightness("A")
+	require.Nil(t, err)
+
+	device.broken.Store(true)
+	_, err = server.GetBrightness("A")
+	assert.NotNil(t, err)
+
+	_, stale, err := server.GetBrightnessDetailed("A")
+	assert.NotNil(t, err)
+	assert.False(t, stale)
+	assert.Eventually(t, func() bool { return recoveries.Load() == 1 }, time.Second, time.Millisecond)
+}
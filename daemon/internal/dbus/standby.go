@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+)
+
+// WithIdleStandby puts displays into standby once they have been idle for after,
+// measured from the last activity like the idle dimming timeout. It only takes
+// effect while idle dimming is enabled and never before displays are dimmed, and
+// only on displays that support standby (see hid.WithExperimentalReports); others
+// stay dimmed. The next activity wakes them before restoring their brightness.
+// Zero, the default, disables it.
+func WithIdleStandby(after time.Duration) ServerOption {
+	return func(s *Server) {
+		s.idleStandbyAfter = after
+	}
+}
+
+// SetStandby puts a display's panel into standby or wakes it. Displays without
+// standby control return an error wrapping hid.ErrStandbyUnsupported; clients can
+// check GetStandbySupported first.
+func (s *Server) SetStandby(serial string, enabled bool) *dbus.Error {
+	s.recordActivity()
+
+	if !s.rateLimits.allow(serial) {
+		return s.rateLimitExceeded("SetStandby")
+	}
+
+	if err := validateSerial(serial); err != nil {
+		return dbus.MakeFailedError(err)
+	}
+
+	display, err := s.manager.GetDisplay(serial)
+	if err != nil {
+		return s.displayLookupFailed("SetStandby", serial, err)
+	}
+
+	err = display.SetStandby(enabled)
+	if errors.Is(err, hid.ErrStandbyUnsupported) {
+		log.Debug().Err(err).Str("serial", serial).Msg("Standby not available")
+		return dbus.MakeFailedError(err)
+	}
+	if err != nil {
+		s.handleDeviceError(serial, err)
+		log.Error().Err(err).Str("serial", serial).Msg("Failed to set standby")
+		return dbus.MakeFailedError(err)
+	}
+
+	log.Info().Str("serial", serial).Bool("standby", enabled).Msg("Standby set")
+	s.emitSignal("StandbyChanged", serial, enabled)
+	return nil
+}
+
+// GetStandbySupported reports whether a display provides standby control.
+func (s *Server) GetStandbySupported(serial string) (bool, *dbus.Error) {
+	if err := validateSerial(serial); err != nil {
+		return false, dbus.MakeFailedError(err)
+	}
+
+	display, err := s.manager.GetDisplay(serial)
+	if err != nil {
+		return false, s.displayLookupFailed("GetStandbySupported", serial, err)
+	}
+	return display.SupportsStandby(), nil
+}
+
+// enterIdleStandbyLocked puts the displays that support standby into standby and
+// remembers them for restoreFromIdleLocked. Must be called with idleMu held.
+func (s *Server) enterIdleStandbyLocked() {
+	var entered []string
+	for serial, display := range s.manager.Snapshot() {
+		if !display.SupportsStandby() {
+			continue
+		}
+
+		if err := display.SetStandby(true); err != nil {
+			s.handleDeviceError(serial, err)
+			log.Warn().Err(err).Str("serial", serial).Msg("Failed to put idle display in standby")
+			continue
+		}
+
+		entered = append(entered, serial)
+		s.emitSignal("StandbyChanged", serial, true)
+	}
+
+	s.idle.inStandby = true
+	s.idle.standby = entered
+	log.Info().Int("displays", len(entered)).Msg("Displays put in standby after idle timeout")
+}
+
+// wakeFromIdleStandbyLocked wakes the displays put into standby by
+// enterIdleStandbyLocked. Must be called with idleMu held.
+func (s *Server) wakeFromIdleStandbyLocked() {
+	if !s.idle.inStandby {
+		return
+	}
+
+	displays := s.manager.Snapshot()
+	for _, serial := range s.idle.standby {
+		display, ok := displays[serial]
+		if !ok {
+			continue
+		}
+
+		if err := display.SetStandby(false); err != nil {
+			s.handleDeviceError(serial, err)
+			log.Warn().Err(err).Str("serial", serial).Msg("Failed to wake display from standby")
+			continue
+		}
+		s.emitSignal("StandbyChanged", serial, false)
+	}
+
+	s.idle.inStandby = false
+	s.idle.standby = nil
+}
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// standbyDevice is a fakeDevice whose firmware answers the standby report.
+type standbyDevice struct {
+	*fakeDevice
+	standby bool
+}
+
+func newStandbyDevice(serial string, percent uint8) *standbyDevice {
+	return &standbyDevice{fakeDevice: newFakeDevice(serial, percent)}
+}
+
+func (d *standbyDevice) GetFeatureReport(data []byte) (int, error) {
+	if data[0] != hid.StandbyReportID {
+		return d.fakeDevice.GetFeatureReport(data)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return copy(data, hid.EncodeStandbyReport(d.standby)), nil
+}
+
+func (d *standbyDevice) SendFeatureReport(data []byte) (int, error) {
+	if data[0] != hid.StandbyReportID {
+		return d.fakeDevice.SendFeatureReport(data)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.standby = data[hid.StandbyOffsetState] != 0
+	return len(data), nil
+}
+
+func (d *standbyDevice) inStandby() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.standby
+}
+
+// addDevice registers a device the fake manager doesn't construct itself, with
+// experimental reports enabled so standby is probed.
+func addDevice(manager *mockDisplayManager, device hid.Device) {
+	display := hid.NewDisplay(device)
+	display.SetExperimentalReports(true)
+	manager.displays = append(manager.displays, device.Info())
+	manager.displayMap[device.Info().Serial] = display
+}
+
+func TestServer_SetStandby(t *testing.T) {
+	supported := newStandbyDevice("A", 50)
+	manager := newFakeManager(newFakeDevice("B", 50))
+	addDevice(manager, supported)
+	server, recorder := newRecordingServer(manager)
+
+	ok, err := server.GetStandbySupported("A")
+	require.Nil(t, err)
+	assert.True(t, ok)
+
+	require.Nil(t, server.SetStandby("A", true))
+	assert.True(t, supported.inStandby())
+	require.Nil(t, server.SetStandby("A", false))
+	assert.False(t, supported.inStandby())
+
+	signals := recorder.named("StandbyChanged")
+	require.Len(t, signals, 2)
+	assert.Equal(t, []any{"A", true}, signals[0].values)
+	assert.Equal(t, []any{"A", false}, signals[1].values)
+
+	// Displays without the standby report are refused without a write
+	ok, err = server.GetStandbySupported("B")
+	require.Nil(t, err)
+	assert.False(t, ok)
+	err = server.SetStandby("B", true)
+	require.NotNil(t, err)
+	assert.Contains(t, err.Body[0], hid.ErrStandbyUnsupported.Error())
+	assert.Len(t, recorder.named("StandbyChanged"), 2)
+
+	features, _ := server.GetSupportedFeatures()
+	assert.Contains(t, features, FeatureStandby)
+}
+
+func TestServer_SetStandby_InvalidSerial(t *testing.T) {
+	server := NewServer(newFakeManager())
+
+	assert.NotNil(t, server.SetStandby("", true))
+	assert.NotNil(t, server.SetStandby("MISSING", true))
+	_, err := server.GetStandbySupported("MISSING")
+	assert.NotNil(t, err)
+}
+
+func TestServer_IdleStandby_FollowsDimmingAndWakesOnActivity(t *testing.T) {
+	supported := newStandbyDevice("A", 50)
+	unsupported := newFakeDevice("B", 50)
+	manager := newFakeManager(unsupported)
+	addDevice(manager, supported)
+	server, recorder := newRecordingServer(manager, WithIdleStandby(2*time.Minute))
+
+	clock := newFakeClock()
+	server.now = clock.Now
+
+	require.Nil(t, server.EnableIdleDim(60, 10))
+	defer server.DisableIdleDim()
+
+	// Dimming comes first
+	clock.Advance(61 * time.Second)
+	server.checkIdle()
+	assert.Equal(t, uint8(10), supported.percent())
+	assert.False(t, supported.inStandby())
+
+	// Standby follows once the longer delay has elapsed, on capable displays only
+	clock.Advance(time.Minute)
+	server.checkIdle()
+	assert.True(t, supported.inStandby())
+	assert.Equal(t, uint8(10), unsupported.percent())
+	assert.Equal(t, []any{"A", true}, recorder.named("StandbyChanged")[0].values)
+
+	// Further checks leave the standby alone
+	clock.Advance(time.Minute)
+	server.checkIdle()
+	assert.Len(t, recorder.named("StandbyChanged"), 1)
+
+	// Activity wakes the display and restores its brightness
+	require.Nil(t, server.NotifyActivity())
+	assert.False(t, supported.inStandby())
+	assert.Equal(t, uint8(50), supported.percent())
+	assert.Equal(t, uint8(50), unsupported.percent())
+	assert.Equal(t, []any{"A", false}, recorder.named("StandbyChanged")[1].values)
+}
+
+func TestServer_IdleStandby_DisabledByDefault(t *testing.T) {
+	supported := newStandbyDevice("A", 50)
+	manager := newFakeManager()
+	addDevice(manager, supported)
+	server := NewServer(manager)
+
+	clock := newFakeClock()
+	server.now = clock.Now
+
+	require.Nil(t, server.EnableIdleDim(60, 10))
+	defer server.DisableIdleDim()
+
+	clock.Advance(time.Hour)
+	server.checkIdle()
+	assert.Equal(t, uint8(10), supported.percent())
+	assert.False(t, supported.inStandby())
+}
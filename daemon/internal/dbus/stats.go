@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"sync/atomic"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// Stats holds cumulative operation counters since the daemon started or since
+// ResetStats was last called, as returned by GetStats.
+type Stats struct {
+	Sets          uint64 // Successful brightness writes made for clients, counted per display
+	Gets          uint64 // Brightness queries answered by GetBrightness and GetAllBrightness
+	Errors        uint64 // Failed display reads and writes
+	Recoveries    uint64 // Device recoveries started or queued for device-gone errors
+	RateLimitHits uint64 // Calls rejected by the rate limit
+}
+
+// serverStats are the atomic counters behind GetStats.
+type serverStats struct {
+	sets          atomic.Uint64
+	gets          atomic.Uint64
+	errors        atomic.Uint64
+	recoveries    atomic.Uint64
+	rateLimitHits atomic.Uint64
+}
+
+// GetStats returns cumulative operation counters, for clients and scripts that
+// want usage numbers without running a metrics collector.
+func (s *Server) GetStats() (Stats, *dbus.Error) {
+	return Stats{
+		Sets:          s.stats.sets.Load(),
+		Gets:          s.stats.gets.Load(),
+		Errors:        s.stats.errors.Load(),
+		Recoveries:    s.stats.recoveries.Load(),
+		RateLimitHits: s.stats.rateLimitHits.Load(),
+	}, nil
+}
+
+// ResetStats sets every counter returned by GetStats back to zero.
+func (s *Server) ResetStats() *dbus.Error {
+	s.stats.sets.Store(0)
+	s.stats.gets.Store(0)
+	s.stats.errors.Store(0)
+	s.stats.recoveries.Store(0)
+	s.stats.rateLimitHits.Store(0)
+
+	log.Debug().Msg("Reset stats")
+	return nil
+}
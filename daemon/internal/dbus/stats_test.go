@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestServer_GetStats(t *testing.T) {
+	manager := newFakeManager(newFakeDevice("A", 50), newFakeDevice("B", 50))
+	manager.displayMap["GONE"] = hid.NewDisplay(&failingDevice{fakeDevice: newFakeDevice("GONE", 50), err: syscall.ENODEV})
+	manager.displayMap["BROKEN"] = hid.NewDisplay(&unreadableDevice{fakeDevice: newFakeDevice("BROKEN", 50)})
+	server := NewServer(manager)
+	server.rateLimits = newRateLimiters(rate.Inf, 0)
+	server.SetDeviceErrorHandler(func(string, error) {})
+
+	stats, err := server.GetStats()
+	require.Nil(t, err)
+	assert.Equal(t, Stats{}, stats)
+
+	require.Nil(t, server.SetBrightness("A", 60))
+	require.Nil(t, server.IncreaseBrightness("A", 10))
+	failures, err := server.SetBrightnessMap(map[string]uint32{"B": 30})
+	require.Nil(t, err)
+	require.Empty(t, failures)
+	_, err = server.GetBrightness("A")
+	require.Nil(t, err)
+	_, err = server.GetBrightness("BROKEN")
+	require.NotNil(t, err)
+	require.NotNil(t, server.SetBrightness("GONE", 10))
+
+	server.rateLimits = newRateLimiters(0, 0)
+	require.NotNil(t, server.SetBrightness("A", 10))
+
+	stats, err = server.GetStats()
+	require.Nil(t, err)
+	assert.Equal(t, Stats{Sets: 3, Gets: 2, Errors: 2, Recoveries: 1, RateLimitHits: 1}, stats)
+
+	require.Nil(t, server.ResetStats())
+	stats, err = server.GetStats()
+	require.Nil(t, err)
+	assert.Equal(t, Stats{}, stats)
+}
+
+func TestServer_GetStats_NoRecoveryWithoutHandler(t *testing.T) {
+	manager := newFakeManager()
+	manager.displayMap["GONE"] = hid.NewDisplay(&failingDevice{fakeDevice: newFakeDevice("GONE", 50), err: syscall.ENODEV})
+	server := NewServer(manager)
+	server.rateLimits = newRateLimiters(rate.Inf, 0)
+
+	require.NotNil(t, server.SetBrightness("GONE", 10))
+
+	stats, err := server.GetStats()
+	require.Nil(t, err)
+	assert.Equal(t, uint64(1), stats.Errors)
+	assert.Zero(t, stats.Recoveries, "nothing recovers the display without a device error handler")
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// FeatureBrightnessStops is advertised when brightness snapping to stops is enabled.
+const FeatureBrightnessStops = "brightness-stops"
+
+// WithBrightnessStops makes brightness snap to a fixed set of percentages, e.g.
+// 0, 25, 50, 75 and 100: every method setting an absolute brightness (SetBrightness,
+// SetBrightnessTimed, FadeBrightness, SetBrightnessChecked, SetBrightnessFraction,
+// SetBrightnessMap and SetAllBrightness) rounds to the nearest stop, and
+// IncreaseBrightness and DecreaseBrightness move to the next or previous stop
+// regardless of their step. Values above 100 are clamped to 100. An empty list
+// disables snapping, which is the default.
+func WithBrightnessStops(stops []uint32) ServerOption {
+	return func(s *Server) {
+		s.stops = make([]uint32, 0, len(stops))
+		for _, stop := range stops {
+			s.stops = append(s.stops, min(stop, 100))
+		}
+		slices.Sort(s.stops)
+		s.stops = slices.Compact(s.stops)
+		if len(s.stops) > 0 {
+			s.extraFeatures = append(s.extraFeatures, FeatureBrightnessStops)
+		}
+	}
+}
+
+// GetBrightnessStops returns the configured brightness stops in ascending order,
+// or an empty list if snapping is disabled.
+func (s *Server) GetBrightnessStops() ([]uint32, *dbus.Error) {
+	return slices.Clone(s.stops), nil
+}
+
+// nearestStop returns the stop closest to percent, preferring the higher stop on
+// a tie. It returns percent unchanged if snapping is disabled.
+func (s *Server) nearestStop(percent uint32) uint32 {
+	if len(s.stops) == 0 {
+		return percent
+	}
+
+	i, _ := slices.BinarySearch(s.stops, percent)
+	switch {
+	case i == len(s.stops):
+		return s.stops[i-1]
+	case i == 0:
+		return s.stops[0]
+	case percent-s.stops[i-1] < s.stops[i]-percent:
+		return s.stops[i-1]
+	default:
+		return s.stops[i]
+	}
+}
+
+// nextStop returns the lowest stop above percent, or the highest stop if there is none.
+func (s *Server) nextStop(percent uint32) uint32 {
+	for _, stop := range s.stops {
+		if stop > percent {
+			return stop
+		}
+	}
+	return s.stops[len(s.stops)-1]
+}
+
+// previousStop returns the highest stop below percent, or the lowest stop if there is none.
+func (s *Server) previousStop(percent uint32) uint32 {
+	for _, stop := range slices.Backward(s.stops) {
+		if stop < percent {
+			return stop
+		}
+	}
+	return s.stops[0]
+}
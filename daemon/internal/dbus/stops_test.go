@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestWithBrightnessStops(t *testing.T) {
+	server := NewServer(newFakeManager(), WithBrightnessStops([]uint32{75, 0, 150, 25, 75}))
+
+	stops, _ := server.GetBrightnessStops()
+	assert.Equal(t, []uint32{0, 25, 75, 100}, stops, "stops are sorted, clamped and deduplicated")
+
+	features, _ := server.GetSupportedFeatures()
+	assert.Contains(t, features, FeatureBrightnessStops)
+
+	disabled := NewServer(newFakeManager())
+	stops, _ = disabled.GetBrightnessStops()
+	assert.Empty(t, stops)
+	features, _ = disabled.GetSupportedFeatures()
+	assert.NotContains(t, features, FeatureBrightnessStops)
+}
+
+func TestServer_NearestStop(t *testing.T) {
+	server := NewServer(newFakeManager(), WithBrightnessStops([]uint32{0, 25, 50, 75, 100}))
+
+	tests := []struct {
+		percent  uint32
+		expected uint32
+	}{
+		{percent: 0, expected: 0},
+		{percent: 12, expected: 0},
+		{percent: 13, expected: 25},
+		{percent: 37, expected: 25},
+		{percent: 38, expected: 50},
+		{percent: 50, expected: 50},
+		{percent: 88, expected: 100},
+		{percent: 100, expected: 100},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, server.nearestStop(tt.percent), "percent %d", tt.percent)
+	}
+
+	narrow := NewServer(newFakeManager(), WithBrightnessStops([]uint32{20, 80}))
+	assert.Equal(t, uint32(20), narrow.nearestStop(5), "below the lowest stop")
+	assert.Equal(t, uint32(80), narrow.nearestStop(95), "above the highest stop")
+	assert.Equal(t, uint32(50), NewServer(newFakeManager()).nearestStop(50), "disabled snapping keeps the value")
+}
+
+func TestServer_SetBrightness_SnapsToStop(t *testing.T) {
+	display := newFakeDevice("A", 50)
+	server, recorder := newRecordingServer(newFakeManager(display), WithBrightnessStops([]uint32{0, 25, 50, 75, 100}))
+	server.rateLimits = newRateLimiters(rate.Inf, 0)
+
+	require.Nil(t, server.SetBrightness("A", 70))
+	assert.Equal(t, uint8(75), display.percent())
+
+	require.Nil(t, server.SetBrightness("A", 10))
+	assert.Equal(t, uint8(0), display.percent())
+
+	signals := recorder.named("BrightnessChanged")
+	require.Len(t, signals, 2)
+	assert.Equal(t, uint32(75), signals[0].values[1])
+}
+
+func TestServer_StepBrightness_MovesBetweenStops(t *testing.T) {
+	display := newFakeDevice("A", 30)
+	server := NewServer(newFakeManager(display), WithBrightnessStops([]uint32{0, 25, 50, 75, 100}))
+	server.rateLimits = newRateLimiters(rate.Inf, 0)
+
+	require.Nil(t, server.IncreaseBrightness("A", 1))
+	assert.Equal(t, uint8(50), display.percent(), "the step is ignored in favour of the next stop")
+	require.Nil(t, server.IncreaseBrightness("A", 100))
+	assert.Equal(t, uint8(75), display.percent())
+	require.Nil(t, server.IncreaseBrightness("A", 5))
+	require.Nil(t, server.IncreaseBrightness("A", 5))
+	assert.Equal(t, uint8(100), display.percent(), "the highest stop is kept")
+
+	require.Nil(t, server.DecreaseBrightness("A", 1))
+	assert.Equal(t, uint8(75), display.percent())
+
+	display.setExternally(60)
+	require.Nil(t, server.DecreaseBrightness("A", 50))
+	assert.Equal(t, uint8(50), display.percent(), "an off-stop value moves to the stop below it")
+	require.Nil(t, server.DecreaseBrightness("A", 5))
+	require.Nil(t, server.DecreaseBrightness("A", 5))
+	require.Nil(t, server.DecreaseBrightness("A", 5))
+	assert.Equal(t, uint8(0), display.percent(), "the lowest stop is kept")
+}
+
+func TestServer_AbsoluteSetPaths_SnapToStop(t *testing.T) {
+	stops := WithBrightnessStops([]uint32{0, 25, 50, 75, 100})
+
+	t.Run("fade", func(t *testing.T) {
+		display := newFakeDevice("A", 30)
+		server := NewServer(newFakeManager(display), stops)
+		server.rateLimits = newRateLimiters(rate.Inf, 0)
+
+		require.Nil(t, server.SetBrightnessTimed("A", 68, 100))
+		assert.Eventually(t, func() bool { return display.percent() == 75 }, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("checked", func(t *testing.T) {
+		display := newFakeDevice("A", 30)
+		server := NewServer(newFakeManager(display), stops)
+		server.rateLimits = newRateLimiters(rate.Inf, 0)
+
+		_, err := server.SetBrightnessChecked("A", 62)
+		require.Nil(t, err)
+		assert.Equal(t, uint8(50), display.percent())
+	})
+
+	t.Run("map", func(t *testing.T) {
+		first, second := newFakeDevice("A", 30), newFakeDevice("B", 30)
+		server := NewServer(newFakeManager(first, second), stops)
+		server.rateLimits = newRateLimiters(rate.Inf, 0)
+
+		_, err := server.SetBrightnessMap(map[string]uint32{"A": 12, "B": 90})
+		require.Nil(t, err)
+		assert.Equal(t, uint8(0), first.percent())
+		assert.Equal(t, uint8(100), second.percent())
+	})
+
+	t.Run("all", func(t *testing.T) {
+		first, second := newFakeDevice("A", 30), newFakeDevice("B", 60)
+		server := NewServer(newFakeManager(first, second), stops)
+		server.rateLimits = newRateLimiters(rate.Inf, 0)
+
+		require.Nil(t, server.SetAllBrightness(40))
+		assert.Equal(t, uint8(50), first.percent())
+		assert.Equal(t, uint8(50), second.percent())
+	})
+}
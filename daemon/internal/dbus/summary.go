@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrNoBrightnessRead is returned by GetBrightnessSummary when displays are
+// connected but none of them could be read.
+var ErrNoBrightnessRead = errors.New("failed to read brightness of any display")
+
+// BrightnessSummary aggregates the brightness of all connected displays, as
+// returned by GetBrightnessSummary. All fields are 0 when no displays are connected.
+type BrightnessSummary struct {
+	Count   uint32 // Number of displays included
+	Min     uint32 // Lowest brightness percentage
+	Max     uint32 // Highest brightness percentage
+	Average uint32 // Mean brightness percentage, rounded to the nearest integer
+}
+
+// GetBrightnessSummary reads every display and returns the minimum, maximum and
+// average brightness, e.g. for a single indicator representing several monitors.
+// Displays that fail to read are left out of the summary. See sweepBrightness for
+// how reads are scheduled.
+func (s *Server) GetBrightnessSummary() (BrightnessSummary, *dbus.Error) {
+	s.recordActivity()
+
+	displays := s.manager.Snapshot()
+	if len(displays) == 0 {
+		return BrightnessSummary{}, nil
+	}
+
+	var readings []uint32
+	for serial, reading := range s.sweepBrightness(displays) {
+		if reading.err != nil {
+			log.Warn().Err(reading.err).Str("serial", serial).Msg("Failed to read brightness for summary")
+			continue
+		}
+		readings = append(readings, reading.percent)
+	}
+
+	if len(readings) == 0 {
+		return BrightnessSummary{}, dbus.MakeFailedError(ErrNoBrightnessRead)
+	}
+	return summarize(readings), nil
+}
+
+// summarize computes the summary of a non-empty set of readings.
+func summarize(readings []uint32) BrightnessSummary {
+	summary := BrightnessSummary{Min: readings[0], Max: readings[0]}
+	var sum uint32
+	for _, percent := range readings {
+		summary.Min = min(summary.Min, percent)
+		summary.Max = max(summary.Max, percent)
+		sum += percent
+	}
+	// #nosec G115 -- the number of displays is bounded by the manager's display cap
+	summary.Count = uint32(len(readings))
+	summary.Average = (sum + summary.Count/2) / summary.Count
+	return summary
+}
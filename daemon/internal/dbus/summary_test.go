@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unreadableDevice fails every feature report read.
+type unreadableDevice struct {
+	*fakeDevice
+}
+
+func (d *unreadableDevice) GetFeatureReport(data []byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestServer_GetBrightnessSummary(t *testing.T) {
+	server := NewServer(newFakeManager(newFakeDevice("A", 20), newFakeDevice("B", 50), newFakeDevice("C", 80)))
+
+	summary, err := server.GetBrightnessSummary()
+	require.Nil(t, err)
+	assert.Equal(t, BrightnessSummary{Count: 3, Min: 20, Max: 80, Average: 50}, summary)
+}
+
+func TestServer_GetBrightnessSummary_NoDisplays(t *testing.T) {
+	server := NewServer(newFakeManager())
+
+	summary, err := server.GetBrightnessSummary()
+	require.Nil(t, err)
+	assert.Equal(t, BrightnessSummary{}, summary)
+}
+
+func TestServer_GetBrightnessSummary_SkipsUnreadableDisplays(t *testing.T) {
+	manager := newFakeManager(newFakeDevice("A", 30), newFakeDevice("B", 61))
+	manager.displayMap["BROKEN"] = hid.NewDisplay(&unreadableDevice{fakeDevice: newFakeDevice("BROKEN", 100)})
+	server := NewServer(manager)
+
+	summary, err := server.GetBrightnessSummary()
+	require.Nil(t, err)
+	assert.Equal(t, BrightnessSummary{Count: 2, Min: 30, Max: 61, Average: 46}, summary)
+
+	delete(manager.displayMap, "A")
+	delete(manager.displayMap, "B")
+	_, err = server.GetBrightnessSummary()
+	require.NotNil(t, err)
+	assert.Contains(t, err.Error(), ErrNoBrightnessRead.Error())
+}
@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+)
+
+// brightnessReading is the outcome of reading one display in a sweep.
+type brightnessReading struct {
+	percent uint32
+	err     error // nil if percent holds the display's brightness
+}
+
+// GetAllBrightness reads every connected display and returns its brightness keyed
+// by serial. Displays that fail to read are left out; the map is empty when no
+// displays are connected. See sweepBrightness for how reads are scheduled.
+func (s *Server) GetAllBrightness() (map[string]uint32, *dbus.Error) {
+	s.recordActivity()
+	s.stats.gets.Add(1)
+
+	readings := s.sweepBrightness(s.manager.Snapshot())
+	values := make(map[string]uint32, len(readings))
+	for serial, reading := range readings {
+		if reading.err != nil {
+			log.Warn().Err(reading.err).Str("serial", serial).Msg("Failed to read brightness")
+			continue
+		}
+		values[serial] = reading.percent
+	}
+	return values, nil
+}
+
+// sweepBrightness reads the brightness of every display, using the GetBrightness
+// cache where it holds a value. Displays on the same USB controller (see
+// hid.WithControllerWriteQueue) are read one after another so they don't contend
+// for a saturated controller, while different controllers, and displays whose
+// controller is unknown, are read in parallel.
+func (s *Server) sweepBrightness(displays map[string]*hid.Display) map[string]brightnessReading {
+	groups := make(map[string][]string)
+	for serial, display := range displays {
+		key := display.Controller()
+		if key == "" {
+			// Not a valid controller path, so it can't collide with one
+			key = "\x00" + serial
+		}
+		groups[key] = append(groups[key], serial)
+	}
+
+	readings := make(map[string]brightnessReading, len(displays))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, serials := range groups {
+		slices.Sort(serials)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, serial := range serials {
+				reading := s.readForSweep(serial, displays[serial])
+				mu.Lock()
+				readings[serial] = reading
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return readings
+}
+
+// readForSweep reads one display for sweepBrightness.
+func (s *Server) readForSweep(serial string, display *hid.Display) brightnessReading {
+	if percent, ok := s.cachedBrightnessFor(serial); ok {
+		return brightnessReading{percent: percent}
+	}
+
+	version := s.cacheVersion(serial)
+	brightness, err := display.GetBrightness()
+	if err != nil {
+		s.handleDeviceError(serial, err)
+		return brightnessReading{err: err}
+	}
+	s.cacheBrightness(serial, uint32(brightness), version)
+	return brightnessReading{percent: uint32(brightness)}
+}
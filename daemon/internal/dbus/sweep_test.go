@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowReadDevice holds every read open for a while and tracks how many reads are
+// in flight per controller and overall.
+type slowReadDevice struct {
+	*fakeDevice
+	inFlight *atomic.Int32 // reads in flight on this device's controller
+	peak     *atomic.Int32 // highest value inFlight reached
+	total    *atomic.Int32 // reads in flight across all controllers
+	overlap  *atomic.Bool  // set when reads on different controllers overlapped
+}
+
+func (d *slowReadDevice) GetFeatureReport(data []byte) (int, error) {
+	n := d.inFlight.Add(1)
+	defer d.inFlight.Add(-1)
+	for {
+		p := d.peak.Load()
+		if n <= p || d.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	if d.total.Add(1) > n {
+		d.overlap.Store(true)
+	}
+	defer d.total.Add(-1)
+
+	time.Sleep(20 * time.Millisecond)
+	return d.fakeDevice.GetFeatureReport(data)
+}
+
+func TestServer_GetAllBrightness_OrdersReadsByController(t *testing.T) {
+	controllers := map[string]string{"A": "ctrl0", "B": "ctrl0", "C": "ctrl0", "D": "ctrl1"}
+	percents := map[string]uint8{"A": 10, "B": 20, "C": 30, "D": 40}
+
+	var total atomic.Int32
+	var overlap atomic.Bool
+	inFlight := map[string]*atomic.Int32{"ctrl0": {}, "ctrl1": {}}
+	peak := map[string]*atomic.Int32{"ctrl0": {}, "ctrl1": {}}
+	manager := hid.NewManager(
+		hid.WithEnumerator(func() ([]hid.DeviceInfo, error) {
+			return []hid.DeviceInfo{{Serial: "A"}, {Serial: "B"}, {Serial: "C"}, {Serial: "D"}}, nil
+		}),
+		hid.WithOpener(func(serial string) (hid.Device, error) {
+			controller := controllers[serial]
+			return &slowReadDevice{
+				fakeDevice: newFakeDevice(serial, percents[serial]),
+				inFlight:   inFlight[controller],
+				peak:       peak[controller],
+				total:      &total,
+				overlap:    &overlap,
+			}, nil
+		}),
+		hid.WithControllerWriteQueue(func(info hid.DeviceInfo) string { return controllers[info.Serial] }),
+	)
+	require.NoError(t, manager.RefreshDisplays())
+	server := NewServer(manager)
+
+	values, dbusErr := server.GetAllBrightness()
+	require.Nil(t, dbusErr)
+
+	assert.Equal(t, map[string]uint32{"A": 10, "B": 20, "C": 30, "D": 40}, values)
+	assert.Equal(t, int32(1), peak["ctrl0"].Load(), "reads on a shared controller must not overlap")
+	assert.True(t, overlap.Load(), "reads on different controllers should run concurrently")
+}
+
+func TestServer_GetAllBrightness_LeavesOutFailingDisplays(t *testing.T) {
+	broken := &unreadableDevice{fakeDevice: newFakeDevice("B", 0)}
+	manager := newFakeManager(newFakeDevice("A", 60))
+	manager.displays = append(manager.displays, broken.Info())
+	manager.displayMap["B"] = hid.NewDisplay(broken)
+	server := NewServer(manager)
+
+	values, dbusErr := server.GetAllBrightness()
+	require.Nil(t, dbusErr)
+	assert.Equal(t, map[string]uint32{"A": 60}, values)
+
+	values, dbusErr = NewServer(newFakeManager()).GetAllBrightness()
+	require.Nil(t, dbusErr)
+	assert.Empty(t, values)
+}
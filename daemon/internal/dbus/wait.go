@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// serviceWaitInterval is the delay between checks for the service name while waiting.
+const serviceWaitInterval = 100 * time.Millisecond
+
+// ErrServiceNotRunning is returned by WaitForService when the daemon didn't claim
+// its name on the session bus in time.
+var ErrServiceNotRunning = errors.New("daemon is not running")
+
+// WaitForService connects to the session bus and waits up to timeout for the
+// daemon to claim ServiceName, so clients started alongside the daemon, e.g. from
+// login scripts, don't fail because they won the race.
+func WaitForService(timeout time.Duration) error {
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		return fmt.Errorf("failed to connect to session bus: %w", err)
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log.Debug().Err(err).Msg("Failed to close session bus connection")
+		}
+	}()
+
+	return waitForName(conn.BusObject(), ServiceName, timeout, serviceWaitInterval)
+}
+
+// waitForName polls NameHasOwner every interval until name has an owner, giving
+// up with ErrServiceNotRunning once timeout has passed. The name is checked at
+// least once, so a zero timeout only checks whether it's owned right now.
+func waitForName(bus busCaller, name string, timeout, interval time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		var owned bool
+		if err := bus.Call("org.freedesktop.DBus.NameHasOwner", 0, name).Store(&owned); err != nil {
+			return fmt.Errorf("failed to check for %s: %w", name, err)
+		}
+		if owned {
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			return fmt.Errorf("%w: %s did not appear on the bus within %s", ErrServiceNotRunning, name, timeout)
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// appearingBus reports a name as unowned for a number of NameHasOwner calls,
+// then as owned.
+type appearingBus struct {
+	absentPolls int
+	calls       int
+	names       []string
+}
+
+func (b *appearingBus) Call(method string, _ dbus.Flags, args ...any) *dbus.Call {
+	if method != "org.freedesktop.DBus.NameHasOwner" {
+		return &dbus.Call{Err: errors.New("unexpected call " + method)}
+	}
+	b.calls++
+	b.names = append(b.names, args[0].(string))
+	return &dbus.Call{Body: []any{b.calls > b.absentPolls}}
+}
+
+func TestWaitForName(t *testing.T) {
+	t.Run("name appears after a couple of polls", func(t *testing.T) {
+		bus := &appearingBus{absentPolls: 2}
+		require.NoError(t, waitForName(bus, ServiceName, time.Second, time.Millisecond))
+		assert.Equal(t, 3, bus.calls)
+		assert.Equal(t, []string{ServiceName, ServiceName, ServiceName}, bus.names)
+	})
+
+	t.Run("name already owned", func(t *testing.T) {
+		bus := &appearingBus{}
+		require.NoError(t, waitForName(bus, ServiceName, 0, time.Millisecond))
+		assert.Equal(t, 1, bus.calls)
+	})
+
+	t.Run("times out", func(t *testing.T) {
+		bus := &appearingBus{absentPolls: 1000}
+		err := waitForName(bus, ServiceName, 20*time.Millisecond, 5*time.Millisecond)
+		assert.ErrorIs(t, err, ErrServiceNotRunning)
+		assert.Less(t, bus.calls, 1000)
+	})
+
+	t.Run("bus error", func(t *testing.T) {
+		bus := &fakeBus{replies: map[string]*dbus.Call{
+			"org.freedesktop.DBus.NameHasOwner": {Err: errors.New("disconnected")},
+		}}
+		err := waitForName(bus, ServiceName, time.Second, time.Millisecond)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrServiceNotRunning)
+	})
+}
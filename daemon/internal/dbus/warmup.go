@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrWarmingUp is returned by GetBrightness for a display that is still warming up
+// under the WarmUpError policy.
+var ErrWarmingUp = errors.New("display is warming up")
+
+// DefaultWarmUpMaxBlock is how long GetBrightness waits at most under WarmUpBlock.
+const DefaultWarmUpMaxBlock = 500 * time.Millisecond
+
+// WarmUpPolicy controls how GetBrightness answers for a display that connected
+// within the warm-up period, when its readings may not be settled yet.
+type WarmUpPolicy int
+
+const (
+	// WarmUpBlock waits for the warm-up period to end, up to a maximum, then reads
+	// the display (default).
+	WarmUpBlock WarmUpPolicy = iota
+	// WarmUpLastKnown returns the display's last known brightness without reading
+	// it, falling back to a read if none is known.
+	WarmUpLastKnown
+	// WarmUpError returns ErrWarmingUp.
+	WarmUpError
+)
+
+// String returns the flag value for the policy.
+func (p WarmUpPolicy) String() string {
+	switch p {
+	case WarmUpLastKnown:
+		return "last-known"
+	case WarmUpError:
+		return "error"
+	default:
+		return "block"
+	}
+}
+
+// ParseWarmUpPolicy parses a policy name ("block", "last-known" or "error").
+func ParseWarmUpPolicy(name string) (WarmUpPolicy, error) {
+	switch name {
+	case "block":
+		return WarmUpBlock, nil
+	case "last-known":
+		return WarmUpLastKnown, nil
+	case "error":
+		return WarmUpError, nil
+	default:
+		return WarmUpBlock, fmt.Errorf("invalid warm-up policy %q (expected block, last-known or error)", name)
+	}
+}
+
+// WithWarmUp treats displays as warming up for period after DisplayAdded and makes
+// GetBrightness answer for them according to policy. maxBlock bounds the wait of
+// WarmUpBlock; values of 0 or less use DefaultWarmUpMaxBlock. A period of 0
+// disables warm-up handling (default). Displays found at startup never warm up.
+func WithWarmUp(period time.Duration, policy WarmUpPolicy, maxBlock time.Duration) ServerOption {
+	return func(s *Server) {
+		if maxBlock <= 0 {
+			maxBlock = DefaultWarmUpMaxBlock
+		}
+		s.warmUpPeriod = period
+		s.warmUpPolicy = policy
+		s.warmUpMaxBlock = maxBlock
+	}
+}
+
+// GetWarmUpPolicy returns the configured warm-up policy name and period in
+// milliseconds. A period of 0 means warm-up handling is disabled.
+func (s *Server) GetWarmUpPolicy() (string, uint32, *dbus.Error) {
+	// #nosec G115 -- warm-up periods are far below 2^32 ms
+	return s.warmUpPolicy.String(), uint32(s.warmUpPeriod.Milliseconds()), nil
+}
+
+// startWarmUp records that serial just connected.
+func (s *Server) startWarmUp(serial string) {
+	if s.warmUpPeriod <= 0 {
+		return
+	}
+
+	s.warmUpMu.Lock()
+	defer s.warmUpMu.Unlock()
+
+	if s.connectedAt == nil {
+		s.connectedAt = make(map[string]time.Time)
+	}
+	s.connectedAt[serial] = s.now()
+}
+
+// endWarmUp forgets the connect time of a removed display.
+func (s *Server) endWarmUp(serial string) {
+	s.warmUpMu.Lock()
+	defer s.warmUpMu.Unlock()
+
+	delete(s.connectedAt, serial)
+}
+
+// warmUpRemaining returns how much of serial's warm-up period is left, or 0 if
+// it isn't warming up.
+func (s *Server) warmUpRemaining(serial string) time.Duration {
+	if s.warmUpPeriod <= 0 {
+		return 0
+	}
+
+	s.warmUpMu.Lock()
+	defer s.warmUpMu.Unlock()
+
+	connected, ok := s.connectedAt[serial]
+	if !ok {
+		return 0
+	}
+	remaining := s.warmUpPeriod - s.now().Sub(connected)
+	if remaining <= 0 {
+		delete(s.connectedAt, serial)
+		return 0
+	}
+	return remaining
+}
+
+// warmUpBrightness applies the warm-up policy to a GetBrightness of serial. With
+// answered set, the caller returns brightness and err as is; otherwise it reads
+// the display as usual.
+func (s *Server) warmUpBrightness(serial string) (brightness uint32, answered bool, err *dbus.Error) {
+	remaining := s.warmUpRemaining(serial)
+	if remaining <= 0 {
+		return 0, false, nil
+	}
+
+	switch s.warmUpPolicy {
+	case WarmUpLastKnown:
+		if known, ok := s.knownBrightness(serial); ok {
+			log.Debug().Str("serial", serial).Uint32("brightness", known).Msg("Display warming up, returning last known brightness")
+			return known, true, nil
+		}
+	case WarmUpError:
+		return 0, true, dbus.MakeFailedError(fmt.Errorf("%w: ready in %s", ErrWarmingUp, remaining.Round(time.Millisecond)))
+	default:
+		wait := min(remaining, s.warmUpMaxBlock)
+		log.Debug().Str("serial", serial).Dur("wait", wait).Msg("Display warming up, waiting before reading brightness")
+		s.sleep(wait)
+	}
+	return 0, false, nil
+}
@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package dbus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newWarmingServer returns a server whose display A connected just now, after
+// its brightness was last known to be 30%. The display itself reads 80%.
+func newWarmingServer(t *testing.T, policy WarmUpPolicy) (*Server, *fakeDevice, *fakeClock, *[]time.Duration) {
+	t.Helper()
+
+	display := newFakeDevice("A", 80)
+	server := NewServer(newFakeManager(display), WithWarmUp(2*time.Second, policy, 500*time.Millisecond))
+	clock := newFakeClock()
+	server.now = clock.Now
+	var waits []time.Duration
+	server.sleep = func(d time.Duration) {
+		waits = append(waits, d)
+		clock.Advance(d)
+	}
+
+	server.recordKnownBrightness("A", 30)
+	server.EmitDisplayAdded("A", "Studio Display")
+	return server, display, clock, &waits
+}
+
+func TestServer_WarmUp_BlockWaitsThenReads(t *testing.T) {
+	server, display, clock, waits := newWarmingServer(t, WarmUpBlock)
+
+	value, err := server.GetBrightness("A")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(80), value)
+	assert.Equal(t, []time.Duration{500 * time.Millisecond}, *waits, "wait is capped")
+	assert.Equal(t, 1, display.readCount())
+
+	clock.Advance(1400 * time.Millisecond)
+	_, err = server.GetBrightness("A")
+	require.Nil(t, err)
+	assert.Equal(t, []time.Duration{500 * time.Millisecond, 100 * time.Millisecond}, *waits, "waits only for the rest of the period")
+
+	_, err = server.GetBrightness("A")
+	require.Nil(t, err)
+	assert.Len(t, *waits, 2, "no wait after warm-up")
+}
+
+func TestServer_WarmUp_LastKnownSkipsRead(t *testing.T) {
+	server, display, clock, waits := newWarmingServer(t, WarmUpLastKnown)
+
+	value, stale, err := server.GetBrightnessDetailed("A")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(30), value)
+	assert.True(t, stale)
+	assert.Equal(t, 0, display.readCount())
+	assert.Empty(t, *waits)
+
+	clock.Advance(2 * time.Second)
+	value, stale, err = server.GetBrightnessDetailed("A")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(80), value)
+	assert.False(t, stale)
+}
+
+func TestServer_WarmUp_LastKnownReadsWithoutKnownValue(t *testing.T) {
+	display := newFakeDevice("B", 60)
+	server := NewServer(newFakeManager(display), WithWarmUp(time.Hour, WarmUpLastKnown, 0))
+	server.EmitDisplayAdded("B", "Studio Display")
+
+	value, err := server.GetBrightness("B")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(60), value)
+}
+
+func TestServer_WarmUp_ErrorPolicy(t *testing.T) {
+	server, display, clock, _ := newWarmingServer(t, WarmUpError)
+
+	_, err := server.GetBrightness("A")
+	require.NotNil(t, err)
+	assert.Contains(t, err.Body[0], ErrWarmingUp.Error())
+	assert.Equal(t, 0, display.readCount())
+
+	clock.Advance(2 * time.Second)
+	value, err := server.GetBrightness("A")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(80), value)
+}
+
+func TestServer_WarmUp_DisabledByDefault(t *testing.T) {
+	display := newFakeDevice("A", 80)
+	server := NewServer(newFakeManager(display))
+	server.EmitDisplayAdded("A", "Studio Display")
+
+	value, err := server.GetBrightness("A")
+	require.Nil(t, err)
+	assert.Equal(t, uint32(80), value)
+
+	policy, period, err := server.GetWarmUpPolicy()
+	require.Nil(t, err)
+	assert.Equal(t, "block", policy)
+	assert.Equal(t, uint32(0), period)
+}
+
+func TestParseWarmUpPolicy(t *testing.T) {
+	for _, policy := range []WarmUpPolicy{WarmUpBlock, WarmUpLastKnown, WarmUpError} {
+		parsed, err := ParseWarmUpPolicy(policy.String())
+		require.NoError(t, err)
+		assert.Equal(t, policy, parsed)
+	}
+
+	_, err := ParseWarmUpPolicy("wait")
+	assert.Error(t, err)
+}
@@ -0,0 +1,265 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/brightness"
+)
+
+// DefaultBacklightDir holds the sysfs backlight class devices.
+const DefaultBacklightDir = "/sys/class/backlight"
+
+// BacklightInterface is the DeviceInfo.Interface of displays driven through a
+// sysfs backlight node rather than a HID interface.
+const BacklightInterface = -1
+
+// backlightSearchDepth is how many levels, starting at the backlight's parent
+// device, are checked for the USB device attributes: the device itself, its USB
+// interface and the USB device.
+const backlightSearchDepth = 3
+
+// ErrInvalidBacklight is returned when a backlight node's attributes can't be used.
+var ErrInvalidBacklight = errors.New("invalid backlight")
+
+// ErrUnsupportedReport is returned by a BacklightDevice for any feature report
+// other than the brightness report, which is all a backlight node can serve.
+var ErrUnsupportedReport = errors.New("unsupported feature report")
+
+// WithBacklightFallback drives displays through their sysfs backlight node, found
+// in dir (normally DefaultBacklightDir), when their HID interface can't be opened.
+// On some connection modes the display has no HID brightness interface at all;
+// such displays are then enumerated from their backlight node alone. The backend
+// is selected per display, preferring HID. An empty dir disables the fallback
+// (default).
+func WithBacklightFallback(dir string) ManagerOption {
+	return func(m *Manager) {
+		m.backlightDir = dir
+	}
+}
+
+// BacklightDevice drives a display through a sysfs backlight node, translating
+// brightness feature reports to and from the node's brightness attribute, so a
+// Display works with it like with a HID device. The node's 0-max_brightness range
+// maps linearly onto the display's range in nits.
+type BacklightDevice struct {
+	dir  string
+	info DeviceInfo
+	max  uint64
+}
+
+// Verify BacklightDevice implements Device interface.
+var _ Device = (*BacklightDevice)(nil)
+
+// OpenBacklight opens the backlight node of a display returned by
+// EnumerateBacklights, whose Path is the node's sysfs directory.
+func OpenBacklight(info DeviceInfo) (*BacklightDevice, error) {
+	maxBrightness, err := readBacklightValue(filepath.Join(info.Path, "max_brightness"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backlight of display %s: %w", info.Serial, err)
+	}
+	if maxBrightness == 0 {
+		return nil, fmt.Errorf("failed to open backlight of display %s: %w: max_brightness is 0", info.Serial, ErrInvalidBacklight)
+	}
+	return &BacklightDevice{dir: info.Path, info: info, max: maxBrightness}, nil
+}
+
+// GetFeatureReport reads the backlight's brightness into a brightness feature report.
+// Returns ErrUnsupportedReport if data doesn't request the brightness report.
+func (d *BacklightDevice) GetFeatureReport(data []byte) (int, error) {
+	if err := checkBacklightReport(data); err != nil {
+		return 0, err
+	}
+	raw, err := readBacklightValue(filepath.Join(d.dir, "brightness"))
+	if err != nil {
+		return 0, err
+	}
+	nits := brightness.FractionToNits(float64(min(raw, d.max)) / float64(d.max))
+	return copy(data, EncodeReport(nits)), nil
+}
+
+// SendFeatureReport writes the brightness of a brightness feature report to the backlight.
+// Returns ErrUnsupportedReport for any other report.
+func (d *BacklightDevice) SendFeatureReport(data []byte) (int, error) {
+	if err := checkBacklightReport(data); err != nil {
+		return 0, err
+	}
+	nits, err := DecodeReport(data)
+	if err != nil {
+		return 0, err
+	}
+	raw := uint64(math.Round(brightness.NitsToFraction(nits) * float64(d.max)))
+	// #nosec G306 -- sysfs attribute; the mode is ignored for existing files
+	if err := os.WriteFile(filepath.Join(d.dir, "brightness"), []byte(strconv.FormatUint(raw, 10)), 0o600); err != nil {
+		return 0, fmt.Errorf("failed to write backlight brightness: %w", err)
+	}
+	return len(data), nil
+}
+
+// checkBacklightReport returns ErrUnsupportedReport unless data carries the
+// brightness report ID.
+func checkBacklightReport(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("%w: empty report", ErrUnsupportedReport)
+	}
+	if data[0] != ReportID {
+		return fmt.Errorf("%w: report ID 0x%02x", ErrUnsupportedReport, data[0])
+	}
+	return nil
+}
+
+// Close does nothing; the backlight node is opened for each access.
+func (d *BacklightDevice) Close() error {
+	return nil
+}
+
+// Info returns information about the device.
+func (d *BacklightDevice) Info() DeviceInfo {
+	return d.info
+}
+
+// EnumerateBacklights returns the Apple Studio Displays that have a backlight node
+// in dir, identified by the USB device the node belongs to. Path is the node's
+// directory and Interface is BacklightInterface. A missing dir means no backlights.
+func EnumerateBacklights(dir string) ([]DeviceInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backlights: %w", err)
+	}
+
+	var displays []DeviceInfo
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		info, ok := backlightDisplay(path)
+		if !ok {
+			continue
+		}
+		log.Debug().Str("serial", info.Serial).Str("backlight", entry.Name()).Msg("Found display backlight")
+		displays = append(displays, info)
+	}
+	return displays, nil
+}
+
+// backlightDisplay returns the display a backlight node belongs to, if it's an
+// Apple Studio Display with a serial number.
+func backlightDisplay(path string) (DeviceInfo, bool) {
+	dir, err := filepath.EvalSymlinks(filepath.Join(path, "device"))
+	if err != nil {
+		return DeviceInfo{}, false
+	}
+
+	// The backlight may hang off the USB interface or a device below it; the
+	// identifying attributes belong to the USB device above them
+	for range backlightSearchDepth {
+		vendor, vendorErr := os.ReadFile(filepath.Join(dir, "idVendor")) // #nosec G304 -- path is built from sysfs
+		if vendorErr == nil {
+			product, _ := os.ReadFile(filepath.Join(dir, "idProduct")) // #nosec G304 -- path is built from sysfs
+			if !sysfsIDEquals(vendor, AppleVendorID) || !sysfsIDEquals(product, StudioDisplayProductID) {
+				return DeviceInfo{}, false
+			}
+			info := DeviceInfo{
+				Path:         path,
+				VendorID:     AppleVendorID,
+				ProductID:    StudioDisplayProductID,
+				Serial:       readSysfsString(filepath.Join(dir, "serial")),
+				Manufacturer: readSysfsString(filepath.Join(dir, "manufacturer")),
+				Product:      readSysfsString(filepath.Join(dir, "product")),
+				Interface:    BacklightInterface,
+			}
+			return info, info.Serial != ""
+		}
+		dir = filepath.Dir(dir)
+	}
+	return DeviceInfo{}, false
+}
+
+// sysfsIDEquals reports whether a hexadecimal sysfs ID attribute equals id.
+func sysfsIDEquals(raw []byte, id uint16) bool {
+	value, err := strconv.ParseUint(strings.TrimSpace(string(raw)), 16, 16)
+	return err == nil && uint16(value) == id
+}
+
+// readSysfsString returns a sysfs string attribute, or "" if it can't be read.
+func readSysfsString(path string) string {
+	raw, err := os.ReadFile(path) // #nosec G304 -- path is built from sysfs
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(raw))
+}
+
+// readBacklightValue reads a numeric backlight attribute.
+func readBacklightValue(path string) (uint64, error) {
+	raw, err := os.ReadFile(path) // #nosec G304 -- path is built from sysfs
+	if err != nil {
+		return 0, fmt.Errorf("failed to read backlight: %w", err)
+	}
+	value, err := strconv.ParseUint(strings.TrimSpace(string(raw)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s: %w", ErrInvalidBacklight, filepath.Base(path), err)
+	}
+	return value, nil
+}
+
+// addBacklights appends the displays with a backlight node in m.backlightDir that
+// devices doesn't already list, and records every display's backlight for open.
+// Must be called with m.refreshMu held.
+func (m *Manager) addBacklights(devices []DeviceInfo) []DeviceInfo {
+	m.backlights = nil
+	if m.backlightDir == "" {
+		return devices
+	}
+	lights, err := EnumerateBacklights(m.backlightDir)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to enumerate display backlights")
+		return devices
+	}
+
+	m.backlights = make(map[string]DeviceInfo, len(lights))
+	for _, light := range lights {
+		m.backlights[light.Serial] = light
+		listed := false
+		for _, info := range devices {
+			listed = listed || info.Serial == light.Serial
+		}
+		if !listed {
+			devices = append(devices, light)
+		}
+	}
+	return devices
+}
+
+// open opens serial with the device opener, falling back to the display's
+// backlight node if that fails and the display has one.
+func (m *Manager) open(serial string) (Device, error) {
+	device, err := m.opener(serial)
+	if err == nil {
+		return device, nil
+	}
+	light, ok := m.backlights[serial]
+	if !ok {
+		return nil, err
+	}
+
+	backlight, lightErr := OpenBacklight(light)
+	if lightErr != nil {
+		return nil, errors.Join(err, lightErr)
+	}
+	log.Info().
+		Str("serial", serial).
+		Str("backlight", filepath.Base(light.Path)).
+		AnErr("hidError", err).
+		Msg("HID interface unavailable, using backlight")
+	return backlight, nil
+}
@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBacklights builds a sysfs tree with a backlight node for a Studio Display
+// with the given serial, one for a laptop panel, and returns the backlight class
+// directory and the display's node.
+func fakeBacklights(t *testing.T, serial string) (dir, node string) {
+	t.Helper()
+	sysfs := t.TempDir()
+
+	usbDevice := filepath.Join(sysfs, "devices", "pci0000:00", "0000:00:14.0", "usb1", "1-2")
+	usbInterface := filepath.Join(usbDevice, "1-2:1.7")
+	require.NoError(t, os.MkdirAll(usbInterface, 0o750))
+	for name, value := range map[string]string{
+		"idVendor": "05ac\n", "idProduct": "1114\n", "serial": serial + "\n",
+		"manufacturer": "Apple Inc.\n", "product": "Studio Display\n",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(usbDevice, name), []byte(value), 0o600))
+	}
+
+	panel := filepath.Join(sysfs, "devices", "pci0000:00", "0000:00:02.0", "drm", "card1", "card1-eDP-1")
+	require.NoError(t, os.MkdirAll(panel, 0o750))
+
+	dir = filepath.Join(sysfs, "class", "backlight")
+	for name, target := range map[string]string{"asd0": usbInterface, "intel_backlight": panel} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, name), 0o750))
+		require.NoError(t, os.Symlink(target, filepath.Join(dir, name, "device")))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name, "max_brightness"), []byte("1000\n"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name, "brightness"), []byte("0\n"), 0o600))
+	}
+	return dir, filepath.Join(dir, "asd0")
+}
+
+func readBacklight(t *testing.T, node string) string {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join(node, "brightness")) // #nosec G304 -- test fixture
+	require.NoError(t, err)
+	return string(raw)
+}
+
+func TestEnumerateBacklights(t *testing.T) {
+	dir, node := fakeBacklights(t, "SN1")
+
+	displays, err := hid.EnumerateBacklights(dir)
+	require.NoError(t, err)
+	require.Len(t, displays, 1, "only Studio Display backlights are listed")
+	assert.Equal(t, hid.DeviceInfo{
+		Path:         node,
+		VendorID:     hid.AppleVendorID,
+		ProductID:    hid.StudioDisplayProductID,
+		Serial:       "SN1",
+		Manufacturer: "Apple Inc.",
+		Product:      "Studio Display",
+		Interface:    hid.BacklightInterface,
+	}, displays[0])
+
+	displays, err = hid.EnumerateBacklights(filepath.Join(dir, "missing"))
+	assert.NoError(t, err)
+	assert.Empty(t, displays)
+}
+
+func TestBacklightDevice_DrivesDisplay(t *testing.T) {
+	dir, node := fakeBacklights(t, "SN1")
+	displays, err := hid.EnumerateBacklights(dir)
+	require.NoError(t, err)
+	device, err := hid.OpenBacklight(displays[0])
+	require.NoError(t, err)
+	display := hid.NewDisplay(device)
+
+	require.NoError(t, display.SetBrightness(50))
+	assert.Equal(t, "500", readBacklight(t, node))
+	require.NoError(t, display.SetBrightness(100))
+	assert.Equal(t, "1000", readBacklight(t, node))
+
+	require.NoError(t, os.WriteFile(filepath.Join(node, "brightness"), []byte("250\n"), 0o600))
+	percent, err := display.GetBrightness()
+	require.NoError(t, err)
+	assert.Equal(t, uint8(25), percent)
+
+	require.NoError(t, os.WriteFile(filepath.Join(node, "brightness"), []byte("bogus\n"), 0o600))
+	_, err = display.GetBrightness()
+	assert.ErrorIs(t, err, hid.ErrInvalidBacklight)
+
+	require.NoError(t, os.WriteFile(filepath.Join(node, "max_brightness"), []byte("0\n"), 0o600))
+	_, err = hid.OpenBacklight(displays[0])
+	assert.ErrorIs(t, err, hid.ErrInvalidBacklight)
+}
+
+func TestManager_BacklightFallback(t *testing.T) {
+	dir, node := fakeBacklights(t, "SN1")
+	errNoInterface := errors.New("no brightness interface")
+
+	hidDevice := &stubDevice{info: hid.DeviceInfo{Serial: "SN2"}}
+	manager := hid.NewManager(
+		hid.WithEnumerator(func() ([]hid.DeviceInfo, error) {
+			return []hid.DeviceInfo{{Serial: "SN2"}}, nil
+		}),
+		hid.WithOpener(func(serial string) (hid.Device, error) {
+			if serial == "SN2" {
+				return hidDevice, nil
+			}
+			return nil, errNoInterface
+		}),
+		hid.WithBacklightFallback(dir),
+	)
+	require.NoError(t, manager.RefreshDisplays())
+	assert.Equal(t, 2, manager.Count(), "a display without a HID interface is found through its backlight")
+
+	// The backend is picked per display
+	hidDisplay, err := manager.GetDisplay("SN2")
+	require.NoError(t, err)
+	require.NoError(t, hidDisplay.SetBrightness(40))
+	assert.Equal(t, 1, hidDevice.writes)
+
+	lightDisplay, err := manager.GetDisplay("SN1")
+	require.NoError(t, err)
+	assert.Equal(t, hid.BacklightInterface, lightDisplay.Info().Interface)
+	require.NoError(t, lightDisplay.SetBrightness(40))
+	assert.Equal(t, "400", readBacklight(t, node))
+
+	// Without the fallback the display can't be used
+	manager = hid.NewManager(
+		hid.WithEnumerator(func() ([]hid.DeviceInfo, error) { return nil, hid.ErrNoDisplaysFound }),
+		hid.WithOpener(func(string) (hid.Device, error) { return nil, errNoInterface }),
+	)
+	assert.ErrorIs(t, manager.RefreshDisplays(), hid.ErrNoDisplaysFound)
+}
+
+func TestBacklightDevice_UnsupportedReport(t *testing.T) {
+	dir, node := fakeBacklights(t, "SN1")
+	displays, err := hid.EnumerateBacklights(dir)
+	require.NoError(t, err)
+	device, err := hid.OpenBacklight(displays[0])
+	require.NoError(t, err)
+
+	report := hid.EncodeReport(400)
+	report[0] = 0x02
+	_, err = device.SendFeatureReport(report)
+	assert.ErrorIs(t, err, hid.ErrUnsupportedReport)
+	assert.True(t, hid.IsStallError(err))
+	assert.Equal(t, "0\n", readBacklight(t, node), "other reports must not change the brightness")
+
+	_, err = device.GetFeatureReport(report)
+	assert.ErrorIs(t, err, hid.ErrUnsupportedReport)
+	_, err = device.GetFeatureReport(nil)
+	assert.ErrorIs(t, err, hid.ErrUnsupportedReport)
+}
@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// HID Feature Report Structure for the factory calibration data
+//
+// This layout is unverified and only used with experimental reports enabled; see
+// experimental.go.
+//
+//	Byte 0:     Report ID (0x02)
+//	Bytes 1-4:  Reference white luminance in nits (little-endian uint32)
+//	Bytes 5-8:  Reserved/unused
+const (
+	// CalibrationReportID is the HID report ID for the calibration data.
+	CalibrationReportID byte = 0x02
+
+	// CalibrationReportSize is the total size of the calibration feature report in bytes.
+	CalibrationReportSize = 9
+
+	// CalibrationOffsetNits is the byte offset of the reference luminance.
+	CalibrationOffsetNits = 1
+
+	// maxReferenceNits bounds plausible reference luminance values; anything higher
+	// is treated as a malformed report rather than passed on to clients.
+	maxReferenceNits = 10000
+)
+
+var (
+	// ErrCalibrationUnsupported is returned when a display doesn't provide calibration data.
+	ErrCalibrationUnsupported = errors.New("calibration data not supported by display")
+
+	// ErrInvalidCalibration is returned when a calibration report is malformed.
+	ErrInvalidCalibration = errors.New("invalid calibration report")
+)
+
+// DecodeCalibrationReport extracts the reference white luminance in nits from a
+// calibration feature report, including its leading report ID byte.
+func DecodeCalibrationReport(data []byte) (uint32, error) {
+	if len(data) == 0 {
+		return 0, ErrCalibrationUnsupported
+	}
+	if data[0] != CalibrationReportID {
+		return 0, fmt.Errorf("%w: report ID 0x%02x, want 0x%02x", ErrInvalidCalibration, data[0], CalibrationReportID)
+	}
+	if len(data) < CalibrationOffsetNits+ReportLenNits {
+		return 0, fmt.Errorf("%w: got %d bytes, need %d", ErrShortReport, len(data), CalibrationOffsetNits+ReportLenNits)
+	}
+
+	nits := binary.LittleEndian.Uint32(data[CalibrationOffsetNits : CalibrationOffsetNits+ReportLenNits])
+	if nits == 0 || nits > maxReferenceNits {
+		return 0, fmt.Errorf("%w: reference luminance %d nits out of range", ErrInvalidCalibration, nits)
+	}
+	return nits, nil
+}
+
+// GetReferenceBrightness reads the factory-calibrated reference white luminance in nits.
+// Returns ErrCalibrationUnsupported if experimental reports are disabled or the
+// display doesn't provide calibration data; the latter is remembered so later calls
+// don't query the display again.
+func (d *Display) GetReferenceBrightness() (uint32, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return 0, d.wrapErr(ErrDisplayClosed)
+	}
+	if !d.experimental || d.noCalibration {
+		return 0, d.wrapErr(ErrCalibrationUnsupported)
+	}
+
+	data := make([]byte, CalibrationReportSize)
+	data[0] = CalibrationReportID
+
+	n, err := d.device.GetFeatureReport(data)
+	if IsStallError(err) {
+		d.noCalibration = true
+		return 0, d.wrapErr(fmt.Errorf("%w: %w", ErrCalibrationUnsupported, err))
+	}
+	if err != nil {
+		return 0, d.wrapErr(fmt.Errorf("failed to get calibration report: %w", err))
+	}
+
+	nits, err := DecodeCalibrationReport(data[:min(n, len(data))])
+	if errors.Is(err, ErrCalibrationUnsupported) {
+		d.noCalibration = true
+	}
+	if err != nil {
+		return 0, d.wrapErr(err)
+	}
+	return nits, nil
+}
@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid_test
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// sampleCalibrationReport carries a reference white of 500 nits (0x1F4).
+var sampleCalibrationReport = []byte{hid.CalibrationReportID, 0xF4, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+
+func TestDecodeCalibrationReport(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected uint32
+		err      error
+	}{
+		{name: "sample report", data: sampleCalibrationReport, expected: 500},
+		{name: "empty report", data: []byte{}, err: hid.ErrCalibrationUnsupported},
+		{name: "wrong report ID", data: []byte{hid.ReportID, 0xF4, 0x01, 0x00, 0x00}, err: hid.ErrInvalidCalibration},
+		{name: "truncated", data: []byte{hid.CalibrationReportID, 0xF4, 0x01}, err: hid.ErrShortReport},
+		{name: "zero luminance", data: []byte{hid.CalibrationReportID, 0x00, 0x00, 0x00, 0x00}, err: hid.ErrInvalidCalibration},
+		{name: "implausible luminance", data: []byte{hid.CalibrationReportID, 0xFF, 0xFF, 0xFF, 0xFF}, err: hid.ErrInvalidCalibration},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nits, err := hid.DecodeCalibrationReport(tt.data)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, nits)
+		})
+	}
+}
+
+func TestDisplay_GetReferenceBrightness(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDevice := mocks.NewMockDevice(ctrl)
+	mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"}).AnyTimes()
+	mockDevice.EXPECT().GetFeatureReport(gomock.Any()).DoAndReturn(func(data []byte) (int, error) {
+		assert.Equal(t, hid.CalibrationReportID, data[0])
+		return copy(data, sampleCalibrationReport), nil
+	})
+
+	display := hid.NewDisplay(mockDevice)
+	display.SetExperimentalReports(true)
+	nits, err := display.GetReferenceBrightness()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(500), nits)
+}
+
+func TestDisplay_GetReferenceBrightness_DisabledByDefault(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDevice := mocks.NewMockDevice(ctrl)
+	mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"}).AnyTimes()
+	// No GetFeatureReport expectation: the unverified report must not be sent
+
+	_, err := hid.NewDisplay(mockDevice).GetReferenceBrightness()
+	require.ErrorIs(t, err, hid.ErrCalibrationUnsupported)
+}
+
+func TestDisplay_GetReferenceBrightness_UnsupportedIsRemembered(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDevice := mocks.NewMockDevice(ctrl)
+	mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"}).AnyTimes()
+	// Firmware without calibration data stalls the request; it must only be sent once
+	mockDevice.EXPECT().GetFeatureReport(gomock.Any()).Return(0, syscall.EPIPE).Times(1)
+
+	display := hid.NewDisplay(mockDevice)
+	display.SetExperimentalReports(true)
+	for range 2 {
+		_, err := display.GetReferenceBrightness()
+		require.ErrorIs(t, err, hid.ErrCalibrationUnsupported)
+		assert.Contains(t, err.Error(), "ABC123")
+	}
+}
+
+func TestDisplay_GetReferenceBrightness_OtherErrorsAreNotRemembered(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "device gone", err: syscall.ENODEV},
+		{name: "transient I/O error", err: syscall.EIO},
+		{name: "timeout", err: syscall.ETIMEDOUT},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockDevice := mocks.NewMockDevice(ctrl)
+			mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"}).AnyTimes()
+			mockDevice.EXPECT().GetFeatureReport(gomock.Any()).Return(0, tt.err).Times(2)
+
+			display := hid.NewDisplay(mockDevice)
+			display.SetExperimentalReports(true)
+			for range 2 {
+				_, err := display.GetReferenceBrightness()
+				require.ErrorIs(t, err, tt.err)
+				assert.NotErrorIs(t, err, hid.ErrCalibrationUnsupported)
+			}
+		})
+	}
+}
+
+func TestIsStallError(t *testing.T) {
+	assert.True(t, hid.IsStallError(syscall.EPIPE))
+	assert.True(t, hid.IsStallError(errors.New("hidapi: ioctl (GFEATURE): Broken pipe")))
+	assert.False(t, hid.IsStallError(syscall.EIO))
+	assert.False(t, hid.IsStallError(nil))
+}
@@ -0,0 +1,90 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// hidrawSysfsDir holds the sysfs entries for hidraw nodes; a var so tests can point it elsewhere.
+var hidrawSysfsDir = "/sys/class/hidraw"
+
+// usbRootHubPattern matches the sysfs component of a USB root hub, e.g. "usb3".
+// Everything before it in the resolved device path identifies the host controller.
+var usbRootHubPattern = regexp.MustCompile(`^usb\d+$`)
+
+// WithControllerWriteQueue serializes brightness writes between displays that share
+// a USB host controller, while displays on different controllers are still written
+// in parallel. Saturated controllers can fail concurrent HID writes with EIO.
+//
+// The resolver maps a display to its controller; displays it maps to "" are not
+// queued. Pass USBController for the real sysfs topology, or nil to disable queuing.
+func WithControllerWriteQueue(resolver func(DeviceInfo) string) ManagerOption {
+	return func(m *Manager) {
+		m.controllerOf = resolver
+	}
+}
+
+// USBController returns the sysfs path of the USB host controller a display's
+// hidraw node hangs off, e.g. "/sys/devices/pci0000:00/0000:00:14.0", or "" if it
+// can't be determined.
+func USBController(info DeviceInfo) string {
+	if info.Path == "" {
+		return ""
+	}
+	devpath, err := filepath.EvalSymlinks(filepath.Join(hidrawSysfsDir, filepath.Base(info.Path), "device"))
+	if err != nil {
+		return ""
+	}
+	return controllerFromDevpath(devpath)
+}
+
+// controllerFromDevpath returns the part of a resolved sysfs device path above its
+// USB root hub, or "" if the path doesn't go through one.
+func controllerFromDevpath(devpath string) string {
+	parts := strings.Split(devpath, "/")
+	for i, part := range parts {
+		if usbRootHubPattern.MatchString(part) {
+			return strings.Join(parts[:i], "/")
+		}
+	}
+	return ""
+}
+
+// Controller returns the USB host controller of the display as resolved when it
+// was opened, or "" if controller queuing is disabled or the controller is unknown.
+// This method does not require locking as the controller is set before the display
+// is published.
+func (d *Display) Controller() string {
+	return d.controller
+}
+
+// controllerFor returns the controller of a display, or "" if controller queuing
+// is disabled or the controller is unknown.
+func (m *Manager) controllerFor(info DeviceInfo) string {
+	if m.controllerOf == nil {
+		return ""
+	}
+	return m.controllerOf(info)
+}
+
+// controllerLock returns the write lock shared by displays on controller, or nil
+// if controller is "". Must be called with m.refreshMu held.
+func (m *Manager) controllerLock(controller string) sync.Locker {
+	if controller == "" {
+		return nil
+	}
+
+	if m.controllerLocks == nil {
+		m.controllerLocks = make(map[string]*sync.Mutex)
+	}
+	lock, ok := m.controllerLocks[controller]
+	if !ok {
+		lock = &sync.Mutex{}
+		m.controllerLocks[controller] = lock
+	}
+	return lock
+}
@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowWriteDevice holds every write open for a while and tracks how many writes
+// are in flight per controller and overall.
+type slowWriteDevice struct {
+	stubDevice
+	inFlight *atomic.Int32 // writes in flight on this device's controller
+	peak     *atomic.Int32 // highest value inFlight reached
+	total    *atomic.Int32 // writes in flight across all controllers
+	overlap  *atomic.Bool  // set when writes on different controllers overlapped
+}
+
+func (d *slowWriteDevice) SendFeatureReport(data []byte) (int, error) {
+	n := d.inFlight.Add(1)
+	defer d.inFlight.Add(-1)
+	for {
+		p := d.peak.Load()
+		if n <= p || d.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	if d.total.Add(1) > n {
+		d.overlap.Store(true)
+	}
+	defer d.total.Add(-1)
+
+	time.Sleep(20 * time.Millisecond)
+	return len(data), nil
+}
+
+func TestManager_ControllerWriteQueue(t *testing.T) {
+	controllers := map[string]string{"A": "ctrl0", "B": "ctrl0", "C": "ctrl1"}
+
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		return []hid.DeviceInfo{{Serial: "A"}, {Serial: "B"}, {Serial: "C"}}, nil
+	}
+
+	var total atomic.Int32
+	var overlap atomic.Bool
+	inFlight := map[string]*atomic.Int32{"ctrl0": {}, "ctrl1": {}}
+	peak := map[string]*atomic.Int32{"ctrl0": {}, "ctrl1": {}}
+	opener := func(serial string) (hid.Device, error) {
+		controller := controllers[serial]
+		return &slowWriteDevice{
+			stubDevice: stubDevice{info: hid.DeviceInfo{Serial: serial}},
+			inFlight:   inFlight[controller],
+			peak:       peak[controller],
+			total:      &total,
+			overlap:    &overlap,
+		}, nil
+	}
+
+	m := hid.NewManager(
+		hid.WithEnumerator(enumerator),
+		hid.WithOpener(opener),
+		hid.WithControllerWriteQueue(func(info hid.DeviceInfo) string { return controllers[info.Serial] }),
+	)
+	require.NoError(t, m.RefreshDisplays())
+	for serial, display := range m.Snapshot() {
+		assert.Equal(t, controllers[serial], display.Controller())
+	}
+
+	var wg sync.WaitGroup
+	for serial, display := range m.Snapshot() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 3 {
+				assert.NoError(t, display.SetBrightness(50), serial)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), peak["ctrl0"].Load(), "writes on a shared controller must not overlap")
+	assert.True(t, overlap.Load(), "writes on different controllers should run concurrently")
+}
+
+func TestManager_ControllerWriteQueue_DisabledByDefault(t *testing.T) {
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		return []hid.DeviceInfo{{Serial: "A"}, {Serial: "B"}}, nil
+	}
+
+	var total atomic.Int32
+	var overlap atomic.Bool
+	var inFlight, peak atomic.Int32
+	opener := func(serial string) (hid.Device, error) {
+		return &slowWriteDevice{
+			stubDevice: stubDevice{info: hid.DeviceInfo{Serial: serial}},
+			inFlight:   &inFlight,
+			peak:       &peak,
+			total:      &total,
+			overlap:    &overlap,
+		}, nil
+	}
+
+	m := hid.NewManager(hid.WithEnumerator(enumerator), hid.WithOpener(opener))
+	require.NoError(t, m.RefreshDisplays())
+
+	var wg sync.WaitGroup
+	for _, display := range m.Snapshot() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, display.SetBrightness(50))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), peak.Load(), "writes should not be queued without the option")
+	display, err := m.GetDisplay("A")
+	require.NoError(t, err)
+	assert.Empty(t, display.Controller())
+}
+
+func TestControllerFromDevpath(t *testing.T) {
+	tests := []struct {
+		devpath  string
+		expected string
+	}{
+		{
+			devpath:  "/sys/devices/pci0000:00/0000:00:14.0/usb3/3-2/3-2:1.7/0003:05AC:1114.0005",
+			expected: "/sys/devices/pci0000:00/0000:00:14.0",
+		},
+		{
+			devpath:  "/sys/devices/pci0000:00/0000:00:0d.0/usb4/4-1/4-1.2/4-1.2:1.7/0003:05AC:1114.0009",
+			expected: "/sys/devices/pci0000:00/0000:00:0d.0",
+		},
+		{devpath: "/sys/devices/virtual/misc/uhid/0003:05AC:1114.0001", expected: ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, hid.ControllerFromDevpath(tt.devpath), tt.devpath)
+	}
+}
+
+func TestUSBController(t *testing.T) {
+	sysfs := t.TempDir()
+	controller := filepath.Join(sysfs, "devices", "pci0000:00", "0000:00:14.0")
+	device := filepath.Join(controller, "usb3", "3-2", "3-2:1.7", "0003:05AC:1114.0005")
+	require.NoError(t, os.MkdirAll(device, 0o750))
+
+	hidraw := filepath.Join(sysfs, "class", "hidraw")
+	require.NoError(t, os.MkdirAll(filepath.Join(hidraw, "hidraw4"), 0o750))
+	require.NoError(t, os.Symlink(device, filepath.Join(hidraw, "hidraw4", "device")))
+	defer hid.SetHidrawSysfsDir(hidraw)()
+
+	resolved, err := filepath.EvalSymlinks(controller)
+	require.NoError(t, err)
+	assert.Equal(t, resolved, hid.USBController(hid.DeviceInfo{Path: "/dev/hidraw4"}))
+	assert.Empty(t, hid.USBController(hid.DeviceInfo{Path: "/dev/hidraw9"}))
+	assert.Empty(t, hid.USBController(hid.DeviceInfo{}))
+}
@@ -18,6 +18,9 @@ type DeviceInfo struct {
 
 // Device represents an interface for HID device operations.
 // This interface allows for mocking in tests.
+//
+// Report buffers passed to a Device belong to the caller and are only lent for
+// the duration of the call; implementations must not keep or reuse them.
 type Device interface {
 	// GetFeatureReport reads a feature report from the device.
 	// The first byte is the report ID.
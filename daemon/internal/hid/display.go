@@ -3,41 +3,17 @@
 package hid
 
 import (
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/shini4i/asd-brightness-daemon/internal/brightness"
 )
 
-// HID Feature Report Structure for Apple Studio Display Brightness Control
-//
-// The brightness is controlled via a 7-byte HID feature report with the following layout:
-//
-//	Byte 0:     Report ID (0x01)
-//	Bytes 1-4:  Brightness value in nits (little-endian uint32)
-//	Bytes 5-6:  Reserved/unused
-//
-// The brightness value is stored as an internal brightness unit (not a percentage).
-// Valid range is MinBrightness to MaxBrightness (see brightness package constants).
-// The daemon converts between internal units and percentage (0-100) for the D-Bus API.
 const (
-	// ReportID is the HID report ID for brightness control (always 0x01).
-	ReportID byte = 0x01
-
-	// ReportSize is the total size of the HID feature report in bytes.
-	// Layout: [ReportID(1)] [Nits(4)] [Reserved(2)] = 7 bytes
-	ReportSize = 7
-
-	// ReportOffsetNits is the byte offset where the nits value starts in the HID report.
-	ReportOffsetNits = 1
-
-	// ReportLenNits is the length in bytes of the nits value (little-endian uint32).
-	ReportLenNits = 4
-
 	// AppleVendorID is the USB vendor ID for Apple.
 	AppleVendorID uint16 = 0x05ac
 
@@ -50,10 +26,33 @@ const (
 
 // Display represents an Apple Studio Display with brightness control capabilities.
 // All methods are thread-safe and can be called concurrently.
+//
+// Every operation allocates its own report buffer and decodes it before returning,
+// so no buffer is shared between calls. Reports are a few bytes, which makes
+// pooling them not worth the ownership bookkeeping.
 type Display struct {
 	device Device
 	mu     sync.Mutex
 	closed bool
+
+	curve         string      // percent-to-nits mapping, see brightness.Curves; "" is linear
+	experimental  bool        // send unverified feature reports, see WithExperimentalReports
+	noCalibration bool        // set once the display is known not to provide calibration data
+	noStandby     bool        // set once the display is known not to provide standby control
+	hasStandby    bool        // set once the display answered the standby report
+	controller    string      // USB host controller; "" if writes aren't queued
+	writeLock     sync.Locker // shared with displays on the same USB controller; nil if writes aren't queued
+
+	transientRetries  int           // retries of reads and writes failing with a transient error
+	transientInterval time.Duration // delay between those retries
+
+	verifyWrites    bool   // read the brightness back after every write
+	verifyTolerance uint32 // accepted difference between written and read-back nits
+
+	lockPath string        // device node flocked around writes; "" if writes aren't locked
+	lockWait time.Duration // how long a write waits for another process's lock
+
+	failing bool // the last brightness read or write failed
 }
 
 // NewDisplay creates a new Display instance wrapping the given HID device.
@@ -64,28 +63,21 @@ func NewDisplay(device Device) *Display {
 // ErrDisplayClosed is returned when an operation is attempted on a closed display.
 var ErrDisplayClosed = errors.New("display is closed")
 
+// ErrReportIDMismatch is returned when a report about to be sent doesn't start with
+// the report ID it was encoded for. It indicates a bug in the encoding code rather
+// than a device problem: some drivers accept such reports and silently ignore them.
+var ErrReportIDMismatch = errors.New("feature report ID mismatch")
+
 // GetBrightness reads the current brightness from the display and returns it as a percentage (0-100).
 func (d *Display) GetBrightness() (uint8, error) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	if d.closed {
-		return 0, ErrDisplayClosed
-	}
-
-	data := make([]byte, ReportSize)
-	data[0] = ReportID
-
-	_, err := d.device.GetFeatureReport(data)
+	nits, err := d.readNits()
 	if err != nil {
-		return 0, fmt.Errorf("failed to get feature report: %w", err)
+		return 0, err
 	}
-
-	// Parse brightness value from little-endian bytes
-	nits := binary.LittleEndian.Uint32(data[ReportOffsetNits : ReportOffsetNits+ReportLenNits])
-	percent := brightness.NitsToPercent(nits)
-
-	return percent, nil
+	return brightness.CurveNitsToPercent(d.curve, nits), nil
 }
 
 // SetBrightness sets the display brightness to the specified percentage (0-100).
@@ -93,24 +85,114 @@ func (d *Display) SetBrightness(percent uint8) error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	return d.writeNits(brightness.CurvePercentToNits(d.curve, percent))
+}
+
+// SetCurve selects the curve GetBrightness and SetBrightness map percentages to
+// nits along, see brightness.Curves. It doesn't touch the display.
+func (d *Display) SetCurve(curve string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.curve = curve
+}
+
+// readNits reads the current brightness in nits. Must be called with d.mu held.
+func (d *Display) readNits() (uint32, error) {
 	if d.closed {
-		return ErrDisplayClosed
+		return 0, d.wrapErr(ErrDisplayClosed)
 	}
 
-	nits := brightness.PercentToNits(percent)
-
+	// A fresh buffer per call; see the Display doc comment
 	data := make([]byte, ReportSize)
 	data[0] = ReportID
-	binary.LittleEndian.PutUint32(data[ReportOffsetNits:ReportOffsetNits+ReportLenNits], nits)
 
-	_, err := d.device.SendFeatureReport(data)
+	var n int
+	err := d.retryTransient(func() (err error) {
+		n, err = d.device.GetFeatureReport(data)
+		return err
+	})
+	d.failing = err != nil
 	if err != nil {
-		return fmt.Errorf("failed to send feature report: %w", err)
+		return 0, d.wrapErr(fmt.Errorf("failed to get feature report: %w", err))
 	}
 
+	nits, err := DecodeReport(data[:min(n, len(data))])
+	d.failing = err != nil
+	if err != nil {
+		return 0, d.wrapErr(err)
+	}
+	return nits, nil
+}
+
+// writeNits writes a brightness in nits, which must be within the display's range.
+// Must be called with d.mu held.
+func (d *Display) writeNits(nits uint32) error {
+	if d.closed {
+		return d.wrapErr(ErrDisplayClosed)
+	}
+
+	data := EncodeReport(nits)
+
+	err := d.withWriteLocks(func() error {
+		err := d.sendReport(ReportID, data)
+		d.failing = err != nil
+		if err != nil {
+			return fmt.Errorf("failed to send feature report: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return d.wrapErr(err)
+	}
+
+	if d.verifyWrites {
+		d.verifyWrite(nits)
+	}
 	return nil
 }
 
+// sendReport sends data as a feature report, retrying transient errors. data must
+// start with want; anything else is refused before it reaches the device.
+// Must be called with d.mu held.
+func (d *Display) sendReport(want byte, data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("%w: empty report, want ID 0x%02x", ErrReportIDMismatch, want)
+	}
+	if data[0] != want {
+		return fmt.Errorf("%w: got ID 0x%02x, want 0x%02x", ErrReportIDMismatch, data[0], want)
+	}
+
+	return d.retryTransient(func() error {
+		_, err := d.device.SendFeatureReport(data)
+		return err
+	})
+}
+
+// withWriteLocks runs send while holding the USB controller write queue and the
+// device node lock, where configured. Must be called with d.mu held.
+func (d *Display) withWriteLocks(send func() error) error {
+	if d.writeLock != nil {
+		d.writeLock.Lock()
+		defer d.writeLock.Unlock()
+	}
+	if d.lockPath != "" {
+		release, err := lockFile(d.lockPath, d.lockWait)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	return send()
+}
+
+// Healthy reports whether the display is open and its last brightness read or
+// write succeeded. A display that hasn't been used yet is healthy.
+func (d *Display) Healthy() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return !d.closed && !d.failing
+}
+
 // Serial returns the serial number of the display.
 // This method does not require locking as device info is immutable.
 func (d *Display) Serial() string {
@@ -123,6 +205,12 @@ func (d *Display) ProductName() string {
 	return d.device.Info().Product
 }
 
+// Info returns the HID device information of the display.
+// This method does not require locking as device info is immutable.
+func (d *Display) Info() DeviceInfo {
+	return d.device.Info()
+}
+
 // Close closes the underlying HID device.
 func (d *Display) Close() error {
 	d.mu.Lock()
@@ -133,7 +221,16 @@ func (d *Display) Close() error {
 	}
 
 	d.closed = true
-	return d.device.Close()
+	if err := d.device.Close(); err != nil {
+		return d.wrapErr(fmt.Errorf("failed to close device: %w", err))
+	}
+	return nil
+}
+
+// wrapErr prefixes err with the display serial so callers get context without
+// adding it themselves. The original error stays reachable via errors.Is/As.
+func (d *Display) wrapErr(err error) error {
+	return fmt.Errorf("display %s: %w", d.Serial(), err)
 }
 
 // IsDeviceGoneError checks if an error indicates that the HID device is no longer available.
@@ -141,7 +238,8 @@ func (d *Display) Close() error {
 // Common causes:
 //   - ENODEV (errno 19): Device has been removed
 //   - ENOENT (errno 2): Device node removed from /dev
-//   - EIO (errno 5): I/O error during device communication (often mid-disconnect)
+//   - EIO (errno 5): I/O error during device communication (often mid-disconnect,
+//     but sometimes a passing glitch; see IsTransientError)
 //   - "No such device": Device path no longer exists
 //   - "No such file or directory": Device node removed from /dev
 func IsDeviceGoneError(err error) bool {
@@ -171,6 +269,7 @@ func IsDeviceGoneError(err error) bool {
 		"no such file or directory",
 		"device not configured",
 		"bad file descriptor",
+		"input/output error", // EIO as reported by hidapi, see IsTransientError
 	}
 
 	for _, pattern := range deviceGonePatterns {
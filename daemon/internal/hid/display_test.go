@@ -5,9 +5,11 @@ package hid_test
 import (
 	"errors"
 	"fmt"
+	"sync"
 	"syscall"
 	"testing"
 
+	"github.com/shini4i/asd-brightness-daemon/internal/brightness"
 	"github.com/shini4i/asd-brightness-daemon/internal/hid"
 	"github.com/shini4i/asd-brightness-daemon/internal/hid/mocks"
 	"github.com/stretchr/testify/assert"
@@ -85,6 +87,7 @@ func TestDisplay_GetBrightness(t *testing.T) {
 			name: "returns error when device fails",
 			setupMock: func() {
 				mockDevice.EXPECT().GetFeatureReport(gomock.Any()).Return(0, errors.New("device error"))
+				mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"})
 			},
 			expectedPercent: 0,
 			expectedError:   true,
@@ -173,6 +176,7 @@ func TestDisplay_SetBrightness(t *testing.T) {
 			percent: 50,
 			setupMock: func() {
 				mockDevice.EXPECT().SendFeatureReport(gomock.Any()).Return(0, errors.New("device error"))
+				mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"})
 			},
 			expectedError: true,
 		},
@@ -240,6 +244,7 @@ func TestDisplay_GetBrightness_AfterClose(t *testing.T) {
 
 	mockDevice := mocks.NewMockDevice(ctrl)
 	mockDevice.EXPECT().Close().Return(nil)
+	mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"})
 
 	display := hid.NewDisplay(mockDevice)
 	err := display.Close()
@@ -256,6 +261,7 @@ func TestDisplay_SetBrightness_AfterClose(t *testing.T) {
 
 	mockDevice := mocks.NewMockDevice(ctrl)
 	mockDevice.EXPECT().Close().Return(nil)
+	mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"})
 
 	display := hid.NewDisplay(mockDevice)
 	err := display.Close()
@@ -266,6 +272,38 @@ func TestDisplay_SetBrightness_AfterClose(t *testing.T) {
 	assert.ErrorIs(t, err, hid.ErrDisplayClosed)
 }
 
+func TestDisplay_SendReport_RejectsWrongReportID(t *testing.T) {
+	tests := []struct {
+		name string
+		want byte
+		data []byte
+	}{
+		{name: "standby report as brightness", want: hid.ReportID, data: hid.EncodeStandbyReport(true)},
+		{name: "brightness report as standby", want: hid.StandbyReportID, data: hid.EncodeReport(brightness.MaxBrightness)},
+		{name: "empty report", want: hid.ReportID, data: []byte{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			// No SendFeatureReport expectation: the report must not reach the device
+			mockDevice := mocks.NewMockDevice(ctrl)
+
+			err := hid.SendReport(hid.NewDisplay(mockDevice), tt.want, tt.data)
+			require.ErrorIs(t, err, hid.ErrReportIDMismatch)
+		})
+	}
+}
+
+func TestDisplay_SendReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDevice := mocks.NewMockDevice(ctrl)
+	report := hid.EncodeReport(brightness.MaxBrightness)
+	mockDevice.EXPECT().SendFeatureReport(report).Return(len(report), nil)
+
+	require.NoError(t, hid.SendReport(hid.NewDisplay(mockDevice), hid.ReportID, report))
+}
+
 func TestDisplay_Close_Idempotent(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -282,6 +320,38 @@ func TestDisplay_Close_Idempotent(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestDisplay_ErrorsIncludeSerial(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDevice := mocks.NewMockDevice(ctrl)
+	mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"}).AnyTimes()
+	mockDevice.EXPECT().GetFeatureReport(gomock.Any()).Return(0, syscall.ENODEV)
+	mockDevice.EXPECT().SendFeatureReport(gomock.Any()).Return(0, syscall.ENODEV)
+	mockDevice.EXPECT().Close().Return(errors.New("close failed"))
+
+	display := hid.NewDisplay(mockDevice)
+
+	_, err := display.GetBrightness()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "display ABC123")
+	assert.ErrorIs(t, err, syscall.ENODEV)
+	assert.True(t, hid.IsDeviceGoneError(err))
+
+	err = display.SetBrightness(50)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "display ABC123")
+	assert.True(t, hid.IsDeviceGoneError(err))
+
+	err = display.Close()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "display ABC123")
+
+	_, err = display.GetBrightness()
+	assert.ErrorIs(t, err, hid.ErrDisplayClosed)
+	assert.Contains(t, err.Error(), "display ABC123")
+}
+
 func TestIsDeviceGoneError(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -357,3 +427,136 @@ func TestIsDeviceGoneError(t *testing.T) {
 		})
 	}
 }
+
+func TestReadBrightnessOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDevice := mocks.NewMockDevice(ctrl)
+	mockDevice.EXPECT().GetFeatureReport(gomock.Any()).DoAndReturn(func(data []byte) (int, error) {
+		// 30200 nits = 50%
+		data[1] = 0xF8
+		data[2] = 0x75
+		return 7, nil
+	})
+	mockDevice.EXPECT().Close().Return(nil).Times(1)
+
+	var openedSerial string
+	opener := func(serial string) (hid.Device, error) {
+		openedSerial = serial
+		return mockDevice, nil
+	}
+
+	percent, err := hid.ReadBrightnessOnceWith("ABC123", opener)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(50), percent)
+	assert.Equal(t, "ABC123", openedSerial)
+}
+
+func TestReadBrightnessOnce_ClosesOnReadError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDevice := mocks.NewMockDevice(ctrl)
+	mockDevice.EXPECT().GetFeatureReport(gomock.Any()).Return(0, syscall.EIO)
+	mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"})
+	mockDevice.EXPECT().Close().Return(nil).Times(1)
+
+	_, err := hid.ReadBrightnessOnceWith("ABC123", func(serial string) (hid.Device, error) {
+		return mockDevice, nil
+	})
+	assert.ErrorIs(t, err, syscall.EIO)
+}
+
+func TestReadBrightnessOnce_OpenError(t *testing.T) {
+	openErr := errors.New("display with serial ABC123 not found")
+
+	_, err := hid.ReadBrightnessOnceWith("ABC123", func(serial string) (hid.Device, error) {
+		return nil, openErr
+	})
+	assert.ErrorIs(t, err, openErr)
+}
+
+func TestDisplay_Healthy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDevice := mocks.NewMockDevice(ctrl)
+	mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"}).AnyTimes()
+	gomock.InOrder(
+		mockDevice.EXPECT().SendFeatureReport(gomock.Any()).Return(0, errors.New("write failed")),
+		mockDevice.EXPECT().SendFeatureReport(gomock.Any()).Return(hid.ReportSize, nil),
+	)
+	mockDevice.EXPECT().Close().Return(nil)
+
+	display := hid.NewDisplay(mockDevice)
+	assert.True(t, display.Healthy(), "unused display is healthy")
+
+	require.Error(t, display.SetBrightness(50))
+	assert.False(t, display.Healthy())
+
+	require.NoError(t, display.SetBrightness(50))
+	assert.True(t, display.Healthy())
+
+	require.NoError(t, display.Close())
+	assert.False(t, display.Healthy(), "closed display is not healthy")
+}
+
+// payloadDevice answers each feature report read with the next brightness from a
+// sequence and keeps every buffer it was given, so tests can check that no two
+// reads shared one.
+type payloadDevice struct {
+	mu      sync.Mutex
+	calls   int
+	served  []uint8
+	buffers [][]byte
+}
+
+func (d *payloadDevice) GetFeatureReport(data []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	percent := uint8(d.calls % 101)
+	d.calls++
+	d.served = append(d.served, percent)
+	d.buffers = append(d.buffers, data)
+	return copy(data, hid.EncodeReport(brightness.PercentToNits(percent))), nil
+}
+
+func (d *payloadDevice) SendFeatureReport(data []byte) (int, error) { return len(data), nil }
+func (d *payloadDevice) Close() error                               { return nil }
+func (d *payloadDevice) Info() hid.DeviceInfo                       { return hid.DeviceInfo{Serial: "PAYLOAD"} }
+
+func TestDisplay_GetBrightness_ConcurrentReadsOwnTheirBuffers(t *testing.T) {
+	device := &payloadDevice{}
+	display := hid.NewDisplay(device)
+
+	const readers, reads = 16, 50
+	results := make(chan uint8, readers*reads)
+	var wg sync.WaitGroup
+	for range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range reads {
+				percent, err := display.GetBrightness()
+				assert.NoError(t, err)
+				results <- percent
+			}
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	var got []uint8
+	for percent := range results {
+		got = append(got, percent)
+	}
+	assert.ElementsMatch(t, device.served, got, "every read returns exactly the payload it was served")
+
+	seen := make(map[*byte]bool, len(device.buffers))
+	for _, buf := range device.buffers {
+		require.False(t, seen[&buf[0]], "a report buffer was reused across reads")
+		seen[&buf[0]] = true
+	}
+}
@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultDRMDir is the sysfs class directory listing DRM connectors.
+const DefaultDRMDir = "/sys/class/drm"
+
+// EDID layout used to identify the display on a connector.
+const (
+	edidBlockSize         = 128
+	edidManufacturerApple = 0x0610 // "APP", three 5-bit letters packed big-endian
+	edidDescriptorStart   = 54     // First of four 18-byte display descriptors
+	edidDescriptorSize    = 18
+	edidDescriptorCount   = 4
+	edidSerialDescriptor  = 0xff // Tag of the display serial number string descriptor
+)
+
+var edidHeader = []byte{0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00}
+
+// ErrInvalidEDID is returned when a connector's EDID can't be parsed.
+var ErrInvalidEDID = errors.New("invalid EDID")
+
+// EDIDInfo is the part of an EDID used to correlate a connector with a display.
+type EDIDInfo struct {
+	Apple  bool   // The manufacturer ID is Apple's
+	Serial string // Serial number string descriptor; empty if absent
+}
+
+// ParseEDID extracts the manufacturer and serial number string from the base
+// block of an EDID.
+func ParseEDID(edid []byte) (EDIDInfo, error) {
+	if len(edid) < edidBlockSize || !bytes.Equal(edid[:len(edidHeader)], edidHeader) {
+		return EDIDInfo{}, fmt.Errorf("%w: missing header", ErrInvalidEDID)
+	}
+	var sum byte
+	for _, b := range edid[:edidBlockSize] {
+		sum += b
+	}
+	if sum != 0 {
+		return EDIDInfo{}, fmt.Errorf("%w: checksum mismatch", ErrInvalidEDID)
+	}
+
+	info := EDIDInfo{Apple: uint16(edid[8])<<8|uint16(edid[9]) == edidManufacturerApple}
+	for i := range edidDescriptorCount {
+		descriptor := edid[edidDescriptorStart+i*edidDescriptorSize:][:edidDescriptorSize]
+		// Display descriptors start with a zero pixel clock, then the tag in byte 3
+		if descriptor[0] != 0 || descriptor[1] != 0 || descriptor[3] != edidSerialDescriptor {
+			continue
+		}
+		text := descriptor[5:]
+		if end := bytes.IndexByte(text, '\n'); end >= 0 {
+			text = text[:end]
+		}
+		info.Serial = strings.TrimSpace(string(text))
+		break
+	}
+	return info, nil
+}
+
+// ConnectorName returns the connector name of a DRM sysfs entry as compositors
+// show it, e.g. "DP-1" for "card1-DP-1", or "" if the entry isn't a connector.
+func ConnectorName(entry string) string {
+	card, name, ok := strings.Cut(entry, "-")
+	if !ok || !strings.HasPrefix(card, "card") || name == "" {
+		return ""
+	}
+	return name
+}
+
+// MapConnectors correlates displays with the DRM connectors listed in dir and
+// returns the serial driven by each connector, keyed by connector name. A
+// connector is matched by the serial number in its EDID; the display's video
+// path, e.g. DisplayPort over Thunderbolt, shares no sysfs ancestry with its USB
+// interface, so there's no path to match on. If the EDIDs carry no usable serial
+// and exactly one Apple connector and one display are left unmatched, they are
+// paired, since that's unambiguous. Connectors that can't be read are skipped; a
+// missing dir yields an empty map.
+func MapConnectors(dir string, displays []DeviceInfo) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list DRM connectors: %w", err)
+	}
+
+	unmatched := make(map[string]bool, len(displays))
+	for _, display := range displays {
+		if display.Serial != "" {
+			unmatched[display.Serial] = true
+		}
+	}
+
+	connectors := make(map[string]string)
+	var appleLeft []string
+	for _, entry := range entries {
+		name := ConnectorName(entry.Name())
+		if name == "" {
+			continue
+		}
+		edid, ok := readConnectorEDID(filepath.Join(dir, entry.Name()))
+		if !ok || !edid.Apple {
+			continue
+		}
+		if unmatched[edid.Serial] {
+			connectors[name] = edid.Serial
+			delete(unmatched, edid.Serial)
+			continue
+		}
+		appleLeft = append(appleLeft, name)
+	}
+
+	if len(appleLeft) == 1 && len(unmatched) == 1 {
+		for serial := range unmatched {
+			connectors[appleLeft[0]] = serial
+		}
+	}
+	return connectors, nil
+}
+
+// readConnectorEDID parses the EDID of a connected connector, reporting false if
+// it's disconnected or its EDID is missing or invalid.
+func readConnectorEDID(path string) (EDIDInfo, bool) {
+	status, err := os.ReadFile(filepath.Join(path, "status")) // #nosec G304 -- path is built from sysfs
+	if err != nil || strings.TrimSpace(string(status)) != "connected" {
+		return EDIDInfo{}, false
+	}
+	raw, err := os.ReadFile(filepath.Join(path, "edid")) // #nosec G304 -- path is built from sysfs
+	if err != nil || len(raw) == 0 {
+		return EDIDInfo{}, false
+	}
+	edid, err := ParseEDID(raw)
+	if err != nil {
+		log.Debug().Err(err).Str("connector", filepath.Base(path)).Msg("Skipping connector with unreadable EDID")
+		return EDIDInfo{}, false
+	}
+	return edid, true
+}
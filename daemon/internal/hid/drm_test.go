@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEDID builds a valid EDID base block for the manufacturer ID, with a serial
+// number string descriptor unless serial is empty.
+func fakeEDID(manufacturer uint16, serial string) []byte {
+	edid := make([]byte, 128)
+	copy(edid, []byte{0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00})
+	edid[8], edid[9] = byte(manufacturer>>8), byte(manufacturer)
+
+	// A product name descriptor first, so the serial isn't simply the first one
+	name := edid[54:72]
+	name[3] = 0xfc
+	copy(name[5:], "StudioDisplay")
+
+	if serial != "" {
+		descriptor := edid[72:90]
+		descriptor[3] = 0xff
+		text := descriptor[5:]
+		for i := range text {
+			text[i] = ' '
+		}
+		copy(text, serial+"\n")
+	}
+
+	var sum byte
+	for _, b := range edid[:127] {
+		sum += b
+	}
+	edid[127] = -sum
+	return edid
+}
+
+const (
+	manufacturerApple = 0x0610 // "APP"
+	manufacturerDell  = 0x10ac // "DEL"
+)
+
+// fakeConnector adds a DRM connector entry to dir.
+func fakeConnector(t *testing.T, dir, entry, status string, edid []byte) {
+	t.Helper()
+	path := filepath.Join(dir, entry)
+	require.NoError(t, os.MkdirAll(path, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(path, "status"), []byte(status+"\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(path, "edid"), edid, 0o600))
+}
+
+func TestParseEDID(t *testing.T) {
+	info, err := hid.ParseEDID(fakeEDID(manufacturerApple, "SN123456"))
+	require.NoError(t, err)
+	assert.Equal(t, hid.EDIDInfo{Apple: true, Serial: "SN123456"}, info)
+
+	info, err = hid.ParseEDID(fakeEDID(manufacturerDell, ""))
+	require.NoError(t, err)
+	assert.Equal(t, hid.EDIDInfo{}, info)
+
+	corrupt := fakeEDID(manufacturerApple, "SN123456")
+	corrupt[80]++
+	_, err = hid.ParseEDID(corrupt)
+	assert.ErrorIs(t, err, hid.ErrInvalidEDID, "checksum mismatch")
+
+	_, err = hid.ParseEDID(make([]byte, 128))
+	assert.ErrorIs(t, err, hid.ErrInvalidEDID, "missing header")
+
+	_, err = hid.ParseEDID(fakeEDID(manufacturerApple, "SN123456")[:100])
+	assert.ErrorIs(t, err, hid.ErrInvalidEDID, "truncated")
+}
+
+func TestConnectorName(t *testing.T) {
+	assert.Equal(t, "DP-1", hid.ConnectorName("card1-DP-1"))
+	assert.Equal(t, "HDMI-A-2", hid.ConnectorName("card0-HDMI-A-2"))
+	assert.Equal(t, "", hid.ConnectorName("card1"))
+	assert.Equal(t, "", hid.ConnectorName("renderD128"))
+	assert.Equal(t, "", hid.ConnectorName("version"))
+}
+
+func TestMapConnectors(t *testing.T) {
+	displays := []hid.DeviceInfo{{Serial: "SN1"}, {Serial: "SN2"}}
+
+	t.Run("matched by EDID serial", func(t *testing.T) {
+		dir := t.TempDir()
+		fakeConnector(t, dir, "card1-DP-1", "connected", fakeEDID(manufacturerApple, "SN2"))
+		fakeConnector(t, dir, "card1-DP-2", "connected", fakeEDID(manufacturerApple, "SN1"))
+		fakeConnector(t, dir, "card1-DP-3", "connected", fakeEDID(manufacturerDell, "SN1"))
+		fakeConnector(t, dir, "card1-eDP-1", "disconnected", nil)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "card1"), 0o750))
+
+		connectors, err := hid.MapConnectors(dir, displays)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"DP-1": "SN2", "DP-2": "SN1"}, connectors)
+	})
+
+	t.Run("single unmatched display is paired", func(t *testing.T) {
+		dir := t.TempDir()
+		fakeConnector(t, dir, "card1-DP-1", "connected", fakeEDID(manufacturerApple, "SN1"))
+		fakeConnector(t, dir, "card1-DP-2", "connected", fakeEDID(manufacturerApple, ""))
+
+		connectors, err := hid.MapConnectors(dir, displays)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"DP-1": "SN1", "DP-2": "SN2"}, connectors)
+	})
+
+	t.Run("ambiguous displays stay unmapped", func(t *testing.T) {
+		dir := t.TempDir()
+		fakeConnector(t, dir, "card1-DP-1", "connected", fakeEDID(manufacturerApple, ""))
+		fakeConnector(t, dir, "card1-DP-2", "connected", fakeEDID(manufacturerApple, ""))
+
+		connectors, err := hid.MapConnectors(dir, displays)
+		require.NoError(t, err)
+		assert.Empty(t, connectors)
+	})
+
+	t.Run("unreadable EDID is skipped", func(t *testing.T) {
+		dir := t.TempDir()
+		fakeConnector(t, dir, "card1-DP-1", "connected", []byte("garbage"))
+		fakeConnector(t, dir, "card1-DP-2", "connected", fakeEDID(manufacturerApple, "SN1"))
+
+		connectors, err := hid.MapConnectors(dir, displays)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"DP-2": "SN1"}, connectors)
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		connectors, err := hid.MapConnectors(filepath.Join(t.TempDir(), "missing"), displays)
+		require.NoError(t, err)
+		assert.Empty(t, connectors)
+	})
+}
@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+)
+
+// Experimental feature reports
+//
+// Apple doesn't document the Studio Display's HID interface. The brightness report
+// (see report.go) is the only one the daemon relies on. The other reports it can
+// query, such as the calibration report, are unverified guesses: no source documents
+// their IDs or layouts and no shipping firmware is known to answer them. They're only
+// sent to displays when enabled with WithExperimentalReports; otherwise the methods
+// using them fail with their unsupported error without touching the device.
+//
+// A display that doesn't implement a report stalls the request or answers with an
+// empty report. Either answer is remembered, so the display isn't asked again; any
+// other failure, e.g. a transient I/O error, is returned without being remembered.
+
+// WithExperimentalReports lets displays be sent the unverified feature reports
+// described above. This includes opening displays with a blank USB descriptor
+// serial to read their serial number report, see EnumerateDisplaysReadingSerials.
+// Disabled by default.
+func WithExperimentalReports(enabled bool) ManagerOption {
+	return func(m *Manager) {
+		m.experimentalReports = enabled
+	}
+}
+
+// SetExperimentalReports enables or disables the unverified feature reports for a
+// display not opened by a Manager, see WithExperimentalReports.
+func (d *Display) SetExperimentalReports(enabled bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.experimental = enabled
+}
+
+// IsStallError reports whether err means the device stalled a request, which is how
+// it refuses a report ID it doesn't implement. hidraw reports a stall as EPIPE, which
+// hidapi only passes on as its message; a backlight node refuses with
+// ErrUnsupportedReport.
+func IsStallError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, ErrUnsupportedReport) || strings.Contains(strings.ToLower(err.Error()), "broken pipe")
+}
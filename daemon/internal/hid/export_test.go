@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+// ReadBrightnessOnceWith exposes readBrightnessOnce to external tests.
+var ReadBrightnessOnceWith = readBrightnessOnce
+
+// ControllerFromDevpath exposes controllerFromDevpath to external tests.
+var ControllerFromDevpath = controllerFromDevpath
+
+// SetHidrawSysfsDir points USBController at a fake sysfs tree and returns a func
+// restoring the real one.
+func SetHidrawSysfsDir(dir string) (restore func()) {
+	old := hidrawSysfsDir
+	hidrawSysfsDir = dir
+	return func() { hidrawSysfsDir = old }
+}
+
+// PortFromDevpath exposes portFromDevpath to external tests.
+var PortFromDevpath = portFromDevpath
+
+// ResolveSerial exposes resolveSerial to external tests.
+var ResolveSerial = resolveSerial
+
+// WithSerials exposes withSerials to external tests.
+var WithSerials = withSerials
+
+// SendReport exposes sendReport to external tests. The display's mutex is not
+// taken; tests must not use the display concurrently.
+func SendReport(d *Display, want byte, data []byte) error {
+	return d.sendReport(want, data)
+}
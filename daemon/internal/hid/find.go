@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrAmbiguousSerial is returned when a partial serial matches more than one display.
+var ErrAmbiguousSerial = errors.New("serial matches more than one display")
+
+// MatchSerial resolves query against serials: an exact match wins, otherwise query
+// must be a case-insensitive substring, e.g. a prefix or suffix, of exactly one
+// serial. It returns ErrDisplayNotFound if nothing matches and ErrAmbiguousSerial,
+// listing the candidates, if several do.
+func MatchSerial(query string, serials []string) (string, error) {
+	if query == "" {
+		return "", fmt.Errorf("%w: empty serial", ErrDisplayNotFound)
+	}
+	if slices.Contains(serials, query) {
+		return query, nil
+	}
+
+	needle := strings.ToLower(query)
+	var matches []string
+	for _, serial := range serials {
+		if strings.Contains(strings.ToLower(serial), needle) {
+			matches = append(matches, serial)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w: serial %s", ErrDisplayNotFound, query)
+	case 1:
+		return matches[0], nil
+	default:
+		slices.Sort(matches)
+		return "", fmt.Errorf("%w: %q matches %s", ErrAmbiguousSerial, query, strings.Join(matches, ", "))
+	}
+}
@@ -0,0 +1,54 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid_test
+
+import (
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchSerial(t *testing.T) {
+	serials := []string{"C02XK1Y2Q6NV", "C02XK1Y2Q7AB", "F39ZZ0001"}
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		wantErr  error
+	}{
+		{name: "exact", query: "F39ZZ0001", expected: "F39ZZ0001"},
+		{name: "unique prefix", query: "F39", expected: "F39ZZ0001"},
+		{name: "unique suffix", query: "6NV", expected: "C02XK1Y2Q6NV"},
+		{name: "case-insensitive", query: "q7ab", expected: "C02XK1Y2Q7AB"},
+		{name: "ambiguous prefix", query: "C02XK", wantErr: hid.ErrAmbiguousSerial},
+		{name: "no match", query: "ZZZ9", wantErr: hid.ErrDisplayNotFound},
+		{name: "empty", query: "", wantErr: hid.ErrDisplayNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serial, err := hid.MatchSerial(tt.query, serials)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, serial)
+		})
+	}
+}
+
+func TestMatchSerial_ExactMatchWinsOverSubstring(t *testing.T) {
+	serial, err := hid.MatchSerial("ABC", []string{"ABC", "ABCD"})
+	require.NoError(t, err)
+	assert.Equal(t, "ABC", serial)
+}
+
+func TestMatchSerial_AmbiguousErrorListsCandidates(t *testing.T) {
+	_, err := hid.MatchSerial("C02", []string{"C02B", "C02A"})
+	require.ErrorIs(t, err, hid.ErrAmbiguousSerial)
+	assert.Contains(t, err.Error(), "C02A, C02B")
+}
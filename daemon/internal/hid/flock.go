@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultAdvisoryLockWait is how long a write waits by default for another process
+// to release a display's advisory lock.
+const DefaultAdvisoryLockWait = 200 * time.Millisecond
+
+// advisoryLockPollInterval is the delay between attempts to take a held advisory lock.
+const advisoryLockPollInterval = 10 * time.Millisecond
+
+// ErrDisplayLocked is returned by writes to a display whose advisory lock another
+// process held for longer than the lock wait.
+var ErrDisplayLocked = errors.New("display is locked by another process")
+
+// WithAdvisoryLock makes displays take an exclusive flock on their device node
+// around every brightness write, so other tools that lock the same hidraw node,
+// e.g. a manual script, don't interleave their writes with the daemon's. While
+// another process holds the lock a write backs off and retries for up to wait,
+// then fails with ErrDisplayLocked. Disabled by default.
+func WithAdvisoryLock(enabled bool, wait time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.advisoryLock = enabled
+		m.advisoryLockWait = max(wait, 0)
+	}
+}
+
+// lockFile takes an exclusive advisory lock on path, retrying until wait has
+// passed while another process holds it. The returned func releases the lock.
+func lockFile(path string, wait time.Duration) (release func(), err error) {
+	file, err := os.Open(path) // #nosec G304 -- path is the display's device node
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			_ = file.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if !time.Now().Before(deadline) {
+			_ = file.Close()
+			return nil, fmt.Errorf("%w: %s", ErrDisplayLocked, path)
+		}
+		time.Sleep(advisoryLockPollInterval)
+	}
+
+	return func() {
+		if err := syscall.Flock(int(file.Fd()), syscall.LOCK_UN); err != nil {
+			log.Debug().Err(err).Str("path", path).Msg("Failed to release advisory lock")
+		}
+		_ = file.Close()
+	}, nil
+}
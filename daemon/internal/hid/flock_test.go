@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid_test
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// holdLock takes an exclusive flock on path the way another tool would and
+// returns a func releasing it.
+func holdLock(t *testing.T, path string) (release func()) {
+	t.Helper()
+	file, err := os.Open(path) // #nosec G304 -- test fixture
+	require.NoError(t, err)
+	require.NoError(t, syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB))
+	return func() { _ = file.Close() }
+}
+
+func TestDisplay_AdvisoryLock(t *testing.T) {
+	node := filepath.Join(t.TempDir(), "hidraw4")
+	require.NoError(t, os.WriteFile(node, nil, 0o600))
+
+	device := &stubDevice{info: hid.DeviceInfo{Serial: "SN1", Path: node}}
+	manager := hid.NewManager(
+		hid.WithEnumerator(func() ([]hid.DeviceInfo, error) { return []hid.DeviceInfo{device.info}, nil }),
+		hid.WithOpener(func(string) (hid.Device, error) { return device, nil }),
+		hid.WithAdvisoryLock(true, 30*time.Millisecond),
+	)
+	require.NoError(t, manager.RefreshDisplays())
+	display, err := manager.GetDisplay("SN1")
+	require.NoError(t, err)
+
+	// The lock is released after every write, so others can take it in between
+	require.NoError(t, display.SetBrightness(50))
+	assert.Equal(t, 1, device.writes)
+	release := holdLock(t, node)
+
+	// A write gives up once another process held the lock for the whole wait
+	err = display.SetBrightness(60)
+	assert.ErrorIs(t, err, hid.ErrDisplayLocked)
+	assert.False(t, hid.IsDeviceGoneError(err), "a locked display is still there")
+	assert.Equal(t, 1, device.writes)
+
+	// and backs off until a lock released within the wait is free
+	time.AfterFunc(10*time.Millisecond, release)
+	require.NoError(t, display.SetBrightness(70))
+	assert.Equal(t, 2, device.writes)
+}
+
+func TestDisplay_AdvisoryLockDisabled(t *testing.T) {
+	node := filepath.Join(t.TempDir(), "hidraw4")
+	require.NoError(t, os.WriteFile(node, nil, 0o600))
+	defer holdLock(t, node)()
+
+	device := &stubDevice{info: hid.DeviceInfo{Serial: "SN1", Path: node}}
+	manager := hid.NewManager(
+		hid.WithEnumerator(func() ([]hid.DeviceInfo, error) { return []hid.DeviceInfo{device.info}, nil }),
+		hid.WithOpener(func(string) (hid.Device, error) { return device, nil }),
+	)
+	require.NoError(t, manager.RefreshDisplays())
+	display, err := manager.GetDisplay("SN1")
+	require.NoError(t, err)
+
+	require.NoError(t, display.SetBrightness(50), "locks are ignored unless enabled")
+	assert.Equal(t, 1, device.writes)
+}
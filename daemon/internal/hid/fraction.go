@@ -0,0 +1,29 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+import "github.com/shini4i/asd-brightness-daemon/internal/brightness"
+
+// GetBrightnessFraction reads the current brightness as a fraction of the display's
+// nits range, from 0.0 at the minimum to 1.0 at the maximum. Unlike GetBrightness
+// it isn't rounded to whole percentages.
+func (d *Display) GetBrightnessFraction() (float64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	nits, err := d.readNits()
+	if err != nil {
+		return 0, err
+	}
+	return brightness.NitsToFraction(nits), nil
+}
+
+// SetBrightnessFraction sets the brightness to a fraction of the display's nits
+// range, for callers that want continuous control rather than whole percentages.
+// The fraction is clamped to 0.0-1.0.
+func (d *Display) SetBrightnessFraction(fraction float64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.writeNits(brightness.FractionToNits(fraction))
+}
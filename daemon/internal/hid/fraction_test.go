@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid_test
+
+import (
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/brightness"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisplay_BrightnessFractionRoundTrip(t *testing.T) {
+	device := &roundingDevice{step: 1}
+	display := hid.NewDisplay(device)
+	resolution := 1 / float64(brightness.BrightnessRange)
+
+	for i := 0; i <= 1000; i++ {
+		fraction := float64(i) / 1000
+		require.NoError(t, display.SetBrightnessFraction(fraction))
+		assert.Equal(t, brightness.FractionToNits(fraction), device.nits, "fraction %.3f", fraction)
+
+		got, err := display.GetBrightnessFraction()
+		require.NoError(t, err)
+		assert.InDelta(t, fraction, got, resolution/2, "fraction %.3f", fraction)
+	}
+}
+
+func TestDisplay_SetBrightnessFraction_Clamps(t *testing.T) {
+	device := &roundingDevice{step: 1}
+	display := hid.NewDisplay(device)
+
+	require.NoError(t, display.SetBrightnessFraction(-0.25))
+	assert.Equal(t, brightness.MinBrightness, device.nits)
+	got, err := display.GetBrightnessFraction()
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, got)
+
+	require.NoError(t, display.SetBrightnessFraction(1.25))
+	assert.Equal(t, brightness.MaxBrightness, device.nits)
+	got, err = display.GetBrightnessFraction()
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, got)
+
+	require.NoError(t, display.Close())
+	require.ErrorIs(t, display.SetBrightnessFraction(0.5), hid.ErrDisplayClosed)
+	_, err = display.GetBrightnessFraction()
+	require.ErrorIs(t, err, hid.ErrDisplayClosed)
+}
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// staleDisplay is a disconnected display whose handle is kept open for the grace period.
+type staleDisplay struct {
+	display *Display
+	timer   *time.Timer // closes the handle when the grace period ends
+}
+
+// WithHandleGrace keeps the handle of a display that disappears from enumeration
+// open for the given duration instead of closing it right away. If the display
+// reappears within that window, RefreshDisplays reuses the handle rather than
+// reopening it, so a flaky connection doesn't repeat the readiness check or the
+// connect brightness. While stale, the display is not tracked: it's absent from
+// ListDisplays, GetDisplay and Snapshot. Zero or negative durations close handles
+// immediately, which is the default.
+func WithHandleGrace(d time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.handleGrace = max(d, 0)
+	}
+}
+
+// retireLocked stops tracking a disconnected display, closing its handle now or
+// after the grace period. Must be called with m.mu held.
+func (m *Manager) retireLocked(serial string, display *Display) {
+	if m.handleGrace <= 0 {
+		closeDisconnected(serial, display)
+		return
+	}
+
+	if m.stale == nil {
+		m.stale = make(map[string]*staleDisplay)
+	}
+	entry := &staleDisplay{display: display}
+	entry.timer = time.AfterFunc(m.handleGrace, func() { m.expireStale(serial, entry) })
+	m.stale[serial] = entry
+	log.Debug().Str("serial", serial).Dur("grace", m.handleGrace).Msg("Keeping handle of disconnected display")
+}
+
+// reclaimLocked returns the stale handle of serial, if any, and stops it from being
+// closed. Must be called with m.mu held.
+func (m *Manager) reclaimLocked(serial string) *Display {
+	entry, ok := m.stale[serial]
+	if !ok {
+		return nil
+	}
+	delete(m.stale, serial)
+	// If the timer already fired, expireStale finds the entry gone and leaves it open
+	entry.timer.Stop()
+	return entry.display
+}
+
+// expireStale closes a stale handle whose grace period ended, unless it was
+// reclaimed in the meantime.
+func (m *Manager) expireStale(serial string, entry *staleDisplay) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stale[serial] != entry {
+		return
+	}
+	delete(m.stale, serial)
+	closeDisconnected(serial, entry.display)
+}
+
+// closeStaleLocked closes all stale handles without waiting for their grace
+// period. Must be called with m.mu held.
+func (m *Manager) closeStaleLocked() {
+	for serial, entry := range m.stale {
+		entry.timer.Stop()
+		closeDisconnected(serial, entry.display)
+		delete(m.stale, serial)
+	}
+}
+
+// closeDisconnected closes the handle of a display that is no longer connected.
+func closeDisconnected(serial string, display *Display) {
+	if err := display.Close(); err != nil {
+		log.Warn().Err(err).Str("serial", serial).Msg("Failed to close disconnected display")
+	}
+}
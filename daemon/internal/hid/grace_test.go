@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closeCountingDevice counts Close calls.
+type closeCountingDevice struct {
+	stubDevice
+	closes atomic.Int32
+}
+
+func (d *closeCountingDevice) Close() error {
+	d.closes.Add(1)
+	return nil
+}
+
+// flakyEnumerator reports serial only while present is true.
+type flakyEnumerator struct {
+	mu      sync.Mutex
+	serial  string
+	present bool
+}
+
+func (e *flakyEnumerator) set(present bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.present = present
+}
+
+func (e *flakyEnumerator) enumerate() ([]hid.DeviceInfo, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.present {
+		return nil, nil
+	}
+	return []hid.DeviceInfo{{Serial: e.serial}}, nil
+}
+
+func newGraceManager(grace time.Duration) (m *hid.Manager, enumerator *flakyEnumerator, device *closeCountingDevice, opens, connects *atomic.Int32) {
+	enumerator = &flakyEnumerator{serial: "ABC123", present: true}
+	device = &closeCountingDevice{stubDevice: stubDevice{info: hid.DeviceInfo{Serial: "ABC123"}}}
+	opens, connects = new(atomic.Int32), new(atomic.Int32)
+	opener := func(string) (hid.Device, error) {
+		opens.Add(1)
+		return device, nil
+	}
+
+	m = hid.NewManager(
+		hid.WithEnumerator(enumerator.enumerate),
+		hid.WithOpener(opener),
+		hid.WithHandleGrace(grace),
+		hid.WithConnectHandler(func(string, *hid.Display) { connects.Add(1) }),
+	)
+	return m, enumerator, device, opens, connects
+}
+
+func TestManager_HandleGrace_ReusesHandleOnQuickReturn(t *testing.T) {
+	m, enumerator, device, opens, connects := newGraceManager(time.Minute)
+
+	require.NoError(t, m.RefreshDisplays())
+	original, err := m.GetDisplay("ABC123")
+	require.NoError(t, err)
+
+	enumerator.set(false)
+	require.ErrorIs(t, m.RefreshDisplays(), hid.ErrNoDisplaysFound)
+	_, err = m.GetDisplay("ABC123")
+	require.ErrorIs(t, err, hid.ErrDisplayNotFound, "stale displays aren't tracked")
+
+	enumerator.set(true)
+	require.NoError(t, m.RefreshDisplays())
+
+	reused, err := m.GetDisplay("ABC123")
+	require.NoError(t, err)
+	assert.Same(t, original, reused)
+	assert.Equal(t, int32(0), device.closes.Load(), "handle must not be closed")
+	assert.Equal(t, int32(1), opens.Load(), "handle must not be reopened")
+	assert.Equal(t, int32(1), connects.Load(), "only the first open is passed to the connect handler")
+}
+
+func TestManager_HandleGrace_ClosesAfterGracePeriod(t *testing.T) {
+	m, enumerator, device, opens, _ := newGraceManager(20 * time.Millisecond)
+
+	require.NoError(t, m.RefreshDisplays())
+	enumerator.set(false)
+	require.ErrorIs(t, m.RefreshDisplays(), hid.ErrNoDisplaysFound)
+
+	assert.Eventually(t, func() bool { return device.closes.Load() == 1 }, time.Second, 5*time.Millisecond)
+
+	enumerator.set(true)
+	require.NoError(t, m.RefreshDisplays())
+	assert.Equal(t, int32(2), opens.Load(), "display is reopened after the grace period")
+}
+
+func TestManager_HandleGrace_DisabledClosesImmediately(t *testing.T) {
+	m, enumerator, device, _, _ := newGraceManager(0)
+
+	require.NoError(t, m.RefreshDisplays())
+	enumerator.set(false)
+	require.ErrorIs(t, m.RefreshDisplays(), hid.ErrNoDisplaysFound)
+
+	assert.Equal(t, int32(1), device.closes.Load())
+}
+
+func TestManager_HandleGrace_CloseReleasesStaleHandles(t *testing.T) {
+	m, enumerator, device, _, _ := newGraceManager(time.Minute)
+
+	require.NoError(t, m.RefreshDisplays())
+	enumerator.set(false)
+	require.ErrorIs(t, m.RefreshDisplays(), hid.ErrNoDisplaysFound)
+
+	require.NoError(t, m.Close())
+	assert.Equal(t, int32(1), device.closes.Load())
+}
@@ -6,6 +6,8 @@ package hid
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	hid "github.com/sstallion/go-hid"
 )
@@ -51,33 +53,73 @@ func (d *HIDAPIDevice) Info() DeviceInfo {
 }
 
 // EnumerateDisplays returns a list of all connected Apple Studio Displays.
-// Returns an error if device enumeration fails.
-// Note: Devices with empty serial numbers are skipped as they may be in a transitional
-// state during connect/disconnect and cannot be reliably identified or opened.
+// Returns ErrNoDisplaysFound if enumeration succeeded but found no displays, and
+// a different error if device enumeration itself fails.
+// Note: Devices with empty serial numbers may be in a transitional state during
+// connect/disconnect, or have a blank USB descriptor serial. They cannot be
+// reliably identified or opened and are skipped.
 func EnumerateDisplays() ([]DeviceInfo, error) {
-	var displays []DeviceInfo
+	return enumerateDisplays(false)
+}
+
+// EnumerateDisplaysReadingSerials is like EnumerateDisplays, but reads the serial
+// of displays that still have a blank USB descriptor serial after BlankSerialSettle
+// through the experimental serial number report (see experimental.go).
+func EnumerateDisplaysReadingSerials() ([]DeviceInfo, error) {
+	return enumerateDisplays(true)
+}
+
+// enumerateDisplays implements EnumerateDisplays and EnumerateDisplaysReadingSerials.
+func enumerateDisplays(readSerials bool) ([]DeviceInfo, error) {
+	candidates, err := enumerateBrightnessInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	if readSerials && slices.ContainsFunc(candidates, func(info DeviceInfo) bool { return info.Serial == "" }) {
+		time.Sleep(BlankSerialSettle)
+		if candidates, err = enumerateBrightnessInterfaces(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Serials are resolved after enumeration, so no device is opened mid-enumeration
+	displays := withSerials(candidates, readSerials, openPath)
+	if len(displays) == 0 {
+		return nil, ErrNoDisplaysFound
+	}
+
+	return displays, nil
+}
+
+// enumerateBrightnessInterfaces returns the brightness interface of every
+// connected Apple Studio Display, including entries without a serial number.
+func enumerateBrightnessInterfaces() ([]DeviceInfo, error) {
+	var candidates []DeviceInfo
 
 	err := hid.Enumerate(AppleVendorID, StudioDisplayProductID, func(info *hid.DeviceInfo) error {
 		// Skip devices that don't match the brightness interface
 		if info.InterfaceNbr != BrightnessInterface {
 			return nil
 		}
+		candidates = append(candidates, deviceInfoFromHID(info))
+		return nil
+	})
 
-		// Skip devices with empty serial numbers - these are in a transitional state
-		// during connect/disconnect and cannot be reliably used
-		if info.SerialNbr == "" {
-			return nil
-		}
+	if err != nil {
+		return nil, fmt.Errorf("failed to enumerate HID devices: %w", err)
+	}
 
-		displays = append(displays, DeviceInfo{
-			Path:         info.Path,
-			VendorID:     info.VendorID,
-			ProductID:    info.ProductID,
-			Serial:       info.SerialNbr,
-			Manufacturer: info.MfrStr,
-			Product:      info.ProductStr,
-			Interface:    info.InterfaceNbr,
-		})
+	return candidates, nil
+}
+
+// EnumerateInterfaces returns every HID interface of every connected Apple Studio
+// Display, including interfaces other than BrightnessInterface and entries without
+// a serial number. It's intended for diagnostics; use EnumerateDisplays otherwise.
+func EnumerateInterfaces() ([]DeviceInfo, error) {
+	var interfaces []DeviceInfo
+
+	err := hid.Enumerate(AppleVendorID, StudioDisplayProductID, func(info *hid.DeviceInfo) error {
+		interfaces = append(interfaces, deviceInfoFromHID(info))
 		return nil
 	})
 
@@ -85,38 +127,50 @@ func EnumerateDisplays() ([]DeviceInfo, error) {
 		return nil, fmt.Errorf("failed to enumerate HID devices: %w", err)
 	}
 
-	return displays, nil
+	return interfaces, nil
 }
 
 // OpenDisplay opens a connection to an Apple Studio Display by serial number.
-// If serial is empty, opens the first available display.
+// If serial is empty, opens the first available display. It returns an error
+// wrapping ErrDisplayNotFound if no display has the serial, or ErrNoDisplaysFound
+// if serial is empty and no display is connected. Displays with a blank USB
+// descriptor serial are skipped.
 func OpenDisplay(serial string) (*HIDAPIDevice, error) {
+	return openDisplay(serial, false)
+}
+
+// OpenDisplayReadingSerials is like OpenDisplay, but matches displays with a blank
+// USB descriptor serial by their experimental serial number report (see
+// experimental.go).
+func OpenDisplayReadingSerials(serial string) (*HIDAPIDevice, error) {
+	return openDisplay(serial, true)
+}
+
+// openDisplay implements OpenDisplay and OpenDisplayReadingSerials.
+func openDisplay(serial string, readSerials bool) (*HIDAPIDevice, error) {
 	var targetInfo *DeviceInfo
+	var blank []DeviceInfo
 
 	err := hid.Enumerate(AppleVendorID, StudioDisplayProductID, func(info *hid.DeviceInfo) error {
 		if info.InterfaceNbr != BrightnessInterface {
 			return nil
 		}
 
-		// Skip devices with empty serial numbers - these are in a transitional state
-		// during connect/disconnect and cannot be reliably used
-		if info.SerialNbr == "" {
+		// Devices with empty serial numbers are only considered if no other
+		// device matches, see below
+		candidate := deviceInfoFromHID(info)
+		if candidate.Serial == "" {
+			if readSerials {
+				blank = append(blank, candidate)
+			}
 			return nil
 		}
 
-		if serial != "" && info.SerialNbr != serial {
+		if serial != "" && candidate.Serial != serial {
 			return nil
 		}
 
-		targetInfo = &DeviceInfo{
-			Path:         info.Path,
-			VendorID:     info.VendorID,
-			ProductID:    info.ProductID,
-			Serial:       info.SerialNbr,
-			Manufacturer: info.MfrStr,
-			Product:      info.ProductStr,
-			Interface:    info.InterfaceNbr,
-		}
+		targetInfo = &candidate
 		return errFound // Stop enumeration
 	})
 
@@ -125,11 +179,20 @@ func OpenDisplay(serial string) (*HIDAPIDevice, error) {
 		return nil, fmt.Errorf("failed to enumerate devices: %w", err)
 	}
 
+	for _, candidate := range blank {
+		if targetInfo != nil {
+			break
+		}
+		if info, ok := resolveSerial(candidate, openPath); ok && (serial == "" || info.Serial == serial) {
+			targetInfo = &info
+		}
+	}
+
 	if targetInfo == nil {
 		if serial != "" {
-			return nil, fmt.Errorf("display with serial %s not found", serial)
+			return nil, fmt.Errorf("%w: serial %s", ErrDisplayNotFound, serial)
 		}
-		return nil, fmt.Errorf("no Apple Studio Display found")
+		return nil, ErrNoDisplaysFound
 	}
 
 	// Open by path (sstallion/go-hid way)
@@ -140,3 +203,25 @@ func OpenDisplay(serial string) (*HIDAPIDevice, error) {
 
 	return NewHIDAPIDevice(device, *targetInfo), nil
 }
+
+// openPath opens a HID device by path, for reading its serial number report.
+func openPath(path string) (Device, error) {
+	device, err := hid.OpenPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return NewHIDAPIDevice(device, DeviceInfo{Path: path}), nil
+}
+
+// deviceInfoFromHID converts device information reported by go-hid.
+func deviceInfoFromHID(info *hid.DeviceInfo) DeviceInfo {
+	return DeviceInfo{
+		Path:         info.Path,
+		VendorID:     info.VendorID,
+		ProductID:    info.ProductID,
+		Serial:       info.SerialNbr,
+		Manufacturer: info.MfrStr,
+		Product:      info.ProductStr,
+		Interface:    info.InterfaceNbr,
+	}
+}
@@ -3,20 +3,85 @@
 package hid
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
+// ErrDisplayNotFound is returned when no tracked display matches a serial number,
+// and by OpenDisplay when no connected display does.
+var ErrDisplayNotFound = errors.New("display not found")
+
+// ErrNoDisplaysFound is returned by EnumerateDisplays when enumeration succeeded but
+// found no displays, and by RefreshDisplays when no displays are tracked after a
+// refresh. It's not a failure: the refresh was applied and callers may simply retry
+// later, e.g. while a display's HID interface is still initializing.
+var ErrNoDisplaysFound = errors.New("no displays found")
+
 // Manager handles the lifecycle of multiple Apple Studio Displays.
 type Manager struct {
-	displays   map[string]*Display // serial -> display
-	mu         sync.RWMutex
-	enumerator func() ([]DeviceInfo, error)
-	opener     func(serial string) (Device, error)
+	displays    map[string]*Display // serial -> display
+	mu          sync.RWMutex
+	refreshMu   sync.Mutex // serializes refreshes; guards state only refreshes use
+	enumerator  func() ([]DeviceInfo, error)
+	opener      func(serial string) (Device, error)
+	maxDisplays int
+	allowlist   []string // product substrings; empty allows all products
+
+	onConnect func(serial string, display *Display) // called with newly opened displays; nil if unset
+
+	curves map[string]string // serial -> curve of the display, see Display.SetCurve
+
+	readyTimeout  time.Duration // how long to wait for a new display to serve reports; 0 disables
+	readyInterval time.Duration // delay between readiness probes
+
+	openConcurrency int // maximum number of displays opened in parallel
+
+	controllerOf    func(DeviceInfo) string // maps displays to their USB controller; nil disables write queuing
+	controllerLocks map[string]*sync.Mutex  // controller -> write lock shared by its displays
+
+	transientRetries  int           // retries of display I/O failing with a transient error
+	transientInterval time.Duration // delay between those retries
+
+	verifyWrites    bool   // read the brightness back after every write
+	verifyTolerance uint32 // accepted difference between written and read-back nits
+
+	experimentalReports bool // send unverified feature reports to displays, see experimental.go
+
+	advisoryLock     bool          // flock device nodes around writes
+	advisoryLockWait time.Duration // how long a write waits for another process's lock
+
+	handleGrace time.Duration            // how long handles of disconnected displays stay open; 0 closes them immediately
+	stale       map[string]*staleDisplay // serial -> handle of a disconnected display within its grace period
+
+	portOf    func(DeviceInfo) string           // maps displays to their USB port; nil disables serial migration
+	onMigrate func(oldSerial, newSerial string) // called when a display reappears under a new serial
+	ports     map[string]DeviceInfo             // serial -> product and USB port (in Path) of tracked and departed displays
+	departed  map[string]time.Time              // serial -> when a display in ports disappeared
+
+	migrationWindow time.Duration // how long a departed display can reappear under a new serial
+
+	backlightDir string                // sysfs backlight class directory; "" disables the backlight fallback
+	backlights   map[string]DeviceInfo // serial -> backlight node found by the last refresh
+
+	seen map[string]struct{} // serials tracked at any point since the manager was created
 }
 
+// DefaultOpenConcurrency is the default number of displays opened in parallel.
+const DefaultOpenConcurrency = 4
+
+// DefaultMaxDisplays is the default cap on the number of tracked displays.
+// It protects against resource exhaustion if a misbehaving bus or dock reports
+// phantom devices, while being far above any realistic setup.
+const DefaultMaxDisplays = 16
+
 // ManagerOption is a functional option for configuring a Manager.
 type ManagerOption func(*Manager)
 
@@ -28,25 +93,128 @@ func WithEnumerator(fn func() ([]DeviceInfo, error)) ManagerOption {
 }
 
 // WithOpener sets a custom device opener for testing.
+// The opener may be called concurrently for different serials (see WithOpenConcurrency).
 func WithOpener(fn func(serial string) (Device, error)) ManagerOption {
 	return func(m *Manager) {
 		m.opener = fn
 	}
 }
 
+// WithMaxDisplays caps the number of displays the manager will track.
+// Values below 1 are ignored.
+func WithMaxDisplays(n int) ManagerOption {
+	return func(m *Manager) {
+		if n > 0 {
+			m.maxDisplays = n
+		}
+	}
+}
+
+// WithProductAllowlist only tracks displays whose product string contains one of
+// the given substrings (case-insensitive), e.g. "Studio Display". This guards against
+// accessories that reuse Apple's vendor and product IDs. An empty list allows all products.
+func WithProductAllowlist(substrings ...string) ManagerOption {
+	return func(m *Manager) {
+		m.allowlist = substrings
+	}
+}
+
+// WithConnectHandler sets a function called with every newly opened display as
+// soon as it's opened, before it's tracked, e.g. to set its brightness so a display
+// that powers up at full brightness doesn't flash. Displays that are already
+// tracked, or whose handle is reused within the grace period (see WithHandleGrace),
+// aren't passed to it again. It's called without the manager's lock held, but must
+// not refresh the manager.
+func WithConnectHandler(fn func(serial string, display *Display)) ManagerOption {
+	return func(m *Manager) {
+		m.onConnect = fn
+	}
+}
+
+// WithCurves sets the percent-to-nits curve of displays keyed by serial, see
+// Display.SetCurve. Displays without an entry use brightness.CurveLinear.
+func WithCurves(curves map[string]string) ManagerOption {
+	return func(m *Manager) {
+		m.curves = maps.Clone(curves)
+	}
+}
+
+// WithOpenConcurrency sets how many newly found displays RefreshDisplays opens in
+// parallel. Values below 1 open displays one at a time.
+func WithOpenConcurrency(n int) ManagerOption {
+	return func(m *Manager) {
+		m.openConcurrency = max(n, 1)
+	}
+}
+
 // NewManager creates a new display manager.
 func NewManager(opts ...ManagerOption) *Manager {
 	m := &Manager{
-		displays:   make(map[string]*Display),
-		enumerator: EnumerateDisplays,
-		opener:     defaultOpener,
+		displays:    make(map[string]*Display),
+		seen:        make(map[string]struct{}),
+		maxDisplays: DefaultMaxDisplays,
+
+		openConcurrency: DefaultOpenConcurrency,
+		migrationWindow: DefaultMigrationWindow,
 	}
 	for _, opt := range opts {
 		opt(m)
 	}
+	if m.enumerator == nil {
+		m.enumerator = m.systemEnumerator()
+	}
+	if m.opener == nil {
+		m.opener = m.systemOpener()
+	}
 	return m
 }
 
+// systemEnumerator returns the enumerator of connected displays, which only reads
+// blank serials with experimental reports enabled.
+func (m *Manager) systemEnumerator() func() ([]DeviceInfo, error) {
+	if m.experimentalReports {
+		return EnumerateDisplaysReadingSerials
+	}
+	return EnumerateDisplays
+}
+
+// systemOpener returns the opener of connected displays, see systemEnumerator.
+func (m *Manager) systemOpener() func(serial string) (Device, error) {
+	if m.experimentalReports {
+		return func(serial string) (Device, error) {
+			return OpenDisplayReadingSerials(serial)
+		}
+	}
+	return defaultOpener
+}
+
+// SetEnumerator replaces the device enumerator at runtime, e.g. to switch between
+// real and simulated displays. It waits for an in-flight RefreshDisplays, so every
+// refresh uses a single enumerator throughout. Tracked displays are kept until the
+// next refresh. A nil fn restores the enumerator of connected displays.
+func (m *Manager) SetEnumerator(fn func() ([]DeviceInfo, error)) {
+	if fn == nil {
+		fn = m.systemEnumerator()
+	}
+
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+	m.enumerator = fn
+}
+
+// SetOpener replaces the device opener at runtime, with the same guarantees as
+// SetEnumerator. Displays that are already open keep their device. A nil fn
+// restores the opener of connected displays.
+func (m *Manager) SetOpener(fn func(serial string) (Device, error)) {
+	if fn == nil {
+		fn = m.systemOpener()
+	}
+
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+	m.opener = fn
+}
+
 // defaultOpener wraps OpenDisplay to match the expected signature.
 func defaultOpener(serial string) (Device, error) {
 	return OpenDisplay(serial)
@@ -71,60 +239,227 @@ func (m *Manager) GetDisplay(serial string) (*Display, error) {
 
 	display, ok := m.displays[serial]
 	if !ok {
-		return nil, fmt.Errorf("display with serial %s not found", serial)
+		return nil, fmt.Errorf("%w: serial %s", ErrDisplayNotFound, serial)
 	}
 	return display, nil
 }
 
+// Snapshot returns the currently tracked displays keyed by serial, taken under a
+// single read lock. The returned map is a copy, so the set of displays stays
+// consistent for a whole batch operation even if a refresh runs concurrently.
+// A display removed by a later refresh is closed, so operations on it return
+// ErrDisplayClosed rather than a "not found" error.
+func (m *Manager) Snapshot() map[string]*Display {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snapshot := make(map[string]*Display, len(m.displays))
+	for serial, d := range m.displays {
+		snapshot[serial] = d
+	}
+	return snapshot
+}
+
 // RefreshDisplays re-enumerates connected displays and updates the internal state.
-// It opens new displays and closes disconnected ones.
+// It opens new displays and closes disconnected ones (see WithHandleGrace). Returns ErrNoDisplaysFound,
+// after applying the refresh, if no displays are tracked afterwards; any other
+// error means enumeration failed and the tracked displays were left unchanged.
+//
+// Enumerating and opening displays, including the readiness probe and the connect
+// brightness, run without the manager's lock held, so lookups such as GetDisplay
+// aren't blocked by a slow display. Refreshes are serialized among themselves.
 func (m *Manager) RefreshDisplays() error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
 
 	// Enumerate current displays
 	currentDevices, err := m.enumerator()
-	if err != nil {
+	if err != nil && !errors.Is(err, ErrNoDisplaysFound) {
 		return fmt.Errorf("failed to enumerate displays: %w", err)
 	}
+	currentDevices = m.addBacklights(currentDevices)
 
 	currentSerials := make(map[string]DeviceInfo)
 	for _, info := range currentDevices {
+		if !ProductAllowed(info.Product, m.allowlist) {
+			log.Debug().
+				Str("serial", info.Serial).
+				Str("product", info.Product).
+				Msg("Skipping device not matching product allowlist")
+			continue
+		}
 		currentSerials[info.Serial] = info
 	}
 
-	// Find and close disconnected displays
+	removed, pending, tracked := m.retireDisconnected(currentSerials)
+	opened := m.openPending(pending, tracked, currentSerials)
+
+	m.mu.Lock()
+	var added []string
+	for _, serial := range pending {
+		display, ok := opened[serial]
+		if !ok {
+			continue
+		}
+		m.displays[serial] = display
+		m.seen[serial] = struct{}{}
+		m.rememberPortLocked(serial, currentSerials[serial])
+		added = append(added, serial)
+	}
+	migrations := m.matchMigrationsLocked(removed, added)
+	count := len(m.displays)
+	m.mu.Unlock()
+
+	m.notifyMigrations(migrations)
+
+	if count == 0 {
+		return ErrNoDisplaysFound
+	}
+	return nil
+}
+
+// retireDisconnected stops tracking displays missing from current and takes back
+// handles kept open by the grace period for displays that reappeared. It returns
+// the removed serials, the serials still to be opened in a stable order so the
+// display cap applies deterministically, and the number of tracked displays.
+func (m *Manager) retireDisconnected(current map[string]DeviceInfo) (removed, pending []string, tracked int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	for serial, display := range m.displays {
-		if _, exists := currentSerials[serial]; !exists {
+		if _, exists := current[serial]; !exists {
 			log.Info().Str("serial", serial).Msg("Display disconnected")
-			if err := display.Close(); err != nil {
-				log.Warn().Err(err).Str("serial", serial).Msg("Failed to close disconnected display")
-			}
 			delete(m.displays, serial)
+			m.retireLocked(serial, display)
+			removed = append(removed, serial)
 		}
 	}
 
-	// Open new displays
-	for serial, info := range currentSerials {
+	for serial := range current {
 		if _, exists := m.displays[serial]; !exists {
-			device, err := m.opener(serial)
-			if err != nil {
-				log.Error().Err(err).Str("serial", serial).Msg("Failed to open display")
+			pending = append(pending, serial)
+		}
+	}
+	sort.Strings(pending)
+
+	// Reuse handles kept open by the grace period before opening anything new
+	pending = slices.DeleteFunc(pending, func(serial string) bool {
+		if len(m.displays) >= m.maxDisplays {
+			return false
+		}
+		display := m.reclaimLocked(serial)
+		if display == nil {
+			return false
+		}
+		m.displays[serial] = display
+		m.seen[serial] = struct{}{}
+		m.rememberPortLocked(serial, current[serial])
+		log.Info().Str("serial", serial).Msg("Display reconnected within grace period, reusing handle")
+		return true
+	})
+
+	return removed, pending, len(m.displays)
+}
+
+// openPending opens the pending serials and applies the connect brightness, without
+// m.mu held, until tracked plus the opened displays reach the display cap. Must be
+// called with refreshMu held.
+func (m *Manager) openPending(pending []string, tracked int, current map[string]DeviceInfo) map[string]*Display {
+	opened := make(map[string]*Display)
+
+	// Open in batches that fill the remaining capacity. A failed open leaves room
+	// for the next candidate, giving the same result as opening one at a time.
+	for len(pending) > 0 {
+		capacity := m.maxDisplays - tracked - len(opened)
+		if capacity <= 0 {
+			log.Warn().
+				Int("max", m.maxDisplays).
+				Int("enumerated", len(current)).
+				Msg("Display limit reached, not opening additional displays")
+			break
+		}
+
+		batch := pending[:min(capacity, len(pending))]
+		pending = pending[len(batch):]
+
+		for i, device := range m.openConcurrently(batch) {
+			if device == nil {
 				continue
 			}
-			m.displays[serial] = NewDisplay(device)
-			log.Info().Str("serial", serial).Str("product", info.Product).Msg("Display connected")
+			serial := batch[i]
+			display := NewDisplay(device)
+			display.controller = m.controllerFor(current[serial])
+			display.writeLock = m.controllerLock(display.controller)
+			display.transientRetries, display.transientInterval = m.transientRetries, m.transientInterval
+			display.verifyWrites, display.verifyTolerance = m.verifyWrites, m.verifyTolerance
+			display.experimental = m.experimentalReports
+			display.curve = m.curves[serial]
+			if m.advisoryLock {
+				display.lockPath, display.lockWait = device.Info().Path, m.advisoryLockWait
+			}
+			opened[serial] = display
+			log.Info().Str("serial", serial).Str("product", current[serial].Product).Msg("Display connected")
+			if m.onConnect != nil {
+				m.onConnect(serial, display)
+			}
 		}
 	}
+	return opened
+}
 
-	return nil
+// openConcurrently opens serials using up to openConcurrency workers, so slow docks
+// don't delay each other. The result holds the device for each serial at the same
+// index, or nil if opening it failed; failures are logged and don't affect the others.
+func (m *Manager) openConcurrently(serials []string) []Device {
+	devices := make([]Device, len(serials))
+
+	sem := make(chan struct{}, max(m.openConcurrency, 1))
+	var wg sync.WaitGroup
+	for i, serial := range serials {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			device, err := m.openReady(serial)
+			if err != nil {
+				log.Error().Err(err).Str("serial", serial).Msg("Failed to open display")
+				return
+			}
+			devices[i] = device
+		}()
+	}
+	wg.Wait()
+
+	return devices
+}
+
+// ProductAllowed reports whether product contains one of the allowlist substrings,
+// ignoring case. An empty allowlist allows every product.
+func ProductAllowed(product string, allowlist []string) bool {
+	if len(allowlist) == 0 {
+		return true
+	}
+	product = strings.ToLower(product)
+	for _, allowed := range allowlist {
+		if allowed != "" && strings.Contains(product, strings.ToLower(allowed)) {
+			return true
+		}
+	}
+	return false
 }
 
-// Close closes all open displays.
+// Close closes all open displays, including handles kept open by the grace period.
 func (m *Manager) Close() error {
+	// Wait for an in-flight refresh so it can't track displays after they were closed
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.closeStaleLocked()
+
 	for serial, display := range m.displays {
 		if err := display.Close(); err != nil {
 			log.Error().Err(err).Str("serial", serial).Msg("Failed to close display")
@@ -134,6 +469,20 @@ func (m *Manager) Close() error {
 	return nil
 }
 
+// KnownSerials returns, sorted, the serial of every display tracked since the
+// manager was created, whether it's still connected or not.
+func (m *Manager) KnownSerials() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	serials := make([]string, 0, len(m.seen))
+	for serial := range m.seen {
+		serials = append(serials, serial)
+	}
+	sort.Strings(serials)
+	return serials
+}
+
 // Count returns the number of connected displays.
 func (m *Manager) Count() int {
 	m.mu.RLock()
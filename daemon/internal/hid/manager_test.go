@@ -4,8 +4,14 @@ package hid_test
 
 import (
 	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/shini4i/asd-brightness-daemon/internal/brightness"
 	"github.com/shini4i/asd-brightness-daemon/internal/hid"
 	"github.com/shini4i/asd-brightness-daemon/internal/hid/mocks"
 	"github.com/stretchr/testify/assert"
@@ -97,10 +103,28 @@ func TestManager_RefreshDisplays_RemovesDisconnectedDisplays(t *testing.T) {
 
 	// Second refresh removes the display
 	err = m.RefreshDisplays()
-	require.NoError(t, err)
+	require.ErrorIs(t, err, hid.ErrNoDisplaysFound)
 	assert.Equal(t, 0, m.Count())
 }
 
+func TestManager_KnownSerials_KeepsDisconnectedDisplays(t *testing.T) {
+	connected := []hid.DeviceInfo{{Serial: "B"}, {Serial: "A"}}
+	m := hid.NewManager(
+		hid.WithEnumerator(func() ([]hid.DeviceInfo, error) { return connected, nil }),
+		hid.WithOpener(func(serial string) (hid.Device, error) {
+			return &stubDevice{info: hid.DeviceInfo{Serial: serial}}, nil
+		}),
+	)
+	assert.Empty(t, m.KnownSerials())
+
+	require.NoError(t, m.RefreshDisplays())
+	connected = []hid.DeviceInfo{{Serial: "C"}}
+	require.NoError(t, m.RefreshDisplays())
+
+	assert.Equal(t, []hid.DeviceInfo{{Serial: "C"}}, m.ListDisplays())
+	assert.Equal(t, []string{"A", "B", "C"}, m.KnownSerials())
+}
+
 func TestManager_RefreshDisplays_EnumerationError(t *testing.T) {
 	enumerator := func() ([]hid.DeviceInfo, error) {
 		return nil, errors.New("enumeration failed")
@@ -110,6 +134,26 @@ func TestManager_RefreshDisplays_EnumerationError(t *testing.T) {
 	err := m.RefreshDisplays()
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to enumerate")
+	assert.NotErrorIs(t, err, hid.ErrNoDisplaysFound)
+}
+
+func TestManager_RefreshDisplays_NoDisplaysFound(t *testing.T) {
+	tests := []struct {
+		name       string
+		enumerator func() ([]hid.DeviceInfo, error)
+	}{
+		{name: "empty enumeration", enumerator: func() ([]hid.DeviceInfo, error) { return nil, nil }},
+		{name: "enumerator reports none found", enumerator: func() ([]hid.DeviceInfo, error) { return nil, hid.ErrNoDisplaysFound }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := hid.NewManager(hid.WithEnumerator(tt.enumerator))
+			err := m.RefreshDisplays()
+			require.ErrorIs(t, err, hid.ErrNoDisplaysFound)
+			assert.NotContains(t, err.Error(), "failed to enumerate")
+		})
+	}
 }
 
 func TestManager_RefreshDisplays_OpenerError(t *testing.T) {
@@ -123,8 +167,8 @@ func TestManager_RefreshDisplays_OpenerError(t *testing.T) {
 
 	m := hid.NewManager(hid.WithEnumerator(enumerator), hid.WithOpener(opener))
 	err := m.RefreshDisplays()
-	// Should not return error, just log and continue
-	require.NoError(t, err)
+	// Open failures are logged, not returned; nothing ends up tracked
+	require.ErrorIs(t, err, hid.ErrNoDisplaysFound)
 	assert.Equal(t, 0, m.Count())
 }
 
@@ -262,3 +306,397 @@ func TestManager_RefreshDisplays_KeepsExistingDisplays(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 1, m.Count())
 }
+
+func TestManager_Snapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDevice := mocks.NewMockDevice(ctrl)
+	mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"}).AnyTimes()
+	mockDevice.EXPECT().Close().Return(nil).Times(1)
+
+	present := true
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		if present {
+			return []hid.DeviceInfo{{Serial: "ABC123"}}, nil
+		}
+		return []hid.DeviceInfo{}, nil
+	}
+	opener := func(serial string) (hid.Device, error) {
+		return mockDevice, nil
+	}
+
+	m := hid.NewManager(hid.WithEnumerator(enumerator), hid.WithOpener(opener))
+	require.NoError(t, m.RefreshDisplays())
+
+	snapshot := m.Snapshot()
+	require.Len(t, snapshot, 1)
+	display := snapshot["ABC123"]
+	require.NotNil(t, display)
+
+	// A refresh that removes the display doesn't mutate the snapshot,
+	// but the handle is closed so operations fail cleanly
+	present = false
+	require.ErrorIs(t, m.RefreshDisplays(), hid.ErrNoDisplaysFound)
+	assert.Len(t, snapshot, 1)
+	assert.Empty(t, m.Snapshot())
+
+	_, err := display.GetBrightness()
+	assert.ErrorIs(t, err, hid.ErrDisplayClosed)
+}
+
+func TestManager_RefreshDisplays_RespectsMaxDisplays(t *testing.T) {
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		var devices []hid.DeviceInfo
+		for i := 0; i < 10; i++ {
+			devices = append(devices, hid.DeviceInfo{Serial: fmt.Sprintf("PHANTOM%02d", i)})
+		}
+		return devices, nil
+	}
+
+	var opened atomic.Int32
+	opener := func(serial string) (hid.Device, error) {
+		opened.Add(1)
+		return &stubDevice{info: hid.DeviceInfo{Serial: serial}}, nil
+	}
+
+	m := hid.NewManager(hid.WithEnumerator(enumerator), hid.WithOpener(opener), hid.WithMaxDisplays(3))
+
+	require.NoError(t, m.RefreshDisplays())
+	assert.Equal(t, 3, m.Count())
+	assert.Equal(t, int32(3), opened.Load(), "no handles should be opened beyond the cap")
+
+	// Subsequent refreshes keep the same displays and don't open more
+	require.NoError(t, m.RefreshDisplays())
+	assert.Equal(t, 3, m.Count())
+	assert.Equal(t, int32(3), opened.Load())
+}
+
+func TestManager_DefaultMaxDisplays(t *testing.T) {
+	assert.Equal(t, 16, hid.DefaultMaxDisplays)
+}
+
+func TestManager_RefreshDisplays_ProductAllowlist(t *testing.T) {
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		return []hid.DeviceInfo{
+			{Serial: "REAL", VendorID: hid.AppleVendorID, ProductID: hid.StudioDisplayProductID, Product: "Studio Display"},
+			{Serial: "CLONE", VendorID: hid.AppleVendorID, ProductID: hid.StudioDisplayProductID, Product: "USB Hub"},
+		}, nil
+	}
+	opener := func(serial string) (hid.Device, error) {
+		return &stubDevice{info: hid.DeviceInfo{Serial: serial}}, nil
+	}
+
+	tests := []struct {
+		name     string
+		opts     []hid.ManagerOption
+		expected []string
+	}{
+		{name: "permissive by default", expected: []string{"REAL", "CLONE"}},
+		{
+			name:     "filters non-matching products",
+			opts:     []hid.ManagerOption{hid.WithProductAllowlist("studio display")},
+			expected: []string{"REAL"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]hid.ManagerOption{hid.WithEnumerator(enumerator), hid.WithOpener(opener)}, tt.opts...)
+			m := hid.NewManager(opts...)
+
+			require.NoError(t, m.RefreshDisplays())
+			assert.ElementsMatch(t, tt.expected, slices.Collect(maps.Keys(m.Snapshot())))
+		})
+	}
+}
+
+func TestProductAllowed(t *testing.T) {
+	tests := []struct {
+		name      string
+		product   string
+		allowlist []string
+		expected  bool
+	}{
+		{name: "empty allowlist allows all", product: "Anything", expected: true},
+		{name: "case-insensitive match", product: "Studio Display", allowlist: []string{"STUDIO DISPLAY"}, expected: true},
+		{name: "any entry may match", product: "Pro Display XDR", allowlist: []string{"Studio", "XDR"}, expected: true},
+		{name: "no match", product: "USB Hub", allowlist: []string{"Studio Display"}, expected: false},
+		{name: "empty entries never match", product: "USB Hub", allowlist: []string{""}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, hid.ProductAllowed(tt.product, tt.allowlist))
+		})
+	}
+}
+
+func TestManager_RefreshDisplays_ConnectHandler(t *testing.T) {
+	serials := []string{"A"}
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		infos := make([]hid.DeviceInfo, 0, len(serials))
+		for _, serial := range serials {
+			infos = append(infos, hid.DeviceInfo{Serial: serial})
+		}
+		return infos, nil
+	}
+	opener := func(serial string) (hid.Device, error) {
+		return &stubDevice{info: hid.DeviceInfo{Serial: serial}}, nil
+	}
+	connected := make(map[string]int)
+	var m *hid.Manager
+	m = hid.NewManager(hid.WithEnumerator(enumerator), hid.WithOpener(opener),
+		hid.WithConnectHandler(func(serial string, display *hid.Display) {
+			connected[serial]++
+			_, err := m.GetDisplay(serial)
+			assert.ErrorIs(t, err, hid.ErrDisplayNotFound, "the handler runs before the display is tracked")
+			assert.NotNil(t, display)
+		}))
+
+	require.NoError(t, m.RefreshDisplays())
+	assert.Equal(t, map[string]int{"A": 1}, connected)
+
+	// Refreshing with the display still present must not call it again
+	require.NoError(t, m.RefreshDisplays())
+	assert.Equal(t, map[string]int{"A": 1}, connected)
+
+	// A second display is passed exactly once as well
+	serials = append(serials, "B")
+	require.NoError(t, m.RefreshDisplays())
+	require.NoError(t, m.RefreshDisplays())
+	assert.Equal(t, map[string]int{"A": 1, "B": 1}, connected)
+}
+
+func TestManager_RefreshDisplays_Curves(t *testing.T) {
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		return []hid.DeviceInfo{{Serial: "A"}, {Serial: "B"}}, nil
+	}
+	devices := make(map[string]*stubDevice)
+	opener := func(serial string) (hid.Device, error) {
+		d := &stubDevice{info: hid.DeviceInfo{Serial: serial}}
+		devices[serial] = d
+		return d, nil
+	}
+
+	m := hid.NewManager(
+		hid.WithEnumerator(enumerator),
+		hid.WithOpener(opener),
+		hid.WithOpenConcurrency(1),
+		hid.WithCurves(map[string]string{"B": brightness.CurvePerceptual}),
+	)
+	require.NoError(t, m.RefreshDisplays())
+	for _, serial := range []string{"A", "B"} {
+		display, err := m.GetDisplay(serial)
+		require.NoError(t, err)
+		require.NoError(t, display.SetBrightness(30))
+	}
+
+	assert.Equal(t, hid.EncodeReport(brightness.PercentToNits(30)), devices["A"].lastWrite, "displays without a curve are linear")
+	assert.Equal(t, hid.EncodeReport(brightness.CurvePercentToNits(brightness.CurvePerceptual, 30)), devices["B"].lastWrite)
+}
+
+func TestManager_RefreshDisplays_NoWritesByDefault(t *testing.T) {
+	var device *stubDevice
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		return []hid.DeviceInfo{{Serial: "A"}}, nil
+	}
+	opener := func(serial string) (hid.Device, error) {
+		device = &stubDevice{info: hid.DeviceInfo{Serial: serial}}
+		return device, nil
+	}
+
+	m := hid.NewManager(hid.WithEnumerator(enumerator), hid.WithOpener(opener))
+
+	require.NoError(t, m.RefreshDisplays())
+	assert.Equal(t, 0, device.writes)
+}
+
+// stubDevice is a minimal hid.Device for tests that only exercise manager bookkeeping.
+// It counts writes so tests can check when the manager touches a display.
+type stubDevice struct {
+	info      hid.DeviceInfo
+	writes    int
+	lastWrite []byte
+}
+
+func (d *stubDevice) GetFeatureReport(data []byte) (int, error) { return len(data), nil }
+func (d *stubDevice) Close() error                              { return nil }
+func (d *stubDevice) Info() hid.DeviceInfo                      { return d.info }
+
+func (d *stubDevice) SendFeatureReport(data []byte) (int, error) {
+	d.writes++
+	d.lastWrite = append([]byte(nil), data...)
+	return len(data), nil
+}
+
+func TestManager_RefreshDisplays_OpensConcurrently(t *testing.T) {
+	const openDelay = 50 * time.Millisecond
+
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		var devices []hid.DeviceInfo
+		for i := 0; i < 4; i++ {
+			devices = append(devices, hid.DeviceInfo{Serial: fmt.Sprintf("DISPLAY%d", i)})
+		}
+		return devices, nil
+	}
+
+	// Two displays sit behind a slow dock; the others open instantly
+	var inFlight, peak atomic.Int32
+	opener := func(serial string) (hid.Device, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		if serial == "DISPLAY0" || serial == "DISPLAY2" {
+			time.Sleep(openDelay)
+		}
+		return &stubDevice{info: hid.DeviceInfo{Serial: serial}}, nil
+	}
+
+	m := hid.NewManager(hid.WithEnumerator(enumerator), hid.WithOpener(opener), hid.WithOpenConcurrency(4))
+
+	start := time.Now()
+	require.NoError(t, m.RefreshDisplays())
+	elapsed := time.Since(start)
+
+	assert.Equal(t, 4, m.Count())
+	assert.Less(t, elapsed, 2*openDelay, "slow opens should overlap rather than add up")
+	assert.LessOrEqual(t, peak.Load(), int32(4))
+}
+
+func TestManager_RefreshDisplays_LookupsDontWaitForOpens(t *testing.T) {
+	var serials atomic.Value
+	serials.Store([]string{"A"})
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		var devices []hid.DeviceInfo
+		for _, serial := range serials.Load().([]string) {
+			devices = append(devices, hid.DeviceInfo{Serial: serial})
+		}
+		return devices, nil
+	}
+
+	// B is still initializing when it appears, e.g. held up by the readiness probe
+	opening, release := make(chan struct{}), make(chan struct{})
+	opener := func(serial string) (hid.Device, error) {
+		if serial == "B" {
+			close(opening)
+			<-release
+		}
+		return &stubDevice{info: hid.DeviceInfo{Serial: serial}}, nil
+	}
+
+	m := hid.NewManager(hid.WithEnumerator(enumerator), hid.WithOpener(opener))
+	require.NoError(t, m.RefreshDisplays())
+
+	serials.Store([]string{"A", "B"})
+	refreshed := make(chan error)
+	go func() { refreshed <- m.RefreshDisplays() }()
+	<-opening
+
+	// The tracked display stays reachable while B is being opened
+	_, err := m.GetDisplay("A")
+	require.NoError(t, err)
+	assert.Len(t, m.ListDisplays(), 1)
+	assert.Len(t, m.Snapshot(), 1)
+
+	close(release)
+	require.NoError(t, <-refreshed)
+	assert.Equal(t, 2, m.Count())
+}
+
+func TestManager_RefreshDisplays_OpenConcurrencyBound(t *testing.T) {
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		var devices []hid.DeviceInfo
+		for i := 0; i < 6; i++ {
+			devices = append(devices, hid.DeviceInfo{Serial: fmt.Sprintf("DISPLAY%d", i)})
+		}
+		return devices, nil
+	}
+
+	var inFlight, peak atomic.Int32
+	opener := func(serial string) (hid.Device, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return &stubDevice{info: hid.DeviceInfo{Serial: serial}}, nil
+	}
+
+	m := hid.NewManager(hid.WithEnumerator(enumerator), hid.WithOpener(opener), hid.WithOpenConcurrency(2))
+	require.NoError(t, m.RefreshDisplays())
+
+	assert.Equal(t, 6, m.Count())
+	assert.Equal(t, int32(2), peak.Load(), "no more than the configured number of opens may run at once")
+}
+
+func TestManager_RefreshDisplays_FailedOpenLeavesRoomUnderCap(t *testing.T) {
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		return []hid.DeviceInfo{{Serial: "A"}, {Serial: "B"}, {Serial: "C"}}, nil
+	}
+	opener := func(serial string) (hid.Device, error) {
+		if serial == "A" {
+			return nil, errors.New("failed to open device")
+		}
+		return &stubDevice{info: hid.DeviceInfo{Serial: serial}}, nil
+	}
+
+	m := hid.NewManager(hid.WithEnumerator(enumerator), hid.WithOpener(opener), hid.WithMaxDisplays(2))
+	require.NoError(t, m.RefreshDisplays())
+
+	assert.ElementsMatch(t, []string{"B", "C"}, slices.Collect(maps.Keys(m.Snapshot())))
+}
+
+func TestManager_SetEnumeratorAndOpener(t *testing.T) {
+	enumerate := func(serials ...string) func() ([]hid.DeviceInfo, error) {
+		return func() ([]hid.DeviceInfo, error) {
+			infos := make([]hid.DeviceInfo, 0, len(serials))
+			for _, serial := range serials {
+				infos = append(infos, hid.DeviceInfo{Serial: serial})
+			}
+			return infos, nil
+		}
+	}
+	var realOpens, simulatedOpens atomic.Int32
+	opener := func(opens *atomic.Int32) func(string) (hid.Device, error) {
+		return func(serial string) (hid.Device, error) {
+			opens.Add(1)
+			return &stubDevice{info: hid.DeviceInfo{Serial: serial}}, nil
+		}
+	}
+
+	m := hid.NewManager(hid.WithEnumerator(enumerate("REAL")), hid.WithOpener(opener(&realOpens)))
+	require.NoError(t, m.RefreshDisplays())
+
+	// Swap to a simulated source while refreshes keep running
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 20 {
+			assert.NoError(t, m.RefreshDisplays())
+		}
+	}()
+	// The opener goes first, so no refresh can open a simulated display with the real opener
+	m.SetOpener(opener(&simulatedOpens))
+	m.SetEnumerator(enumerate("SIM1", "SIM2"))
+	<-done
+
+	require.NoError(t, m.RefreshDisplays())
+	serials := make([]string, 0, 2)
+	for _, info := range m.ListDisplays() {
+		serials = append(serials, info.Serial)
+	}
+	slices.Sort(serials)
+	assert.Equal(t, []string{"SIM1", "SIM2"}, serials, "refreshes after the swap should use the new enumerator")
+	assert.Equal(t, int32(1), realOpens.Load(), "the old opener must not be used after the swap")
+	assert.Equal(t, int32(2), simulatedOpens.Load())
+}
@@ -0,0 +1,156 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// usbPortPattern matches the sysfs component of a USB device, e.g. "3-2" or "3-2.4".
+var usbPortPattern = regexp.MustCompile(`^\d+-\d+(\.\d+)*$`)
+
+// DefaultMigrationWindow is how long a display that disappeared may reappear under
+// a new serial and still be matched, long enough for a firmware update to restart it.
+const DefaultMigrationWindow = 5 * time.Minute
+
+// serialMigration is a display that reappeared under a new serial.
+type serialMigration struct {
+	from, to string
+}
+
+// WithSerialMigration detects displays whose serial changes, e.g. after a firmware
+// update: when a display disappears and, in the same refresh or a later one within
+// the migration window (see WithMigrationWindow), a display with the same product
+// appears on the same USB port under another serial, onMigrate is called with both
+// serials after the refresh, so per-display state can be carried over. The port
+// resolver maps a display to its USB port; displays it maps to "" are never
+// matched. Pass USBPort for the real sysfs topology.
+//
+// Two displays swapped between ports within the window would be matched wrongly,
+// so this is off by default.
+func WithSerialMigration(port func(DeviceInfo) string, onMigrate func(oldSerial, newSerial string)) ManagerOption {
+	return func(m *Manager) {
+		m.portOf = port
+		m.onMigrate = onMigrate
+	}
+}
+
+// WithMigrationWindow sets how long a disconnected display's port is remembered
+// for serial migration, see WithSerialMigration. The default is
+// DefaultMigrationWindow; 0 only matches displays replaced within one refresh.
+func WithMigrationWindow(d time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.migrationWindow = max(d, 0)
+	}
+}
+
+// USBPort returns the sysfs path of the USB device a display's hidraw node belongs
+// to, e.g. "/sys/devices/pci0000:00/0000:00:14.0/usb3/3-2", which stays the same
+// while the display is plugged into the same port. It returns "" if it can't be
+// determined.
+func USBPort(info DeviceInfo) string {
+	if info.Path == "" {
+		return ""
+	}
+	devpath, err := filepath.EvalSymlinks(filepath.Join(hidrawSysfsDir, filepath.Base(info.Path), "device"))
+	if err != nil {
+		return ""
+	}
+	return portFromDevpath(devpath)
+}
+
+// portFromDevpath returns the part of a resolved sysfs device path up to its
+// innermost USB device, or "" if the path doesn't go through one.
+func portFromDevpath(devpath string) string {
+	parts := strings.Split(devpath, "/")
+	for i := len(parts) - 1; i >= 0; i-- {
+		if usbPortPattern.MatchString(parts[i]) {
+			return strings.Join(parts[:i+1], "/")
+		}
+	}
+	return ""
+}
+
+// rememberPortLocked records the USB port of a newly tracked display; the sysfs
+// entry is gone by the time the display disappears. Must be called with m.mu held.
+func (m *Manager) rememberPortLocked(serial string, info DeviceInfo) {
+	if m.portOf == nil {
+		return
+	}
+	if m.ports == nil {
+		m.ports = make(map[string]DeviceInfo)
+	}
+	delete(m.departed, serial)
+	port := m.portOf(info)
+	if port == "" {
+		return
+	}
+	info.Path = port
+	m.ports[serial] = info
+}
+
+// matchMigrationsLocked pairs newly tracked displays with displays on the same port
+// with the same product that were removed in this refresh or departed within the
+// migration window, preferring the one that departed last. Ports of displays that
+// departed longer ago are forgotten. Must be called with m.mu held, after
+// rememberPortLocked ran for the new displays.
+func (m *Manager) matchMigrationsLocked(removed, added []string) []serialMigration {
+	if m.portOf == nil {
+		return nil
+	}
+
+	now := time.Now()
+	if m.departed == nil {
+		m.departed = make(map[string]time.Time)
+	}
+	for _, serial := range removed {
+		if _, ok := m.ports[serial]; ok {
+			m.departed[serial] = now
+		}
+	}
+	for serial, at := range m.departed {
+		if now.Sub(at) > m.migrationWindow {
+			delete(m.departed, serial)
+			delete(m.ports, serial)
+		}
+	}
+
+	var migrations []serialMigration
+	for _, to := range added {
+		next, ok := m.ports[to]
+		if !ok {
+			continue
+		}
+		from, latest := "", time.Time{}
+		for serial, at := range m.departed {
+			previous := m.ports[serial]
+			if previous.Path != next.Path || previous.Product != next.Product {
+				continue
+			}
+			if from == "" || at.After(latest) {
+				from, latest = serial, at
+			}
+		}
+		if from == "" {
+			continue
+		}
+		log.Info().Str("from", from).Str("to", to).Str("port", next.Path).Msg("Display serial changed")
+		migrations = append(migrations, serialMigration{from: from, to: to})
+		delete(m.departed, from)
+		delete(m.ports, from)
+	}
+	return migrations
+}
+
+// notifyMigrations calls the migration callback for each migration. It runs
+// without m.mu held, so the callback may use the manager.
+func (m *Manager) notifyMigrations(migrations []serialMigration) {
+	for _, migration := range migrations {
+		m.onMigrate(migration.from, migration.to)
+	}
+}
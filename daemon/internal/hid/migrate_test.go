@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// swappableEnumerator returns whatever devices were set last.
+type swappableEnumerator struct {
+	mu      sync.Mutex
+	devices []hid.DeviceInfo
+}
+
+func (e *swappableEnumerator) set(devices ...hid.DeviceInfo) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.devices = devices
+}
+
+func (e *swappableEnumerator) enumerate() ([]hid.DeviceInfo, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.devices, nil
+}
+
+// newMigratingManager returns a manager whose port resolver uses the device path
+// as the port, and the migrations it reported.
+func newMigratingManager(enumerator *swappableEnumerator, opts ...hid.ManagerOption) (*hid.Manager, *[][2]string) {
+	var migrations [][2]string
+	m := hid.NewManager(append([]hid.ManagerOption{
+		hid.WithEnumerator(enumerator.enumerate),
+		hid.WithOpener(func(serial string) (hid.Device, error) {
+			return &stubDevice{info: hid.DeviceInfo{Serial: serial}}, nil
+		}),
+		hid.WithSerialMigration(
+			func(info hid.DeviceInfo) string { return info.Path },
+			func(oldSerial, newSerial string) { migrations = append(migrations, [2]string{oldSerial, newSerial}) },
+		),
+	}, opts...)...)
+	return m, &migrations
+}
+
+func TestManager_SerialMigration_SamePortAndProduct(t *testing.T) {
+	enumerator := &swappableEnumerator{}
+	enumerator.set(
+		hid.DeviceInfo{Serial: "OLD", Product: "Studio Display", Path: "port-1"},
+		hid.DeviceInfo{Serial: "OTHER", Product: "Studio Display", Path: "port-2"},
+	)
+	m, migrations := newMigratingManager(enumerator)
+	require.NoError(t, m.RefreshDisplays())
+
+	enumerator.set(
+		hid.DeviceInfo{Serial: "NEW", Product: "Studio Display", Path: "port-1"},
+		hid.DeviceInfo{Serial: "OTHER", Product: "Studio Display", Path: "port-2"},
+	)
+	require.NoError(t, m.RefreshDisplays())
+	assert.Equal(t, [][2]string{{"OLD", "NEW"}}, *migrations)
+
+	// The migrated display keeps its port for a later change
+	enumerator.set(
+		hid.DeviceInfo{Serial: "NEWER", Product: "Studio Display", Path: "port-1"},
+		hid.DeviceInfo{Serial: "OTHER", Product: "Studio Display", Path: "port-2"},
+	)
+	require.NoError(t, m.RefreshDisplays())
+	assert.Equal(t, [][2]string{{"OLD", "NEW"}, {"NEW", "NEWER"}}, *migrations)
+}
+
+func TestManager_SerialMigration_NoMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		next hid.DeviceInfo
+	}{
+		{name: "different port", next: hid.DeviceInfo{Serial: "NEW", Product: "Studio Display", Path: "port-2"}},
+		{name: "different product", next: hid.DeviceInfo{Serial: "NEW", Product: "Pro Display XDR", Path: "port-1"}},
+		{name: "unknown port", next: hid.DeviceInfo{Serial: "NEW", Product: "Studio Display"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enumerator := &swappableEnumerator{}
+			enumerator.set(hid.DeviceInfo{Serial: "OLD", Product: "Studio Display", Path: "port-1"})
+			m, migrations := newMigratingManager(enumerator)
+			require.NoError(t, m.RefreshDisplays())
+
+			enumerator.set(tt.next)
+			require.NoError(t, m.RefreshDisplays())
+			assert.Empty(t, *migrations)
+		})
+	}
+}
+
+func TestManager_SerialMigration_AcrossRefreshes(t *testing.T) {
+	enumerator := &swappableEnumerator{}
+	enumerator.set(hid.DeviceInfo{Serial: "OLD", Product: "Studio Display", Path: "port-1"})
+	m, migrations := newMigratingManager(enumerator)
+	require.NoError(t, m.RefreshDisplays())
+
+	// udev reports the removal and the addition separately
+	enumerator.set()
+	require.ErrorIs(t, m.RefreshDisplays(), hid.ErrNoDisplaysFound)
+	enumerator.set(hid.DeviceInfo{Serial: "NEW", Product: "Studio Display", Path: "port-1"})
+	require.NoError(t, m.RefreshDisplays())
+	assert.Equal(t, [][2]string{{"OLD", "NEW"}}, *migrations)
+
+	// The old serial is matched only once
+	enumerator.set()
+	require.ErrorIs(t, m.RefreshDisplays(), hid.ErrNoDisplaysFound)
+	enumerator.set(hid.DeviceInfo{Serial: "NEWER", Product: "Studio Display", Path: "port-1"})
+	require.NoError(t, m.RefreshDisplays())
+	assert.Equal(t, [][2]string{{"OLD", "NEW"}, {"NEW", "NEWER"}}, *migrations)
+}
+
+func TestManager_SerialMigration_WindowExpired(t *testing.T) {
+	enumerator := &swappableEnumerator{}
+	enumerator.set(hid.DeviceInfo{Serial: "OLD", Product: "Studio Display", Path: "port-1"})
+	m, migrations := newMigratingManager(enumerator, hid.WithMigrationWindow(time.Millisecond))
+	require.NoError(t, m.RefreshDisplays())
+
+	enumerator.set()
+	require.ErrorIs(t, m.RefreshDisplays(), hid.ErrNoDisplaysFound)
+	time.Sleep(10 * time.Millisecond)
+	enumerator.set(hid.DeviceInfo{Serial: "NEW", Product: "Studio Display", Path: "port-1"})
+	require.NoError(t, m.RefreshDisplays())
+
+	assert.Empty(t, *migrations, "a display gone longer than the window is a different display")
+}
+
+func TestPortFromDevpath(t *testing.T) {
+	tests := []struct {
+		devpath  string
+		expected string
+	}{
+		{
+			devpath:  "/sys/devices/pci0000:00/0000:00:14.0/usb3/3-2/3-2:1.7/0003:05AC:1114.0005",
+			expected: "/sys/devices/pci0000:00/0000:00:14.0/usb3/3-2",
+		},
+		{
+			devpath:  "/sys/devices/pci0000:00/0000:00:14.0/usb3/3-2/3-2.4/3-2.4:1.7/0003:05AC:1114.0005",
+			expected: "/sys/devices/pci0000:00/0000:00:14.0/usb3/3-2/3-2.4",
+		},
+		{devpath: "/sys/devices/virtual/misc/uhid/0003:05AC:1114.0005", expected: ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, hid.PortFromDevpath(tt.devpath), tt.devpath)
+	}
+}
+
+func TestUSBPort(t *testing.T) {
+	sysfs := t.TempDir()
+	port := filepath.Join(sysfs, "devices", "pci0000:00", "0000:00:14.0", "usb3", "3-2")
+	device := filepath.Join(port, "3-2:1.7", "0003:05AC:1114.0005")
+	require.NoError(t, os.MkdirAll(device, 0o750))
+
+	hidraw := filepath.Join(sysfs, "class", "hidraw")
+	require.NoError(t, os.MkdirAll(filepath.Join(hidraw, "hidraw4"), 0o750))
+	require.NoError(t, os.Symlink(device, filepath.Join(hidraw, "hidraw4", "device")))
+	defer hid.SetHidrawSysfsDir(hidraw)()
+
+	resolved, err := filepath.EvalSymlinks(port)
+	require.NoError(t, err)
+	assert.Equal(t, resolved, hid.USBPort(hid.DeviceInfo{Path: "/dev/hidraw4"}))
+	assert.Empty(t, hid.USBPort(hid.DeviceInfo{Path: "/dev/hidraw9"}))
+}
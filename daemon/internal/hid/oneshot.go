@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+import "github.com/rs/zerolog/log"
+
+// ReadBrightnessOnce opens the display with the given serial, reads its brightness
+// as a percentage (0-100), and closes the handle again. It doesn't use or modify
+// any Manager, so it's suitable for one-shot CLI invocations and other processes
+// that only need a single reading. An empty serial reads the first display found.
+//
+// Caveat: hidraw nodes are not opened exclusively, so this can run while the
+// daemon holds its own handle to the same display. Each feature report is a
+// self-contained transfer, so a concurrent read won't corrupt the daemon's
+// writes, but the value returned may already be stale if the daemon changes
+// brightness at the same moment. Prefer the D-Bus API while the daemon is running.
+func ReadBrightnessOnce(serial string) (uint8, error) {
+	return readBrightnessOnce(serial, defaultOpener)
+}
+
+// readBrightnessOnce implements ReadBrightnessOnce with an injectable opener.
+func readBrightnessOnce(serial string, open func(serial string) (Device, error)) (uint8, error) {
+	device, err := open(serial)
+	if err != nil {
+		return 0, err
+	}
+
+	display := NewDisplay(device)
+	defer func() {
+		if closeErr := display.Close(); closeErr != nil {
+			log.Warn().Err(closeErr).Str("serial", serial).Msg("Failed to close display after one-shot read")
+		}
+	}()
+
+	return display.GetBrightness()
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// MinRawReportSize is the smallest raw feature report: a report ID and one data byte.
+	MinRawReportSize = 2
+
+	// MaxRawReportSize is the largest raw feature report, the hidraw buffer limit.
+	MaxRawReportSize = 4096
+)
+
+// ErrInvalidReportLength is returned when a raw feature report is out of bounds.
+var ErrInvalidReportLength = errors.New("invalid feature report length")
+
+// RawFeatureReport sends data as a feature report, then reads the report with the
+// same ID back and returns it. data must start with the report ID. It's meant for
+// probing firmware features; nothing about the payload is validated beyond its length.
+func (d *Display) RawFeatureReport(data []byte) ([]byte, error) {
+	if len(data) < MinRawReportSize || len(data) > MaxRawReportSize {
+		return nil, d.wrapErr(fmt.Errorf("%w: got %d bytes, want %d-%d",
+			ErrInvalidReportLength, len(data), MinRawReportSize, MaxRawReportSize))
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.closed {
+		return nil, d.wrapErr(ErrDisplayClosed)
+	}
+
+	if _, err := d.device.SendFeatureReport(data); err != nil {
+		return nil, d.wrapErr(fmt.Errorf("failed to send feature report: %w", err))
+	}
+
+	reply := make([]byte, len(data))
+	reply[0] = data[0]
+	n, err := d.device.GetFeatureReport(reply)
+	if err != nil {
+		return nil, d.wrapErr(fmt.Errorf("failed to get feature report: %w", err))
+	}
+	return reply[:min(n, len(reply))], nil
+}
@@ -0,0 +1,45 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid_test
+
+import (
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDisplay_RawFeatureReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDevice := mocks.NewMockDevice(ctrl)
+	mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"}).AnyTimes()
+
+	payload := []byte{0x05, 0x01, 0x02}
+	gomock.InOrder(
+		mockDevice.EXPECT().SendFeatureReport(payload).Return(len(payload), nil),
+		mockDevice.EXPECT().GetFeatureReport(gomock.Any()).DoAndReturn(func(data []byte) (int, error) {
+			require.Len(t, data, len(payload))
+			assert.Equal(t, byte(0x05), data[0], "the reply should be requested with the same report ID")
+			return copy(data, []byte{0x05, 0xAA}), nil
+		}),
+	)
+
+	reply, err := hid.NewDisplay(mockDevice).RawFeatureReport(payload)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x05, 0xAA}, reply)
+}
+
+func TestDisplay_RawFeatureReport_LengthBounds(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDevice := mocks.NewMockDevice(ctrl)
+	mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"}).AnyTimes()
+
+	display := hid.NewDisplay(mockDevice)
+	for _, size := range []int{0, 1, hid.MaxRawReportSize + 1} {
+		_, err := display.RawFeatureReport(make([]byte, size))
+		assert.ErrorIs(t, err, hid.ErrInvalidReportLength, "size %d", size)
+	}
+}
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrDeviceNotReady is returned when a display's hidraw node opens but doesn't
+// serve feature reports within the readiness timeout.
+var ErrDeviceNotReady = errors.New("device not ready")
+
+// DefaultReadinessPollInterval is the delay between readiness probes.
+const DefaultReadinessPollInterval = 100 * time.Millisecond
+
+// WithReadinessProbe makes RefreshDisplays verify that a newly opened display can
+// serve a brightness feature report before tracking it. On some kernels the hidraw
+// node exists slightly before the HID descriptor is usable; failed probes close the
+// handle and reopen it every interval until timeout has passed. A timeout of 0
+// disables the probe (default).
+func WithReadinessProbe(timeout, interval time.Duration) ManagerOption {
+	return func(m *Manager) {
+		if interval <= 0 {
+			interval = DefaultReadinessPollInterval
+		}
+		m.readyTimeout = timeout
+		m.readyInterval = interval
+	}
+}
+
+// openReady opens serial and, if the readiness probe is enabled, retries until the
+// device answers a feature report or the readiness timeout expires.
+func (m *Manager) openReady(serial string) (Device, error) {
+	if m.readyTimeout <= 0 {
+		return m.open(serial)
+	}
+
+	start := time.Now()
+	deadline := start.Add(m.readyTimeout)
+	for attempt := 1; ; attempt++ {
+		device, err := m.open(serial)
+		if err == nil {
+			if err = probeDevice(device); err == nil {
+				if attempt > 1 {
+					log.Info().
+						Str("serial", serial).
+						Int("attempts", attempt).
+						Dur("delay", time.Since(start)).
+						Msg("Display became ready")
+				}
+				return device, nil
+			}
+			if closeErr := device.Close(); closeErr != nil {
+				log.Debug().Err(closeErr).Str("serial", serial).Msg("Failed to close unready display")
+			}
+		}
+
+		if time.Now().Add(m.readyInterval).After(deadline) {
+			return nil, fmt.Errorf("%w after %s: %w", ErrDeviceNotReady, time.Since(start).Round(time.Millisecond), err)
+		}
+		log.Debug().Err(err).Str("serial", serial).Int("attempt", attempt).Msg("Display not ready yet, retrying")
+		time.Sleep(m.readyInterval)
+	}
+}
+
+// probeDevice issues a harmless brightness read to check the device serves feature reports.
+func probeDevice(device Device) error {
+	data := make([]byte, ReportSize)
+	data[0] = ReportID
+
+	n, err := device.GetFeatureReport(data)
+	if err != nil {
+		return fmt.Errorf("readiness probe failed: %w", err)
+	}
+	if _, err := DecodeReport(data[:min(n, len(data))]); err != nil {
+		return fmt.Errorf("readiness probe failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// probedDevice fails feature report reads until ready is set and records whether it was closed.
+type probedDevice struct {
+	stubDevice
+	ready  bool
+	closed bool
+}
+
+func (d *probedDevice) GetFeatureReport(data []byte) (int, error) {
+	if !d.ready {
+		return 0, syscall.EPIPE
+	}
+	return len(data), nil
+}
+
+func (d *probedDevice) Close() error {
+	d.closed = true
+	return nil
+}
+
+func TestManager_RefreshDisplays_ReadinessProbe(t *testing.T) {
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		return []hid.DeviceInfo{{Serial: "ABC123"}}, nil
+	}
+
+	// The first handle isn't ready yet; the one opened on the retry is
+	var opened []*probedDevice
+	opener := func(serial string) (hid.Device, error) {
+		device := &probedDevice{stubDevice: stubDevice{info: hid.DeviceInfo{Serial: serial}}, ready: len(opened) > 0}
+		opened = append(opened, device)
+		return device, nil
+	}
+
+	m := hid.NewManager(
+		hid.WithEnumerator(enumerator),
+		hid.WithOpener(opener),
+		hid.WithReadinessProbe(time.Second, time.Millisecond),
+	)
+	require.NoError(t, m.RefreshDisplays())
+
+	require.Len(t, opened, 2)
+	assert.True(t, opened[0].closed, "the handle that failed the probe should be closed")
+	assert.False(t, opened[1].closed)
+	assert.Equal(t, 1, m.Count())
+
+	display, err := m.GetDisplay("ABC123")
+	require.NoError(t, err)
+	_, err = display.GetBrightness()
+	assert.NoError(t, err, "the tracked display should be the handle that passed the probe")
+}
+
+func TestManager_RefreshDisplays_ReadinessTimeout(t *testing.T) {
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		return []hid.DeviceInfo{{Serial: "ABC123"}}, nil
+	}
+	var opens atomic.Int32
+	opener := func(serial string) (hid.Device, error) {
+		opens.Add(1)
+		return &probedDevice{stubDevice: stubDevice{info: hid.DeviceInfo{Serial: serial}}}, nil
+	}
+
+	m := hid.NewManager(
+		hid.WithEnumerator(enumerator),
+		hid.WithOpener(opener),
+		hid.WithReadinessProbe(20*time.Millisecond, 5*time.Millisecond),
+	)
+
+	assert.ErrorIs(t, m.RefreshDisplays(), hid.ErrNoDisplaysFound)
+	assert.Equal(t, 0, m.Count(), "a display that never becomes ready must not be tracked")
+	assert.Greater(t, opens.Load(), int32(1), "opening should be retried")
+}
+
+func TestManager_RefreshDisplays_ReadinessProbeRetriesOpenErrors(t *testing.T) {
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		return []hid.DeviceInfo{{Serial: "ABC123"}}, nil
+	}
+	var opens atomic.Int32
+	opener := func(serial string) (hid.Device, error) {
+		if opens.Add(1) == 1 {
+			return nil, errors.New("hidraw node not accessible yet")
+		}
+		return &probedDevice{stubDevice: stubDevice{info: hid.DeviceInfo{Serial: serial}}, ready: true}, nil
+	}
+
+	m := hid.NewManager(
+		hid.WithEnumerator(enumerator),
+		hid.WithOpener(opener),
+		hid.WithReadinessProbe(time.Second, time.Millisecond),
+	)
+
+	require.NoError(t, m.RefreshDisplays())
+	assert.Equal(t, 1, m.Count())
+	assert.Equal(t, int32(2), opens.Load())
+}
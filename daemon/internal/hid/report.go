@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// HID Feature Report Structure for Apple Studio Display Brightness Control
+//
+// The brightness is controlled via a 7-byte HID feature report with the following layout:
+//
+//	Byte 0:     Report ID (0x01)
+//	Bytes 1-4:  Brightness value in nits (little-endian uint32)
+//	Bytes 5-6:  Reserved/unused
+//
+// The brightness value is stored as an internal brightness unit (not a percentage).
+// Valid range is MinBrightness to MaxBrightness (see brightness package constants).
+// The daemon converts between internal units and percentage (0-100) for the D-Bus API.
+const (
+	// ReportID is the HID report ID for brightness control (always 0x01).
+	ReportID byte = 0x01
+
+	// ReportSize is the total size of the HID feature report in bytes.
+	// Layout: [ReportID(1)] [Nits(4)] [Reserved(2)] = 7 bytes
+	ReportSize = 7
+
+	// ReportOffsetNits is the byte offset where the nits value starts in the HID report.
+	ReportOffsetNits = 1
+
+	// ReportLenNits is the length in bytes of the nits value (little-endian uint32).
+	ReportLenNits = 4
+)
+
+// ErrShortReport is returned when a feature report is too short to contain a brightness value.
+var ErrShortReport = errors.New("feature report too short")
+
+// EncodeReport builds a complete brightness feature report (including the report ID)
+// carrying the given value in nits. The value is not clamped; callers are expected
+// to pass a value within the display's supported range.
+func EncodeReport(nits uint32) []byte {
+	data := make([]byte, ReportSize)
+	data[0] = ReportID
+	binary.LittleEndian.PutUint32(data[ReportOffsetNits:ReportOffsetNits+ReportLenNits], nits)
+	return data
+}
+
+// DecodeReport extracts the brightness value in nits from a feature report.
+// The data must start with the report ID byte, as returned by GetFeatureReport.
+// Returns ErrShortReport if data doesn't contain a complete nits field.
+func DecodeReport(data []byte) (uint32, error) {
+	if len(data) < ReportOffsetNits+ReportLenNits {
+		return 0, fmt.Errorf("%w: got %d bytes, need %d", ErrShortReport, len(data), ReportOffsetNits+ReportLenNits)
+	}
+	return binary.LittleEndian.Uint32(data[ReportOffsetNits : ReportOffsetNits+ReportLenNits]), nil
+}
@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid_test
+
+import (
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/brightness"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeReport(t *testing.T) {
+	data := hid.EncodeReport(30200)
+
+	require.Len(t, data, hid.ReportSize)
+	assert.Equal(t, []byte{hid.ReportID, 0xF8, 0x75, 0x00, 0x00, 0x00, 0x00}, data)
+}
+
+func TestEncodeDecodeReport_RoundTrip(t *testing.T) {
+	values := []uint32{0, brightness.MinBrightness, 30200, brightness.MaxBrightness, 0xDEADBEEF}
+	for _, nits := range values {
+		decoded, err := hid.DecodeReport(hid.EncodeReport(nits))
+		require.NoError(t, err)
+		assert.Equal(t, nits, decoded)
+	}
+
+	for percent := uint8(0); percent <= 100; percent++ {
+		decoded, err := hid.DecodeReport(hid.EncodeReport(brightness.PercentToNits(percent)))
+		require.NoError(t, err)
+		assert.Equal(t, percent, brightness.NitsToPercent(decoded))
+	}
+}
+
+func TestDecodeReport_ShortBuffer(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "nil", data: nil},
+		{name: "report ID only", data: []byte{hid.ReportID}},
+		{name: "truncated nits", data: []byte{hid.ReportID, 0xF8, 0x75, 0x00}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := hid.DecodeReport(tt.data)
+			assert.ErrorIs(t, err, hid.ErrShortReport)
+		})
+	}
+
+	// Exactly report ID + nits is enough; reserved bytes are optional
+	nits, err := hid.DecodeReport([]byte{hid.ReportID, 0xF8, 0x75, 0x00, 0x00})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(30200), nits)
+}
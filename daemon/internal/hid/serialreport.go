@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// HID Feature Report Structure for the display serial number
+//
+// This layout is unverified and only used with experimental reports enabled; see
+// experimental.go. It's meant for displays that enumerate with a blank USB
+// descriptor serial number.
+//
+//	Byte 0:     Report ID (0x03)
+//	Bytes 1-32: Serial number in ASCII, padded with NUL bytes
+const (
+	// SerialReportID is the HID report ID for the serial number.
+	SerialReportID byte = 0x03
+
+	// SerialReportSize is the total size of the serial number feature report in bytes.
+	SerialReportSize = 33
+)
+
+// BlankSerialSettle is how long EnumerateDisplaysReadingSerials waits before asking
+// displays with a blank USB descriptor serial for their serial number report. A
+// blank serial is usually transitional while a display connects, so only displays
+// still without one after settling are opened.
+const BlankSerialSettle = time.Second
+
+var (
+	// ErrSerialUnsupported is returned when a display doesn't report its serial number.
+	ErrSerialUnsupported = errors.New("serial number report not supported by display")
+
+	// ErrInvalidSerialReport is returned when a serial number report is malformed.
+	ErrInvalidSerialReport = errors.New("invalid serial number report")
+)
+
+// DecodeSerialReport extracts the serial number from a serial number feature
+// report, including its leading report ID byte.
+func DecodeSerialReport(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", ErrSerialUnsupported
+	}
+	if data[0] != SerialReportID {
+		return "", fmt.Errorf("%w: report ID 0x%02x, want 0x%02x", ErrInvalidSerialReport, data[0], SerialReportID)
+	}
+
+	serial := data[1:]
+	if end := bytes.IndexByte(serial, 0); end >= 0 {
+		serial = serial[:end]
+	}
+	serial = bytes.TrimSpace(serial)
+	if len(serial) == 0 {
+		return "", ErrSerialUnsupported
+	}
+	for i, c := range serial {
+		if c < 0x21 || c > 0x7e {
+			return "", fmt.Errorf("%w: unprintable character at position %d", ErrInvalidSerialReport, i)
+		}
+	}
+	return string(serial), nil
+}
+
+// ReadSerialReport reads the serial number of a display through its serial number
+// feature report.
+func ReadSerialReport(device Device) (string, error) {
+	data := make([]byte, SerialReportSize)
+	data[0] = SerialReportID
+
+	n, err := device.GetFeatureReport(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to get serial number report: %w", err)
+	}
+	return DecodeSerialReport(data[:min(n, len(data))])
+}
+
+// withSerials returns the candidates that have a serial number. Displays with a
+// blank USB descriptor serial are skipped, unless readSerials is set, in which case
+// their serial is read with resolveSerial.
+func withSerials(candidates []DeviceInfo, readSerials bool, open func(path string) (Device, error)) []DeviceInfo {
+	var displays []DeviceInfo
+	for _, candidate := range candidates {
+		if candidate.Serial == "" && !readSerials {
+			log.Debug().Str("path", candidate.Path).Msg("Skipping display without serial number")
+			continue
+		}
+		if info, ok := resolveSerial(candidate, open); ok {
+			displays = append(displays, info)
+		}
+	}
+	return displays
+}
+
+// resolveSerial fills in the serial number of a display whose USB descriptor
+// serial is blank by opening it with open and reading its serial number report.
+// It reports false if the display has no serial either way, in which case it
+// can't be told apart from others and is skipped.
+func resolveSerial(info DeviceInfo, open func(path string) (Device, error)) (DeviceInfo, bool) {
+	if info.Serial != "" {
+		return info, true
+	}
+
+	device, err := open(info.Path)
+	if err != nil {
+		log.Debug().Err(err).Str("path", info.Path).Msg("Failed to open display without serial number")
+		return info, false
+	}
+	defer func() {
+		if err := device.Close(); err != nil {
+			log.Debug().Err(err).Str("path", info.Path).Msg("Failed to close display after reading its serial number")
+		}
+	}()
+
+	serial, err := ReadSerialReport(device)
+	if err != nil {
+		log.Debug().Err(err).Str("path", info.Path).Msg("Skipping display without serial number")
+		return info, false
+	}
+	log.Debug().Str("path", info.Path).Str("serial", serial).Msg("Read serial number of display with a blank USB serial")
+	info.Serial = serial
+	return info, true
+}
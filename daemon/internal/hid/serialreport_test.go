@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serialDevice answers the serial number report with report, or err.
+type serialDevice struct {
+	report []byte
+	err    error
+	closed bool
+}
+
+func (d *serialDevice) GetFeatureReport(data []byte) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+	return copy(data, d.report), nil
+}
+func (d *serialDevice) SendFeatureReport(data []byte) (int, error) { return len(data), nil }
+func (d *serialDevice) Close() error                               { d.closed = true; return nil }
+func (d *serialDevice) Info() hid.DeviceInfo                       { return hid.DeviceInfo{} }
+
+func serialReport(serial string) []byte {
+	report := make([]byte, hid.SerialReportSize)
+	report[0] = hid.SerialReportID
+	copy(report[1:], serial)
+	return report
+}
+
+func TestDecodeSerialReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantErr error
+	}{
+		{name: "padded", data: serialReport("SERIAL123"), want: "SERIAL123"},
+		{name: "surrounding spaces", data: serialReport(" SERIAL123 "), want: "SERIAL123"},
+		{name: "full length", data: serialReport("ABCDEFGHIJKLMNOPQRSTUVWXYZ012345"), want: "ABCDEFGHIJKLMNOPQRSTUVWXYZ012345"},
+		{name: "empty", data: nil, wantErr: hid.ErrSerialUnsupported},
+		{name: "blank", data: serialReport(""), wantErr: hid.ErrSerialUnsupported},
+		{name: "wrong report ID", data: append([]byte{0x01}, "SERIAL123"...), wantErr: hid.ErrInvalidSerialReport},
+		{name: "unprintable", data: serialReport("SER\x07AL"), wantErr: hid.ErrInvalidSerialReport},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serial, err := hid.DecodeSerialReport(tt.data)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, serial)
+		})
+	}
+}
+
+func TestResolveSerial(t *testing.T) {
+	info := hid.DeviceInfo{Path: "/dev/hidraw4", Interface: hid.BrightnessInterface}
+
+	t.Run("blank descriptor serial is read from the display", func(t *testing.T) {
+		device := &serialDevice{report: serialReport("SERIAL123")}
+		var opened string
+		resolved, ok := hid.ResolveSerial(info, func(path string) (hid.Device, error) {
+			opened = path
+			return device, nil
+		})
+		require.True(t, ok)
+		assert.Equal(t, "SERIAL123", resolved.Serial)
+		assert.Equal(t, info.Path, resolved.Path)
+		assert.Equal(t, info.Path, opened)
+		assert.True(t, device.closed, "the display is closed after reading its serial")
+	})
+
+	t.Run("descriptor serial is kept", func(t *testing.T) {
+		withSerial := info
+		withSerial.Serial = "SN1"
+		resolved, ok := hid.ResolveSerial(withSerial, func(string) (hid.Device, error) {
+			t.Fatal("a display with a serial isn't opened")
+			return nil, nil
+		})
+		require.True(t, ok)
+		assert.Equal(t, withSerial, resolved)
+	})
+
+	t.Run("skipped if both serials are blank", func(t *testing.T) {
+		device := &serialDevice{report: serialReport("")}
+		_, ok := hid.ResolveSerial(info, func(string) (hid.Device, error) { return device, nil })
+		assert.False(t, ok)
+		assert.True(t, device.closed)
+
+		device = &serialDevice{err: errors.New("stall")}
+		_, ok = hid.ResolveSerial(info, func(string) (hid.Device, error) { return device, nil })
+		assert.False(t, ok)
+
+		_, ok = hid.ResolveSerial(info, func(string) (hid.Device, error) { return nil, errors.New("permission denied") })
+		assert.False(t, ok)
+	})
+}
+
+func TestWithSerials(t *testing.T) {
+	candidates := []hid.DeviceInfo{
+		{Path: "/dev/hidraw2", Serial: "SN1"},
+		{Path: "/dev/hidraw4"},
+	}
+
+	t.Run("blank serials are skipped by default", func(t *testing.T) {
+		displays := hid.WithSerials(candidates, false, func(string) (hid.Device, error) {
+			t.Fatal("a display with a blank serial isn't opened unless serials are read")
+			return nil, nil
+		})
+		assert.Equal(t, candidates[:1], displays)
+	})
+
+	t.Run("blank serials are read if enabled", func(t *testing.T) {
+		displays := hid.WithSerials(candidates, true, func(string) (hid.Device, error) {
+			return &serialDevice{report: serialReport("SN2")}, nil
+		})
+		require.Len(t, displays, 2)
+		assert.Equal(t, "SN2", displays[1].Serial)
+	})
+}
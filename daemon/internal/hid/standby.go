@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+import (
+	"errors"
+	"fmt"
+)
+
+// HID Feature Report Structure for panel standby
+//
+// This layout is unverified and only used with experimental reports enabled; see
+// experimental.go. It's meant for blanking the panel without the display dropping
+// off the bus.
+//
+//	Byte 0: Report ID (0x04)
+//	Byte 1: Panel state (0x00 = on, 0x01 = standby)
+const (
+	// StandbyReportID is the HID report ID for the panel standby state.
+	StandbyReportID byte = 0x04
+
+	// StandbyReportSize is the total size of the standby feature report in bytes.
+	StandbyReportSize = 2
+
+	// StandbyOffsetState is the byte offset of the panel state.
+	StandbyOffsetState = 1
+
+	standbyStateOn      byte = 0x00
+	standbyStateStandby byte = 0x01
+)
+
+var (
+	// ErrStandbyUnsupported is returned when a display doesn't provide standby control.
+	ErrStandbyUnsupported = errors.New("standby not supported by display")
+
+	// ErrInvalidStandby is returned when a standby report is malformed.
+	ErrInvalidStandby = errors.New("invalid standby report")
+)
+
+// DecodeStandbyReport extracts whether the panel is in standby from a standby
+// feature report, including its leading report ID byte.
+func DecodeStandbyReport(data []byte) (bool, error) {
+	if len(data) == 0 {
+		return false, ErrStandbyUnsupported
+	}
+	if data[0] != StandbyReportID {
+		return false, fmt.Errorf("%w: report ID 0x%02x, want 0x%02x", ErrInvalidStandby, data[0], StandbyReportID)
+	}
+	if len(data) < StandbyReportSize {
+		return false, fmt.Errorf("%w: got %d bytes, need %d", ErrShortReport, len(data), StandbyReportSize)
+	}
+
+	switch data[StandbyOffsetState] {
+	case standbyStateOn:
+		return false, nil
+	case standbyStateStandby:
+		return true, nil
+	default:
+		return false, fmt.Errorf("%w: panel state 0x%02x", ErrInvalidStandby, data[StandbyOffsetState])
+	}
+}
+
+// EncodeStandbyReport creates a standby feature report entering or leaving standby.
+func EncodeStandbyReport(standby bool) []byte {
+	data := make([]byte, StandbyReportSize)
+	data[0] = StandbyReportID
+	if standby {
+		data[StandbyOffsetState] = standbyStateStandby
+	}
+	return data
+}
+
+// SupportsStandby reports whether the display provides standby control, which is
+// never the case with experimental reports disabled. The display is queried once;
+// the answer is remembered for later calls. A query failing for another reason,
+// e.g. because the display can't be reached, reports false without the answer
+// being remembered.
+func (d *Display) SupportsStandby() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.probeStandby() == nil
+}
+
+// SetStandby puts the panel into standby or wakes it. Returns ErrStandbyUnsupported
+// if experimental reports are disabled or the display doesn't provide standby control.
+func (d *Display) SetStandby(enabled bool) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.probeStandby(); err != nil {
+		return err
+	}
+
+	data := EncodeStandbyReport(enabled)
+	err := d.withWriteLocks(func() error {
+		return d.sendReport(StandbyReportID, data)
+	})
+	if err != nil {
+		return d.wrapErr(fmt.Errorf("failed to send standby report: %w", err))
+	}
+	return nil
+}
+
+// probeStandby checks, once, whether the display answers the standby report.
+// Must be called with d.mu held.
+func (d *Display) probeStandby() error {
+	if d.closed {
+		return d.wrapErr(ErrDisplayClosed)
+	}
+	if !d.experimental || d.noStandby {
+		return d.wrapErr(ErrStandbyUnsupported)
+	}
+	if d.hasStandby {
+		return nil
+	}
+
+	data := make([]byte, StandbyReportSize)
+	data[0] = StandbyReportID
+
+	n, err := d.device.GetFeatureReport(data)
+	if IsStallError(err) {
+		d.noStandby = true
+		return d.wrapErr(fmt.Errorf("%w: %w", ErrStandbyUnsupported, err))
+	}
+	if err != nil {
+		return d.wrapErr(fmt.Errorf("failed to get standby report: %w", err))
+	}
+
+	if _, err := DecodeStandbyReport(data[:min(n, len(data))]); err != nil {
+		if errors.Is(err, ErrStandbyUnsupported) {
+			d.noStandby = true
+		}
+		return d.wrapErr(err)
+	}
+	d.hasStandby = true
+	return nil
+}
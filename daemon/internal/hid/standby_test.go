@@ -0,0 +1,123 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid_test
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDecodeStandbyReport(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected bool
+		err      error
+	}{
+		{name: "panel on", data: []byte{hid.StandbyReportID, 0x00}, expected: false},
+		{name: "panel in standby", data: []byte{hid.StandbyReportID, 0x01}, expected: true},
+		{name: "empty report", data: []byte{}, err: hid.ErrStandbyUnsupported},
+		{name: "wrong report ID", data: []byte{hid.ReportID, 0x01}, err: hid.ErrInvalidStandby},
+		{name: "truncated", data: []byte{hid.StandbyReportID}, err: hid.ErrShortReport},
+		{name: "unknown state", data: []byte{hid.StandbyReportID, 0x07}, err: hid.ErrInvalidStandby},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			standby, err := hid.DecodeStandbyReport(tt.data)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, standby)
+		})
+	}
+}
+
+func TestEncodeStandbyReport(t *testing.T) {
+	assert.Equal(t, []byte{hid.StandbyReportID, 0x01}, hid.EncodeStandbyReport(true))
+	assert.Equal(t, []byte{hid.StandbyReportID, 0x00}, hid.EncodeStandbyReport(false))
+}
+
+func TestDisplay_SetStandby(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDevice := mocks.NewMockDevice(ctrl)
+	mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"}).AnyTimes()
+	// The capability is probed once, then each call writes the report
+	mockDevice.EXPECT().GetFeatureReport(gomock.Any()).DoAndReturn(func(data []byte) (int, error) {
+		assert.Equal(t, hid.StandbyReportID, data[0])
+		return copy(data, []byte{hid.StandbyReportID, 0x00}), nil
+	}).Times(1)
+	gomock.InOrder(
+		mockDevice.EXPECT().SendFeatureReport([]byte{hid.StandbyReportID, 0x01}).Return(hid.StandbyReportSize, nil),
+		mockDevice.EXPECT().SendFeatureReport([]byte{hid.StandbyReportID, 0x00}).Return(hid.StandbyReportSize, nil),
+	)
+
+	display := hid.NewDisplay(mockDevice)
+	display.SetExperimentalReports(true)
+	assert.True(t, display.SupportsStandby())
+	require.NoError(t, display.SetStandby(true))
+	require.NoError(t, display.SetStandby(false))
+}
+
+func TestDisplay_SetStandby_UnsupportedIsRemembered(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDevice := mocks.NewMockDevice(ctrl)
+	mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"}).AnyTimes()
+	// Firmware without standby control stalls the probe; nothing is ever written
+	mockDevice.EXPECT().GetFeatureReport(gomock.Any()).Return(0, syscall.EPIPE).Times(1)
+
+	display := hid.NewDisplay(mockDevice)
+	display.SetExperimentalReports(true)
+	assert.False(t, display.SupportsStandby())
+	for range 2 {
+		err := display.SetStandby(true)
+		require.ErrorIs(t, err, hid.ErrStandbyUnsupported)
+		assert.Contains(t, err.Error(), "ABC123")
+	}
+}
+
+func TestDisplay_SetStandby_DisabledByDefault(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDevice := mocks.NewMockDevice(ctrl)
+	mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"}).AnyTimes()
+	// No report expectations: the unverified report must not be sent
+
+	display := hid.NewDisplay(mockDevice)
+	assert.False(t, display.SupportsStandby())
+	require.ErrorIs(t, display.SetStandby(true), hid.ErrStandbyUnsupported)
+}
+
+func TestDisplay_SetStandby_OtherErrorsAreNotRemembered(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "device gone", err: syscall.ENODEV},
+		{name: "transient I/O error", err: syscall.EIO},
+		{name: "timeout", err: syscall.ETIMEDOUT},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockDevice := mocks.NewMockDevice(ctrl)
+			mockDevice.EXPECT().Info().Return(hid.DeviceInfo{Serial: "ABC123"}).AnyTimes()
+			mockDevice.EXPECT().GetFeatureReport(gomock.Any()).Return(0, tt.err).Times(2)
+
+			display := hid.NewDisplay(mockDevice)
+			display.SetExperimentalReports(true)
+			assert.False(t, display.SupportsStandby())
+			err := display.SetStandby(true)
+			require.ErrorIs(t, err, tt.err)
+			assert.NotErrorIs(t, err, hid.ErrStandbyUnsupported)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultTransientRetryInterval is the default delay between retries of an
+// operation that failed with a transient error.
+const DefaultTransientRetryInterval = 20 * time.Millisecond
+
+// IsTransientError reports whether err may be a passing I/O glitch on a device that
+// is still present. EIO is both transient and device-gone: it's retried first (see
+// WithTransientRetries) and only treated as a disconnect if it persists. hidapi only
+// passes EIO on as its message, like the stall in IsStallError.
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return errors.Is(err, syscall.EIO) || strings.Contains(strings.ToLower(err.Error()), "input/output error")
+}
+
+// WithTransientRetries makes displays retry a brightness read or write up to retries
+// times, interval apart, when it fails with a transient error, before returning the
+// error and letting callers fall back to a full device recovery. Values below 1
+// disable retries, which is the default.
+func WithTransientRetries(retries int, interval time.Duration) ManagerOption {
+	return func(m *Manager) {
+		m.transientRetries = max(retries, 0)
+		m.transientInterval = interval
+	}
+}
+
+// retryTransient runs op, repeating it while it fails with a transient error and
+// retries remain. Must be called with d.mu held.
+func (d *Display) retryTransient(op func() error) error {
+	err := op()
+	for attempt := 1; attempt <= d.transientRetries && IsTransientError(err); attempt++ {
+		log.Debug().Err(err).Str("serial", d.Serial()).Int("attempt", attempt).Msg("Retrying after transient error")
+		time.Sleep(d.transientInterval)
+		err = op()
+	}
+	return err
+}
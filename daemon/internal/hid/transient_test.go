@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid_test
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// glitchingDevice fails the first failures reads and writes with err.
+type glitchingDevice struct {
+	stubDevice
+	err      error
+	failures atomic.Int32
+	calls    atomic.Int32
+}
+
+func (d *glitchingDevice) fail() error {
+	d.calls.Add(1)
+	if d.failures.Add(-1) >= 0 {
+		return fmt.Errorf("hidapi: %w", d.err)
+	}
+	return nil
+}
+
+func (d *glitchingDevice) GetFeatureReport(data []byte) (int, error) {
+	if err := d.fail(); err != nil {
+		return 0, err
+	}
+	return d.stubDevice.GetFeatureReport(data)
+}
+
+func (d *glitchingDevice) SendFeatureReport(data []byte) (int, error) {
+	if err := d.fail(); err != nil {
+		return 0, err
+	}
+	return d.stubDevice.SendFeatureReport(data)
+}
+
+func newGlitchingDisplay(t *testing.T, err error, failures int32, retries int) (*hid.Display, *glitchingDevice, *atomic.Int32) {
+	t.Helper()
+
+	device := &glitchingDevice{stubDevice: stubDevice{info: hid.DeviceInfo{Serial: "ABC123"}}, err: err}
+	var enumerations atomic.Int32
+	enumerator := func() ([]hid.DeviceInfo, error) {
+		enumerations.Add(1)
+		return []hid.DeviceInfo{device.info}, nil
+	}
+	m := hid.NewManager(
+		hid.WithEnumerator(enumerator),
+		hid.WithOpener(func(string) (hid.Device, error) { return device, nil }),
+		hid.WithTransientRetries(retries, time.Millisecond),
+	)
+	require.NoError(t, m.RefreshDisplays())
+
+	display, getErr := m.GetDisplay("ABC123")
+	require.NoError(t, getErr)
+	device.failures.Store(failures)
+	device.calls.Store(0)
+	return display, device, &enumerations
+}
+
+func TestDisplay_TransientRetries_RetryThenSucceed(t *testing.T) {
+	display, device, enumerations := newGlitchingDisplay(t, syscall.EIO, 2, 3)
+
+	require.NoError(t, display.SetBrightness(50))
+	assert.Equal(t, int32(3), device.calls.Load(), "two failed attempts and one success")
+	assert.Equal(t, 1, device.writes)
+
+	device.failures.Store(1)
+	_, err := display.GetBrightness()
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(1), enumerations.Load(), "no re-enumeration after a transient error")
+}
+
+func TestDisplay_TransientRetries_HidapiMessage(t *testing.T) {
+	// go-hid reports errors as plain strings without the errno
+	display, device, _ := newGlitchingDisplay(t, errors.New("ioctl (SFEATURE): Input/output error"), 1, 2)
+
+	require.NoError(t, display.SetBrightness(50))
+	assert.Equal(t, int32(2), device.calls.Load(), "the message alone marks the error as transient")
+
+	device.failures.Store(10)
+	err := display.SetBrightness(60)
+	require.Error(t, err)
+	assert.True(t, hid.IsDeviceGoneError(err), "a persistent hidapi EIO still triggers recovery")
+}
+
+func TestDisplay_TransientRetries_PersistentEIOFallsBackToDeviceGone(t *testing.T) {
+	display, device, _ := newGlitchingDisplay(t, syscall.EIO, 10, 2)
+
+	err := display.SetBrightness(50)
+	require.Error(t, err)
+	assert.True(t, hid.IsDeviceGoneError(err), "persistent EIO still triggers recovery")
+	assert.Equal(t, int32(3), device.calls.Load(), "one attempt plus two retries")
+}
+
+func TestDisplay_TransientRetries_OnlyRetriesTransientErrors(t *testing.T) {
+	display, device, _ := newGlitchingDisplay(t, syscall.ENODEV, 1, 3)
+
+	err := display.SetBrightness(50)
+	require.ErrorIs(t, err, syscall.ENODEV)
+	assert.Equal(t, int32(1), device.calls.Load())
+}
+
+func TestDisplay_TransientRetries_DisabledByDefault(t *testing.T) {
+	display, device, _ := newGlitchingDisplay(t, syscall.EIO, 1, 0)
+
+	require.Error(t, display.SetBrightness(50))
+	assert.Equal(t, int32(1), device.calls.Load())
+}
+
+func TestIsTransientError(t *testing.T) {
+	assert.True(t, hid.IsTransientError(fmt.Errorf("wrapped: %w", syscall.EIO)))
+	assert.False(t, hid.IsTransientError(syscall.ENODEV))
+	assert.True(t, hid.IsTransientError(errors.New("hidapi: ioctl (GFEATURE): Input/output error")))
+	assert.False(t, hid.IsTransientError(errors.New("hidapi: ioctl (GFEATURE): Broken pipe")))
+	assert.False(t, hid.IsTransientError(nil))
+}
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// USB link speeds reported by USBSpeed.
+const (
+	USBSpeedLow       = "Low"        // USB 1.x, 1.5 Mbit/s
+	USBSpeedFull      = "Full"       // USB 1.x, 12 Mbit/s
+	USBSpeedHigh      = "High"       // USB 2.0, 480 Mbit/s
+	USBSpeedSuper     = "Super"      // USB 3.x, 5 Gbit/s
+	USBSpeedSuperPlus = "Super Plus" // USB 3.1 and later, 10 Gbit/s and faster
+)
+
+// usbSpeedSearchDepth is how many levels, starting at the HID device, are checked
+// for the speed attribute: the HID device, its USB interface and the USB device.
+// Going further would report the speed of the hub above the display.
+const usbSpeedSearchDepth = 3
+
+// USBSpeed returns the link speed of the USB device a display's hidraw node belongs
+// to, e.g. USBSpeedHigh for a display behind a USB 2.0 hub, or "" if it can't be
+// determined. The display works at any speed, but one connected through USB 2.0 is
+// a common cause of odd behavior.
+func USBSpeed(info DeviceInfo) string {
+	if info.Path == "" {
+		return ""
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Join(hidrawSysfsDir, filepath.Base(info.Path), "device"))
+	if err != nil {
+		return ""
+	}
+
+	// The hidraw device sits below the HID device and the USB interface; the
+	// speed attribute belongs to the USB device above them
+	for range usbSpeedSearchDepth {
+		raw, err := os.ReadFile(filepath.Join(dir, "speed")) // #nosec G304 -- path is built from sysfs
+		if err == nil {
+			return ParseUSBSpeed(string(raw))
+		}
+		dir = filepath.Dir(dir)
+	}
+	return ""
+}
+
+// ParseUSBSpeed maps the sysfs speed attribute of a USB device, in Mbit/s, to a
+// speed name. Unknown values, including the kernel's "unknown", map to "".
+func ParseUSBSpeed(raw string) string {
+	switch strings.TrimSpace(raw) {
+	case "1.5":
+		return USBSpeedLow
+	case "12":
+		return USBSpeedFull
+	case "480":
+		return USBSpeedHigh
+	case "5000":
+		return USBSpeedSuper
+	case "10000", "20000", "40000", "80000":
+		return USBSpeedSuperPlus
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUSBSpeed(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected string
+	}{
+		{raw: "1.5\n", expected: hid.USBSpeedLow},
+		{raw: "12\n", expected: hid.USBSpeedFull},
+		{raw: "480\n", expected: hid.USBSpeedHigh},
+		{raw: "5000\n", expected: hid.USBSpeedSuper},
+		{raw: "10000\n", expected: hid.USBSpeedSuperPlus},
+		{raw: "20000", expected: hid.USBSpeedSuperPlus},
+		{raw: "unknown\n", expected: ""},
+		{raw: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, hid.ParseUSBSpeed(tt.raw), "%q", tt.raw)
+	}
+}
+
+func TestUSBSpeed(t *testing.T) {
+	sysfs := t.TempDir()
+	usbDevice := filepath.Join(sysfs, "devices", "pci0000:00", "0000:00:14.0", "usb1", "1-2")
+	hidDevice := filepath.Join(usbDevice, "1-2:1.7", "0003:05AC:1114.0005")
+	require.NoError(t, os.MkdirAll(hidDevice, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(usbDevice, "speed"), []byte("480\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(usbDevice), "speed"), []byte("5000\n"), 0o600))
+
+	// A display whose USB device has no speed attribute
+	bare := filepath.Join(sysfs, "devices", "pci0000:00", "0000:00:14.0", "usb1", "1-3", "1-3:1.7", "0003:05AC:1114.0006")
+	require.NoError(t, os.MkdirAll(bare, 0o750))
+
+	hidraw := filepath.Join(sysfs, "class", "hidraw")
+	for node, target := range map[string]string{"hidraw4": hidDevice, "hidraw5": bare} {
+		require.NoError(t, os.MkdirAll(filepath.Join(hidraw, node), 0o750))
+		require.NoError(t, os.Symlink(target, filepath.Join(hidraw, node, "device")))
+	}
+	defer hid.SetHidrawSysfsDir(hidraw)()
+
+	assert.Equal(t, hid.USBSpeedHigh, hid.USBSpeed(hid.DeviceInfo{Path: "/dev/hidraw4"}))
+	assert.Empty(t, hid.USBSpeed(hid.DeviceInfo{Path: "/dev/hidraw5"}), "the root hub's speed must not be reported")
+	assert.Empty(t, hid.USBSpeed(hid.DeviceInfo{Path: "/dev/hidraw9"}))
+	assert.Empty(t, hid.USBSpeed(hid.DeviceInfo{}))
+}
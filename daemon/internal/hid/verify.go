@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid
+
+import (
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultVerifyTolerance is the default difference in nits between a written and
+// read-back brightness that write verification accepts as firmware rounding.
+const DefaultVerifyTolerance = 50
+
+// WithWriteVerification makes displays read the brightness back after every write
+// and log a warning if it differs from the written value by more than tolerance
+// nits, which surfaces firmware that rounds or ignores writes. It costs an extra
+// HID round-trip per write, so it's off by default. A failed read-back is only
+// logged; the write itself still counts as successful.
+func WithWriteVerification(enabled bool, tolerance uint32) ManagerOption {
+	return func(m *Manager) {
+		m.verifyWrites = enabled
+		m.verifyTolerance = tolerance
+	}
+}
+
+// verifyWrite reads the brightness back and warns if it's further than the verify
+// tolerance from the written nits. Must be called with d.mu held.
+func (d *Display) verifyWrite(nits uint32) {
+	data := make([]byte, ReportSize)
+	data[0] = ReportID
+
+	n, err := d.device.GetFeatureReport(data)
+	if err != nil {
+		log.Debug().Err(err).Str("serial", d.Serial()).Msg("Failed to read brightness back for verification")
+		return
+	}
+	actual, err := DecodeReport(data[:min(n, len(data))])
+	if err != nil {
+		log.Debug().Err(err).Str("serial", d.Serial()).Msg("Failed to decode read-back brightness")
+		return
+	}
+
+	if max(actual, nits)-min(actual, nits) > d.verifyTolerance {
+		log.Warn().
+			Str("serial", d.Serial()).
+			Uint32("requestedNits", nits).
+			Uint32("actualNits", actual).
+			Msg("Display brightness differs from the value written, firmware may have rounded or ignored it")
+	}
+}
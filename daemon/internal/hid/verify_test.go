@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: GPL-3.0-only
+
+package hid_test
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/shini4i/asd-brightness-daemon/internal/hid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundingDevice stores written brightness rounded down to a multiple of step nits,
+// like firmware that quantizes writes, and reports the stored value on reads.
+type roundingDevice struct {
+	stubDevice
+	step  uint32
+	nits  uint32
+	reads int
+}
+
+func (d *roundingDevice) GetFeatureReport(data []byte) (int, error) {
+	d.reads++
+	return copy(data, hid.EncodeReport(d.nits)), nil
+}
+
+func (d *roundingDevice) SendFeatureReport(data []byte) (int, error) {
+	nits, err := hid.DecodeReport(data)
+	if err != nil {
+		return 0, err
+	}
+	d.nits = nits / d.step * d.step
+	return len(data), nil
+}
+
+// captureLogs redirects the global logger into a buffer for the rest of the test.
+func captureLogs(t *testing.T) *syncBuffer {
+	t.Helper()
+
+	buf := &syncBuffer{}
+	old := log.Logger
+	log.Logger = zerolog.New(buf)
+	t.Cleanup(func() { log.Logger = old })
+	return buf
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func newVerifiedDisplay(t *testing.T, device hid.Device, opts ...hid.ManagerOption) *hid.Display {
+	t.Helper()
+
+	m := hid.NewManager(append([]hid.ManagerOption{
+		hid.WithEnumerator(func() ([]hid.DeviceInfo, error) { return []hid.DeviceInfo{device.Info()}, nil }),
+		hid.WithOpener(func(string) (hid.Device, error) { return device, nil }),
+	}, opts...)...)
+	require.NoError(t, m.RefreshDisplays())
+
+	display, err := m.GetDisplay(device.Info().Serial)
+	require.NoError(t, err)
+	return display
+}
+
+func TestDisplay_WriteVerification_LogsMismatch(t *testing.T) {
+	logs := captureLogs(t)
+	device := &roundingDevice{stubDevice: stubDevice{info: hid.DeviceInfo{Serial: "ABC123"}}, step: 5000}
+	display := newVerifiedDisplay(t, device, hid.WithWriteVerification(true, hid.DefaultVerifyTolerance))
+
+	require.NoError(t, display.SetBrightness(50), "a mismatch doesn't fail the write")
+	assert.Equal(t, 1, device.reads, "the brightness should be read back once")
+	assert.Contains(t, logs.String(), `"level":"warn"`)
+	assert.Contains(t, logs.String(), `"actualNits":30000`)
+	assert.Contains(t, logs.String(), `"serial":"ABC123"`)
+}
+
+func TestDisplay_WriteVerification_WithinTolerance(t *testing.T) {
+	logs := captureLogs(t)
+	device := &roundingDevice{stubDevice: stubDevice{info: hid.DeviceInfo{Serial: "ABC123"}}, step: 1}
+	display := newVerifiedDisplay(t, device, hid.WithWriteVerification(true, hid.DefaultVerifyTolerance))
+
+	require.NoError(t, display.SetBrightness(50))
+	assert.Equal(t, 1, device.reads)
+	assert.NotContains(t, logs.String(), `"level":"warn"`)
+}
+
+func TestDisplay_WriteVerification_DisabledByDefault(t *testing.T) {
+	device := &roundingDevice{stubDevice: stubDevice{info: hid.DeviceInfo{Serial: "ABC123"}}, step: 5000}
+	display := newVerifiedDisplay(t, device)
+
+	require.NoError(t, display.SetBrightness(50))
+	assert.Zero(t, device.reads, "no read-back without verification")
+}
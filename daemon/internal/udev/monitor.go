@@ -6,6 +6,7 @@ package udev
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -65,9 +66,61 @@ type EventHandler func(event Event)
 // (e.g., netlink buffer overflow) and needs to trigger a refresh.
 type RecoveryHandler func()
 
+// Conn is a netlink uevent connection, abstracted so tests can drive Monitor
+// without a real socket.
+type Conn interface {
+	// Connect opens the connection in the given mode.
+	Connect(mode netlink.Mode) error
+
+	// Monitor delivers events accepted by matcher to queue and receive errors to
+	// errs until a value is sent on the returned channel.
+	Monitor(queue chan netlink.UEvent, errs chan error, matcher netlink.Matcher) chan struct{}
+
+	// Close closes the connection.
+	Close() error
+}
+
+// netlinkConn is the default Conn, a netlink socket with an enlarged receive buffer.
+type netlinkConn struct {
+	netlink.UEventConn
+}
+
+// Connect opens the netlink socket and increases its receive buffer to prevent
+// ENOBUFS during rapid USB hot-plug events.
+func (c *netlinkConn) Connect(mode netlink.Mode) error {
+	if err := c.UEventConn.Connect(mode); err != nil {
+		return err
+	}
+
+	if err := setSocketBufferSize(c.Fd, netlinkBufferSize); err != nil {
+		log.Warn().Err(err).Int("size", netlinkBufferSize).Msg("Failed to set netlink buffer size")
+		// Continue anyway - the default buffer may still work for most cases
+	} else {
+		log.Debug().Int("size", netlinkBufferSize).Msg("Netlink socket buffer size configured")
+	}
+	return nil
+}
+
+// newNetlinkConn returns a new, unconnected netlink connection.
+func newNetlinkConn() Conn {
+	return &netlinkConn{}
+}
+
+// MonitorOption is a functional option for configuring a Monitor.
+type MonitorOption func(*Monitor)
+
+// WithConnFactory sets how Start creates its connection, e.g. to inject a fake
+// connection in tests. It's called once per Start.
+func WithConnFactory(newConn func() Conn) MonitorOption {
+	return func(m *Monitor) {
+		m.newConn = newConn
+	}
+}
+
 // Monitor watches for Apple Studio Display connect/disconnect events.
 type Monitor struct {
-	conn            *netlink.UEventConn
+	conn            Conn
+	newConn         func() Conn
 	handler         EventHandler
 	recoveryHandler RecoveryHandler
 	quit            chan struct{}
@@ -80,11 +133,16 @@ type Monitor struct {
 }
 
 // NewMonitor creates a new udev monitor with the given event handler.
-func NewMonitor(handler EventHandler) *Monitor {
-	return &Monitor{
+func NewMonitor(handler EventHandler, opts ...MonitorOption) *Monitor {
+	m := &Monitor{
 		handler:        handler,
+		newConn:        newNetlinkConn,
 		lastRemoveTime: make(map[string]time.Time),
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 // SetRecoveryHandler sets the handler called when the monitor recovers from errors.
@@ -105,19 +163,11 @@ func (m *Monitor) Start() error {
 		return fmt.Errorf("monitor already started")
 	}
 
-	m.conn = &netlink.UEventConn{}
-	if err := m.conn.Connect(netlink.UdevEvent); err != nil {
-		m.conn = nil
+	conn := m.newConn()
+	if err := conn.Connect(netlink.UdevEvent); err != nil {
 		return fmt.Errorf("failed to connect to netlink: %w", err)
 	}
-
-	// Increase socket receive buffer to prevent ENOBUFS during rapid USB hot-plug events
-	if err := setSocketBufferSize(m.conn.Fd, netlinkBufferSize); err != nil {
-		log.Warn().Err(err).Int("size", netlinkBufferSize).Msg("Failed to set netlink buffer size")
-		// Continue anyway - the default buffer may still work for most cases
-	} else {
-		log.Debug().Int("size", netlinkBufferSize).Msg("Netlink socket buffer size configured")
-	}
+	m.conn = conn
 
 	queue := make(chan netlink.UEvent)
 	errs := make(chan error)
@@ -259,11 +309,46 @@ func isBufferOverflowError(err error) bool {
 	return strings.Contains(strings.ToLower(err.Error()), "no buffer space available")
 }
 
+// appleVendorID is Apple's USB vendor ID as normalized by parseProduct.
+const appleVendorID = "5ac"
+
+// parseProduct splits the PRODUCT value of a USB uevent, "vendorId/productId/bcdDevice"
+// in hexadecimal (e.g. "5ac/1114/157"), into its fields. The fields are returned in
+// lower case without leading zeros, so variants reported by different kernels compare
+// equal. ok is false unless there are exactly three hexadecimal fields of 1-4 digits.
+func parseProduct(s string) (vendor, product, bcd string, ok bool) {
+	fields := strings.Split(s, "/")
+	if len(fields) != 3 {
+		return "", "", "", false
+	}
+	for i, field := range fields {
+		value, err := strconv.ParseUint(field, 16, 16)
+		if err != nil || len(field) > 4 {
+			return "", "", "", false
+		}
+		fields[i] = strconv.FormatUint(value, 16)
+	}
+	return fields[0], fields[1], fields[2], true
+}
+
 // handleEvent processes a single udev event.
 func (m *Monitor) handleEvent(uevent netlink.UEvent) {
-	product := uevent.Env["PRODUCT"]
 	devtype := uevent.Env["DEVTYPE"]
 
+	// The matcher only lets Studio Display events through, but the PRODUCT value is
+	// checked again before it's used as a key, so a malformed one can't be mistaken
+	// for a display
+	vendor, productID, bcd, ok := parseProduct(uevent.Env["PRODUCT"])
+	if !ok || vendor != appleVendorID || productID != StudioDisplayProductID {
+		log.Debug().
+			Str("action", string(uevent.Action)).
+			Str("devpath", uevent.KObj).
+			Str("product", uevent.Env["PRODUCT"]).
+			Msg("Ignoring USB event with malformed or foreign PRODUCT")
+		return
+	}
+	product := vendor + "/" + productID + "/" + bcd
+
 	// Filter for usb_device type only (not usb_interface) on ADD events.
 	// For REMOVE events, DEVTYPE may not be present since the device is already gone,
 	// so we use debouncing instead to filter duplicate events from USB interfaces.
@@ -4,6 +4,7 @@ package udev
 
 import (
 	"errors"
+	"fmt"
 	"sync"
 	"syscall"
 	"testing"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/pilebones/go-udev/netlink"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewMonitor(t *testing.T) {
@@ -172,6 +174,23 @@ func TestMonitor_HandleEvent(t *testing.T) {
 		},
 	}
 
+	for _, product := range []string{"", "5ac1114157", "5ac/1114", "5ac//157", "5ac/1114/157/1", "zzz/1114/157", "5ac/8286/100"} {
+		tests = append(tests, struct {
+			name          string
+			uevent        netlink.UEvent
+			expectHandler bool
+			expectedType  EventType
+		}{
+			name: fmt.Sprintf("malformed or foreign PRODUCT %q is ignored", product),
+			uevent: netlink.UEvent{
+				Action: netlink.ADD,
+				KObj:   "/devices/pci0000:00/usb1/1-1",
+				Env:    map[string]string{"DEVTYPE": "usb_device", "PRODUCT": product},
+			},
+			expectHandler: false,
+		})
+	}
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			var mu sync.Mutex
@@ -201,6 +220,37 @@ func TestMonitor_HandleEvent(t *testing.T) {
 	}
 }
 
+func TestParseProduct(t *testing.T) {
+	tests := []struct {
+		input   string
+		vendor  string
+		product string
+		bcd     string
+		ok      bool
+	}{
+		{input: "5ac/1114/157", vendor: "5ac", product: "1114", bcd: "157", ok: true},
+		{input: "05AC/1114/0157", vendor: "5ac", product: "1114", bcd: "157", ok: true},
+		{input: "5Ac/1114/0", vendor: "5ac", product: "1114", bcd: "0", ok: true},
+		{input: ""},
+		{input: "5ac"},
+		{input: "5ac/1114"},
+		{input: "5ac/1114/"},
+		{input: "/1114/157"},
+		{input: "5ac/1114/157/"},
+		{input: "5ac/1114/157/1"},
+		{input: "5ac/11149/157"},
+		{input: "5ac/0x1114/157"},
+		{input: "5ac/-1/157"},
+		{input: "g5ac/1114/157"},
+	}
+
+	for _, tt := range tests {
+		vendor, product, bcd, ok := parseProduct(tt.input)
+		assert.Equal(t, tt.ok, ok, "%q", tt.input)
+		assert.Equal(t, []string{tt.vendor, tt.product, tt.bcd}, []string{vendor, product, bcd}, "%q", tt.input)
+	}
+}
+
 func TestMonitor_HandleEvent_NilHandler(t *testing.T) {
 	// Should not panic with nil handler
 	monitor := NewMonitor(nil)
@@ -616,3 +666,119 @@ func TestMonitor_AddEventsNotDebounced(t *testing.T) {
 	assert.Equal(t, 3, callCount, "ADD events should not be debounced")
 	mu.Unlock()
 }
+
+// fakeConn is a Conn that hands its event and error channels to the test.
+type fakeConn struct {
+	connectErr error
+	mode       netlink.Mode
+	closed     bool
+	queue      chan netlink.UEvent
+	errs       chan error
+	matcher    netlink.Matcher
+	quit       chan struct{}
+}
+
+func (c *fakeConn) Connect(mode netlink.Mode) error {
+	c.mode = mode
+	return c.connectErr
+}
+
+func (c *fakeConn) Monitor(queue chan netlink.UEvent, errs chan error, matcher netlink.Matcher) chan struct{} {
+	c.queue, c.errs, c.matcher = queue, errs, matcher
+	c.quit = make(chan struct{}, 1)
+	return c.quit
+}
+
+func (c *fakeConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+// startFakeMonitor starts a monitor on a fake connection.
+func startFakeMonitor(t *testing.T, handler EventHandler) (*Monitor, *fakeConn) {
+	t.Helper()
+
+	conn := &fakeConn{}
+	monitor := NewMonitor(handler, WithConnFactory(func() Conn { return conn }))
+	require.NoError(t, monitor.Start())
+	return monitor, conn
+}
+
+func TestMonitor_Start_DeliversEvents(t *testing.T) {
+	events := make(chan Event, 1)
+	monitor, conn := startFakeMonitor(t, func(event Event) { events <- event })
+
+	assert.Equal(t, netlink.UdevEvent, conn.mode)
+	assert.Error(t, monitor.Start(), "starting twice should fail")
+
+	conn.queue <- netlink.UEvent{
+		Action: netlink.ADD,
+		KObj:   "/devices/pci0000:00/usb1/1-1",
+		Env:    map[string]string{"DEVTYPE": "usb_device", "PRODUCT": "5ac/1114/157", "SUBSYSTEM": "usb"},
+	}
+	select {
+	case event := <-events:
+		assert.Equal(t, EventAdd, event.Type)
+	case <-time.After(time.Second):
+		t.Fatal("event was not delivered")
+	}
+
+	assert.True(t, conn.matcher.Evaluate(netlink.UEvent{
+		Action: netlink.REMOVE,
+		Env:    map[string]string{"SUBSYSTEM": "usb", "PRODUCT": "5ac/1114/157"},
+	}), "the monitor should subscribe with the Studio Display matcher")
+
+	require.NoError(t, monitor.Stop())
+	assert.True(t, conn.closed)
+	assert.Len(t, conn.quit, 1, "the connection's monitor loop should be told to quit")
+}
+
+func TestMonitor_Start_ConnectFailure(t *testing.T) {
+	conn := &fakeConn{connectErr: errors.New("permission denied")}
+	monitor := NewMonitor(nil, WithConnFactory(func() Conn { return conn }))
+
+	err := monitor.Start()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "permission denied")
+
+	// A failed start leaves the monitor stopped and restartable
+	assert.NoError(t, monitor.Stop())
+	conn.connectErr = nil
+	require.NoError(t, monitor.Start())
+	assert.NoError(t, monitor.Stop())
+}
+
+func TestMonitor_BufferOverflowTriggersRecovery(t *testing.T) {
+	monitor, conn := startFakeMonitor(t, nil)
+
+	recovered := make(chan struct{}, 2)
+	monitor.SetRecoveryHandler(func() { recovered <- struct{}{} })
+
+	conn.errs <- fmt.Errorf("receive failed: %w", syscall.ENOBUFS)
+	select {
+	case <-recovered:
+	case <-time.After(time.Second):
+		t.Fatal("buffer overflow should trigger a recovery refresh")
+	}
+
+	// Other errors are only logged
+	conn.errs <- errors.New("malformed message")
+	conn.errs <- syscall.ENOBUFS
+	select {
+	case <-recovered:
+	case <-time.After(time.Second):
+		t.Fatal("monitoring should continue after errors")
+	}
+	assert.Empty(t, recovered)
+
+	require.NoError(t, monitor.Stop())
+
+	// Errors racing with shutdown don't trigger recovery
+	select {
+	case conn.errs <- syscall.ENOBUFS:
+	case <-time.After(time.Second):
+		t.Fatal("event loop should still drain errors after stop")
+	}
+	time.Sleep(10 * time.Millisecond)
+	assert.Empty(t, recovered)
+}